@@ -0,0 +1,151 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type seriesResponse[P any] struct {
+	Bucket string    `json:"bucket"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Points []P       `json:"points"`
+}
+
+type workloadPoint struct {
+	Start   string  `json:"start"`
+	Sets    int     `json:"sets"`
+	Reps    int     `json:"reps"`
+	Tonnage float64 `json:"tonnage"`
+}
+
+type muscleGroupPoint struct {
+	Start       string `json:"start"`
+	MuscleGroup string `json:"muscleGroup"`
+	Sets        int    `json:"sets"`
+}
+
+type oneRepMaxPoint struct {
+	Start              string  `json:"start"`
+	EstimatedOneRepMax float64 `json:"estimated1rm"`
+	Weight             float64 `json:"weight"`
+	Reps               int     `json:"reps"`
+}
+
+type frequencyPoint struct {
+	Start    string `json:"start"`
+	Sessions int    `json:"sessions"`
+	Days     int    `json:"days"`
+}
+
+func getSeries[P any](t *testing.T, ts *testServer, path, token string) seriesResponse[P] {
+	t.Helper()
+	data, resp := ts.doRequest(http.MethodGet, path, nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var series seriesResponse[P]
+	require.NoError(t, json.Unmarshal(data, &series))
+	return series
+}
+
+func TestProgressAnalytics(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	squatID := ts.exerciseIDByName("Barbell Back Squat")
+	pullUpID := ts.exerciseIDByName("Pull-Up")
+	plankID := ts.exerciseIDByName("Plank")
+	for _, body := range []string{
+		`{"startedAt":"2026-02-20T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":1,"reps":1,"weight":120}]}`,
+		`{"startedAt":"2026-03-02T10:00:00Z","entries":[
+			{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100},
+			{"exerciseId":"` + squatID + `","sets":2,"reps":5,"weight":140}]}`,
+		`{"startedAt":"2026-03-04T07:00:00Z","entries":[
+			{"exerciseId":"` + benchID + `","setDetails":[{"type":"warmup","reps":10,"weight":60},{"reps":8,"weight":100}]},
+			{"exerciseId":"` + pullUpID + `","sets":3,"reps":10}]}`,
+		`{"startedAt":"2026-03-04T18:00:00Z","entries":[{"exerciseId":"` + plankID + `","durationSeconds":60}]}`,
+		`{"startedAt":"2026-03-17T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":1,"reps":3,"weight":110}]}`,
+	} {
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(body), token)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	}
+	const weeks = "?bucket=week&from=2026-03-02&to=2026-03-23"
+
+	// Act
+	workload := getSeries[workloadPoint](t, ts, "/api/v1/analytics/workload"+weeks, token)
+	frequency := getSeries[frequencyPoint](t, ts, "/api/v1/analytics/frequency"+weeks, token)
+	muscleGroups := getSeries[muscleGroupPoint](t, ts, "/api/v1/analytics/muscle-groups"+weeks, token)
+	oneRepMax := getSeries[oneRepMaxPoint](t, ts, "/api/v1/analytics/one-rep-max"+weeks+"&exerciseId="+benchID, token)
+	monthly := getSeries[oneRepMaxPoint](t, ts,
+		"/api/v1/analytics/one-rep-max?bucket=month&from=2026-02-01&to=2026-04-01&exerciseId="+benchID, token)
+
+	// Assert: empty weeks are filled in for totals but left out elsewhere.
+	require.Equal(t, "week", workload.Bucket)
+	require.Equal(t, []workloadPoint{
+		{Start: "2026-03-02T00:00:00Z", Sets: 10, Reps: 63, Tonnage: 3700},
+		{Start: "2026-03-09T00:00:00Z"},
+		{Start: "2026-03-16T00:00:00Z", Sets: 1, Reps: 3, Tonnage: 330},
+	}, workload.Points)
+	require.Equal(t, []frequencyPoint{
+		{Start: "2026-03-02T00:00:00Z", Sessions: 3, Days: 2},
+		{Start: "2026-03-09T00:00:00Z"},
+		{Start: "2026-03-16T00:00:00Z", Sessions: 1, Days: 1},
+	}, frequency.Points)
+	require.Equal(t, []muscleGroupPoint{
+		{Start: "2026-03-02T00:00:00Z", MuscleGroup: "back", Sets: 3},
+		{Start: "2026-03-02T00:00:00Z", MuscleGroup: "chest", Sets: 4},
+		{Start: "2026-03-02T00:00:00Z", MuscleGroup: "core", Sets: 1},
+		{Start: "2026-03-02T00:00:00Z", MuscleGroup: "legs", Sets: 2},
+		{Start: "2026-03-16T00:00:00Z", MuscleGroup: "chest", Sets: 1},
+	}, muscleGroups.Points)
+	require.Equal(t, []oneRepMaxPoint{
+		{Start: "2026-03-02T00:00:00Z", EstimatedOneRepMax: 124.14, Weight: 100, Reps: 8},
+		{Start: "2026-03-16T00:00:00Z", EstimatedOneRepMax: 116.47, Weight: 110, Reps: 3},
+	}, oneRepMax.Points)
+	require.Equal(t, []oneRepMaxPoint{
+		{Start: "2026-02-01T00:00:00Z", EstimatedOneRepMax: 120, Weight: 120, Reps: 1},
+		{Start: "2026-03-01T00:00:00Z", EstimatedOneRepMax: 124.14, Weight: 100, Reps: 8},
+	}, monthly.Points)
+
+	// Act: only weight and reps exercises have a one-rep max, as in records.
+	weighted := []byte(`{"startedAt":"2026-04-01T10:00:00Z","entries":[{"exerciseId":"` + pullUpID + `","sets":1,"reps":5,"weight":20}]}`)
+	data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts", weighted, token)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	pullUps := getSeries[oneRepMaxPoint](t, ts,
+		"/api/v1/analytics/one-rep-max?bucket=month&from=2026-04-01&to=2026-05-01&exerciseId="+pullUpID, token)
+
+	// Assert
+	require.Empty(t, pullUps.Points)
+
+	// Act: without a range the last twelve weeks are covered.
+	recent := getSeries[frequencyPoint](t, ts, "/api/v1/analytics/frequency", token)
+
+	// Assert
+	require.Len(t, recent.Points, 12)
+	require.WithinDuration(t, time.Now(), recent.To, time.Minute)
+
+	// Act
+	cases := map[string]string{
+		"/api/v1/analytics/workload?bucket=year":                              "bucket",
+		"/api/v1/analytics/frequency?from=2026-03-10&to=2026-03-01":           "to",
+		"/api/v1/analytics/workload?bucket=day&from=2024-01-01&to=2026-01-01": "from",
+		"/api/v1/analytics/one-rep-max":                                       "exerciseId",
+	}
+	for path, field := range cases {
+		data, resp := ts.doRequest(http.MethodGet, path, nil, token)
+
+		// Assert
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, path)
+		var fieldErr fieldErrorResponse
+		require.NoError(t, json.Unmarshal(data, &fieldErr))
+		require.Equal(t, []string{field}, fieldErr.fieldNames(), path)
+	}
+	_, unknownResp := ts.doRequest(http.MethodGet,
+		"/api/v1/analytics/one-rep-max?exerciseId=0b0e8b4e-5d53-4c1e-9a1a-1f2d3c4b5a69", nil, token)
+	require.Equal(t, http.StatusNotFound, unknownResp.StatusCode)
+}
@@ -0,0 +1,234 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type bodyMetricResponse struct {
+	ID             string             `json:"id"`
+	MeasuredAt     time.Time          `json:"measuredAt"`
+	Bodyweight     *float64           `json:"bodyweight"`
+	BodyFat        *float64           `json:"bodyFat"`
+	Circumferences map[string]float64 `json:"circumferences"`
+	Note           string             `json:"note"`
+	Units          struct {
+		Weight string `json:"weight"`
+		Length string `json:"length"`
+	} `json:"units"`
+}
+
+type bodyTrendResponse struct {
+	Metric     string  `json:"metric"`
+	Unit       string  `json:"unit"`
+	WindowDays int     `json:"windowDays"`
+	Change     float64 `json:"change"`
+	Points     []struct {
+		Value   float64 `json:"value"`
+		Average float64 `json:"average"`
+	} `json:"points"`
+}
+
+func TestBodyMetrics(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	measuredAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second).Format(time.RFC3339)
+	payload := `{"measuredAt":"` + measuredAt + `","bodyweight":180,"bodyFat":18.5,"circumferences":{"waist":32},
+		"weightUnit":"lb","lengthUnit":"in","note":"morning"}`
+	post := func(body, key string) (bodyMetricResponse, *http.Response) {
+		data, resp := ts.doRequestWithHeaders(http.MethodPost, "/api/v1/body-metrics", []byte(body), token, http.Header{"Idempotency-Key": {key}})
+		var metric bodyMetricResponse
+		if resp.StatusCode < 300 {
+			require.NoError(t, json.Unmarshal(data, &metric))
+		}
+		return metric, resp
+	}
+	get := func(path string) bodyMetricResponse {
+		data, resp := ts.doRequest(http.MethodGet, path, nil, token)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+		var metric bodyMetricResponse
+		require.NoError(t, json.Unmarshal(data, &metric))
+		return metric
+	}
+
+	// Act
+	created, createResp := post(payload, "scale-1")
+
+	// Assert: the response uses the units of the request, storage is metric.
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	require.Equal(t, 180.0, *created.Bodyweight)
+	require.Equal(t, 32.0, created.Circumferences["waist"])
+	require.Equal(t, "lb", created.Units.Weight)
+	metric := get("/api/v1/body-metrics/" + created.ID)
+	require.Equal(t, 81.65, *metric.Bodyweight)
+	require.Equal(t, 18.5, *metric.BodyFat)
+	require.Equal(t, 81.28, metric.Circumferences["waist"])
+	require.Equal(t, "cm", metric.Units.Length)
+
+	// Act: an offline client retries, then reuses the key for other data.
+	replayed, replayResp := post(payload, "scale-1")
+	_, conflictResp := post(`{"bodyweight":90}`, "scale-1")
+
+	// Assert
+	require.Equal(t, http.StatusOK, replayResp.StatusCode)
+	require.Equal(t, "true", replayResp.Header.Get("Idempotent-Replayed"))
+	require.Equal(t, created, replayed)
+	require.Equal(t, http.StatusConflict, conflictResp.StatusCode)
+
+	// Act: a client-generated id also makes submissions idempotent.
+	clientID := "5f0c7d52-8f3b-4b8e-9a8a-2f8f0c3a9e11"
+	first, firstResp := post(`{"id":"`+clientID+`","bodyFat":18}`, "")
+	again, againResp := post(`{"id":"`+clientID+`","bodyFat":18}`, "")
+
+	// Assert
+	require.Equal(t, http.StatusCreated, firstResp.StatusCode)
+	require.Equal(t, http.StatusOK, againResp.StatusCode)
+	require.Equal(t, clientID, first.ID)
+	require.Equal(t, first, again)
+
+	// Act: invalid measurements.
+	future := time.Now().UTC().Add(2 * time.Hour).Format(time.RFC3339)
+	for _, tc := range []struct {
+		body   string
+		fields []string
+	}{
+		{`{"note":"forgot the scale"}`, []string{"bodyweight"}},
+		{`{"bodyweight":15,"bodyFat":90}`, []string{"bodyweight", "bodyFat"}},
+		{`{"circumferences":{"ankle":20,"waist":2}}`, []string{"circumferences.ankle", "circumferences.waist"}},
+		{`{"bodyweight":12,"weightUnit":"st","lengthUnit":"mm"}`, []string{"weightUnit", "lengthUnit"}},
+		{`{"bodyweight":80,"measuredAt":"` + future + `"}`, []string{"measuredAt"}},
+	} {
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/body-metrics", []byte(tc.body), token)
+
+		// Assert
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, string(data))
+		var fieldErr fieldErrorResponse
+		require.NoError(t, json.Unmarshal(data, &fieldErr))
+		require.ElementsMatch(t, tc.fields, fieldErr.fieldNames(), tc.body)
+	}
+
+	// Act
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/body-metrics?weightUnit=lb&lengthUnit=in", nil, token)
+
+	// Assert: newest first, converted to the requested units.
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var listed []bodyMetricResponse
+	require.NoError(t, json.Unmarshal(data, &listed))
+	require.Len(t, listed, 2)
+	require.Equal(t, clientID, listed[0].ID)
+	require.Equal(t, 180.0, *listed[1].Bodyweight)
+	require.Equal(t, 32.0, listed[1].Circumferences["waist"])
+
+	// Act
+	data, resp = ts.doRequest(http.MethodPut, "/api/v1/body-metrics/"+created.ID,
+		[]byte(`{"measuredAt":"`+measuredAt+`","bodyweight":82,"circumferences":{"waist":80,"hips":98}}`), token)
+
+	// Assert: a replacement drops what it leaves out.
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	updated := get("/api/v1/body-metrics/" + created.ID)
+	require.Equal(t, 82.0, *updated.Bodyweight)
+	require.Nil(t, updated.BodyFat)
+	require.Equal(t, map[string]float64{"waist": 80, "hips": 98}, updated.Circumferences)
+	require.Empty(t, updated.Note)
+
+	// Act: measurements are private.
+	_, registerResp := ts.doRequest(http.MethodPost, "/api/v1/auth/register", []byte(`{"email":"partner@example.com","password":"TrainHard123!"}`), "")
+	require.Equal(t, http.StatusCreated, registerResp.StatusCode)
+	partner := ts.login("partner@example.com", "TrainHard123!").Tokens.AccessToken
+	_, partnerGet := ts.doRequest(http.MethodGet, "/api/v1/body-metrics/"+created.ID, nil, partner)
+	_, partnerDelete := ts.doRequest(http.MethodDelete, "/api/v1/body-metrics/"+created.ID, nil, partner)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, partnerGet.StatusCode)
+	require.Equal(t, http.StatusNotFound, partnerDelete.StatusCode)
+
+	// Act
+	_, resp = ts.doRequest(http.MethodDelete, "/api/v1/body-metrics/"+created.ID, nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	_, resp = ts.doRequest(http.MethodGet, "/api/v1/body-metrics/"+created.ID, nil, token)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestBodyweightTrendAndGoals(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange: weighed every other day, losing half a kilogram a day.
+	token := ts.registerAthlete().Tokens.AccessToken
+	now := time.Now().UTC()
+	for i, weight := range []float64{82, 81, 80, 79} {
+		body := fmt.Sprintf(`{"measuredAt":%q,"bodyweight":%g}`, now.AddDate(0, 0, 2*i-6).Format(time.RFC3339), weight)
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/body-metrics", []byte(body), token)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	}
+	trend := func(query string) bodyTrendResponse {
+		data, resp := ts.doRequest(http.MethodGet, "/api/v1/body-metrics/trend?"+query, nil, token)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+		var trend bodyTrendResponse
+		require.NoError(t, json.Unmarshal(data, &trend))
+		return trend
+	}
+
+	// Act
+	full := trend("metric=bodyweight&window=3")
+	recent := trend("metric=bodyweight&window=3&from=" + now.AddDate(0, 0, -3).Format(time.RFC3339))
+	pounds := trend("metric=bodyweight&weightUnit=lb")
+
+	// Assert: each average covers the weigh-ins of the three days up to it,
+	// including those before from.
+	require.Equal(t, "kg", full.Unit)
+	require.Len(t, full.Points, 4)
+	averages := make([]float64, 0, len(full.Points))
+	for _, p := range full.Points {
+		averages = append(averages, p.Average)
+	}
+	require.Equal(t, []float64{82, 81.5, 80.5, 79.5}, averages)
+	require.Equal(t, -2.5, full.Change)
+	require.Len(t, recent.Points, 2)
+	require.Equal(t, 80.5, recent.Points[0].Average)
+	require.Equal(t, -1.0, recent.Change)
+	require.Equal(t, "lb", pounds.Unit)
+	require.Equal(t, 7, pounds.WindowDays)
+	require.Equal(t, 174.17, pounds.Points[3].Value)
+
+	// Act
+	_, badMetric := ts.doRequest(http.MethodGet, "/api/v1/body-metrics/trend?metric=height", nil, token)
+	_, badSite := ts.doRequest(http.MethodGet, "/api/v1/body-metrics/trend?metric=circumference&site=ankle", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, badMetric.StatusCode)
+	require.Equal(t, http.StatusBadRequest, badSite.StatusCode)
+
+	// Act: a bodyweight goal follows the logged bodyweight.
+	data, resp := ts.doRequest(http.MethodPost, "/api/v1/goals",
+		[]byte(`{"type":"bodyweight","target":75,"deadline":"`+now.AddDate(0, 0, 30).Format(time.DateOnly)+`"}`), token)
+
+	// Assert: at that rate 75kg is eight days away.
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	var goal goalResponse
+	require.NoError(t, json.Unmarshal(data, &goal))
+	require.Equal(t, 79.0, *goal.Start)
+	require.Equal(t, 79.0, *goal.Current)
+	require.Equal(t, "on_track", goal.Status)
+	require.NotNil(t, goal.Projection)
+	require.WithinDuration(t, now.AddDate(0, 0, 8), goal.Projection.Date, 24*time.Hour)
+
+	// Act
+	data, resp = ts.doRequest(http.MethodPost, "/api/v1/body-metrics", []byte(`{"bodyweight":74.8}`), token)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	data, resp = ts.doRequest(http.MethodGet, "/api/v1/goals/"+goal.ID, nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	require.NoError(t, json.Unmarshal(data, &goal))
+	require.Equal(t, "achieved", goal.Status)
+	require.Equal(t, 74.8, *goal.Current)
+}
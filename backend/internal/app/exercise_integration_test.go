@@ -0,0 +1,769 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type exerciseRevision struct {
+	Number       int    `json:"number"`
+	Action       string `json:"action"`
+	AuthorID     string `json:"authorId"`
+	RevertedFrom int    `json:"revertedFrom"`
+	Changes      []struct {
+		Field string      `json:"field"`
+		From  interface{} `json:"from"`
+		To    interface{} `json:"to"`
+	} `json:"changes"`
+}
+
+func (ts *testServer) createExercise(token string, payload []byte) string {
+	ts.t.Helper()
+
+	data, resp := ts.doRequest(http.MethodPost, "/api/v1/exercises", payload, token)
+	require.Equal(ts.t, http.StatusCreated, resp.StatusCode, string(data))
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(ts.t, json.Unmarshal(data, &created))
+	return created.ID
+}
+
+func TestExerciseRevisionHistory(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	exerciseID := ts.createExercise(admin.Tokens.AccessToken, readTestData(t, filepath.Join("exercises", "create.json")))
+
+	// Act
+	updateData, updateResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+exerciseID,
+		[]byte(`{"equipment":"Safety Bar","baseRevision":1}`), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, updateResp.StatusCode, string(updateData))
+	var updated struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Equipment   string `json:"equipment"`
+	}
+	require.NoError(t, json.Unmarshal(updateData, &updated))
+	require.Equal(t, "Back Squat", updated.Name)
+	require.Equal(t, "Barbell squat targeting the posterior chain", updated.Description)
+	require.Equal(t, "Safety Bar", updated.Equipment)
+
+	// Act
+	_, staleResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+exerciseID,
+		[]byte(`{"description":"Overwrites the other admin","baseRevision":1}`), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, staleResp.StatusCode)
+
+	// Act
+	historyData, historyResp := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+exerciseID+"/revisions", nil, admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, historyResp.StatusCode)
+	var history []exerciseRevision
+	require.NoError(t, json.Unmarshal(historyData, &history))
+	require.Len(t, history, 2)
+	require.Equal(t, 2, history[0].Number)
+	require.Equal(t, "update", history[0].Action)
+	require.Equal(t, admin.User.ID, history[0].AuthorID)
+	require.Len(t, history[0].Changes, 1)
+	require.Equal(t, "equipment", history[0].Changes[0].Field)
+	require.Equal(t, "Barbell", history[0].Changes[0].From)
+	require.Equal(t, "Safety Bar", history[0].Changes[0].To)
+	require.Equal(t, "create", history[1].Action)
+
+	// Act
+	revertData, revertResp := ts.doRequest(http.MethodPost, fmt.Sprintf("/api/v1/exercises/%s/revisions/%d/revert", exerciseID, 1), nil, admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, revertResp.StatusCode, string(revertData))
+	require.NoError(t, json.Unmarshal(revertData, &updated))
+	require.Equal(t, "Barbell", updated.Equipment)
+	historyData, _ = ts.doRequest(http.MethodGet, "/api/v1/exercises/"+exerciseID+"/revisions", nil, admin.Tokens.AccessToken)
+	require.NoError(t, json.Unmarshal(historyData, &history))
+	require.Len(t, history, 3)
+	require.Equal(t, "revert", history[0].Action)
+	require.Equal(t, 1, history[0].RevertedFrom)
+
+	// Act
+	_, missingResp := ts.doRequest(http.MethodPost, fmt.Sprintf("/api/v1/exercises/%s/revisions/%d/revert", exerciseID, 42), nil, admin.Tokens.AccessToken)
+	_, userResp := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+exerciseID+"/revisions", nil, "")
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, missingResp.StatusCode)
+	require.Equal(t, http.StatusUnauthorized, userResp.StatusCode)
+}
+
+type exerciseChangeFeed struct {
+	Cursor    string `json:"cursor"`
+	Exercises []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"exercises"`
+	Tombstones []struct {
+		ID         string `json:"id"`
+		Reason     string `json:"reason"`
+		ReplacedBy string `json:"replacedBy"`
+	} `json:"tombstones"`
+}
+
+func (ts *testServer) exerciseChanges(since string) exerciseChangeFeed {
+	ts.t.Helper()
+
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/exercises?since="+since, nil, "")
+	require.Equal(ts.t, http.StatusOK, resp.StatusCode, string(data))
+	var feed exerciseChangeFeed
+	require.NoError(ts.t, json.Unmarshal(data, &feed))
+	return feed
+}
+
+func TestExerciseChangeFeed(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	initial := ts.exerciseChanges("")
+	require.NotEmpty(t, initial.Exercises)
+	require.Empty(t, initial.Tombstones)
+	keptID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Goblet Squat","equipment":"Kettlebell"}`))
+	archivedID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Smith Machine Squat","equipment":"Machine"}`))
+	deletedID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Sissy Squat","equipment":"Bodyweight"}`))
+
+	// Act
+	created := ts.exerciseChanges(initial.Cursor)
+
+	// Assert
+	require.Len(t, created.Exercises, 3)
+	require.Greater(t, cursorPosition(t, created.Cursor), cursorPosition(t, initial.Cursor))
+
+	// Act
+	_, archiveResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+archivedID+"/archive", nil, admin.Tokens.AccessToken)
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/exercises/"+deletedID, nil, admin.Tokens.AccessToken)
+	_, updateResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+keptID, []byte(`{"name":"Kettlebell Goblet Squat"}`), admin.Tokens.AccessToken)
+	changed := ts.exerciseChanges(created.Cursor)
+
+	// Assert
+	require.Equal(t, http.StatusOK, archiveResp.StatusCode)
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	require.Len(t, changed.Exercises, 1)
+	require.Equal(t, keptID, changed.Exercises[0].ID)
+	require.Equal(t, "Kettlebell Goblet Squat", changed.Exercises[0].Name)
+	reasons := map[string]string{}
+	for _, tombstone := range changed.Tombstones {
+		reasons[tombstone.ID] = tombstone.Reason
+	}
+	require.Equal(t, map[string]string{archivedID: "archived", deletedID: "deleted"}, reasons)
+
+	// Act
+	unchanged := ts.exerciseChanges(changed.Cursor)
+	listData, _ := ts.doRequest(http.MethodGet, "/api/v1/exercises", nil, "")
+	_, badCursorResp := ts.doRequest(http.MethodGet, "/api/v1/exercises?since=abc", nil, "")
+
+	// Assert
+	require.Empty(t, unchanged.Exercises)
+	require.Empty(t, unchanged.Tombstones)
+	require.Equal(t, changed.Cursor, unchanged.Cursor)
+	require.NotContains(t, string(listData), archivedID)
+	require.Equal(t, http.StatusBadRequest, badCursorResp.StatusCode)
+
+	// Act: switching language restarts the feed so every name is resent.
+	germanData, germanResp := ts.doRequest(http.MethodGet, "/api/v1/exercises?lang=de&since="+url.QueryEscape(changed.Cursor), nil, "")
+
+	// Assert
+	require.Equal(t, http.StatusOK, germanResp.StatusCode, string(germanData))
+	var german exerciseChangeFeed
+	require.NoError(t, json.Unmarshal(germanData, &german))
+	require.Len(t, german.Exercises, len(initial.Exercises)+1)
+	require.Equal(t, cursorPosition(t, changed.Cursor), cursorPosition(t, german.Cursor))
+	require.NotEqual(t, changed.Cursor, german.Cursor)
+}
+
+// cursorPosition returns the change sequence number a feed cursor points at.
+func cursorPosition(t *testing.T, cursor string) int64 {
+	t.Helper()
+
+	raw, _, _ := strings.Cut(cursor, ".")
+	position, err := strconv.ParseInt(raw, 10, 64)
+	require.NoError(t, err)
+	return position
+}
+
+func TestExerciseAliasesAndFuzzySearch(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	rdlID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Romanian Deadlift","equipment":"Barbell"}`))
+	ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Chin-Up","measurementType":"bodyweight_reps"}`))
+
+	// Act
+	aliasData, aliasResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+rdlID+"/aliases", []byte(`{"alias":"RDL"}`), admin.Tokens.AccessToken)
+	_, takenResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+rdlID+"/aliases", []byte(`{"alias":"Bench Press"}`), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, aliasResp.StatusCode, string(aliasData))
+	var withAlias struct {
+		Aliases []string `json:"aliases"`
+	}
+	require.NoError(t, json.Unmarshal(aliasData, &withAlias))
+	require.Equal(t, []string{"RDL"}, withAlias.Aliases)
+	require.Equal(t, http.StatusConflict, takenResp.StatusCode)
+
+	// Act & Assert
+	for query, expected := range map[string]string{
+		"rdl":        "Romanian Deadlift",
+		"chin up":    "Chin-Up",
+		"benchpress": "Bench Press",
+		"dedlift":    "Deadlift",
+	} {
+		data, resp := ts.doRequest(http.MethodGet, "/api/v1/exercises?q="+url.QueryEscape(query), nil, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var results []struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.Unmarshal(data, &results))
+		require.NotEmpty(t, results, query)
+		require.Equal(t, expected, results[0].Name, query)
+	}
+
+	// Act
+	duplicateData, duplicateResp := ts.doRequest(http.MethodPost, "/api/v1/exercises", []byte(`{"name":"Pullup"}`), admin.Tokens.AccessToken)
+	_, forcedResp := ts.doRequest(http.MethodPost, "/api/v1/exercises", []byte(`{"name":"Pullup","force":true}`), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, duplicateResp.StatusCode)
+	var duplicate struct {
+		Candidates []struct {
+			Name string `json:"name"`
+		} `json:"candidates"`
+	}
+	require.NoError(t, json.Unmarshal(duplicateData, &duplicate))
+	require.Len(t, duplicate.Candidates, 1)
+	require.Equal(t, "Pull-Up", duplicate.Candidates[0].Name)
+	require.Equal(t, http.StatusCreated, forcedResp.StatusCode)
+
+	// Act
+	removeData, removeResp := ts.doRequest(http.MethodDelete, "/api/v1/exercises/"+rdlID+"/aliases/rdl", nil, admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, removeResp.StatusCode, string(removeData))
+	require.NoError(t, json.Unmarshal(removeData, &withAlias))
+	require.Empty(t, withAlias.Aliases)
+}
+
+type exerciseAlternative struct {
+	Exercise struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"exercise"`
+	Score   float64  `json:"score"`
+	Pinned  bool     `json:"pinned"`
+	Reasons []string `json:"reasons"`
+}
+
+func (ts *testServer) exerciseAlternatives(exerciseID, query, token string) []string {
+	ts.t.Helper()
+
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+exerciseID+"/alternatives"+query, nil, token)
+	require.Equal(ts.t, http.StatusOK, resp.StatusCode, string(data))
+	var alternatives []exerciseAlternative
+	require.NoError(ts.t, json.Unmarshal(data, &alternatives))
+	names := make([]string, 0, len(alternatives))
+	for _, alt := range alternatives {
+		names = append(names, alt.Exercise.Name)
+	}
+	return names
+}
+
+func TestExerciseAlternatives(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	token := admin.Tokens.AccessToken
+	frontSquatID := ts.createExercise(token, []byte(`{"name":"Front Squat","muscleGroup":"Legs","equipment":"Barbell","primaryMuscles":["Quadriceps","glutes"],"movementPattern":"squat"}`))
+	ts.createExercise(token, []byte(`{"name":"Goblet Squat","muscleGroup":"Legs","equipment":"Dumbbell","primaryMuscles":["quadriceps","glutes"],"movementPattern":"squat"}`))
+	legPressID := ts.createExercise(token, []byte(`{"name":"Leg Press","muscleGroup":"Legs","equipment":"Machine","primaryMuscles":["quadriceps","glutes"],"movementPattern":"squat"}`))
+	ts.createExercise(token, []byte(`{"name":"Walking Lunge","muscleGroup":"Legs","equipment":"Dumbbell","primaryMuscles":["quadriceps","glutes"],"movementPattern":"lunge"}`))
+	hackSquatID := ts.createExercise(token, []byte(`{"name":"Hack Squat","muscleGroup":"Legs","equipment":"Machine","primaryMuscles":["quadriceps","glutes"],"movementPattern":"squat"}`))
+	_, archiveResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+hackSquatID+"/archive", nil, token)
+	require.Equal(t, http.StatusOK, archiveResp.StatusCode)
+	user := ts.registerAthlete()
+
+	// Act & Assert
+	require.Equal(t,
+		[]string{"Barbell Back Squat", "Goblet Squat", "Leg Press", "Walking Lunge", "Deadlift"},
+		ts.exerciseAlternatives(frontSquatID, "", user.Tokens.AccessToken))
+	require.Equal(t,
+		[]string{"Goblet Squat", "Walking Lunge"},
+		ts.exerciseAlternatives(frontSquatID, "?equipment=Dumbbell,Bodyweight", user.Tokens.AccessToken))
+	require.Equal(t,
+		[]string{"Barbell Back Squat", "Goblet Squat"},
+		ts.exerciseAlternatives(frontSquatID, "?limit=2", user.Tokens.AccessToken))
+
+	// Act
+	pinBody := []byte(`{"exerciseIds":["` + legPressID + `"]}`)
+	_, forbiddenResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+frontSquatID+"/alternatives/pinned", pinBody, user.Tokens.AccessToken)
+	invalidData, invalidResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+frontSquatID+"/alternatives/pinned",
+		[]byte(`{"exerciseIds":["`+frontSquatID+`","00000000-0000-0000-0000-000000000000"]}`), token)
+	pinData, pinResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+frontSquatID+"/alternatives/pinned", pinBody, token)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, forbiddenResp.StatusCode)
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.Equal(t, []string{"exerciseIds[0]", "exerciseIds[1]"}, invalid.fieldNames())
+	require.Equal(t, http.StatusOK, pinResp.StatusCode, string(pinData))
+
+	// Act
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+frontSquatID+"/alternatives?equipment=barbell,machine", nil, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var pinned []exerciseAlternative
+	require.NoError(t, json.Unmarshal(data, &pinned))
+	require.Len(t, pinned, 3)
+	require.Equal(t, "Leg Press", pinned[0].Exercise.Name)
+	require.True(t, pinned[0].Pinned)
+	require.Equal(t, "Barbell Back Squat", pinned[1].Exercise.Name)
+	require.Equal(t, 1.0, pinned[1].Score)
+	require.Contains(t, pinned[1].Reasons, "same movement pattern (squat)")
+	require.Equal(t, "Deadlift", pinned[2].Exercise.Name)
+}
+
+type localizedExercise struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Locale      string `json:"locale"`
+}
+
+func (ts *testServer) localizedExercises(query string, header http.Header, token string) (map[string]localizedExercise, string) {
+	ts.t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, ts.httpServer.URL+"/api/v1/exercises"+query, nil)
+	require.NoError(ts.t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.client.Do(req)
+	require.NoError(ts.t, err)
+	defer resp.Body.Close()
+	require.Equal(ts.t, http.StatusOK, resp.StatusCode)
+
+	var exercises []localizedExercise
+	require.NoError(ts.t, json.NewDecoder(resp.Body).Decode(&exercises))
+	byID := make(map[string]localizedExercise, len(exercises))
+	for _, ex := range exercises {
+		byID[ex.ID] = ex
+	}
+	return byID, resp.Header.Get("Content-Language")
+}
+
+func TestExerciseTranslations(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	token := admin.Tokens.AccessToken
+	squatID := ts.createExercise(token, []byte(`{"name":"Goblet Squat","description":"Hold a dumbbell at your chest."}`))
+	lungeID := ts.createExercise(token, []byte(`{"name":"Walking Lunge","description":"Step forward alternately."}`))
+
+	// Act
+	_, germanResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+squatID+"/translations/de",
+		[]byte(`{"name":"Goblet-Kniebeuge","description":"Kurzhantel vor der Brust halten."}`), token)
+	_, spanishResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+lungeID+"/translations/es",
+		[]byte(`{"name":"Zancada caminando"}`), token)
+	_, defaultResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+squatID+"/translations/en", []byte(`{"name":"Squat"}`), token)
+	_, unknownResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+squatID+"/translations/fr", []byte(`{"name":"Squat"}`), token)
+	user := ts.registerAthlete()
+	_, forbiddenResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+squatID+"/translations/de", []byte(`{"name":"X"}`), user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, germanResp.StatusCode)
+	require.Equal(t, http.StatusOK, spanishResp.StatusCode)
+	require.Equal(t, http.StatusBadRequest, defaultResp.StatusCode)
+	require.Equal(t, http.StatusBadRequest, unknownResp.StatusCode)
+	require.Equal(t, http.StatusForbidden, forbiddenResp.StatusCode)
+
+	// Act
+	german, germanLocale := ts.localizedExercises("", http.Header{"Accept-Language": {"fr;q=0.9, de-AT;q=0.8, en;q=0.5"}}, "")
+	spanish, spanishLocale := ts.localizedExercises("?lang=es", http.Header{"Accept-Language": {"de"}}, "")
+	fallback, fallbackLocale := ts.localizedExercises("", http.Header{"Accept-Language": {"fr"}}, "")
+
+	// Assert
+	require.Equal(t, "de", germanLocale)
+	require.Equal(t, "Goblet-Kniebeuge", german[squatID].Name)
+	require.Equal(t, "Kurzhantel vor der Brust halten.", german[squatID].Description)
+	require.Equal(t, "de", german[squatID].Locale)
+	require.Equal(t, "Walking Lunge", german[lungeID].Name)
+	require.Empty(t, german[lungeID].Locale)
+	require.Equal(t, "es", spanishLocale)
+	require.Equal(t, "Zancada caminando", spanish[lungeID].Name)
+	require.Equal(t, "Step forward alternately.", spanish[lungeID].Description)
+	require.Equal(t, "en", fallbackLocale)
+	require.Equal(t, "Goblet Squat", fallback[squatID].Name)
+
+	// Act
+	profileData, profileResp := ts.doRequest(http.MethodPatch, "/api/v1/profile", []byte(`{"locale":"es"}`), user.Tokens.AccessToken)
+	_, badProfileResp := ts.doRequest(http.MethodPatch, "/api/v1/profile", []byte(`{"locale":"xx"}`), user.Tokens.AccessToken)
+	preferred, preferredLocale := ts.localizedExercises("", http.Header{"Accept-Language": {"de"}}, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, profileResp.StatusCode)
+	require.Contains(t, string(profileData), `"locale":"es"`)
+	require.Equal(t, http.StatusBadRequest, badProfileResp.StatusCode)
+	require.Equal(t, "es", preferredLocale)
+	require.Equal(t, "Zancada caminando", preferred[lungeID].Name)
+
+	// Act
+	coverageData, coverageResp := ts.doRequest(http.MethodGet, "/api/v1/exercises/translations/coverage", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, coverageResp.StatusCode, string(coverageData))
+	var coverage []struct {
+		Locale     string `json:"locale"`
+		Total      int    `json:"total"`
+		Translated int    `json:"translated"`
+		Missing    []struct {
+			ID string `json:"id"`
+		} `json:"missing"`
+	}
+	require.NoError(t, json.Unmarshal(coverageData, &coverage))
+	require.Len(t, coverage, 2)
+	require.Equal(t, "de", coverage[0].Locale)
+	require.Equal(t, 1, coverage[0].Translated)
+	require.Len(t, coverage[0].Missing, coverage[0].Total-1)
+	require.Equal(t, "es", coverage[1].Locale)
+	require.Equal(t, 1, coverage[1].Translated)
+
+	// Act
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/exercises/"+squatID+"/translations/de", nil, token)
+	afterDelete, _ := ts.localizedExercises("?lang=de", nil, "")
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	require.Equal(t, "Goblet Squat", afterDelete[squatID].Name)
+}
+
+func (ts *testServer) exerciseIDByName(name string) string {
+	ts.t.Helper()
+
+	exercises, _ := ts.localizedExercises("", nil, "")
+	for id, ex := range exercises {
+		if ex.Name == name {
+			return id
+		}
+	}
+	ts.t.Fatalf("exercise %q not found", name)
+	return ""
+}
+
+func TestExerciseRelationsAndVolumeRollup(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	token := admin.Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	closeGripID := ts.createExercise(token, []byte(`{"name":"Close-Grip Bench Press","force":true}`))
+	kneeID := ts.createExercise(token, []byte(`{"name":"Knee Push-Up","measurementType":"bodyweight_reps","force":true}`))
+	pushUpID := ts.createExercise(token, []byte(`{"name":"Push-Up","measurementType":"bodyweight_reps","force":true}`))
+	deficitID := ts.createExercise(token, []byte(`{"name":"Deficit Push-Up","measurementType":"bodyweight_reps","force":true}`))
+	relate := func(fromID, relationType, toID string) *http.Response {
+		_, resp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+fromID+"/relations",
+			[]byte(`{"type":"`+relationType+`","targetId":"`+toID+`"}`), token)
+		return resp
+	}
+
+	// Act & Assert
+	require.Equal(t, http.StatusCreated, relate(closeGripID, "variation_of", benchID).StatusCode)
+	require.Equal(t, http.StatusCreated, relate(kneeID, "progression_to", pushUpID).StatusCode)
+	require.Equal(t, http.StatusCreated, relate(deficitID, "regression_to", pushUpID).StatusCode)
+	require.Equal(t, http.StatusConflict, relate(closeGripID, "variation_of", benchID).StatusCode)
+	require.Equal(t, http.StatusConflict, relate(benchID, "variation_of", closeGripID).StatusCode)
+	require.Equal(t, http.StatusConflict, relate(deficitID, "progression_to", kneeID).StatusCode)
+	require.Equal(t, http.StatusConflict, relate(pushUpID, "regression_to", deficitID).StatusCode)
+	require.Equal(t, http.StatusBadRequest, relate(pushUpID, "progression_to", pushUpID).StatusCode)
+	require.Equal(t, http.StatusBadRequest, relate(pushUpID, "harder_than", kneeID).StatusCode)
+
+	// Act
+	graphData, graphResp := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+kneeID+"/graph?depth=1", nil, token)
+	fullGraphData, _ := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+kneeID+"/graph", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, graphResp.StatusCode, string(graphData))
+	type exerciseGraph struct {
+		Nodes []struct {
+			Name  string `json:"name"`
+			Depth int    `json:"depth"`
+		} `json:"nodes"`
+		Edges []struct {
+			Type string `json:"type"`
+		} `json:"edges"`
+	}
+	var graph, fullGraph exerciseGraph
+	require.NoError(t, json.Unmarshal(graphData, &graph))
+	require.NoError(t, json.Unmarshal(fullGraphData, &fullGraph))
+	require.Len(t, graph.Nodes, 2)
+	require.Len(t, graph.Edges, 1)
+	require.Len(t, fullGraph.Nodes, 3)
+	require.Equal(t, "Deficit Push-Up", fullGraph.Nodes[2].Name)
+	require.Equal(t, 2, fullGraph.Nodes[2].Depth)
+	require.Len(t, fullGraph.Edges, 2)
+
+	// Arrange
+	user := ts.registerAthlete()
+	workout := []byte(`{"startedAt":"2026-03-02T10:00:00Z","completedAt":"2026-03-02T11:00:00Z","entries":[
+		{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100},
+		{"exerciseId":"` + closeGripID + `","sets":3,"reps":8,"weight":60}
+	]}`)
+	_, workoutResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", workout, user.Tokens.AccessToken)
+	require.Equal(t, http.StatusCreated, workoutResp.StatusCode)
+
+	// Act
+	type volumeReport struct {
+		Exercises []struct {
+			ExerciseID string   `json:"exerciseId"`
+			Name       string   `json:"name"`
+			Sets       int      `json:"sets"`
+			Volume     float64  `json:"volume"`
+			Includes   []string `json:"includes"`
+		} `json:"exercises"`
+	}
+	var separate, rolledUp, outOfRange volumeReport
+	separateData, separateResp := ts.doRequest(http.MethodGet, "/api/v1/analytics/volume?from=2026-03-01&to=2026-04-01", nil, user.Tokens.AccessToken)
+	rolledUpData, _ := ts.doRequest(http.MethodGet, "/api/v1/analytics/volume?rollup=variations", nil, user.Tokens.AccessToken)
+	outOfRangeData, _ := ts.doRequest(http.MethodGet, "/api/v1/analytics/volume?from=2026-04-01", nil, user.Tokens.AccessToken)
+	_, badDateResp := ts.doRequest(http.MethodGet, "/api/v1/analytics/volume?from=March", nil, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusOK, separateResp.StatusCode, string(separateData))
+	require.NoError(t, json.Unmarshal(separateData, &separate))
+	require.NoError(t, json.Unmarshal(rolledUpData, &rolledUp))
+	require.NoError(t, json.Unmarshal(outOfRangeData, &outOfRange))
+	require.Len(t, separate.Exercises, 2)
+	require.Equal(t, "Bench Press", separate.Exercises[0].Name)
+	require.Equal(t, 1500.0, separate.Exercises[0].Volume)
+	require.Equal(t, 1440.0, separate.Exercises[1].Volume)
+	require.Len(t, rolledUp.Exercises, 1)
+	require.Equal(t, benchID, rolledUp.Exercises[0].ExerciseID)
+	require.Equal(t, 6, rolledUp.Exercises[0].Sets)
+	require.Equal(t, 2940.0, rolledUp.Exercises[0].Volume)
+	require.Equal(t, []string{closeGripID}, rolledUp.Exercises[0].Includes)
+	require.Empty(t, outOfRange.Exercises)
+	require.Equal(t, http.StatusBadRequest, badDateResp.StatusCode)
+}
+
+func TestExerciseMerge(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	token := admin.Tokens.AccessToken
+	pullUpID := ts.exerciseIDByName("Pull-Up")
+	duplicateID := ts.createExercise(token, []byte(`{"name":"Overhand Pull-Up","measurementType":"bodyweight_reps","force":true}`))
+	_, aliasResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+duplicateID+"/aliases", []byte(`{"alias":"Pronated Pull-Up"}`), token)
+	require.Equal(t, http.StatusOK, aliasResp.StatusCode)
+	user := ts.registerAthlete()
+	workout := []byte(`{"entries":[
+		{"exerciseId":"` + duplicateID + `","sets":3,"reps":8},
+		{"exerciseId":"` + pullUpID + `","sets":2,"reps":6},
+		{"exerciseId":"` + duplicateID + `","sets":1,"reps":5}
+	]}`)
+	_, workoutResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", workout, user.Tokens.AccessToken)
+	require.Equal(t, http.StatusCreated, workoutResp.StatusCode)
+	cursor := ts.exerciseChanges("0").Cursor
+
+	// Act
+	_, selfResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+pullUpID+"/merge", []byte(`{"duplicateId":"`+pullUpID+`"}`), token)
+	_, forbiddenResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+pullUpID+"/merge", []byte(`{"duplicateId":"`+duplicateID+`"}`), user.Tokens.AccessToken)
+	mergeData, mergeResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+pullUpID+"/merge", []byte(`{"duplicateId":"`+duplicateID+`"}`), token)
+	_, againResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+pullUpID+"/merge", []byte(`{"duplicateId":"`+duplicateID+`"}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, selfResp.StatusCode)
+	require.Equal(t, http.StatusForbidden, forbiddenResp.StatusCode)
+	require.Equal(t, http.StatusOK, mergeResp.StatusCode, string(mergeData))
+	require.Equal(t, http.StatusNotFound, againResp.StatusCode)
+	var merged struct {
+		Exercise struct {
+			ID      string   `json:"id"`
+			Aliases []string `json:"aliases"`
+		} `json:"exercise"`
+		MergedID     string `json:"mergedId"`
+		MovedEntries int    `json:"movedEntries"`
+	}
+	require.NoError(t, json.Unmarshal(mergeData, &merged))
+	require.Equal(t, pullUpID, merged.Exercise.ID)
+	require.Equal(t, duplicateID, merged.MergedID)
+	require.Equal(t, 2, merged.MovedEntries)
+	require.Equal(t, []string{"Overhand Pull-Up", "Pronated Pull-Up"}, merged.Exercise.Aliases)
+
+	// Act
+	workoutsData, _ := ts.doRequest(http.MethodGet, "/api/v1/workouts", nil, user.Tokens.AccessToken)
+	historyData, _ := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+pullUpID+"/revisions", nil, token)
+	feed := ts.exerciseChanges(cursor)
+
+	// Assert
+	var sessions []struct {
+		Entries []struct {
+			ExerciseID string `json:"exerciseId"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(workoutsData, &sessions))
+	require.Len(t, sessions, 1)
+	for _, entry := range sessions[0].Entries {
+		require.Equal(t, pullUpID, entry.ExerciseID)
+	}
+	var history []exerciseRevision
+	require.NoError(t, json.Unmarshal(historyData, &history))
+	require.Equal(t, "merge", history[0].Action)
+	require.Equal(t, "mergedFrom", history[0].Changes[0].Field)
+	require.Len(t, feed.Tombstones, 1)
+	require.Equal(t, duplicateID, feed.Tombstones[0].ID)
+	require.Equal(t, "merged", feed.Tombstones[0].Reason)
+	require.Equal(t, pullUpID, feed.Tombstones[0].ReplacedBy)
+	require.Len(t, feed.Exercises, 1)
+	require.Equal(t, pullUpID, feed.Exercises[0].ID)
+
+	// Act
+	searchData, _ := ts.doRequest(http.MethodGet, "/api/v1/exercises?q=overhand", nil, "")
+
+	// Assert
+	var found []struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(searchData, &found))
+	require.NotEmpty(t, found)
+	require.Equal(t, pullUpID, found[0].ID)
+
+	// Arrange: an exercise measured differently, and a progression chain
+	// that merging its ends would close into a loop.
+	deadHangID := ts.createExercise(token, []byte(`{"name":"Dead Hang","measurementType":"time","force":true}`))
+	negativeID := ts.createExercise(token, []byte(`{"name":"Negative Pull-Up","measurementType":"bodyweight_reps","force":true}`))
+	weightedID := ts.createExercise(token, []byte(`{"name":"Weighted Pull-Up","measurementType":"bodyweight_reps","force":true}`))
+	for _, edge := range [][2]string{{negativeID, pullUpID}, {pullUpID, weightedID}} {
+		_, relateResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+edge[0]+"/relations",
+			[]byte(`{"type":"progression_to","targetId":"`+edge[1]+`"}`), token)
+		require.Equal(t, http.StatusCreated, relateResp.StatusCode)
+	}
+
+	// Act
+	_, incompatibleResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+pullUpID+"/merge", []byte(`{"duplicateId":"`+deadHangID+`"}`), token)
+	_, cycleResp := ts.doRequest(http.MethodPost, "/api/v1/exercises/"+negativeID+"/merge", []byte(`{"duplicateId":"`+weightedID+`"}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, incompatibleResp.StatusCode)
+	require.Equal(t, http.StatusConflict, cycleResp.StatusCode)
+	for _, id := range []string{deadHangID, weightedID} {
+		_, getResp := ts.doRequest(http.MethodGet, "/api/v1/exercises/"+id+"/revisions", nil, token)
+		require.Equal(t, http.StatusOK, getResp.StatusCode, "rejected merges keep the duplicate")
+	}
+}
+
+type catalogueReport struct {
+	FromVersion int      `json:"fromVersion"`
+	ToVersion   int      `json:"toVersion"`
+	Created     []string `json:"created"`
+	Matched     []string `json:"matched"`
+	Removed     int      `json:"removed"`
+}
+
+func (ts *testServer) catalogueReport(token string) catalogueReport {
+	ts.t.Helper()
+
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/exercises/catalogue/report", nil, token)
+	require.Equal(ts.t, http.StatusOK, resp.StatusCode, string(data))
+	var report catalogueReport
+	require.NoError(ts.t, json.Unmarshal(data, &report))
+	return report
+}
+
+func TestExerciseCatalogueUpgrade(t *testing.T) {
+	// Arrange: version 1 of the catalogue seeds the original lifts.
+	repo := newMemoryRepository()
+	cfg := newTestConfig(t)
+	ts := startTestServer(t, cfg, repo)
+	token := ts.login("admin@test.app", "AdminPass123!").Tokens.AccessToken
+
+	first := ts.catalogueReport(token)
+	require.Equal(t, 0, first.FromVersion)
+	require.Equal(t, 1, first.ToVersion)
+	require.Len(t, first.Created, 5)
+
+	_, resp := ts.doRequest(http.MethodDelete, "/api/v1/exercises/"+ts.exerciseIDByName("Plank"), nil, token)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	farmersWalkID := ts.createExercise(token, []byte(`{"name": "Farmers Walk", "muscleGroup": "Full Body", "measurementType": "distance_time"}`))
+
+	// Act: restart on the same data with version 2, which adds two exercises.
+	cfg.ExerciseCatalogue = testDataPath(t, "exercises/catalogue_v2.json")
+	upgraded := startTestServer(t, cfg, repo)
+	report := upgraded.catalogueReport(token)
+
+	// Assert: the new exercise is created, the admin's own copy is adopted
+	// through the catalogue alias and the deleted one stays deleted.
+	require.Equal(t, 1, report.FromVersion)
+	require.Equal(t, 2, report.ToVersion)
+	require.Equal(t, []string{"Front Squat"}, report.Created)
+	require.Equal(t, []string{"Farmer's Carry"}, report.Matched)
+	require.Equal(t, 1, report.Removed)
+
+	exercises, _ := upgraded.localizedExercises("", nil, "")
+	require.Len(t, exercises, 6)
+	require.Contains(t, exercises, farmersWalkID)
+	require.Equal(t, "Farmers Walk", exercises[farmersWalkID].Name)
+	upgraded.exerciseIDByName("Front Squat")
+	for _, ex := range exercises {
+		require.NotEqual(t, "Plank", ex.Name)
+	}
+
+	// Act: restarting on the same version changes nothing.
+	again := startTestServer(t, cfg, repo).catalogueReport(token)
+
+	// Assert
+	require.Equal(t, 2, again.FromVersion)
+	require.Equal(t, 2, again.ToVersion)
+	require.Empty(t, again.Created)
+	require.Empty(t, again.Matched)
+}
+
+func TestEmbeddedExerciseCatalogue(t *testing.T) {
+	// Arrange
+	cfg := newTestConfig(t)
+	cfg.ExerciseCatalogue = ""
+
+	// Act
+	ts := startTestServer(t, cfg, newMemoryRepository())
+	token := ts.login("admin@test.app", "AdminPass123!").Tokens.AccessToken
+
+	// Assert
+	report := ts.catalogueReport(token)
+	require.GreaterOrEqual(t, len(report.Created), 200)
+	exercises, _ := ts.localizedExercises("", nil, "")
+	require.Len(t, exercises, len(report.Created))
+
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/exercises?q=rdl", nil, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var results []localizedExercise
+	require.NoError(t, json.Unmarshal(data, &results))
+	require.NotEmpty(t, results)
+	require.Equal(t, "Romanian Deadlift", results[0].Name)
+}
@@ -0,0 +1,168 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type goalResponse struct {
+	ID          string     `json:"id"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	TargetValue float64    `json:"targetValue"`
+	Start       *float64   `json:"start"`
+	Current     *float64   `json:"current"`
+	Percent     float64    `json:"percent"`
+	AchievedAt  *time.Time `json:"achievedAt"`
+	Projection  *struct {
+		Model string    `json:"model"`
+		Date  time.Time `json:"date"`
+	} `json:"projection"`
+}
+
+func TestGoals(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange: bench press estimates climb 5.625kg every two weeks.
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	plankID := ts.exerciseIDByName("Plank")
+	now := time.Now().UTC()
+	daysAgo := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+	inDays := func(days int) string { return now.AddDate(0, 0, days).Format(time.DateOnly) }
+	postWorkout := func(startedAt string, weight float64, reps int) string {
+		body := fmt.Sprintf(`{"startedAt":%q,"entries":[{"exerciseId":%q,"sets":3,"reps":%d,"weight":%g}]}`, startedAt, benchID, reps, weight)
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(body), token)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+		var workout struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal(data, &workout))
+		return workout.ID
+	}
+	for i, weight := range []float64{80, 85, 90, 95} {
+		postWorkout(daysAgo(57-14*i), weight, 5)
+	}
+	createGoal := func(body string) goalResponse {
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/goals", []byte(body), token)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+		var goal goalResponse
+		require.NoError(t, json.Unmarshal(data, &goal))
+		return goal
+	}
+	getGoal := func(id string) goalResponse {
+		data, resp := ts.doRequest(http.MethodGet, "/api/v1/goals/"+id, nil, token)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+		var goal goalResponse
+		require.NoError(t, json.Unmarshal(data, &goal))
+		return goal
+	}
+
+	// Act
+	strength := createGoal(fmt.Sprintf(`{"type":"strength","exerciseId":%q,"target":120,"deadline":%q}`, benchID, inDays(60)))
+	rushed := createGoal(fmt.Sprintf(`{"type":"strength","exerciseId":%q,"target":120,"deadline":%q}`, benchID, inDays(7)))
+
+	// Assert: the linear trend reaches 120kg about two and a half weeks out.
+	require.Equal(t, "on_track", strength.Status)
+	require.Equal(t, 120.0, strength.TargetValue)
+	require.Equal(t, 106.88, *strength.Start)
+	require.Equal(t, 106.88, *strength.Current)
+	require.NotNil(t, strength.Projection)
+	require.Equal(t, "linear", strength.Projection.Model)
+	require.WithinDuration(t, now.AddDate(0, 0, 18), strength.Projection.Date, 2*24*time.Hour)
+	require.Equal(t, "behind", rushed.Status)
+	require.Equal(t, strength.Projection.Date.Unix(), rushed.Projection.Date.Unix())
+
+	// Act: frequency and bodyweight goals.
+	frequency := createGoal(fmt.Sprintf(`{"type":"frequency","target":3,"deadline":%q}`, inDays(14)))
+	bodyweight := createGoal(fmt.Sprintf(`{"type":"bodyweight","target":80,"deadline":%q}`, inDays(90)))
+
+	// Assert: one workout in the last four weeks is a quarter a week.
+	require.Equal(t, 0.25, *frequency.Current)
+	require.Equal(t, "behind", frequency.Status)
+	require.Nil(t, bodyweight.Start)
+	require.Nil(t, bodyweight.Current)
+	require.Equal(t, "behind", bodyweight.Status)
+
+	// Act: a heavy single reaches the strength goals.
+	heavy := postWorkout(daysAgo(0), 125, 1)
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/goals", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var goals []goalResponse
+	require.NoError(t, json.Unmarshal(data, &goals))
+	require.Len(t, goals, 4)
+	require.Equal(t, []string{rushed.ID, frequency.ID, strength.ID, bodyweight.ID},
+		[]string{goals[0].ID, goals[1].ID, goals[2].ID, goals[3].ID}, "goals are listed by deadline")
+	require.Equal(t, "achieved", goals[0].Status)
+	achieved := getGoal(strength.ID)
+	require.Equal(t, "achieved", achieved.Status)
+	require.Equal(t, 100.0, achieved.Percent)
+	require.NotNil(t, achieved.AchievedAt)
+	require.Nil(t, achieved.Projection)
+
+	// Act: removing the workout keeps the goal achieved.
+	_, resp = ts.doRequest(http.MethodDelete, "/api/v1/workouts/"+heavy, nil, token)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Assert
+	require.Equal(t, "achieved", getGoal(strength.ID).Status)
+	require.Equal(t, "achieved", getGoal(rushed.ID).Status)
+
+	// Act: raising the target has to be reached again.
+	data, resp = ts.doRequest(http.MethodPut, "/api/v1/goals/"+strength.ID,
+		[]byte(fmt.Sprintf(`{"type":"strength","exerciseId":%q,"target":150,"reps":3,"deadline":%q}`, benchID, inDays(60))), token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var raised goalResponse
+	require.NoError(t, json.Unmarshal(data, &raised))
+	require.Nil(t, raised.AchievedAt)
+	require.Equal(t, 158.82, raised.TargetValue)
+	require.Equal(t, "behind", raised.Status)
+
+	// Act: invalid goals.
+	for _, tc := range []struct {
+		body   string
+		fields []string
+	}{
+		{`{"type":"weight","deadline":"` + inDays(30) + `"}`, []string{"type"}},
+		{`{"type":"strength","target":100}`, []string{"exerciseId", "deadline"}},
+		{`{"type":"strength","exerciseId":"` + plankID + `","target":100,"reps":31,"deadline":"` + inDays(-1) + `"}`, []string{"exerciseId", "reps", "deadline"}},
+		{`{"type":"frequency","exerciseId":"` + benchID + `","target":20,"reps":5,"deadline":"30/01/2027"}`, []string{"exerciseId", "reps", "target", "deadline"}},
+		{`{"type":"bodyweight","target":10,"deadline":"` + inDays(30) + `"}`, []string{"target"}},
+	} {
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/goals", []byte(tc.body), token)
+
+		// Assert
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, string(data))
+		var fieldErr fieldErrorResponse
+		require.NoError(t, json.Unmarshal(data, &fieldErr))
+		require.ElementsMatch(t, tc.fields, fieldErr.fieldNames(), tc.body)
+	}
+
+	// Act: goals are private.
+	_, registerResp := ts.doRequest(http.MethodPost, "/api/v1/auth/register", []byte(`{"email":"partner@example.com","password":"TrainHard123!"}`), "")
+	require.Equal(t, http.StatusCreated, registerResp.StatusCode)
+	partner := ts.login("partner@example.com", "TrainHard123!").Tokens.AccessToken
+	_, getResp := ts.doRequest(http.MethodGet, "/api/v1/goals/"+strength.ID, nil, partner)
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/goals/"+strength.ID, nil, partner)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, getResp.StatusCode)
+	require.Equal(t, http.StatusNotFound, deleteResp.StatusCode)
+
+	// Act
+	_, resp = ts.doRequest(http.MethodDelete, "/api/v1/goals/"+bodyweight.ID, nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	_, resp = ts.doRequest(http.MethodGet, "/api/v1/goals/"+bodyweight.ID, nil, token)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
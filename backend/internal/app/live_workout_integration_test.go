@@ -0,0 +1,327 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type liveSetResponse struct {
+	Reps        int        `json:"reps"`
+	Weight      float64    `json:"weight"`
+	CompletedAt *time.Time `json:"completedAt"`
+	RestSeconds *int       `json:"restSeconds"`
+}
+
+type liveWorkoutResponse struct {
+	ID             string     `json:"id"`
+	StartedAt      time.Time  `json:"startedAt"`
+	LastActivityAt time.Time  `json:"lastActivityAt"`
+	ClosesAt       *time.Time `json:"closesAt"`
+	Revision       int        `json:"revision"`
+	Entries        []struct {
+		ID         string            `json:"id"`
+		ExerciseID string            `json:"exerciseId"`
+		Sets       []liveSetResponse `json:"sets"`
+	} `json:"entries"`
+}
+
+type finishedWorkout struct {
+	ID          string    `json:"id"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+	Entries     []struct {
+		ExerciseID string            `json:"exerciseId"`
+		SetDetails []liveSetResponse `json:"setDetails"`
+	} `json:"entries"`
+	Records []recordResponse `json:"records"`
+}
+
+// liveRequest sends a request to the live workout endpoints and decodes the
+// live workout answered, if any.
+func (ts *testServer) liveRequest(method, path, body, token string, status int) liveWorkoutResponse {
+	ts.t.Helper()
+
+	var payload []byte
+	if body != "" {
+		payload = []byte(body)
+	}
+	data, resp := ts.doRequest(method, "/api/v1/workouts/live"+path, payload, token)
+	require.Equal(ts.t, status, resp.StatusCode, string(data))
+	var live liveWorkoutResponse
+	if status < 300 && len(data) > 0 {
+		require.NoError(ts.t, json.Unmarshal(data, &live))
+	}
+	return live
+}
+
+func TestLiveWorkouts(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	pullUpID := ts.exerciseIDByName("Pull-Up")
+
+	// Act
+	started := ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+	ts.liveRequest(http.MethodPost, "", "", token, http.StatusConflict)
+
+	// Assert
+	require.NotEmpty(t, started.ID)
+	require.Empty(t, started.Entries)
+	require.NotNil(t, started.ClosesAt)
+	require.WithinDuration(t, started.LastActivityAt.Add(time.Hour), *started.ClosesAt, time.Second)
+
+	// Act: log two sets with a rest between them.
+	live := ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"`+benchID+`"}`, token, http.StatusOK)
+	bench := live.Entries[0].ID
+	ts.liveRequest(http.MethodPost, "/entries/"+bench+"/sets", `{"reps":5,"weight":100}`, token, http.StatusOK)
+	time.Sleep(1100 * time.Millisecond)
+	live = ts.liveRequest(http.MethodPost, "/entries/"+bench+"/sets", `{"reps":5,"weight":100,"restSeconds":600}`, token, http.StatusOK)
+
+	// Assert: the server times the sets and ignores client timings.
+	sets := live.Entries[0].Sets
+	require.Len(t, sets, 2)
+	require.NotNil(t, sets[0].CompletedAt)
+	require.Nil(t, sets[0].RestSeconds, "the first set has no rest before it")
+	require.NotNil(t, sets[1].RestSeconds)
+	require.GreaterOrEqual(t, *sets[1].RestSeconds, 1)
+	require.Less(t, *sets[1].RestSeconds, 600)
+
+	// Act: another device resumes the workout and corrects a set.
+	resumed := ts.liveRequest(http.MethodGet, "", "", token, http.StatusOK)
+	corrected := ts.liveRequest(http.MethodPut, "/entries/"+bench+"/sets/1", `{"reps":4,"weight":102.5}`, token, http.StatusOK)
+
+	// Assert
+	require.Equal(t, live, resumed)
+	require.Equal(t, 4, corrected.Entries[0].Sets[1].Reps)
+	require.Equal(t, sets[1].CompletedAt, corrected.Entries[0].Sets[1].CompletedAt, "corrections keep the timing")
+	require.Equal(t, sets[1].RestSeconds, corrected.Entries[0].Sets[1].RestSeconds)
+	require.Greater(t, corrected.Revision, live.Revision)
+
+	// Act: invalid changes.
+	data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts/live/entries/"+bench+"/sets", []byte(`{"weight":100}`), token)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, string(data))
+	var fieldErr fieldErrorResponse
+	require.NoError(t, json.Unmarshal(data, &fieldErr))
+
+	// Assert
+	require.Equal(t, []string{"set.reps"}, fieldErr.fieldNames())
+	ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"`+benchID+`","side":"left"}`, token, http.StatusBadRequest)
+	ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"5f0c7d52-8f3b-4b8e-9a8a-2f8f0c3a9e11"}`, token, http.StatusBadRequest)
+	ts.liveRequest(http.MethodDelete, "/entries/"+bench+"/sets/2", "", token, http.StatusNotFound)
+	ts.liveRequest(http.MethodPut, "/entries/missing/sets/0", `{"reps":5,"weight":100}`, token, http.StatusNotFound)
+
+	// Act: an exercise started but not performed is left out when finishing.
+	live = ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"`+pullUpID+`"}`, token, http.StatusOK)
+	require.Len(t, live.Entries, 2)
+	data, resp = ts.doRequest(http.MethodPost, "/api/v1/workouts/live/finish", nil, token)
+
+	// Assert: the workout is saved with its timings under the same id.
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	var finished finishedWorkout
+	require.NoError(t, json.Unmarshal(data, &finished))
+	require.Equal(t, started.ID, finished.ID)
+	require.Len(t, finished.Entries, 1)
+	require.Equal(t, benchID, finished.Entries[0].ExerciseID)
+	require.Len(t, finished.Entries[0].SetDetails, 2)
+	require.Equal(t, sets[1].RestSeconds, finished.Entries[0].SetDetails[1].RestSeconds)
+	require.NotEmpty(t, finished.Records)
+	data, resp = ts.doRequest(http.MethodGet, "/api/v1/workouts/"+finished.ID, nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var stored finishedWorkout
+	require.NoError(t, json.Unmarshal(data, &stored))
+	require.Equal(t, finished.Entries, stored.Entries)
+	ts.liveRequest(http.MethodGet, "", "", token, http.StatusNotFound)
+	ts.liveRequest(http.MethodPost, "/finish", "", token, http.StatusNotFound)
+
+	// Act: a workout without sets cannot be finished, only discarded.
+	ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+	ts.liveRequest(http.MethodPost, "/finish", "", token, http.StatusBadRequest)
+	ts.liveRequest(http.MethodDelete, "", "", token, http.StatusNoContent)
+
+	// Assert
+	ts.liveRequest(http.MethodGet, "", "", token, http.StatusNotFound)
+	ts.liveRequest(http.MethodDelete, "", "", token, http.StatusNotFound)
+}
+
+func TestIdleLiveWorkoutsClose(t *testing.T) {
+	repo := newMemoryRepository()
+	cfg := newTestConfig(t)
+	cfg.LiveWorkoutTimeout = 100 * time.Millisecond
+	ts := startTestServer(t, cfg, repo)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	logSet := func(ts *testServer) liveWorkoutResponse {
+		ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+		live := ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"`+benchID+`"}`, token, http.StatusOK)
+		return ts.liveRequest(http.MethodPost, "/entries/"+live.Entries[0].ID+"/sets", `{"reps":5,"weight":100}`, token, http.StatusOK)
+	}
+	abandoned := logSet(ts)
+	time.Sleep(2 * cfg.LiveWorkoutTimeout)
+
+	// Act
+	ts.liveRequest(http.MethodGet, "", "", token, http.StatusNotFound)
+
+	// Assert: the workout was saved as of its last activity.
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/workouts/"+abandoned.ID, nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var saved finishedWorkout
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.True(t, abandoned.LastActivityAt.Equal(saved.CompletedAt))
+
+	// Act: a workout abandoned without sets is discarded.
+	ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+	time.Sleep(2 * cfg.LiveWorkoutTimeout)
+	ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+
+	// Assert
+	data, resp = ts.doRequest(http.MethodGet, "/api/v1/workouts", nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var history []finishedWorkout
+	require.NoError(t, json.Unmarshal(data, &history))
+	require.Len(t, history, 1)
+
+	// Act: idle workouts are also closed when the server starts.
+	ts.liveRequest(http.MethodDelete, "", "", token, http.StatusNoContent)
+	idle := logSet(ts)
+	time.Sleep(2 * cfg.LiveWorkoutTimeout)
+	restarted := startTestServer(t, cfg, repo)
+
+	// Assert
+	_, resp = restarted.doRequest(http.MethodGet, "/api/v1/workouts/"+idle.ID, nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestIdleLiveWorkoutsThatFailValidationClose(t *testing.T) {
+	repo := newMemoryRepository()
+	cfg := newTestConfig(t)
+	cfg.LiveWorkoutTimeout = 100 * time.Millisecond
+	ts := startTestServer(t, cfg, repo)
+
+	// Arrange
+	athlete := ts.registerAthlete()
+	token := athlete.Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	start := func(startedAt time.Time, exerciseID, plannedID string) string {
+		completedAt := startedAt.Add(time.Hour)
+		live := &domain.LiveWorkout{
+			UserID: athlete.User.ID, StartedAt: startedAt, LastActivityAt: time.Now().UTC(), PlannedSessionID: plannedID,
+			Entries: []domain.LiveEntry{{ID: uuid.NewString(), ExerciseID: exerciseID, Sets: []domain.WorkoutSet{
+				{Type: domain.SetWorking, Reps: 5, Weight: 100, Completed: true, CompletedAt: &completedAt},
+			}}},
+		}
+		require.NoError(t, repo.LiveWorkouts.Create(live))
+		return live.ID
+	}
+	// Left open for more than a day, from a planned workout that is gone.
+	staleID := start(time.Now().UTC().Add(-30*time.Hour), benchID, uuid.NewString())
+	time.Sleep(2 * cfg.LiveWorkoutTimeout)
+
+	// Act
+	ts.liveRequest(http.MethodGet, "", "", token, http.StatusNotFound)
+
+	// Assert: it is saved without the planned workout and ends a day in.
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/workouts/"+staleID, nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var saved struct {
+		finishedWorkout
+		PlannedSessionID string `json:"plannedSessionId"`
+	}
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Empty(t, saved.PlannedSessionID)
+	require.Equal(t, 24*time.Hour, saved.CompletedAt.Sub(saved.StartedAt))
+
+	// Act: a workout that cannot be saved at all is discarded.
+	brokenID := start(time.Now().UTC().Add(-time.Hour), uuid.NewString(), "")
+	time.Sleep(2 * cfg.LiveWorkoutTimeout)
+	ts.liveRequest(http.MethodGet, "", "", token, http.StatusNotFound)
+
+	// Assert: rather than staying open to fail again on every sweep.
+	open, err := repo.LiveWorkouts.Idle(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, open)
+	_, resp = ts.doRequest(http.MethodGet, "/api/v1/workouts/"+brokenID, nil, token)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestIdleLiveWorkoutsSweep(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.LiveWorkoutTimeout = 100 * time.Millisecond
+	cfg.LiveWorkoutSweepInterval = 20 * time.Millisecond
+	ts := startTestServer(t, cfg, newMemoryRepository())
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+	live := ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"`+benchID+`"}`, token, http.StatusOK)
+	ts.liveRequest(http.MethodPost, "/entries/"+live.Entries[0].ID+"/sets", `{"reps":5,"weight":100}`, token, http.StatusOK)
+
+	// Act & Assert: the sweep saves it without anyone opening it again.
+	require.Eventually(t, func() bool {
+		_, resp := ts.doRequest(http.MethodGet, "/api/v1/workouts/"+live.ID, nil, token)
+		return resp.StatusCode == http.StatusOK
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+// racingLiveWorkouts logs a set on another device just before the first
+// live workout is removed.
+type racingLiveWorkouts struct {
+	repository.LiveWorkoutRepository
+	raced bool
+}
+
+func (r *racingLiveWorkouts) Delete(id string, revision int) error {
+	if !r.raced {
+		r.raced = true
+		open, err := r.Idle(time.Now().Add(time.Hour))
+		if err != nil {
+			return err
+		}
+		for _, live := range open {
+			if live.ID == id {
+				live.Entries[0].Sets = append(live.Entries[0].Sets, domain.WorkoutSet{Type: domain.SetWorking, Reps: 3, Weight: 110, Completed: true})
+				if err := r.Update(&live); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return r.LiveWorkoutRepository.Delete(id, revision)
+}
+
+func TestFinishingLiveWorkoutKeepsConcurrentSets(t *testing.T) {
+	repo := newMemoryRepository()
+	repo.LiveWorkouts = &racingLiveWorkouts{LiveWorkoutRepository: repo.LiveWorkouts}
+	ts := startTestServer(t, newTestConfig(t), repo)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	ts.liveRequest(http.MethodPost, "", "", token, http.StatusCreated)
+	live := ts.liveRequest(http.MethodPost, "/entries", `{"exerciseId":"`+benchID+`"}`, token, http.StatusOK)
+	ts.liveRequest(http.MethodPost, "/entries/"+live.Entries[0].ID+"/sets", `{"reps":5,"weight":100}`, token, http.StatusOK)
+
+	// Act
+	data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts/live/finish", nil, token)
+
+	// Assert: the set logged while finishing is saved too.
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	var finished finishedWorkout
+	require.NoError(t, json.Unmarshal(data, &finished))
+	require.Len(t, finished.Entries, 1)
+	require.Len(t, finished.Entries[0].SetDetails, 2)
+	require.Equal(t, 3, finished.Entries[0].SetDetails[1].Reps)
+	ts.liveRequest(http.MethodGet, "", "", token, http.StatusNotFound)
+}
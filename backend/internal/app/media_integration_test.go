@@ -0,0 +1,167 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type exerciseMedia struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	ContentType  string `json:"contentType"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+}
+
+func (ts *testServer) uploadMedia(exerciseID, filename string, content []byte, token string) ([]byte, *http.Response) {
+	ts.t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(ts.t, err)
+	_, err = part.Write(content)
+	require.NoError(ts.t, err)
+	require.NoError(ts.t, writer.Close())
+
+	req, err := http.NewRequest(http.MethodPost, ts.httpServer.URL+"/api/v1/exercises/"+exerciseID+"/media", &body)
+	require.NoError(ts.t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := ts.client.Do(req)
+	require.NoError(ts.t, err)
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(ts.t, err)
+	return data, resp
+}
+
+func encodePNG(t *testing.T, width, height int, noisy bool) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255}
+			if noisy {
+				c = color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// resizePNGHeader rewrites the dimensions in a PNG's header chunk without
+// touching its pixel data.
+func resizePNGHeader(t *testing.T, data []byte, width, height uint32) []byte {
+	t.Helper()
+
+	// Signature (8 bytes), chunk length (4) and type (4) come before the
+	// IHDR data, whose CRC covers the type and data.
+	const ihdr = 16
+	require.Equal(t, "IHDR", string(data[ihdr-4:ihdr]))
+	out := append([]byte{}, data...)
+	binary.BigEndian.PutUint32(out[ihdr:], width)
+	binary.BigEndian.PutUint32(out[ihdr+4:], height)
+	binary.BigEndian.PutUint32(out[ihdr+13:], crc32.ChecksumIEEE(out[ihdr-4:ihdr+13]))
+	return out
+}
+
+func TestExerciseMediaUploads(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	exerciseID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Turkish Get-Up","equipment":"Kettlebell"}`))
+
+	// Act
+	uploadData, uploadResp := ts.uploadMedia(exerciseID, "demo.png", encodePNG(t, 800, 400, false), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, uploadResp.StatusCode, string(uploadData))
+	var uploaded exerciseMedia
+	require.NoError(t, json.Unmarshal(uploadData, &uploaded))
+	require.Equal(t, "image", uploaded.Kind)
+	require.Equal(t, "image/png", uploaded.ContentType)
+	require.True(t, strings.HasPrefix(uploaded.URL, "/api/v1/media/exercises/"+exerciseID+"/"))
+	require.NotEmpty(t, uploaded.ThumbnailURL)
+
+	// Act
+	originalData, originalResp := ts.doRequest(http.MethodGet, uploaded.URL, nil, "")
+	thumbData, thumbResp := ts.doRequest(http.MethodGet, uploaded.ThumbnailURL, nil, "")
+
+	// Assert
+	require.Equal(t, http.StatusOK, originalResp.StatusCode)
+	require.Equal(t, "image/png", originalResp.Header.Get("Content-Type"))
+	require.NotEmpty(t, originalData)
+	require.Equal(t, http.StatusOK, thumbResp.StatusCode)
+	thumb, err := jpeg.DecodeConfig(bytes.NewReader(thumbData))
+	require.NoError(t, err)
+	require.Equal(t, 320, thumb.Width)
+	require.Equal(t, 160, thumb.Height)
+
+	// Act
+	listData, _ := ts.doRequest(http.MethodGet, "/api/v1/exercises", nil, "")
+
+	// Assert
+	var list []struct {
+		ID    string          `json:"id"`
+		Media []exerciseMedia `json:"media"`
+	}
+	require.NoError(t, json.Unmarshal(listData, &list))
+	for _, item := range list {
+		if item.ID == exerciseID {
+			require.Len(t, item.Media, 1)
+			require.Equal(t, uploaded.URL, item.Media[0].URL)
+		} else {
+			require.Empty(t, item.Media)
+		}
+	}
+
+	// Act
+	_, textResp := ts.uploadMedia(exerciseID, "notes.txt", []byte("just some text"), admin.Tokens.AccessToken)
+	_, largeResp := ts.uploadMedia(exerciseID, "huge.png", encodePNG(t, 400, 400, true), admin.Tokens.AccessToken)
+	_, userResp := ts.uploadMedia(exerciseID, "demo.png", encodePNG(t, 10, 10, false), ts.registerAthlete().Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusUnsupportedMediaType, textResp.StatusCode)
+	require.Equal(t, http.StatusRequestEntityTooLarge, largeResp.StatusCode)
+	require.Equal(t, http.StatusForbidden, userResp.StatusCode)
+
+	// Act: a tiny file declaring huge dimensions is rejected before decoding.
+	bombData, bombResp := ts.uploadMedia(exerciseID, "bomb.png", resizePNGHeader(t, encodePNG(t, 10, 10, false), 50000, 50000), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, bombResp.StatusCode, string(bombData))
+	require.Contains(t, string(bombData), "too many pixels")
+
+	// Act
+	otherID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Windmill","equipment":"Kettlebell"}`))
+	_, wrongExerciseResp := ts.doRequest(http.MethodDelete, "/api/v1/exercises/"+otherID+"/media/"+uploaded.ID, nil, admin.Tokens.AccessToken)
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/exercises/"+exerciseID+"/media/"+uploaded.ID, nil, admin.Tokens.AccessToken)
+	_, goneResp := ts.doRequest(http.MethodGet, uploaded.URL, nil, "")
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, wrongExerciseResp.StatusCode)
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	require.Equal(t, http.StatusNotFound, goneResp.StatusCode)
+}
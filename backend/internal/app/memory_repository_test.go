@@ -1,15 +1,19 @@
 package app_test
 
 import (
+	"encoding/json"
 	"errors"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/records"
 	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/search"
 )
 
 type memoryStore struct {
@@ -17,7 +21,33 @@ type memoryStore struct {
 	users         map[string]domain.User
 	refreshTokens map[string]time.Time
 	exercises     map[string]domain.Exercise
+	exerciseSeq   map[string]int64
+	tombstones    map[string]memoryTombstone
+	aliasOwners   map[string]string
+	changeSeq     int64
+	revisions     map[string][]domain.ExerciseRevision
+	media         map[string]domain.ExerciseMedia
+	substitutes   map[string][]string
+	translations  map[string]map[string]domain.ExerciseTranslation
+	relations     []domain.ExerciseRelation
+	seeds         map[string]string
+	seedVersion   int
 	workouts      map[string]domain.WorkoutSession
+	submissions   map[string]domain.WorkoutSubmission
+	templates     map[string]domain.WorkoutTemplate
+	programs      map[string]domain.Program
+	enrollments   map[string]domain.ProgramEnrollment
+	planned       map[string]domain.PlannedSession
+	trainingMaxes map[string]domain.TrainingMax
+	records       map[string][]domain.PersonalRecord
+	// recordsMu serializes record updates; their compute step reads other
+	// repositories and so cannot run under mu.
+	recordsMu    sync.Mutex
+	scored       map[string]bool
+	goals        map[string]domain.Goal
+	bodyMetrics  map[string]domain.BodyMetric
+	metricSubs   map[string]domain.BodyMetricSubmission
+	liveWorkouts map[string]domain.LiveWorkout
 }
 
 func newMemoryRepository() repository.Repository {
@@ -25,13 +55,47 @@ func newMemoryRepository() repository.Repository {
 		users:         make(map[string]domain.User),
 		refreshTokens: make(map[string]time.Time),
 		exercises:     make(map[string]domain.Exercise),
+		exerciseSeq:   make(map[string]int64),
+		tombstones:    make(map[string]memoryTombstone),
+		aliasOwners:   make(map[string]string),
+		revisions:     make(map[string][]domain.ExerciseRevision),
+		media:         make(map[string]domain.ExerciseMedia),
+		substitutes:   make(map[string][]string),
+		translations:  make(map[string]map[string]domain.ExerciseTranslation),
+		seeds:         make(map[string]string),
 		workouts:      make(map[string]domain.WorkoutSession),
+		submissions:   make(map[string]domain.WorkoutSubmission),
+		templates:     make(map[string]domain.WorkoutTemplate),
+		programs:      make(map[string]domain.Program),
+		enrollments:   make(map[string]domain.ProgramEnrollment),
+		planned:       make(map[string]domain.PlannedSession),
+		trainingMaxes: make(map[string]domain.TrainingMax),
+		records:       make(map[string][]domain.PersonalRecord),
+		scored:        make(map[string]bool),
+		goals:         make(map[string]domain.Goal),
+		bodyMetrics:   make(map[string]domain.BodyMetric),
+		metricSubs:    make(map[string]domain.BodyMetricSubmission),
+		liveWorkouts:  make(map[string]domain.LiveWorkout),
 	}
 	return repository.Repository{
-		Users:         &memoryUserRepo{store: store},
-		RefreshTokens: &memoryRefreshRepo{store: store},
-		Exercises:     &memoryExerciseRepo{store: store},
-		Workouts:      &memoryWorkoutRepo{store: store},
+		Users:             &memoryUserRepo{store: store},
+		RefreshTokens:     &memoryRefreshRepo{store: store},
+		Exercises:         &memoryExerciseRepo{store: store},
+		ExerciseRevisions: &memoryExerciseRevisionRepo{store: store},
+		ExerciseMedia:     &memoryExerciseMediaRepo{store: store},
+		Substitutions:     &memorySubstitutionRepo{store: store},
+		Translations:      &memoryTranslationRepo{store: store},
+		Relations:         &memoryRelationRepo{store: store},
+		Seeds:             &memorySeedRepo{store: store},
+		Workouts:          &memoryWorkoutRepo{store: store},
+		Templates:         &memoryTemplateRepo{store: store},
+		Programs:          &memoryProgramRepo{store: store},
+		TrainingMaxes:     &memoryTrainingMaxRepo{store: store},
+		Records:           &memoryRecordRepo{store: store},
+		Analytics:         &memoryAnalyticsRepo{store: store},
+		Goals:             &memoryGoalRepo{store: store},
+		BodyMetrics:       &memoryBodyMetricRepo{store: store},
+		LiveWorkouts:      &memoryLiveWorkoutRepo{store: store},
 	}
 }
 
@@ -93,6 +157,37 @@ func (r *memoryUserRepo) CountAdmins() (int, error) {
 	return count, nil
 }
 
+func (r *memoryUserRepo) SetLocale(userID, locale string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.Locale = locale
+	r.store.users[userID] = user
+	return nil
+}
+
+func (r *memoryUserRepo) SetLoading(userID string, loading *domain.LoadingSettings) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.Loading = nil
+	if loading != nil {
+		copied := *loading
+		copied.Plates = append([]float64{}, loading.Plates...)
+		user.Loading = &copied
+	}
+	r.store.users[userID] = user
+	return nil
+}
+
 type memoryRefreshRepo struct {
 	store *memoryStore
 }
@@ -142,6 +237,16 @@ type memoryExerciseRepo struct {
 	store *memoryStore
 }
 
+type memoryTombstone struct {
+	tombstone domain.ExerciseTombstone
+	seq       int64
+}
+
+func (s *memoryStore) nextChangeSeq() int64 {
+	s.changeSeq++
+	return s.changeSeq
+}
+
 func (r *memoryExerciseRepo) List() ([]domain.Exercise, error) {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
@@ -156,29 +261,44 @@ func (r *memoryExerciseRepo) List() ([]domain.Exercise, error) {
 	return exercises, nil
 }
 
-func (r *memoryExerciseRepo) Create(ex *domain.Exercise) error {
+func (r *memoryExerciseRepo) Create(ex *domain.Exercise, rev *domain.ExerciseRevision) error {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
 
 	if ex.ID == "" {
 		ex.ID = uuid.NewString()
 	}
+	if rev != nil {
+		rev.ExerciseID = ex.ID
+		if err := r.store.addRevision(rev); err != nil {
+			return err
+		}
+	}
 	now := time.Now().UTC()
 	ex.CreatedAt = now
 	ex.UpdatedAt = now
 	r.store.exercises[ex.ID] = *ex
+	r.store.exerciseSeq[ex.ID] = r.store.nextChangeSeq()
 	return nil
 }
 
-func (r *memoryExerciseRepo) Update(ex *domain.Exercise) error {
+func (r *memoryExerciseRepo) Update(ex *domain.Exercise, rev *domain.ExerciseRevision) error {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
 
-	if _, ok := r.store.exercises[ex.ID]; !ok {
+	existing, ok := r.store.exercises[ex.ID]
+	if !ok {
 		return errors.New("exercise not found")
 	}
+	if rev != nil {
+		if err := r.store.addRevision(rev); err != nil {
+			return err
+		}
+	}
+	ex.ArchivedAt = existing.ArchivedAt
 	ex.UpdatedAt = time.Now().UTC()
 	r.store.exercises[ex.ID] = *ex
+	r.store.exerciseSeq[ex.ID] = r.store.nextChangeSeq()
 	return nil
 }
 
@@ -186,7 +306,42 @@ func (r *memoryExerciseRepo) Delete(id string) error {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
 
+	if _, ok := r.store.exercises[id]; !ok {
+		return nil
+	}
+	for _, alias := range r.store.exercises[id].Aliases {
+		delete(r.store.aliasOwners, search.Normalize(alias))
+	}
+	for mediaID, item := range r.store.media {
+		if item.ExerciseID == id {
+			delete(r.store.media, mediaID)
+		}
+	}
 	delete(r.store.exercises, id)
+	delete(r.store.exerciseSeq, id)
+	delete(r.store.revisions, id)
+	delete(r.store.substitutes, id)
+	delete(r.store.translations, id)
+	relations := []domain.ExerciseRelation{}
+	for _, rel := range r.store.relations {
+		if rel.FromID != id && rel.ToID != id {
+			relations = append(relations, rel)
+		}
+	}
+	r.store.relations = relations
+	for exerciseID, ids := range r.store.substitutes {
+		kept := []string{}
+		for _, substituteID := range ids {
+			if substituteID != id {
+				kept = append(kept, substituteID)
+			}
+		}
+		r.store.substitutes[exerciseID] = kept
+	}
+	r.store.tombstones[id] = memoryTombstone{
+		tombstone: domain.ExerciseTombstone{ID: id, Reason: domain.TombstoneDeleted, RemovedAt: time.Now().UTC()},
+		seq:       r.store.nextChangeSeq(),
+	}
 	return nil
 }
 
@@ -198,56 +353,1769 @@ func (r *memoryExerciseRepo) GetByID(id string) (*domain.Exercise, error) {
 		exercise := ex
 		return &exercise, nil
 	}
-	return nil, errors.New("exercise not found")
+	return nil, repository.ErrNotFound
 }
 
-type memoryWorkoutRepo struct {
+func (r *memoryExerciseRepo) SetArchived(id string, archivedAt *time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ex, ok := r.store.exercises[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	ex.ArchivedAt = archivedAt
+	ex.UpdatedAt = time.Now().UTC()
+	r.store.exercises[id] = ex
+	r.store.exerciseSeq[id] = r.store.nextChangeSeq()
+	return nil
+}
+
+func (r *memoryExerciseRepo) AddAlias(exerciseID, alias, normalized string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ex, ok := r.store.exercises[exerciseID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if _, taken := r.store.aliasOwners[normalized]; taken {
+		return repository.ErrConflict
+	}
+	r.store.aliasOwners[normalized] = exerciseID
+	ex.Aliases = append(append([]string{}, ex.Aliases...), alias)
+	sort.Strings(ex.Aliases)
+	r.store.exercises[exerciseID] = ex
+	r.store.exerciseSeq[exerciseID] = r.store.nextChangeSeq()
+	return nil
+}
+
+func (r *memoryExerciseRepo) RemoveAlias(exerciseID, normalized string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ex, ok := r.store.exercises[exerciseID]
+	if !ok || r.store.aliasOwners[normalized] != exerciseID {
+		return repository.ErrNotFound
+	}
+	delete(r.store.aliasOwners, normalized)
+	aliases := []string{}
+	for _, alias := range ex.Aliases {
+		if search.Normalize(alias) != normalized {
+			aliases = append(aliases, alias)
+		}
+	}
+	ex.Aliases = aliases
+	r.store.exercises[exerciseID] = ex
+	r.store.exerciseSeq[exerciseID] = r.store.nextChangeSeq()
+	return nil
+}
+
+func (r *memoryExerciseRepo) Changes(since int64) (*domain.ExerciseChangeFeed, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	feed := &domain.ExerciseChangeFeed{
+		Seq:        since,
+		Exercises:  []domain.Exercise{},
+		Tombstones: []domain.ExerciseTombstone{},
+	}
+	for id, seq := range r.store.exerciseSeq {
+		if seq <= since {
+			continue
+		}
+		if seq > feed.Seq {
+			feed.Seq = seq
+		}
+		ex := r.store.exercises[id]
+		if ex.Archived() {
+			feed.Tombstones = append(feed.Tombstones, domain.ExerciseTombstone{ID: id, Reason: domain.TombstoneArchived, RemovedAt: *ex.ArchivedAt})
+			continue
+		}
+		feed.Exercises = append(feed.Exercises, ex)
+	}
+	for _, entry := range r.store.tombstones {
+		if entry.seq <= since {
+			continue
+		}
+		if entry.seq > feed.Seq {
+			feed.Seq = entry.seq
+		}
+		feed.Tombstones = append(feed.Tombstones, entry.tombstone)
+	}
+	return feed, nil
+}
+
+func (r *memoryExerciseRepo) Merge(merge *domain.ExerciseMerge) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	canonical, ok := r.store.exercises[merge.CanonicalID]
+	duplicate, dupOK := r.store.exercises[merge.DuplicateID]
+	if !ok || !dupOK {
+		return repository.ErrNotFound
+	}
+	if err := r.store.addRevision(merge.Revision); err != nil {
+		return err
+	}
+
+	merge.MovedEntries = 0
+	for id, session := range r.store.workouts {
+		for i := range session.Entries {
+			if session.Entries[i].ExerciseID == merge.DuplicateID {
+				session.Entries[i].ExerciseID = merge.CanonicalID
+				merge.MovedEntries++
+			}
+		}
+		r.store.workouts[id] = session
+	}
+	for id, template := range r.store.templates {
+		for i := range template.Exercises {
+			if template.Exercises[i].ExerciseID == merge.DuplicateID {
+				template.Exercises[i].ExerciseID = merge.CanonicalID
+			}
+		}
+		r.store.templates[id] = template
+	}
+	for key, trainingMax := range r.store.trainingMaxes {
+		if trainingMax.ExerciseID != merge.DuplicateID {
+			continue
+		}
+		delete(r.store.trainingMaxes, key)
+		if _, kept := r.store.trainingMaxes[trainingMaxKey(trainingMax.UserID, merge.CanonicalID)]; !kept {
+			trainingMax.ExerciseID = merge.CanonicalID
+			r.store.trainingMaxes[trainingMaxKey(trainingMax.UserID, merge.CanonicalID)] = trainingMax
+		}
+	}
+	for key, records := range r.store.records {
+		if len(records) > 0 && records[0].ExerciseID == merge.DuplicateID {
+			delete(r.store.records, key)
+		}
+	}
+	for id, goal := range r.store.goals {
+		if goal.ExerciseID == merge.DuplicateID {
+			goal.ExerciseID = merge.CanonicalID
+			r.store.goals[id] = goal
+		}
+	}
+	for id, live := range r.store.liveWorkouts {
+		for i := range live.Entries {
+			if live.Entries[i].ExerciseID == merge.DuplicateID {
+				live.Entries[i].ExerciseID = merge.CanonicalID
+				live.Revision++
+			}
+		}
+		r.store.liveWorkouts[id] = live
+	}
+
+	aliases := append([]string{}, canonical.Aliases...)
+	for _, alias := range duplicate.Aliases {
+		r.store.aliasOwners[search.Normalize(alias)] = merge.CanonicalID
+		aliases = append(aliases, alias)
+	}
+	if _, taken := r.store.aliasOwners[merge.NormalizedAlias]; merge.Alias != "" && !taken {
+		r.store.aliasOwners[merge.NormalizedAlias] = merge.CanonicalID
+		aliases = append(aliases, merge.Alias)
+	}
+	sort.Strings(aliases)
+	canonical.Aliases = aliases
+	canonical.UpdatedAt = time.Now().UTC()
+	r.store.exercises[merge.CanonicalID] = canonical
+
+	relations := []domain.ExerciseRelation{}
+	for _, rel := range r.store.relations {
+		if rel.FromID == merge.DuplicateID {
+			rel.FromID = merge.CanonicalID
+		}
+		if rel.ToID == merge.DuplicateID {
+			rel.ToID = merge.CanonicalID
+		}
+		if rel.FromID == rel.ToID || containsRelation(relations, rel) {
+			continue
+		}
+		relations = append(relations, rel)
+	}
+	r.store.relations = relations
+	for exerciseID, ids := range r.store.substitutes {
+		for i, id := range ids {
+			if id == merge.DuplicateID && exerciseID != merge.CanonicalID {
+				ids[i] = merge.CanonicalID
+			}
+		}
+	}
+	for locale, t := range r.store.translations[merge.DuplicateID] {
+		if _, exists := r.store.translations[merge.CanonicalID][locale]; !exists {
+			if r.store.translations[merge.CanonicalID] == nil {
+				r.store.translations[merge.CanonicalID] = make(map[string]domain.ExerciseTranslation)
+			}
+			t.ExerciseID = merge.CanonicalID
+			r.store.translations[merge.CanonicalID][locale] = t
+		}
+	}
+	for id, item := range r.store.media {
+		if item.ExerciseID == merge.DuplicateID {
+			item.ExerciseID = merge.CanonicalID
+			r.store.media[id] = item
+		}
+	}
+
+	delete(r.store.exercises, merge.DuplicateID)
+	delete(r.store.exerciseSeq, merge.DuplicateID)
+	delete(r.store.revisions, merge.DuplicateID)
+	delete(r.store.substitutes, merge.DuplicateID)
+	delete(r.store.translations, merge.DuplicateID)
+	r.store.tombstones[merge.DuplicateID] = memoryTombstone{
+		tombstone: domain.ExerciseTombstone{
+			ID:         merge.DuplicateID,
+			Reason:     domain.TombstoneMerged,
+			RemovedAt:  time.Now().UTC(),
+			ReplacedBy: merge.CanonicalID,
+		},
+		seq: r.store.nextChangeSeq(),
+	}
+	r.store.exerciseSeq[merge.CanonicalID] = r.store.nextChangeSeq()
+	return nil
+}
+
+// containsRelation reports whether rel collides with one of relations the way
+// the database constraints would: same triple, or a second parent.
+func containsRelation(relations []domain.ExerciseRelation, rel domain.ExerciseRelation) bool {
+	for _, existing := range relations {
+		if existing.FromID != rel.FromID || existing.Type != rel.Type {
+			continue
+		}
+		if existing.ToID == rel.ToID || rel.Type == domain.RelationVariationOf {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryExerciseRevisionRepo struct {
 	store *memoryStore
 }
 
-func (r *memoryWorkoutRepo) CreateSession(session *domain.WorkoutSession) error {
+func (r *memoryExerciseRevisionRepo) Create(rev *domain.ExerciseRevision) error {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
 
-	if session.ID == "" {
-		session.ID = uuid.NewString()
+	return r.store.addRevision(rev)
+}
+
+// addRevision records rev unless its number is taken. The caller holds the
+// store lock.
+func (s *memoryStore) addRevision(rev *domain.ExerciseRevision) error {
+	for _, existing := range s.revisions[rev.ExerciseID] {
+		if existing.Number == rev.Number {
+			return repository.ErrConflict
+		}
 	}
-	if session.StartedAt.IsZero() {
-		session.StartedAt = time.Now().UTC()
+	if rev.ID == "" {
+		rev.ID = uuid.NewString()
 	}
-	if session.CompletedAt.IsZero() {
-		session.CompletedAt = session.StartedAt
+	rev.CreatedAt = time.Now().UTC()
+	s.revisions[rev.ExerciseID] = append(s.revisions[rev.ExerciseID], *rev)
+	return nil
+}
+
+func (r *memoryExerciseRevisionRepo) ListByExercise(exerciseID string) ([]domain.ExerciseRevision, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	revisions := append([]domain.ExerciseRevision{}, r.store.revisions[exerciseID]...)
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number > revisions[j].Number
+	})
+	return revisions, nil
+}
+
+func (r *memoryExerciseRevisionRepo) Get(exerciseID string, number int) (*domain.ExerciseRevision, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, rev := range r.store.revisions[exerciseID] {
+		if rev.Number == number {
+			revision := rev
+			return &revision, nil
+		}
 	}
-	session.CreatedAt = time.Now().UTC()
+	return nil, repository.ErrNotFound
+}
 
-	entries := make([]domain.WorkoutEntry, len(session.Entries))
-	for i := range session.Entries {
-		entry := session.Entries[i]
-		if entry.ID == "" {
-			entry.ID = uuid.NewString()
+func (r *memoryExerciseRevisionRepo) Latest(exerciseID string) (*domain.ExerciseRevision, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var latest *domain.ExerciseRevision
+	for _, rev := range r.store.revisions[exerciseID] {
+		if latest == nil || rev.Number > latest.Number {
+			revision := rev
+			latest = &revision
 		}
-		entry.SessionID = session.ID
-		entry.CreatedAt = time.Now().UTC()
-		entries[i] = entry
 	}
-	session.Entries = entries
-	r.store.workouts[session.ID] = *session
+	if latest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return latest, nil
+}
+
+type memoryExerciseMediaRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryExerciseMediaRepo) Create(item *domain.ExerciseMedia) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.exercises[item.ExerciseID]; !ok {
+		return repository.ErrNotFound
+	}
+	if item.ID == "" {
+		item.ID = uuid.NewString()
+	}
+	item.CreatedAt = time.Now().UTC()
+	r.store.media[item.ID] = *item
+	r.store.exerciseSeq[item.ExerciseID] = r.store.nextChangeSeq()
 	return nil
 }
 
-func (r *memoryWorkoutRepo) ListSessions(userID string) ([]domain.WorkoutSession, error) {
+func (r *memoryExerciseMediaRepo) Get(id string) (*domain.ExerciseMedia, error) {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
 
-	sessions := make([]domain.WorkoutSession, 0)
-	for _, session := range r.store.workouts {
-		if session.UserID == userID {
-			copySession := session
-			sessions = append(sessions, copySession)
+	item, ok := r.store.media[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &item, nil
+}
+
+func (r *memoryExerciseMediaRepo) ListByExercise(exerciseID string) ([]domain.ExerciseMedia, error) {
+	items, _ := r.ListAll()
+	filtered := []domain.ExerciseMedia{}
+	for _, item := range items {
+		if item.ExerciseID == exerciseID {
+			filtered = append(filtered, item)
 		}
 	}
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].StartedAt.After(sessions[j].StartedAt)
+	return filtered, nil
+}
+
+func (r *memoryExerciseMediaRepo) ListAll() ([]domain.ExerciseMedia, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	items := make([]domain.ExerciseMedia, 0, len(r.store.media))
+	for _, item := range r.store.media {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
 	})
-	return sessions, nil
+	return items, nil
+}
+
+func (r *memoryExerciseMediaRepo) Delete(id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, ok := r.store.media[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.store.media, id)
+	if _, exists := r.store.exercises[item.ExerciseID]; exists {
+		r.store.exerciseSeq[item.ExerciseID] = r.store.nextChangeSeq()
+	}
+	return nil
+}
+
+type memorySubstitutionRepo struct {
+	store *memoryStore
+}
+
+func (r *memorySubstitutionRepo) ListPinned(exerciseID string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return append([]string{}, r.store.substitutes[exerciseID]...), nil
+}
+
+func (r *memorySubstitutionRepo) SetPinned(exerciseID string, substituteIDs []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.substitutes[exerciseID] = append([]string{}, substituteIDs...)
+	return nil
+}
+
+type memoryTranslationRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryTranslationRepo) Upsert(t *domain.ExerciseTranslation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.exercises[t.ExerciseID]; !ok {
+		return repository.ErrNotFound
+	}
+	t.UpdatedAt = time.Now().UTC()
+	if r.store.translations[t.ExerciseID] == nil {
+		r.store.translations[t.ExerciseID] = make(map[string]domain.ExerciseTranslation)
+	}
+	r.store.translations[t.ExerciseID][t.Locale] = *t
+	r.store.exerciseSeq[t.ExerciseID] = r.store.nextChangeSeq()
+	return nil
+}
+
+func (r *memoryTranslationRepo) Delete(exerciseID, locale string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.translations[exerciseID][locale]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.store.translations[exerciseID], locale)
+	r.store.exerciseSeq[exerciseID] = r.store.nextChangeSeq()
+	return nil
+}
+
+func (r *memoryTranslationRepo) ListByExercise(exerciseID string) ([]domain.ExerciseTranslation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	translations := []domain.ExerciseTranslation{}
+	for _, t := range r.store.translations[exerciseID] {
+		translations = append(translations, t)
+	}
+	sort.Slice(translations, func(i, j int) bool {
+		return translations[i].Locale < translations[j].Locale
+	})
+	return translations, nil
+}
+
+func (r *memoryTranslationRepo) ListByLocale(locale string) ([]domain.ExerciseTranslation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	translations := []domain.ExerciseTranslation{}
+	for _, byLocale := range r.store.translations {
+		if t, ok := byLocale[locale]; ok {
+			translations = append(translations, t)
+		}
+	}
+	sort.Slice(translations, func(i, j int) bool {
+		return translations[i].ExerciseID < translations[j].ExerciseID
+	})
+	return translations, nil
+}
+
+type memoryRelationRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryRelationRepo) Create(rel *domain.ExerciseRelation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.relations {
+		if existing.FromID == rel.FromID && existing.ToID == rel.ToID && existing.Type == rel.Type {
+			return repository.ErrConflict
+		}
+	}
+	if rel.ID == "" {
+		rel.ID = uuid.NewString()
+	}
+	rel.CreatedAt = time.Now().UTC()
+	r.store.relations = append(r.store.relations, *rel)
+	return nil
+}
+
+func (r *memoryRelationRepo) Get(id string) (*domain.ExerciseRelation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, rel := range r.store.relations {
+		if rel.ID == id {
+			found := rel
+			return &found, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryRelationRepo) Delete(id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, rel := range r.store.relations {
+		if rel.ID == id {
+			r.store.relations = append(r.store.relations[:i:i], r.store.relations[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *memoryRelationRepo) List() ([]domain.ExerciseRelation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return append([]domain.ExerciseRelation{}, r.store.relations...), nil
+}
+
+type memorySeedRepo struct {
+	store *memoryStore
+}
+
+func (r *memorySeedRepo) Version() (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.seedVersion, nil
+}
+
+func (r *memorySeedRepo) SetVersion(version int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.seedVersion = version
+	return nil
+}
+
+func (r *memorySeedRepo) Seeded() (map[string]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	seeded := make(map[string]string, len(r.store.seeds))
+	for key, id := range r.store.seeds {
+		seeded[key] = id
+	}
+	return seeded, nil
+}
+
+func (r *memorySeedRepo) Record(key, exerciseID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.seeds[key]; !exists {
+		r.store.seeds[key] = exerciseID
+	}
+	return nil
+}
+
+type memoryWorkoutRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryWorkoutRepo) CreateSession(session *domain.WorkoutSession) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.plannedTaken(session.ID, session.PlannedSessionID) {
+		return repository.ErrPlannedSessionTaken
+	}
+	r.createSession(session)
+	return nil
+}
+
+func (r *memoryWorkoutRepo) CreateSessionOnce(session *domain.WorkoutSession, submission *domain.WorkoutSubmission) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	submissionKey := submission.UserID + "\x00" + submission.Key
+	if _, used := r.store.submissions[submissionKey]; used {
+		return repository.ErrConflict
+	}
+	if _, taken := r.store.workouts[session.ID]; taken && session.ID != "" {
+		return repository.ErrConflict
+	}
+	if r.plannedTaken(session.ID, session.PlannedSessionID) {
+		return repository.ErrPlannedSessionTaken
+	}
+	r.createSession(session)
+	response, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	submission.SessionID = session.ID
+	submission.Response = response
+	submission.CreatedAt = session.CreatedAt
+	r.store.submissions[submissionKey] = *submission
+	return nil
+}
+
+func (r *memoryWorkoutRepo) GetSubmission(userID, key string) (*domain.WorkoutSubmission, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	submission, ok := r.store.submissions[userID+"\x00"+key]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &submission, nil
+}
+
+// plannedTaken reports whether a session other than id outside the trash
+// completed the planned session, as the unique index does in PostgreSQL.
+func (r *memoryWorkoutRepo) plannedTaken(id, plannedID string) bool {
+	if plannedID == "" {
+		return false
+	}
+	for _, other := range r.store.workouts {
+		if other.ID != id && other.PlannedSessionID == plannedID && other.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryWorkoutRepo) createSession(session *domain.WorkoutSession) {
+	if session.ID == "" {
+		session.ID = uuid.NewString()
+	}
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now().UTC()
+	}
+	if session.CompletedAt.IsZero() {
+		session.CompletedAt = session.StartedAt
+	}
+	session.CreatedAt = time.Now().UTC()
+	session.UpdatedAt = session.CreatedAt
+
+	entries := make([]domain.WorkoutEntry, len(session.Entries))
+	for i := range session.Entries {
+		entry := session.Entries[i]
+		if entry.ID == "" {
+			entry.ID = uuid.NewString()
+		}
+		entry.SessionID = session.ID
+		entry.CreatedAt = time.Now().UTC()
+		entry.UpdatedAt = entry.CreatedAt
+		entries[i] = entry
+	}
+	session.Entries = entries
+	r.store.workouts[session.ID] = *session
+}
+
+func (r *memoryWorkoutRepo) ListSessions(filter repository.SessionFilter, after *repository.SessionKey, limit int) ([]domain.WorkoutSession, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	sessions := r.matchingSessions(filter)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessionBefore(sessions[i], sessions[j].StartedAt, sessions[j].ID)
+	})
+	if after != nil {
+		start := sort.Search(len(sessions), func(i int) bool {
+			return !sessionBefore(sessions[i], after.StartedAt, after.ID) && sessions[i].ID != after.ID
+		})
+		sessions = sessions[start:]
+	}
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+func (r *memoryWorkoutRepo) CountSessions(filter repository.SessionFilter) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return len(r.matchingSessions(filter)), nil
+}
+
+func (r *memoryWorkoutRepo) LogsExercise(exerciseID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, session := range r.store.workouts {
+		for _, entry := range session.Entries {
+			if entry.ExerciseID == exerciseID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// sessionBefore reports whether session sorts ahead of the given position,
+// i.e. started later or at the same time with a greater id.
+func sessionBefore(session domain.WorkoutSession, startedAt time.Time, id string) bool {
+	if !session.StartedAt.Equal(startedAt) {
+		return session.StartedAt.After(startedAt)
+	}
+	return session.ID > id
+}
+
+func (r *memoryWorkoutRepo) matchingSessions(filter repository.SessionFilter) []domain.WorkoutSession {
+	sessions := make([]domain.WorkoutSession, 0)
+	for _, session := range r.store.workouts {
+		if session.UserID != filter.UserID || session.DeletedAt != nil {
+			continue
+		}
+		if filter.From != nil && session.StartedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !session.StartedAt.Before(*filter.To) {
+			continue
+		}
+		if filter.ExerciseID == "" && filter.MuscleGroup == "" && filter.Notes == "" {
+			sessions = append(sessions, session)
+			continue
+		}
+		for _, entry := range session.Entries {
+			if filter.ExerciseID != "" && entry.ExerciseID != filter.ExerciseID {
+				continue
+			}
+			if filter.MuscleGroup != "" && !strings.EqualFold(r.store.exercises[entry.ExerciseID].MuscleGroup, filter.MuscleGroup) {
+				continue
+			}
+			if filter.Notes != "" && !strings.Contains(strings.ToLower(entry.Notes), strings.ToLower(filter.Notes)) {
+				continue
+			}
+			sessions = append(sessions, session)
+			break
+		}
+	}
+	return sessions
+}
+
+func (r *memoryWorkoutRepo) GetSession(id string) (*domain.WorkoutSession, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// PostgreSQL fails to compare other ids with the uuid column.
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("invalid input syntax for type uuid: " + id)
+	}
+	session, ok := r.store.workouts[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	session.Entries = append([]domain.WorkoutEntry(nil), session.Entries...)
+	return &session, nil
+}
+
+func (r *memoryWorkoutRepo) UpdateSession(session *domain.WorkoutSession) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.workouts[session.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for i := range session.Entries {
+		entry := &session.Entries[i]
+		if entry.ID == "" {
+			entry.ID = uuid.NewString()
+			entry.SessionID = session.ID
+			entry.CreatedAt = time.Now().UTC()
+			entry.UpdatedAt = entry.CreatedAt
+		}
+	}
+	stored.StartedAt = session.StartedAt
+	stored.CompletedAt = session.CompletedAt
+	stored.UpdatedAt = session.UpdatedAt
+	stored.Entries = append([]domain.WorkoutEntry(nil), session.Entries...)
+	r.store.workouts[session.ID] = stored
+	return nil
+}
+
+func (r *memoryWorkoutRepo) TrashSession(id string, at time.Time) error {
+	return r.setDeleted(id, &at, at)
+}
+
+func (r *memoryWorkoutRepo) RestoreSession(id string, at time.Time) error {
+	return r.setDeleted(id, nil, at)
+}
+
+func (r *memoryWorkoutRepo) setDeleted(id string, deletedAt *time.Time, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.workouts[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if deletedAt == nil && r.plannedTaken(id, session.PlannedSessionID) {
+		return repository.ErrPlannedSessionTaken
+	}
+	session.DeletedAt = deletedAt
+	session.UpdatedAt = at
+	r.store.workouts[id] = session
+	return nil
+}
+
+func (r *memoryWorkoutRepo) ListTrash(userID string) ([]domain.WorkoutSession, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	sessions := make([]domain.WorkoutSession, 0)
+	for _, session := range r.store.workouts {
+		if session.UserID == userID && session.DeletedAt != nil {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].DeletedAt.After(*sessions[j].DeletedAt)
+	})
+	return sessions, nil
+}
+
+func (r *memoryWorkoutRepo) PurgeTrash(cutoff time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := 0
+	for id, session := range r.store.workouts {
+		if session.DeletedAt != nil && session.DeletedAt.Before(cutoff) {
+			delete(r.store.workouts, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+type memoryTemplateRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryTemplateRepo) Create(template *domain.WorkoutTemplate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.nameTaken(template) {
+		return repository.ErrConflict
+	}
+	if template.ID == "" {
+		template.ID = uuid.NewString()
+	}
+	template.CreatedAt = time.Now().UTC()
+	template.UpdatedAt = template.CreatedAt
+	r.store.templates[template.ID] = copyTemplate(*template)
+	return nil
+}
+
+func (r *memoryTemplateRepo) Get(id string) (*domain.WorkoutTemplate, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	template, ok := r.store.templates[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	template = copyTemplate(template)
+	return &template, nil
+}
+
+func (r *memoryTemplateRepo) List(userID string) ([]domain.WorkoutTemplate, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	templates := make([]domain.WorkoutTemplate, 0)
+	for _, template := range r.store.templates {
+		if template.UserID == userID {
+			templates = append(templates, copyTemplate(template))
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return strings.ToLower(templates[i].Name) < strings.ToLower(templates[j].Name)
+	})
+	return templates, nil
+}
+
+func (r *memoryTemplateRepo) Update(template *domain.WorkoutTemplate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.templates[template.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	if r.nameTaken(template) {
+		return repository.ErrConflict
+	}
+	template.UpdatedAt = time.Now().UTC()
+	r.store.templates[template.ID] = copyTemplate(*template)
+	return nil
+}
+
+func (r *memoryTemplateRepo) Delete(id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.templates[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, program := range r.store.programs {
+		for _, day := range program.Days {
+			if day.TemplateID == id {
+				return repository.ErrConflict
+			}
+		}
+	}
+	for _, planned := range r.store.planned {
+		if planned.TemplateID == id {
+			return repository.ErrConflict
+		}
+	}
+	delete(r.store.templates, id)
+	return nil
+}
+
+func (r *memoryTemplateRepo) nameTaken(template *domain.WorkoutTemplate) bool {
+	for id, other := range r.store.templates {
+		if id != template.ID && other.UserID == template.UserID && strings.EqualFold(other.Name, template.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyTemplate(template domain.WorkoutTemplate) domain.WorkoutTemplate {
+	template.Exercises = append([]domain.TemplateExercise{}, template.Exercises...)
+	return template
+}
+
+type memoryProgramRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryProgramRepo) Create(program *domain.Program) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if program.ID == "" {
+		program.ID = uuid.NewString()
+	}
+	program.CreatedAt = time.Now().UTC()
+	program.UpdatedAt = program.CreatedAt
+	r.store.programs[program.ID] = copyProgram(*program)
+	return nil
+}
+
+func (r *memoryProgramRepo) Get(id string) (*domain.Program, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	program, ok := r.store.programs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	program = copyProgram(program)
+	return &program, nil
+}
+
+func (r *memoryProgramRepo) List() ([]domain.Program, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	programs := make([]domain.Program, 0, len(r.store.programs))
+	for _, program := range r.store.programs {
+		programs = append(programs, copyProgram(program))
+	}
+	sort.Slice(programs, func(i, j int) bool {
+		a, b := strings.ToLower(programs[i].Name), strings.ToLower(programs[j].Name)
+		if a != b {
+			return a < b
+		}
+		return programs[i].ID < programs[j].ID
+	})
+	return programs, nil
+}
+
+func (r *memoryProgramRepo) Update(program *domain.Program) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.programs[program.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	program.UpdatedAt = time.Now().UTC()
+	r.store.programs[program.ID] = copyProgram(*program)
+	return nil
+}
+
+func (r *memoryProgramRepo) Delete(id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.programs[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, enrollment := range r.store.enrollments {
+		if enrollment.ProgramID == id {
+			return repository.ErrConflict
+		}
+	}
+	delete(r.store.programs, id)
+	return nil
+}
+
+func (r *memoryProgramRepo) Enroll(enrollment *domain.ProgramEnrollment, planned []domain.PlannedSession) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, other := range r.store.enrollments {
+		if other.UserID == enrollment.UserID && other.ProgramID == enrollment.ProgramID && other.EndedAt == nil {
+			return repository.ErrConflict
+		}
+	}
+	if enrollment.ID == "" {
+		enrollment.ID = uuid.NewString()
+	}
+	enrollment.CreatedAt = time.Now().UTC()
+	r.store.enrollments[enrollment.ID] = *enrollment
+	for i := range planned {
+		if planned[i].ID == "" {
+			planned[i].ID = uuid.NewString()
+		}
+		planned[i].EnrollmentID = enrollment.ID
+		r.store.planned[planned[i].ID] = planned[i]
+	}
+	return nil
+}
+
+func (r *memoryProgramRepo) GetEnrollment(id string) (*domain.ProgramEnrollment, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	enrollment, ok := r.store.enrollments[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &enrollment, nil
+}
+
+func (r *memoryProgramRepo) ListEnrollments(userID string) ([]domain.ProgramEnrollment, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	enrollments := make([]domain.ProgramEnrollment, 0)
+	for _, enrollment := range r.store.enrollments {
+		if enrollment.UserID == userID {
+			enrollments = append(enrollments, enrollment)
+		}
+	}
+	sort.Slice(enrollments, func(i, j int) bool {
+		if !enrollments[i].CreatedAt.Equal(enrollments[j].CreatedAt) {
+			return enrollments[i].CreatedAt.After(enrollments[j].CreatedAt)
+		}
+		return enrollments[i].ID < enrollments[j].ID
+	})
+	return enrollments, nil
+}
+
+func (r *memoryProgramRepo) EndEnrollment(id string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	enrollment, ok := r.store.enrollments[id]
+	if !ok || enrollment.EndedAt != nil {
+		return repository.ErrNotFound
+	}
+	enrollment.EndedAt = &at
+	r.store.enrollments[id] = enrollment
+	return nil
+}
+
+func (r *memoryProgramRepo) GetPlanned(id string) (*domain.PlannedSession, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	planned, ok := r.store.planned[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	planned = r.withSession(planned)
+	return &planned, nil
+}
+
+func (r *memoryProgramRepo) ListPlanned(enrollmentID string) ([]domain.PlannedSession, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.plannedWhere(func(planned domain.PlannedSession) bool {
+		return planned.EnrollmentID == enrollmentID
+	}), nil
+}
+
+func (r *memoryProgramRepo) Schedule(userID string, from, to time.Time) ([]domain.PlannedSession, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.plannedWhere(func(planned domain.PlannedSession) bool {
+		enrollment := r.store.enrollments[planned.EnrollmentID]
+		return enrollment.UserID == userID && enrollment.EndedAt == nil &&
+			!planned.ScheduledOn.Before(from) && planned.ScheduledOn.Before(to)
+	}), nil
+}
+
+func (r *memoryProgramRepo) plannedWhere(match func(domain.PlannedSession) bool) []domain.PlannedSession {
+	planned := make([]domain.PlannedSession, 0)
+	for _, p := range r.store.planned {
+		if match(p) {
+			planned = append(planned, r.withSession(p))
+		}
+	}
+	sort.Slice(planned, func(i, j int) bool {
+		if !planned[i].ScheduledOn.Equal(planned[j].ScheduledOn) {
+			return planned[i].ScheduledOn.Before(planned[j].ScheduledOn)
+		}
+		return planned[i].ID < planned[j].ID
+	})
+	return planned
+}
+
+// withSession fills in the earliest workout outside the trash that
+// completed planned.
+func (r *memoryProgramRepo) withSession(planned domain.PlannedSession) domain.PlannedSession {
+	var completedBy *domain.WorkoutSession
+	for _, session := range r.store.workouts {
+		if session.PlannedSessionID != planned.ID || session.DeletedAt != nil {
+			continue
+		}
+		if completedBy == nil || sessionBefore(*completedBy, session.StartedAt, session.ID) {
+			session := session
+			completedBy = &session
+		}
+	}
+	if completedBy != nil {
+		planned.SessionID = completedBy.ID
+	}
+	return planned
+}
+
+func copyProgram(program domain.Program) domain.Program {
+	program.Days = append([]domain.ProgramDay{}, program.Days...)
+	return program
+}
+
+type memoryTrainingMaxRepo struct {
+	store *memoryStore
+}
+
+func trainingMaxKey(userID, exerciseID string) string {
+	return userID + "\x00" + exerciseID
+}
+
+func (r *memoryTrainingMaxRepo) List(userID string) ([]domain.TrainingMax, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	maxes := make([]domain.TrainingMax, 0)
+	for _, trainingMax := range r.store.trainingMaxes {
+		if trainingMax.UserID == userID {
+			maxes = append(maxes, trainingMax)
+		}
+	}
+	sort.Slice(maxes, func(i, j int) bool { return maxes[i].ExerciseID < maxes[j].ExerciseID })
+	return maxes, nil
+}
+
+func (r *memoryTrainingMaxRepo) Set(trainingMax *domain.TrainingMax) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	trainingMax.UpdatedAt = time.Now().UTC()
+	r.store.trainingMaxes[trainingMaxKey(trainingMax.UserID, trainingMax.ExerciseID)] = *trainingMax
+	return nil
+}
+
+func (r *memoryTrainingMaxRepo) Delete(userID, exerciseID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := trainingMaxKey(userID, exerciseID)
+	if _, ok := r.store.trainingMaxes[key]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.store.trainingMaxes, key)
+	return nil
+}
+
+type memoryRecordRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryRecordRepo) List(userID string) ([]domain.PersonalRecord, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	records := make([]domain.PersonalRecord, 0)
+	for _, held := range r.store.records {
+		for _, record := range held {
+			if record.UserID == userID {
+				records = append(records, record)
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.ExerciseID != b.ExerciseID {
+			return a.ExerciseID < b.ExerciseID
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Weight < b.Weight
+	})
+	return records, nil
+}
+
+func (r *memoryRecordRepo) Update(userID, exerciseID string, compute func([]domain.PersonalRecord) ([]domain.PersonalRecord, error)) error {
+	r.store.recordsMu.Lock()
+	defer r.store.recordsMu.Unlock()
+
+	key := trainingMaxKey(userID, exerciseID)
+	r.store.mu.Lock()
+	held := append([]domain.PersonalRecord{}, r.store.records[key]...)
+	r.store.mu.Unlock()
+	records, err := compute(held)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if len(records) == 0 {
+		delete(r.store.records, key)
+		return nil
+	}
+	stored := make([]domain.PersonalRecord, len(records))
+	for i, record := range records {
+		record.UserID, record.ExerciseID = userID, exerciseID
+		stored[i] = record
+	}
+	r.store.records[key] = stored
+	return nil
+}
+
+func (r *memoryRecordRepo) Holders(exerciseID string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	holders := make([]string, 0)
+	for _, held := range r.store.records {
+		if len(held) > 0 && held[0].ExerciseID == exerciseID {
+			holders = append(holders, held[0].UserID)
+		}
+	}
+	sort.Strings(holders)
+	return holders, nil
+}
+
+func (r *memoryRecordRepo) Unscored() ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	scored := map[string]bool{}
+	for _, held := range r.store.records {
+		if len(held) > 0 {
+			scored[held[0].UserID] = true
+		}
+	}
+	seen := map[string]bool{}
+	users := make([]string, 0)
+	for _, session := range r.store.workouts {
+		if session.DeletedAt == nil && !scored[session.UserID] && !r.store.scored[session.UserID] && !seen[session.UserID] {
+			seen[session.UserID] = true
+			users = append(users, session.UserID)
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+func (r *memoryRecordRepo) MarkScored(userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.scored[userID] = true
+	return nil
+}
+
+type memoryAnalyticsRepo struct {
+	store *memoryStore
+}
+
+// memorySet is a working set with what the series group it by.
+type memorySet struct {
+	domain.WorkoutSet
+	start    time.Time
+	exercise domain.Exercise
+}
+
+// workingSets returns the working sets the query covers, in session order.
+func (r *memoryAnalyticsRepo) workingSets(query repository.SeriesQuery) []memorySet {
+	var sets []memorySet
+	for _, session := range r.sessions(query) {
+		for _, entry := range session.Entries {
+			exercise := r.store.exercises[entry.ExerciseID]
+			for _, set := range entry.WorkingSets() {
+				sets = append(sets, memorySet{
+					WorkoutSet: set,
+					start:      query.Bucket.Start(session.StartedAt),
+					exercise:   exercise,
+				})
+			}
+		}
+	}
+	return sets
+}
+
+func (r *memoryAnalyticsRepo) sessions(query repository.SeriesQuery) []domain.WorkoutSession {
+	sessions := make([]domain.WorkoutSession, 0)
+	for _, session := range r.store.workouts {
+		if session.UserID == query.UserID && session.DeletedAt == nil &&
+			!session.StartedAt.Before(query.From) && session.StartedAt.Before(query.To) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].StartedAt.Before(sessions[j].StartedAt) })
+	return sessions
+}
+
+func (r *memoryAnalyticsRepo) ExerciseVolume(query repository.VolumeQuery) ([]domain.ExerciseVolume, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	byExercise := map[string]*domain.ExerciseVolume{}
+	totals := make([]domain.ExerciseVolume, 0)
+	for _, session := range r.store.workouts {
+		if session.UserID != query.UserID || session.DeletedAt != nil ||
+			(query.From != nil && session.StartedAt.Before(*query.From)) ||
+			(query.To != nil && !session.StartedAt.Before(*query.To)) {
+			continue
+		}
+		for _, entry := range session.Entries {
+			exercise := r.store.exercises[entry.ExerciseID]
+			for _, set := range entry.WorkingSets() {
+				total, ok := byExercise[entry.ExerciseID]
+				if !ok {
+					total = &domain.ExerciseVolume{ExerciseID: entry.ExerciseID}
+					byExercise[entry.ExerciseID] = total
+				}
+				total.Sets++
+				total.Reps += set.Reps
+				if exercise.MeasurementType == domain.MeasurementWeightReps {
+					total.Volume += float64(set.Reps) * set.Weight
+				}
+			}
+		}
+	}
+	for _, total := range byExercise {
+		totals = append(totals, *total)
+	}
+	return totals, nil
+}
+
+func (r *memoryAnalyticsRepo) Workload(query repository.SeriesQuery) ([]domain.WorkloadPoint, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	points := make([]domain.WorkloadPoint, 0)
+	for _, set := range r.workingSets(query) {
+		if len(points) == 0 || !points[len(points)-1].Start.Equal(set.start) {
+			points = append(points, domain.WorkloadPoint{Start: set.start})
+		}
+		point := &points[len(points)-1]
+		point.Sets++
+		point.Reps += set.Reps
+		if set.exercise.MeasurementType != domain.MeasurementAssisted {
+			point.Tonnage += float64(set.Reps) * set.Weight
+		}
+	}
+	return points, nil
+}
+
+func (r *memoryAnalyticsRepo) MuscleGroupSets(query repository.SeriesQuery) ([]domain.MuscleGroupPoint, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	counts := map[domain.MuscleGroupPoint]int{}
+	for _, set := range r.workingSets(query) {
+		counts[domain.MuscleGroupPoint{Start: set.start, MuscleGroup: strings.ToLower(set.exercise.MuscleGroup)}]++
+	}
+	points := make([]domain.MuscleGroupPoint, 0, len(counts))
+	for point, sets := range counts {
+		point.Sets = sets
+		points = append(points, point)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		a, b := points[i], points[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.MuscleGroup < b.MuscleGroup
+	})
+	return points, nil
+}
+
+func (r *memoryAnalyticsRepo) OneRepMax(query repository.SeriesQuery) ([]domain.OneRepMaxPoint, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	points := make([]domain.OneRepMaxPoint, 0)
+	for _, set := range r.workingSets(query) {
+		if set.exercise.ID != query.ExerciseID || set.exercise.MeasurementType != domain.MeasurementWeightReps ||
+			set.Reps <= 0 || set.Weight <= 0 {
+			continue
+		}
+		candidate := domain.OneRepMaxPoint{
+			Start:              set.start,
+			EstimatedOneRepMax: records.EstimateOneRepMax(set.Weight, set.Reps),
+			Weight:             set.Weight,
+			Reps:               set.Reps,
+		}
+		if len(points) == 0 || !points[len(points)-1].Start.Equal(set.start) {
+			points = append(points, candidate)
+			continue
+		}
+		best := &points[len(points)-1]
+		if candidate.EstimatedOneRepMax > best.EstimatedOneRepMax ||
+			(candidate.EstimatedOneRepMax == best.EstimatedOneRepMax && candidate.Weight > best.Weight) {
+			*best = candidate
+		}
+	}
+	return points, nil
+}
+
+func (r *memoryAnalyticsRepo) Frequency(query repository.SeriesQuery) ([]domain.FrequencyPoint, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	points := make([]domain.FrequencyPoint, 0)
+	days := map[time.Time]bool{}
+	for _, session := range r.sessions(query) {
+		start := query.Bucket.Start(session.StartedAt)
+		if len(points) == 0 || !points[len(points)-1].Start.Equal(start) {
+			points = append(points, domain.FrequencyPoint{Start: start})
+		}
+		point := &points[len(points)-1]
+		point.Sessions++
+		if day := domain.BucketDay.Start(session.StartedAt); !days[day] {
+			days[day] = true
+			point.Days++
+		}
+	}
+	return points, nil
+}
+
+type memoryGoalRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryGoalRepo) Create(goal *domain.Goal) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if goal.ID == "" {
+		goal.ID = uuid.NewString()
+	}
+	goal.CreatedAt = time.Now().UTC()
+	goal.UpdatedAt = goal.CreatedAt
+	r.store.goals[goal.ID] = *goal
+	return nil
+}
+
+func (r *memoryGoalRepo) Get(id string) (*domain.Goal, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	goal, ok := r.store.goals[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &goal, nil
+}
+
+func (r *memoryGoalRepo) List(userID string) ([]domain.Goal, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	goals := make([]domain.Goal, 0)
+	for _, goal := range r.store.goals {
+		if goal.UserID == userID {
+			goals = append(goals, goal)
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool {
+		if !goals[i].Deadline.Equal(goals[j].Deadline) {
+			return goals[i].Deadline.Before(goals[j].Deadline)
+		}
+		return goals[i].CreatedAt.Before(goals[j].CreatedAt)
+	})
+	return goals, nil
+}
+
+func (r *memoryGoalRepo) Update(goal *domain.Goal) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.goals[goal.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	goal.UserID, goal.CreatedAt = stored.UserID, stored.CreatedAt
+	goal.UpdatedAt = time.Now().UTC()
+	r.store.goals[goal.ID] = *goal
+	return nil
+}
+
+func (r *memoryGoalRepo) Delete(id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.goals[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.store.goals, id)
+	return nil
+}
+
+func (r *memoryGoalRepo) Achieve(id string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if goal, ok := r.store.goals[id]; ok && goal.AchievedAt == nil {
+		goal.AchievedAt = &at
+		r.store.goals[id] = goal
+	}
+	return nil
+}
+
+type memoryBodyMetricRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryBodyMetricRepo) Create(metric *domain.BodyMetric) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.create(metric)
+	return nil
+}
+
+func (r *memoryBodyMetricRepo) CreateOnce(metric *domain.BodyMetric, submission *domain.BodyMetricSubmission) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	submissionKey := submission.UserID + "\x00" + submission.Key
+	if _, used := r.store.metricSubs[submissionKey]; used {
+		return repository.ErrConflict
+	}
+	if _, taken := r.store.bodyMetrics[metric.ID]; taken && metric.ID != "" {
+		return repository.ErrConflict
+	}
+	r.create(metric)
+	response, err := json.Marshal(metric)
+	if err != nil {
+		return err
+	}
+	submission.MetricID = metric.ID
+	submission.Response = response
+	submission.CreatedAt = metric.CreatedAt
+	r.store.metricSubs[submissionKey] = *submission
+	return nil
+}
+
+func (r *memoryBodyMetricRepo) create(metric *domain.BodyMetric) {
+	if metric.ID == "" {
+		metric.ID = uuid.NewString()
+	}
+	metric.CreatedAt = time.Now().UTC()
+	metric.UpdatedAt = metric.CreatedAt
+	r.store.bodyMetrics[metric.ID] = cloneBodyMetric(*metric)
+}
+
+func (r *memoryBodyMetricRepo) GetSubmission(userID, key string) (*domain.BodyMetricSubmission, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	submission, ok := r.store.metricSubs[userID+"\x00"+key]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &submission, nil
+}
+
+func (r *memoryBodyMetricRepo) Get(id string) (*domain.BodyMetric, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	metric, ok := r.store.bodyMetrics[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	metric = cloneBodyMetric(metric)
+	return &metric, nil
+}
+
+func (r *memoryBodyMetricRepo) List(filter repository.BodyMetricFilter) ([]domain.BodyMetric, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	metrics := make([]domain.BodyMetric, 0)
+	for _, metric := range r.store.bodyMetrics {
+		if metric.UserID != filter.UserID ||
+			(filter.From != nil && metric.MeasuredAt.Before(*filter.From)) ||
+			(filter.To != nil && !metric.MeasuredAt.Before(*filter.To)) {
+			continue
+		}
+		metrics = append(metrics, cloneBodyMetric(metric))
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if !metrics[i].MeasuredAt.Equal(metrics[j].MeasuredAt) {
+			return metrics[i].MeasuredAt.After(metrics[j].MeasuredAt)
+		}
+		return metrics[i].ID < metrics[j].ID
+	})
+	return metrics, nil
+}
+
+func (r *memoryBodyMetricRepo) Update(metric *domain.BodyMetric) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.bodyMetrics[metric.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	metric.UserID, metric.CreatedAt = existing.UserID, existing.CreatedAt
+	metric.UpdatedAt = time.Now().UTC()
+	r.store.bodyMetrics[metric.ID] = cloneBodyMetric(*metric)
+	return nil
+}
+
+func (r *memoryBodyMetricRepo) Delete(id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.bodyMetrics[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.store.bodyMetrics, id)
+	return nil
+}
+
+// cloneBodyMetric copies a metric as stored, in metric units, so callers
+// cannot change the store through its pointers and map.
+func cloneBodyMetric(metric domain.BodyMetric) domain.BodyMetric {
+	clone := metric
+	clone.Units = domain.MetricUnits
+	if metric.Bodyweight != nil {
+		weight := *metric.Bodyweight
+		clone.Bodyweight = &weight
+	}
+	if metric.BodyFat != nil {
+		fat := *metric.BodyFat
+		clone.BodyFat = &fat
+	}
+	if len(metric.Circumferences) > 0 {
+		clone.Circumferences = make(map[domain.CircumferenceSite]float64, len(metric.Circumferences))
+		for site, cm := range metric.Circumferences {
+			clone.Circumferences[site] = cm
+		}
+	} else {
+		clone.Circumferences = nil
+	}
+	return clone
+}
+
+type memoryLiveWorkoutRepo struct {
+	store *memoryStore
+}
+
+func (r *memoryLiveWorkoutRepo) Create(live *domain.LiveWorkout) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.liveWorkouts {
+		if existing.UserID == live.UserID || existing.ID == live.ID {
+			return repository.ErrConflict
+		}
+	}
+	if live.ID == "" {
+		live.ID = uuid.NewString()
+	}
+	r.store.liveWorkouts[live.ID] = cloneLiveWorkout(*live)
+	return nil
+}
+
+func (r *memoryLiveWorkoutRepo) GetByUser(userID string) (*domain.LiveWorkout, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, live := range r.store.liveWorkouts {
+		if live.UserID == userID {
+			live = cloneLiveWorkout(live)
+			return &live, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryLiveWorkoutRepo) Update(live *domain.LiveWorkout) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.liveWorkouts[live.ID]
+	if !ok || existing.Revision != live.Revision {
+		return repository.ErrConflict
+	}
+	live.Revision++
+	stored := cloneLiveWorkout(*live)
+	stored.UserID, stored.StartedAt = existing.UserID, existing.StartedAt
+	r.store.liveWorkouts[live.ID] = stored
+	return nil
+}
+
+func (r *memoryLiveWorkoutRepo) Delete(id string, revision int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.liveWorkouts[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if existing.Revision != revision {
+		return repository.ErrConflict
+	}
+	delete(r.store.liveWorkouts, id)
+	return nil
+}
+
+func (r *memoryLiveWorkoutRepo) Idle(before time.Time) ([]domain.LiveWorkout, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	idle := make([]domain.LiveWorkout, 0)
+	for _, live := range r.store.liveWorkouts {
+		if live.LastActivityAt.Before(before) {
+			idle = append(idle, cloneLiveWorkout(live))
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].LastActivityAt.Before(idle[j].LastActivityAt) })
+	return idle, nil
+}
+
+// cloneLiveWorkout deep-copies a live workout the way storing it as JSON
+// does, so callers cannot change the store through its slices.
+func cloneLiveWorkout(live domain.LiveWorkout) domain.LiveWorkout {
+	data, err := json.Marshal(live.Entries)
+	if err != nil {
+		panic(err)
+	}
+	clone := live
+	clone.Entries = nil
+	if err := json.Unmarshal(data, &clone.Entries); err != nil {
+		panic(err)
+	}
+	clone.ClosesAt = nil
+	return clone
 }
@@ -0,0 +1,139 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type draftResponse struct {
+	Entries []struct {
+		ExerciseID    string  `json:"exerciseId"`
+		Reps          int     `json:"reps"`
+		Weight        float64 `json:"weight"`
+		TargetPercent float64 `json:"targetPercent"`
+		TrainingMax   float64 `json:"trainingMax"`
+	} `json:"entries"`
+}
+
+func TestPercentagePrescriptions(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	athlete := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	plankID := ts.exerciseIDByName("Plank")
+
+	// Act
+	_, unknownResp := ts.doRequest(http.MethodPut, "/api/v1/training-maxes/0b0e8b4e-5d53-4c1e-9a1a-1f2d3c4b5a69", []byte(`{"weight":100}`), athlete)
+	plankData, plankResp := ts.doRequest(http.MethodPut, "/api/v1/training-maxes/"+plankID, []byte(`{"weight":0}`), athlete)
+	setData, setResp := ts.doRequest(http.MethodPut, "/api/v1/training-maxes/"+benchID, []byte(`{"weight":142}`), athlete)
+	listData, _ := ts.doRequest(http.MethodGet, "/api/v1/training-maxes", nil, athlete)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, unknownResp.StatusCode)
+	require.Equal(t, http.StatusBadRequest, plankResp.StatusCode)
+	var plankErr fieldErrorResponse
+	require.NoError(t, json.Unmarshal(plankData, &plankErr))
+	require.Equal(t, []string{"exerciseId", "weight"}, plankErr.fieldNames())
+	require.Equal(t, http.StatusOK, setResp.StatusCode, string(setData))
+	var maxes []struct {
+		ExerciseID string  `json:"exerciseId"`
+		Weight     float64 `json:"weight"`
+	}
+	require.NoError(t, json.Unmarshal(listData, &maxes))
+	require.Len(t, maxes, 1)
+	require.Equal(t, 142.0, maxes[0].Weight)
+
+	// Act
+	invalidData, invalidResp := ts.doRequest(http.MethodPost, "/api/v1/templates", []byte(`{"name":"Broken","exercises":[
+		{"exerciseId":"`+benchID+`","targetWeight":100,"targetPercent":75},
+		{"exerciseId":"`+plankID+`","targetPercent":50}
+	]}`), athlete)
+	templateData, templateResp := ts.doRequest(http.MethodPost, "/api/v1/templates", []byte(`{"name":"Bench Wave","exercises":[
+		{"exerciseId":"`+benchID+`","targetSets":3,"targetReps":5,"targetPercent":75}
+	]}`), athlete)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.Equal(t, []string{"exercises[0].targetWeight", "exercises[1].targetPercent"}, invalid.fieldNames())
+	require.Equal(t, http.StatusCreated, templateResp.StatusCode, string(templateData))
+	var template templateResponse
+	require.NoError(t, json.Unmarshal(templateData, &template))
+
+	start := func() draftResponse {
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/templates/"+template.ID+"/start", nil, athlete)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+		var draft draftResponse
+		require.NoError(t, json.Unmarshal(data, &draft))
+		return draft
+	}
+
+	// Act: 75% of 142 kg is 106.5 kg, loadable as 107.5 kg with standard plates.
+	standard := start()
+
+	// Assert
+	require.Equal(t, 107.5, standard.Entries[0].Weight)
+	require.Equal(t, 75.0, standard.Entries[0].TargetPercent)
+	require.Equal(t, 142.0, standard.Entries[0].TrainingMax)
+
+	// Act: without 1.25 kg plates the bar moves in 5 kg steps.
+	_, badLoadingResp := ts.doRequest(http.MethodPatch, "/api/v1/profile", []byte(`{"loading":{"barWeight":20,"plates":[20,-5]}}`), athlete)
+	profileData, profileResp := ts.doRequest(http.MethodPatch, "/api/v1/profile", []byte(`{"loading":{"barWeight":20,"plates":[20,10,5,2.5]}}`), athlete)
+	homeGym := start()
+	_, clearResp := ts.doRequest(http.MethodPatch, "/api/v1/profile", []byte(`{"loading":null}`), athlete)
+	cleared := start()
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, badLoadingResp.StatusCode)
+	require.Equal(t, http.StatusOK, profileResp.StatusCode, string(profileData))
+	require.Contains(t, string(profileData), `"plates":[20,10,5,2.5]`)
+	require.Equal(t, 105.0, homeGym.Entries[0].Weight)
+	require.Equal(t, http.StatusOK, clearResp.StatusCode)
+	require.Equal(t, 107.5, cleared.Entries[0].Weight)
+
+	// Act: a program adds 2.5 kg a week on top of 80%.
+	admin := ts.login("admin@test.app", "AdminPass123!").Tokens.AccessToken
+	programTemplateData, _ := ts.doRequest(http.MethodPost, "/api/v1/templates", []byte(`{"name":"Heavy Bench","exercises":[
+		{"exerciseId":"`+benchID+`","targetSets":3,"targetReps":5,"targetPercent":80}
+	]}`), admin)
+	var programTemplate templateResponse
+	require.NoError(t, json.Unmarshal(programTemplateData, &programTemplate))
+	programData, programResp := ts.doRequest(http.MethodPost, "/api/v1/programs", []byte(`{"name":"Bench Block","weeks":2,
+		"days":[{"day":1,"templateId":"`+programTemplate.ID+`"}],"progression":{"weightIncrement":2.5}}`), admin)
+	require.Equal(t, http.StatusCreated, programResp.StatusCode, string(programData))
+	var program struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(programData, &program))
+	_, enrollResp := ts.doRequest(http.MethodPost, "/api/v1/programs/"+program.ID+"/enroll",
+		[]byte(`{"startDate":"`+time.Now().UTC().Format(time.DateOnly)+`"}`), athlete)
+	require.Equal(t, http.StatusCreated, enrollResp.StatusCode)
+	scheduleData, scheduleResp := ts.doRequest(http.MethodGet, "/api/v1/schedule?days=7", nil, athlete)
+
+	// Assert: 113.6 kg rounds to 112.5 kg, then 116.1 kg to 115 kg.
+	require.Equal(t, http.StatusOK, scheduleResp.StatusCode, string(scheduleData))
+	var schedule struct {
+		Today    []struct{ Draft draftResponse } `json:"today"`
+		Upcoming []struct{ Draft draftResponse } `json:"upcoming"`
+	}
+	require.NoError(t, json.Unmarshal(scheduleData, &schedule))
+	require.Len(t, schedule.Today, 1)
+	require.Len(t, schedule.Upcoming, 1)
+	require.Equal(t, 112.5, schedule.Today[0].Draft.Entries[0].Weight)
+	require.Equal(t, 115.0, schedule.Upcoming[0].Draft.Entries[0].Weight)
+
+	// Act
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/training-maxes/"+benchID, nil, athlete)
+	withoutMax := start()
+
+	// Assert: the load stays open until a training max is set.
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	require.Equal(t, 0.0, withoutMax.Entries[0].Weight)
+	require.Equal(t, 0.0, withoutMax.Entries[0].TrainingMax)
+}
@@ -0,0 +1,199 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scheduledWorkoutResponse struct {
+	ID           string `json:"id"`
+	Week         int    `json:"week"`
+	Day          int    `json:"day"`
+	ScheduledOn  string `json:"scheduledOn"`
+	SessionID    string `json:"sessionId"`
+	ProgramName  string `json:"programName"`
+	TemplateName string `json:"templateName"`
+	Status       string `json:"status"`
+	Draft        *struct {
+		PlannedSessionID string `json:"plannedSessionId"`
+		Entries          []struct {
+			ExerciseID string  `json:"exerciseId"`
+			Sets       int     `json:"sets"`
+			Reps       int     `json:"reps"`
+			Weight     float64 `json:"weight"`
+		} `json:"entries"`
+	} `json:"draft"`
+}
+
+type scheduleResponse struct {
+	Today    []scheduledWorkoutResponse `json:"today"`
+	Upcoming []scheduledWorkoutResponse `json:"upcoming"`
+}
+
+type enrollmentResponse struct {
+	ID        string                     `json:"id"`
+	Planned   int                        `json:"planned"`
+	Completed int                        `json:"completed"`
+	Missed    int                        `json:"missed"`
+	Sessions  []scheduledWorkoutResponse `json:"sessions"`
+}
+
+func TestTrainingPrograms(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!").Tokens.AccessToken
+	athlete := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	squatID := ts.exerciseIDByName("Barbell Back Squat")
+	createTemplate := func(token, body string) string {
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/templates", []byte(body), token)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+		var template templateResponse
+		require.NoError(t, json.Unmarshal(data, &template))
+		return template.ID
+	}
+	pushID := createTemplate(admin, `{"name":"Strength A","exercises":[{"exerciseId":"`+benchID+`","targetSets":3,"targetReps":5,"targetWeight":100}]}`)
+	legsID := createTemplate(admin, `{"name":"Strength B","exercises":[{"exerciseId":"`+squatID+`","targetSets":3,"targetReps":5,"targetWeight":120}]}`)
+	ownID := createTemplate(athlete, `{"name":"My Day","exercises":[{"exerciseId":"`+benchID+`"}]}`)
+	program := []byte(`{"name":"Novice Strength","weeks":2,
+		"days":[{"day":3,"templateId":"` + legsID + `"},{"day":1,"templateId":"` + pushID + `"}],
+		"progression":{"weightIncrement":2.5}}`)
+
+	// Act
+	_, forbiddenResp := ts.doRequest(http.MethodPost, "/api/v1/programs", program, athlete)
+	invalidData, invalidResp := ts.doRequest(http.MethodPost, "/api/v1/programs",
+		[]byte(`{"name":"Broken","weeks":0,"days":[{"day":8,"templateId":"`+ownID+`"}],"progression":{"repsIncrement":-1}}`), admin)
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/programs", program, admin)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, forbiddenResp.StatusCode)
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.Equal(t, []string{"weeks", "days[0].day", "days[0].templateId", "progression.repsIncrement"}, invalid.fieldNames())
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode, string(createdData))
+	var created struct {
+		ID   string `json:"id"`
+		Days []struct {
+			Day int `json:"day"`
+		} `json:"days"`
+	}
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	require.Equal(t, 1, created.Days[0].Day)
+
+	// Act
+	today := time.Now().UTC()
+	enrollData, enrollResp := ts.doRequest(http.MethodPost, "/api/v1/programs/"+created.ID+"/enroll",
+		[]byte(`{"startDate":"`+today.Format(time.DateOnly)+`"}`), athlete)
+	_, repeatResp := ts.doRequest(http.MethodPost, "/api/v1/programs/"+created.ID+"/enroll", nil, athlete)
+	scheduleData, scheduleResp := ts.doRequest(http.MethodGet, "/api/v1/schedule?days=7", nil, athlete)
+
+	// Assert: week two repeats the days with heavier targets.
+	require.Equal(t, http.StatusCreated, enrollResp.StatusCode, string(enrollData))
+	require.Equal(t, http.StatusConflict, repeatResp.StatusCode)
+	require.Equal(t, http.StatusOK, scheduleResp.StatusCode, string(scheduleData))
+	var schedule scheduleResponse
+	require.NoError(t, json.Unmarshal(scheduleData, &schedule))
+	require.Len(t, schedule.Today, 1)
+	planned := schedule.Today[0]
+	require.Equal(t, "planned", planned.Status)
+	require.Equal(t, "Novice Strength", planned.ProgramName)
+	require.Equal(t, "Strength A", planned.TemplateName)
+	require.Equal(t, planned.ID, planned.Draft.PlannedSessionID)
+	require.Equal(t, 100.0, planned.Draft.Entries[0].Weight)
+	require.Len(t, schedule.Upcoming, 2)
+	require.Equal(t, "Strength B", schedule.Upcoming[0].TemplateName)
+	require.Equal(t, 1, schedule.Upcoming[0].Week)
+	require.Equal(t, 2, schedule.Upcoming[1].Week)
+	require.Equal(t, 102.5, schedule.Upcoming[1].Draft.Entries[0].Weight)
+
+	// Act: log today's workout from its draft.
+	draft, err := json.Marshal(planned.Draft)
+	require.NoError(t, err)
+	workoutData, workoutResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", draft, athlete)
+	againData, againResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", draft, athlete)
+	scheduleData, _ = ts.doRequest(http.MethodGet, "/api/v1/schedule", nil, athlete)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, workoutResp.StatusCode, string(workoutData))
+	var workout struct {
+		ID               string `json:"id"`
+		PlannedSessionID string `json:"plannedSessionId"`
+	}
+	require.NoError(t, json.Unmarshal(workoutData, &workout))
+	require.Equal(t, planned.ID, workout.PlannedSessionID)
+	require.Equal(t, http.StatusBadRequest, againResp.StatusCode)
+	var again fieldErrorResponse
+	require.NoError(t, json.Unmarshal(againData, &again))
+	require.Equal(t, []string{"plannedSessionId"}, again.fieldNames())
+	schedule = scheduleResponse{}
+	require.NoError(t, json.Unmarshal(scheduleData, &schedule))
+	require.Equal(t, "completed", schedule.Today[0].Status)
+	require.Equal(t, workout.ID, schedule.Today[0].SessionID)
+	require.Nil(t, schedule.Today[0].Draft)
+
+	// Act
+	progressData, progressResp := ts.doRequest(http.MethodGet, "/api/v1/enrollments", nil, athlete)
+
+	// Assert
+	require.Equal(t, http.StatusOK, progressResp.StatusCode)
+	var progress []enrollmentResponse
+	require.NoError(t, json.Unmarshal(progressData, &progress))
+	require.Len(t, progress, 1)
+	require.Equal(t, 4, progress[0].Planned)
+	require.Equal(t, 1, progress[0].Completed)
+
+	// Act: trashing the workout reopens the planned session.
+	_, trashResp := ts.doRequest(http.MethodDelete, "/api/v1/workouts/"+workout.ID, nil, athlete)
+	detailData, detailResp := ts.doRequest(http.MethodGet, "/api/v1/enrollments/"+progress[0].ID, nil, athlete)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, trashResp.StatusCode)
+	require.Equal(t, http.StatusOK, detailResp.StatusCode, string(detailData))
+	var detail enrollmentResponse
+	require.NoError(t, json.Unmarshal(detailData, &detail))
+	require.Equal(t, 0, detail.Completed)
+	require.Len(t, detail.Sessions, 4)
+	require.Equal(t, "planned", detail.Sessions[0].Status)
+
+	// Act: another workout completes it, so the trashed one stays out.
+	redoData, redoResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", draft, athlete)
+	restoreData, restoreResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/"+workout.ID+"/restore", nil, athlete)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, redoResp.StatusCode, string(redoData))
+	require.Equal(t, http.StatusConflict, restoreResp.StatusCode, string(restoreData))
+
+	// Act: programs and their templates stay while anyone is enrolled.
+	_, deleteTemplateResp := ts.doRequest(http.MethodDelete, "/api/v1/templates/"+pushID, nil, admin)
+	_, deleteProgramResp := ts.doRequest(http.MethodDelete, "/api/v1/programs/"+created.ID, nil, admin)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, deleteTemplateResp.StatusCode)
+	require.Equal(t, http.StatusConflict, deleteProgramResp.StatusCode)
+
+	// Act: leave, then restart the program as if begun a week ago.
+	_, leaveResp := ts.doRequest(http.MethodDelete, "/api/v1/enrollments/"+progress[0].ID, nil, athlete)
+	lateData, lateResp := ts.doRequest(http.MethodPost, "/api/v1/programs/"+created.ID+"/enroll",
+		[]byte(`{"startDate":"`+today.AddDate(0, 0, -7).Format(time.DateOnly)+`"}`), athlete)
+	progressData, _ = ts.doRequest(http.MethodGet, "/api/v1/enrollments", nil, athlete)
+	scheduleData, _ = ts.doRequest(http.MethodGet, "/api/v1/schedule?days=1", nil, athlete)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, leaveResp.StatusCode)
+	require.Equal(t, http.StatusCreated, lateResp.StatusCode, string(lateData))
+	progress = nil
+	require.NoError(t, json.Unmarshal(progressData, &progress))
+	require.Len(t, progress, 2)
+	require.Equal(t, 2, progress[0].Missed)
+	schedule = scheduleResponse{}
+	require.NoError(t, json.Unmarshal(scheduleData, &schedule))
+	require.Len(t, schedule.Today, 1)
+	require.Equal(t, 2, schedule.Today[0].Week)
+	require.Empty(t, schedule.Upcoming)
+}
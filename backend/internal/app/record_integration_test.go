@@ -0,0 +1,170 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordResponse struct {
+	ExerciseID string  `json:"exerciseId"`
+	Type       string  `json:"type"`
+	Value      float64 `json:"value"`
+	Weight     float64 `json:"weight"`
+	Reps       int     `json:"reps"`
+	SessionID  string  `json:"sessionId"`
+}
+
+type recordedWorkout struct {
+	ID      string `json:"id"`
+	Entries []struct {
+		ID string `json:"id"`
+	} `json:"entries"`
+	Records []recordResponse `json:"records"`
+}
+
+// recordSummary describes records as "type@weight=value" for compact
+// comparisons.
+func recordSummary(records []recordResponse) map[string]float64 {
+	summary := make(map[string]float64, len(records))
+	for _, r := range records {
+		key := r.Type
+		if r.Type == "most_reps" {
+			key = r.Type + "@" + strconv.FormatFloat(r.Weight, 'g', -1, 64)
+		}
+		summary[key] = r.Value
+	}
+	return summary
+}
+
+func TestPersonalRecords(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+	plankID := ts.exerciseIDByName("Plank")
+	post := func(body string, header ...string) (recordedWorkout, *http.Response) {
+		var data []byte
+		var resp *http.Response
+		if len(header) > 0 {
+			data, resp = ts.doRequestWithHeaders(http.MethodPost, "/api/v1/workouts", []byte(body), token, http.Header{"Idempotency-Key": header})
+		} else {
+			data, resp = ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(body), token)
+		}
+		require.Less(t, resp.StatusCode, 300, string(data))
+		var workout recordedWorkout
+		require.NoError(t, json.Unmarshal(data, &workout))
+		return workout, resp
+	}
+	listRecords := func(query string) map[string]float64 {
+		data, resp := ts.doRequest(http.MethodGet, "/api/v1/records"+query, nil, token)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+		var records []recordResponse
+		require.NoError(t, json.Unmarshal(data, &records))
+		return recordSummary(records)
+	}
+
+	// Act
+	first, _ := post(`{"startedAt":"2026-03-02T10:00:00Z","entries":[
+		{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]}`)
+	second, _ := post(`{"startedAt":"2026-03-09T10:00:00Z","entries":[
+		{"exerciseId":"` + benchID + `","setDetails":[
+			{"type":"warmup","reps":10,"weight":60},{"reps":3,"weight":110},{"reps":8,"weight":100}]}]}`)
+	matched, _ := post(`{"startedAt":"2026-03-16T10:00:00Z","entries":[
+		{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]}`)
+
+	// Assert: the first workout sets every record, later ones only what they beat.
+	require.Equal(t, map[string]float64{
+		"heaviest_weight": 100, "estimated_1rm": 112.5, "most_reps@100": 5, "best_volume": 1500,
+	}, recordSummary(first.Records))
+	require.Equal(t, map[string]float64{
+		"heaviest_weight": 110, "estimated_1rm": 124.14, "most_reps@100": 8, "most_reps@110": 3,
+	}, recordSummary(second.Records))
+	require.Empty(t, matched.Records, "matching a record does not take it over")
+
+	// Act: retried submissions report the records of the original.
+	plank, plankResp := post(`{"startedAt":"2026-03-16T11:00:00Z","entries":[
+		{"exerciseId":"`+plankID+`","durationSeconds":90}]}`, "plank-1")
+	replayed, replayedResp := post(`{"startedAt":"2026-03-16T11:00:00Z","entries":[
+		{"exerciseId":"`+plankID+`","durationSeconds":90}]}`, "plank-1")
+
+	// Assert
+	require.Equal(t, http.StatusCreated, plankResp.StatusCode)
+	require.Equal(t, http.StatusOK, replayedResp.StatusCode)
+	require.Equal(t, map[string]float64{"longest_duration": 90}, recordSummary(plank.Records))
+	require.Equal(t, plank.Records, replayed.Records)
+	require.Len(t, listRecords(""), 6)
+	require.Equal(t, map[string]float64{"longest_duration": 90}, listRecords("?exerciseId="+plankID))
+
+	// Act: deleting a workout hands its records back to the previous best.
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/workouts/"+second.ID, nil, token)
+	afterDelete := listRecords("?exerciseId=" + benchID)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	require.Equal(t, map[string]float64{
+		"heaviest_weight": 100, "estimated_1rm": 112.5, "most_reps@100": 5, "best_volume": 1500,
+	}, afterDelete)
+
+	// Act: restoring it brings them back.
+	restoreData, restoreResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/"+second.ID+"/restore", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, restoreResp.StatusCode, string(restoreData))
+	var restored recordedWorkout
+	require.NoError(t, json.Unmarshal(restoreData, &restored))
+	require.Equal(t, recordSummary(second.Records), recordSummary(restored.Records))
+
+	// Act: editing the first workout down loses its records to the others.
+	patch := `{"entries":[{"id":"` + first.Entries[0].ID + `","exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":80}]}`
+	patchedData, patchedResp := ts.doRequest(http.MethodPatch, "/api/v1/workouts/"+first.ID, []byte(patch), token)
+
+	// Assert: the third workout now holds the 5-rep and volume records it matched.
+	require.Equal(t, http.StatusOK, patchedResp.StatusCode, string(patchedData))
+	var patched recordedWorkout
+	require.NoError(t, json.Unmarshal(patchedData, &patched))
+	require.Equal(t, map[string]float64{"most_reps@80": 5}, recordSummary(patched.Records))
+	getData, _ := ts.doRequest(http.MethodGet, "/api/v1/workouts/"+matched.ID, nil, token)
+	var third recordedWorkout
+	require.NoError(t, json.Unmarshal(getData, &third))
+	require.Equal(t, map[string]float64{"best_volume": 1500}, recordSummary(third.Records))
+}
+
+func TestPersonalRecordsConcurrentSaves(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	token := ts.registerAthlete().Tokens.AccessToken
+	benchID := ts.exerciseIDByName("Bench Press")
+
+	// Act: saves racing on one exercise must not overwrite each other's records.
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"startedAt":"2026-04-%02dT10:00:00Z","entries":[
+				{"exerciseId":"%s","sets":1,"reps":%d,"weight":%d}]}`, i, benchID, i, 100+i)
+			data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(body), token)
+			require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	data, resp := ts.doRequest(http.MethodGet, "/api/v1/records?exerciseId="+benchID, nil, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	var records []recordResponse
+	require.NoError(t, json.Unmarshal(data, &records))
+	summary := recordSummary(records)
+	require.Equal(t, 108.0, summary["heaviest_weight"])
+	require.Equal(t, 8.0, summary["most_reps@108"])
+	require.Equal(t, 1.0, summary["most_reps@101"])
+	require.Len(t, summary, 11)
+}
@@ -3,18 +3,21 @@ package app
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	chMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"github.com/musclementour/app/internal/catalogue"
 	"github.com/musclementour/app/internal/config"
 	"github.com/musclementour/app/internal/domain"
 	"github.com/musclementour/app/internal/http/handlers"
 	appMiddleware "github.com/musclementour/app/internal/http/middleware"
 	"github.com/musclementour/app/internal/repository"
 	"github.com/musclementour/app/internal/services"
+	"github.com/musclementour/app/internal/storage/blob"
 	"github.com/musclementour/app/internal/storage/postgres"
 )
 
@@ -22,6 +25,8 @@ type Server struct {
 	cfg        *config.Config
 	router     *chi.Mux
 	shutdownFn func(context.Context) error
+	// stopSweep stops the idle live workout sweep and waits for it to end.
+	stopSweep func()
 }
 
 func NewServer(ctx context.Context, cfg *config.Config) (*Server, error) {
@@ -50,15 +55,69 @@ func newServerWithRepository(cfg *config.Config, repo repository.Repository, shu
 	if err := authService.EnsureAdminExists(); err != nil {
 		return nil, fmt.Errorf("ensure admin: %w", err)
 	}
-	exerciseService := services.NewExerciseService(repo)
-	if err := exerciseService.EnsureDefaults(); err != nil {
+	blobs, err := blob.NewLocalStore(cfg.MediaDir, cfg.MediaBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("init media storage: %w", err)
+	}
+	exerciseService := services.NewExerciseService(repo, blobs, services.MediaLimits{
+		MaxImageBytes: cfg.MaxImageBytes,
+		MaxVideoBytes: cfg.MaxVideoBytes,
+	})
+	exerciseCatalogue, err := catalogue.Load(cfg.ExerciseCatalogue)
+	if err != nil {
+		return nil, err
+	}
+	seedReport, err := exerciseService.SeedCatalogue(exerciseCatalogue)
+	if err != nil {
 		return nil, fmt.Errorf("seed exercises: %w", err)
 	}
-	workoutService := services.NewWorkoutService(repo)
+	log.Printf("exercise catalogue v%d -> v%d: %d created, %d matched existing, %d previously seeded now removed",
+		seedReport.FromVersion, seedReport.ToVersion, len(seedReport.Created), len(seedReport.Matched), seedReport.Removed)
+	workoutService := services.NewWorkoutService(repo, services.BatchLimits{
+		MaxItems: cfg.WorkoutBatchMaxItems,
+		MaxBytes: cfg.WorkoutBatchMaxBytes,
+	}, cfg.WorkoutTrashRetention)
+	purged, err := workoutService.PurgeTrash()
+	if err != nil {
+		return nil, fmt.Errorf("purge workout trash: %w", err)
+	}
+	if purged > 0 {
+		log.Printf("purged %d workouts from the trash", purged)
+	}
+	liveWorkoutService := services.NewLiveWorkoutService(repo, workoutService, cfg.LiveWorkoutTimeout)
+	closed, err := liveWorkoutService.CloseIdle()
+	if err != nil {
+		return nil, fmt.Errorf("close idle live workouts: %w", err)
+	}
+	if closed > 0 {
+		log.Printf("closed %d idle live workouts", closed)
+	}
+	recordService := services.NewRecordService(repo)
+	scored, err := recordService.Backfill()
+	if err != nil {
+		return nil, fmt.Errorf("backfill personal records: %w", err)
+	}
+	if scored > 0 {
+		log.Printf("computed personal records for %d users", scored)
+	}
+	templateService := services.NewTemplateService(repo)
+	programService := services.NewProgramService(repo)
+	trainingMaxService := services.NewTrainingMaxService(repo)
+	analyticsService := services.NewAnalyticsService(repo)
+	goalService := services.NewGoalService(repo)
+	bodyMetricService := services.NewBodyMetricService(repo)
 
 	authHandler := handlers.NewAuthHandler(authService)
 	exerciseHandler := handlers.NewExerciseHandler(exerciseService)
 	workoutHandler := handlers.NewWorkoutHandler(workoutService)
+	liveWorkoutHandler := handlers.NewLiveWorkoutHandler(liveWorkoutService)
+	templateHandler := handlers.NewTemplateHandler(templateService)
+	programHandler := handlers.NewProgramHandler(programService)
+	trainingMaxHandler := handlers.NewTrainingMaxHandler(trainingMaxService)
+	recordHandler := handlers.NewRecordHandler(recordService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	goalHandler := handlers.NewGoalHandler(goalService)
+	bodyMetricHandler := handlers.NewBodyMetricHandler(bodyMetricService)
 	profileHandler := handlers.NewProfileHandler(repo)
 
 	router := chi.NewRouter()
@@ -68,8 +127,9 @@ func newServerWithRepository(cfg *config.Config, repo repository.Repository, shu
 
 	corsOpts := cors.Options{
 		AllowedOrigins:   cfg.AllowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Content-Language", "Idempotent-Replayed", "X-Next-Cursor", "X-Total-Count"},
 		AllowCredentials: true,
 	}
 	if len(cfg.AllowedOrigins) == 0 {
@@ -83,6 +143,7 @@ func newServerWithRepository(cfg *config.Config, repo repository.Repository, shu
 	})
 
 	authMw := appMiddleware.WithAuth(cfg)
+	optionalAuthMw := appMiddleware.OptionalAuth(cfg)
 
 	router.Route("/api/v1", func(r chi.Router) {
 		r.Post("/auth/register", authHandler.Register)
@@ -93,9 +154,61 @@ func newServerWithRepository(cfg *config.Config, repo repository.Repository, shu
 		r.Group(func(pr chi.Router) {
 			pr.Use(authMw)
 			pr.Get("/profile", profileHandler.GetProfile)
-			pr.Get("/exercises", exerciseHandler.List)
+			pr.Patch("/profile", profileHandler.UpdateProfile)
+			pr.Get("/exercises/{id}/alternatives", exerciseHandler.Alternatives)
+			pr.Get("/exercises/{id}/graph", exerciseHandler.Graph)
 			pr.Get("/workouts", workoutHandler.List)
 			pr.Post("/workouts", workoutHandler.Create)
+			pr.Post("/workouts/batch", workoutHandler.CreateBatch)
+			pr.Get("/workouts/trash", workoutHandler.Trash)
+			pr.Post("/workouts/live", liveWorkoutHandler.Start)
+			pr.Get("/workouts/live", liveWorkoutHandler.Current)
+			pr.Delete("/workouts/live", liveWorkoutHandler.Discard)
+			pr.Post("/workouts/live/finish", liveWorkoutHandler.Finish)
+			pr.Post("/workouts/live/entries", liveWorkoutHandler.AddEntry)
+			pr.Delete("/workouts/live/entries/{entryID}", liveWorkoutHandler.RemoveEntry)
+			pr.Post("/workouts/live/entries/{entryID}/sets", liveWorkoutHandler.AddSet)
+			pr.Put("/workouts/live/entries/{entryID}/sets/{index}", liveWorkoutHandler.UpdateSet)
+			pr.Delete("/workouts/live/entries/{entryID}/sets/{index}", liveWorkoutHandler.RemoveSet)
+			pr.Get("/workouts/{id}", workoutHandler.Get)
+			pr.Put("/workouts/{id}", workoutHandler.Replace)
+			pr.Patch("/workouts/{id}", workoutHandler.Patch)
+			pr.Delete("/workouts/{id}", workoutHandler.Delete)
+			pr.Post("/workouts/{id}/restore", workoutHandler.Restore)
+			pr.Post("/workouts/{id}/template", templateHandler.FromSession)
+			pr.Get("/templates", templateHandler.List)
+			pr.Post("/templates", templateHandler.Create)
+			pr.Get("/templates/{id}", templateHandler.Get)
+			pr.Put("/templates/{id}", templateHandler.Update)
+			pr.Delete("/templates/{id}", templateHandler.Delete)
+			pr.Post("/templates/{id}/start", templateHandler.Start)
+			pr.Get("/programs", programHandler.List)
+			pr.Get("/programs/{id}", programHandler.Get)
+			pr.Post("/programs/{id}/enroll", programHandler.Enroll)
+			pr.Get("/enrollments", programHandler.Enrollments)
+			pr.Get("/enrollments/{id}", programHandler.Enrollment)
+			pr.Delete("/enrollments/{id}", programHandler.Leave)
+			pr.Get("/schedule", programHandler.Schedule)
+			pr.Get("/training-maxes", trainingMaxHandler.List)
+			pr.Put("/training-maxes/{exerciseID}", trainingMaxHandler.Set)
+			pr.Delete("/training-maxes/{exerciseID}", trainingMaxHandler.Delete)
+			pr.Get("/records", recordHandler.List)
+			pr.Get("/analytics/volume", analyticsHandler.Volume)
+			pr.Get("/analytics/workload", analyticsHandler.Workload)
+			pr.Get("/analytics/muscle-groups", analyticsHandler.MuscleGroups)
+			pr.Get("/analytics/one-rep-max", analyticsHandler.OneRepMax)
+			pr.Get("/analytics/frequency", analyticsHandler.Frequency)
+			pr.Get("/goals", goalHandler.List)
+			pr.Post("/goals", goalHandler.Create)
+			pr.Get("/goals/{id}", goalHandler.Get)
+			pr.Put("/goals/{id}", goalHandler.Update)
+			pr.Delete("/goals/{id}", goalHandler.Delete)
+			pr.Get("/body-metrics", bodyMetricHandler.List)
+			pr.Post("/body-metrics", bodyMetricHandler.Create)
+			pr.Get("/body-metrics/trend", bodyMetricHandler.Trend)
+			pr.Get("/body-metrics/{id}", bodyMetricHandler.Get)
+			pr.Put("/body-metrics/{id}", bodyMetricHandler.Update)
+			pr.Delete("/body-metrics/{id}", bodyMetricHandler.Delete)
 
 			pr.Group(func(ar chi.Router) {
 				ar.Use(func(next http.Handler) http.Handler {
@@ -111,13 +224,46 @@ func newServerWithRepository(cfg *config.Config, repo repository.Repository, shu
 				ar.Post("/exercises", exerciseHandler.Create)
 				ar.Put("/exercises/{id}", exerciseHandler.Update)
 				ar.Delete("/exercises/{id}", exerciseHandler.Delete)
+				ar.Get("/exercises/{id}/revisions", exerciseHandler.History)
+				ar.Post("/exercises/{id}/revisions/{revision}/revert", exerciseHandler.Revert)
+				ar.Post("/exercises/{id}/archive", exerciseHandler.Archive)
+				ar.Post("/exercises/{id}/unarchive", exerciseHandler.Unarchive)
+				ar.Post("/exercises/{id}/merge", exerciseHandler.Merge)
+				ar.Post("/exercises/{id}/aliases", exerciseHandler.AddAlias)
+				ar.Delete("/exercises/{id}/aliases/{alias}", exerciseHandler.RemoveAlias)
+				ar.Post("/exercises/{id}/media", exerciseHandler.UploadMedia)
+				ar.Delete("/exercises/{id}/media/{mediaID}", exerciseHandler.DeleteMedia)
+				ar.Put("/exercises/{id}/alternatives/pinned", exerciseHandler.PinAlternatives)
+				ar.Get("/exercises/{id}/translations", exerciseHandler.Translations)
+				ar.Put("/exercises/{id}/translations/{locale}", exerciseHandler.SetTranslation)
+				ar.Delete("/exercises/{id}/translations/{locale}", exerciseHandler.DeleteTranslation)
+				ar.Get("/exercises/translations/coverage", exerciseHandler.TranslationCoverage)
+				ar.Get("/exercises/catalogue/report", exerciseHandler.CatalogueReport)
+				ar.Post("/exercises/{id}/relations", exerciseHandler.AddRelation)
+				ar.Delete("/exercises/{id}/relations/{relationID}", exerciseHandler.RemoveRelation)
+				ar.Post("/programs", programHandler.Create)
+				ar.Put("/programs/{id}", programHandler.Update)
+				ar.Delete("/programs/{id}", programHandler.Delete)
 			})
 		})
 
-		r.Get("/exercises", exerciseHandler.List)
+		// Public, but signed-in readers get exercises in their preferred locale.
+		r.With(optionalAuthMw).Get("/exercises", exerciseHandler.List)
+		r.Get("/media/*", exerciseHandler.ServeMedia)
 	})
 
-	return &Server{cfg: cfg, router: router, shutdownFn: shutdown}, nil
+	sweepCtx, cancelSweep := context.WithCancel(context.Background())
+	sweepDone := make(chan struct{})
+	go func() {
+		defer close(sweepDone)
+		liveWorkoutService.Sweep(sweepCtx, cfg.LiveWorkoutSweepInterval)
+	}()
+	stopSweep := func() {
+		cancelSweep()
+		<-sweepDone
+	}
+
+	return &Server{cfg: cfg, router: router, shutdownFn: shutdown, stopSweep: stopSweep}, nil
 }
 
 func (s *Server) Router() http.Handler {
@@ -125,6 +271,7 @@ func (s *Server) Router() http.Handler {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopSweep()
 	if s.shutdownFn != nil {
 		return s.shutdownFn(ctx)
 	}
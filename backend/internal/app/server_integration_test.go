@@ -16,6 +16,7 @@ import (
 
 	"github.com/musclementour/app/internal/app"
 	"github.com/musclementour/app/internal/config"
+	"github.com/musclementour/app/internal/repository"
 )
 
 type testServer struct {
@@ -28,7 +29,13 @@ type testServer struct {
 func newTestServer(t *testing.T) *testServer {
 	t.Helper()
 
-	cfg := &config.Config{
+	return startTestServer(t, newTestConfig(t), newMemoryRepository())
+}
+
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	return &config.Config{
 		ServerPort:         0,
 		DatabaseURL:        "",
 		AccessTokenSecret:  "test-access-secret",
@@ -37,9 +44,25 @@ func newTestServer(t *testing.T) *testServer {
 		RefreshTokenTTL:    time.Hour,
 		AdminEmail:         "admin@test.app",
 		AdminPassword:      "AdminPass123!",
+		MediaDir:           t.TempDir(),
+		MediaBaseURL:       "/api/v1/media",
+		MaxImageBytes:      256 << 10,
+		MaxVideoBytes:      1 << 20,
+		// Most tests expect the small original library rather than the
+		// full embedded catalogue.
+		ExerciseCatalogue:     testDataPath(t, "exercises/catalogue.json"),
+		WorkoutBatchMaxItems:  5,
+		WorkoutBatchMaxBytes:  16 << 10,
+		WorkoutTrashRetention: time.Hour,
+		LiveWorkoutTimeout:    time.Hour,
 	}
+}
+
+// startTestServer serves repo; tests that restart the server pass the same
+// repo to a second call.
+func startTestServer(t *testing.T, cfg *config.Config, repo repository.Repository) *testServer {
+	t.Helper()
 
-	repo := newMemoryRepository()
 	srv, err := app.NewServerWithRepository(cfg, repo)
 	require.NoError(t, err)
 
@@ -63,8 +86,17 @@ func newTestServer(t *testing.T) *testServer {
 func (ts *testServer) doRequest(method, path string, body []byte, token string) ([]byte, *http.Response) {
 	ts.t.Helper()
 
+	return ts.doRequestWithHeaders(method, path, body, token, nil)
+}
+
+func (ts *testServer) doRequestWithHeaders(method, path string, body []byte, token string, header http.Header) ([]byte, *http.Response) {
+	ts.t.Helper()
+
 	req, err := http.NewRequest(method, ts.httpServer.URL+path, bytes.NewReader(body))
 	require.NoError(ts.t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -85,13 +117,18 @@ func (ts *testServer) doRequest(method, path string, body []byte, token string)
 func readTestData(t *testing.T, relativePath string) []byte {
 	t.Helper()
 
+	data, err := os.ReadFile(testDataPath(t, relativePath))
+	require.NoError(t, err)
+	return data
+}
+
+func testDataPath(t *testing.T, relativePath string) string {
+	t.Helper()
+
 	_, filename, _, ok := runtime.Caller(0)
 	require.True(t, ok)
 
-	base := filepath.Join(filepath.Dir(filename), "..", "..", "testdata", relativePath)
-	data, err := os.ReadFile(base)
-	require.NoError(t, err)
-	return data
+	return filepath.Join(filepath.Dir(filename), "..", "..", "testdata", relativePath)
 }
 
 type authResponse struct {
@@ -0,0 +1,146 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type templateResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Exercises []struct {
+		ExerciseID            string  `json:"exerciseId"`
+		TargetSets            int     `json:"targetSets"`
+		TargetReps            int     `json:"targetReps"`
+		TargetWeight          float64 `json:"targetWeight"`
+		TargetDurationSeconds int     `json:"targetDurationSeconds"`
+		RestSeconds           int     `json:"restSeconds"`
+	} `json:"exercises"`
+}
+
+func TestWorkoutTemplates(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	plankID := ts.exerciseIDByName("Plank")
+	token := ts.registerAthlete().Tokens.AccessToken
+	pushDay := []byte(`{"name":"Push Day","notes":"Mondays","exercises":[
+		{"exerciseId":"` + benchID + `","targetSets":3,"targetReps":5,"targetWeight":100,"restSeconds":180},
+		{"exerciseId":"` + plankID + `","targetSets":2,"targetDurationSeconds":60,"restSeconds":60}
+	]}`)
+
+	// Act
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/templates", pushDay, token)
+	duplicateData, duplicateResp := ts.doRequest(http.MethodPost, "/api/v1/templates", []byte(`{"name":"push day","exercises":[{"exerciseId":"`+benchID+`"}]}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode, string(createdData))
+	var created templateResponse
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	require.Equal(t, "Push Day", created.Name)
+	require.Len(t, created.Exercises, 2)
+	require.Equal(t, 180, created.Exercises[0].RestSeconds)
+	require.Equal(t, http.StatusConflict, duplicateResp.StatusCode, string(duplicateData))
+
+	listData, listResp := ts.doRequest(http.MethodGet, "/api/v1/templates", nil, token)
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+	var listed []templateResponse
+	require.NoError(t, json.Unmarshal(listData, &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, created.ID, listed[0].ID)
+
+	// Act
+	invalidData, invalidResp := ts.doRequest(http.MethodPost, "/api/v1/templates", []byte(`{"name":" ","exercises":[
+		{"exerciseId":"`+plankID+`","targetReps":10},
+		{"exerciseId":"0b0e8b4e-5d53-4c1e-9a1a-1f2d3c4b5a69"},
+		{"exerciseId":"`+benchID+`","restSeconds":-30}
+	]}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.Equal(t, []string{"name", "exercises[0].targetReps", "exercises[1].exerciseId", "exercises[2].restSeconds"}, invalid.fieldNames())
+
+	// Act: start a session from the template and submit the draft unchanged.
+	draftData, draftResp := ts.doRequest(http.MethodPost, "/api/v1/templates/"+created.ID+"/start", nil, token)
+	require.Equal(t, http.StatusOK, draftResp.StatusCode, string(draftData))
+	workoutData, workoutResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", draftData, token)
+
+	// Assert
+	var draft struct {
+		TemplateID string `json:"templateId"`
+		Entries    []struct {
+			ExerciseID      string  `json:"exerciseId"`
+			Sets            int     `json:"sets"`
+			Reps            int     `json:"reps"`
+			Weight          float64 `json:"weight"`
+			DurationSeconds int     `json:"durationSeconds"`
+			RestSeconds     int     `json:"restSeconds"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(draftData, &draft))
+	require.Equal(t, created.ID, draft.TemplateID)
+	require.Len(t, draft.Entries, 2)
+	require.Equal(t, benchID, draft.Entries[0].ExerciseID)
+	require.Equal(t, 3, draft.Entries[0].Sets)
+	require.Equal(t, 100.0, draft.Entries[0].Weight)
+	require.Equal(t, 60, draft.Entries[1].DurationSeconds)
+	require.Equal(t, http.StatusCreated, workoutResp.StatusCode, string(workoutData))
+
+	// Act: save the logged session as a new template.
+	var workout struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(workoutData, &workout))
+	savedData, savedResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/"+workout.ID+"/template", []byte(`{"name":"Push Day B"}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, savedResp.StatusCode, string(savedData))
+	var saved templateResponse
+	require.NoError(t, json.Unmarshal(savedData, &saved))
+	require.Equal(t, "Push Day B", saved.Name)
+	require.Len(t, saved.Exercises, 2)
+	require.Equal(t, 3, saved.Exercises[0].TargetSets)
+	require.Equal(t, 5, saved.Exercises[0].TargetReps)
+	require.Equal(t, 100.0, saved.Exercises[0].TargetWeight)
+	require.Equal(t, 60, saved.Exercises[1].TargetDurationSeconds)
+
+	// Act
+	updateData, updateResp := ts.doRequest(http.MethodPut, "/api/v1/templates/"+created.ID, []byte(`{"name":"Push Day","exercises":[
+		{"exerciseId":"`+plankID+`","targetDurationSeconds":90},
+		{"exerciseId":"`+benchID+`","targetSets":5,"targetReps":5,"targetWeight":102.5}
+	]}`), token)
+
+	// Assert: the new order is kept.
+	require.Equal(t, http.StatusOK, updateResp.StatusCode, string(updateData))
+	var updated templateResponse
+	require.NoError(t, json.Unmarshal(updateData, &updated))
+	require.Equal(t, plankID, updated.Exercises[0].ExerciseID)
+	require.Equal(t, 102.5, updated.Exercises[1].TargetWeight)
+
+	// Act: templates are private.
+	_, registerResp := ts.doRequest(http.MethodPost, "/api/v1/auth/register", []byte(`{"email":"partner@example.com","password":"TrainHard123!"}`), "")
+	require.Equal(t, http.StatusCreated, registerResp.StatusCode)
+	partner := ts.login("partner@example.com", "TrainHard123!").Tokens.AccessToken
+	_, partnerGet := ts.doRequest(http.MethodGet, "/api/v1/templates/"+created.ID, nil, partner)
+	_, partnerStart := ts.doRequest(http.MethodPost, "/api/v1/templates/"+created.ID+"/start", nil, partner)
+	_, partnerSave := ts.doRequest(http.MethodPost, "/api/v1/workouts/"+workout.ID+"/template", nil, partner)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, partnerGet.StatusCode)
+	require.Equal(t, http.StatusNotFound, partnerStart.StatusCode)
+	require.Equal(t, http.StatusNotFound, partnerSave.StatusCode)
+
+	// Act
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/templates/"+created.ID, nil, token)
+	_, getResp := ts.doRequest(http.MethodGet, "/api/v1/templates/"+created.ID, nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	require.Equal(t, http.StatusNotFound, getResp.StatusCode)
+}
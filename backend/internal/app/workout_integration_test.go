@@ -0,0 +1,714 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fieldErrorResponse struct {
+	Error  string `json:"error"`
+	Fields []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"fields"`
+}
+
+func (r fieldErrorResponse) fieldNames() []string {
+	names := make([]string, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		names = append(names, f.Field)
+	}
+	return names
+}
+
+func (ts *testServer) registerAthlete() authResponse {
+	ts.t.Helper()
+
+	registerPayload := readTestData(ts.t, filepath.Join("auth", "register_user.json"))
+	_, registerResp := ts.doRequest(http.MethodPost, "/api/v1/auth/register", registerPayload, "")
+	require.Equal(ts.t, http.StatusCreated, registerResp.StatusCode)
+	return ts.login("athlete@example.com", "TrainHard123!")
+}
+
+func TestWorkoutEntriesFollowMeasurementType(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	plankID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Weighted Plank","measurementType":"time"}`))
+	rowID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Single-Arm Row","measurementType":"weight_reps","unilateral":true}`))
+	runID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Treadmill Run","measurementType":"distance_time"}`))
+	user := ts.registerAthlete()
+
+	// Act
+	validBody := []byte(`{"entries":[
+		{"exerciseId":"` + plankID + `","durationSeconds":60,"weight":10},
+		{"exerciseId":"` + rowID + `","sets":3,"reps":10,"weight":30},
+		{"exerciseId":"` + runID + `","distanceMeters":5000,"durationSeconds":1500}
+	]}`)
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", validBody, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode, string(createdData))
+	var created struct {
+		Entries []struct {
+			Sets           int     `json:"sets"`
+			Side           string  `json:"side"`
+			DistanceMeters float64 `json:"distanceMeters"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	require.Len(t, created.Entries, 3)
+	require.Equal(t, 1, created.Entries[0].Sets)
+	require.Equal(t, "", created.Entries[0].Side)
+	require.Equal(t, "both", created.Entries[1].Side)
+	require.Equal(t, 5000.0, created.Entries[2].DistanceMeters)
+
+	// Act
+	invalidBody := []byte(`{"entries":[
+		{"exerciseId":"` + plankID + `","reps":12},
+		{"exerciseId":"` + rowID + `","reps":10,"side":"middle"},
+		{"exerciseId":"` + runID + `","distanceMeters":5000,"side":"left"},
+		{"exerciseId":"00000000-0000-0000-0000-000000000000","reps":5}
+	]}`)
+	invalidData, invalidResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", invalidBody, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.ElementsMatch(t, []string{
+		"entries[0].reps",
+		"entries[0].durationSeconds",
+		"entries[1].side",
+		"entries[2].durationSeconds",
+		"entries[2].side",
+		"entries[3].exerciseId",
+	}, invalid.fieldNames())
+
+	// Act: logged entries pin the measurement type.
+	unusedID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Sled Push","measurementType":"weight_reps"}`))
+	retypeLogged, retypeLoggedResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+plankID, []byte(`{"measurementType":"weight_reps"}`), admin.Tokens.AccessToken)
+	retypeUnused, retypeUnusedResp := ts.doRequest(http.MethodPut, "/api/v1/exercises/"+unusedID, []byte(`{"measurementType":"distance"}`), admin.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, retypeLoggedResp.StatusCode, string(retypeLogged))
+	require.Equal(t, http.StatusOK, retypeUnusedResp.StatusCode, string(retypeUnused))
+}
+
+func TestWorkoutSetDetails(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	plankID := ts.exerciseIDByName("Plank")
+	user := ts.registerAthlete()
+
+	// Act
+	body := []byte(`{"startedAt":"2024-06-03T10:00:00Z","entries":[
+		{"exerciseId":"` + benchID + `","setDetails":[
+			{"type":"warmup","reps":10,"weight":60},
+			{"reps":8,"weight":100,"rpe":7,"tempo":"3-1-x-0"},
+			{"reps":6,"weight":110,"rir":2},
+			{"type":"failure","reps":4,"weight":120,"rpe":10},
+			{"type":"drop","reps":2,"weight":125,"completed":false}
+		]},
+		{"exerciseId":"` + plankID + `","sets":2,"durationSeconds":45}
+	]}`)
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", body, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode, string(createdData))
+	type workoutSet struct {
+		Type            string   `json:"type"`
+		Reps            int      `json:"reps"`
+		Weight          float64  `json:"weight"`
+		DurationSeconds int      `json:"durationSeconds"`
+		RPE             *float64 `json:"rpe"`
+		RIR             *int     `json:"rir"`
+		Tempo           string   `json:"tempo"`
+		Completed       bool     `json:"completed"`
+	}
+	var created struct {
+		Entries []struct {
+			Sets            int          `json:"sets"`
+			Reps            int          `json:"reps"`
+			Weight          float64      `json:"weight"`
+			DurationSeconds int          `json:"durationSeconds"`
+			SetDetails      []workoutSet `json:"setDetails"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	require.Len(t, created.Entries, 2)
+
+	bench := created.Entries[0]
+	require.Equal(t, 3, bench.Sets)
+	require.Equal(t, 4, bench.Reps)
+	require.Equal(t, 120.0, bench.Weight)
+	require.Len(t, bench.SetDetails, 5)
+	require.Equal(t, "warmup", bench.SetDetails[0].Type)
+	require.Equal(t, "working", bench.SetDetails[1].Type)
+	require.Equal(t, "3-1-X-0", bench.SetDetails[1].Tempo)
+	require.Equal(t, 7.0, *bench.SetDetails[1].RPE)
+	require.Equal(t, 2, *bench.SetDetails[2].RIR)
+	require.False(t, bench.SetDetails[4].Completed)
+
+	plank := created.Entries[1]
+	require.Equal(t, 2, plank.Sets)
+	require.Equal(t, []workoutSet{
+		{Type: "working", DurationSeconds: 45, Completed: true},
+		{Type: "working", DurationSeconds: 45, Completed: true},
+	}, plank.SetDetails)
+
+	// Act
+	admin := ts.login("admin@test.app", "AdminPass123!")
+	dipID := ts.createExercise(admin.Tokens.AccessToken, []byte(`{"name":"Assisted Dip","measurementType":"assisted"}`))
+	dipData, dipResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(`{"startedAt":"2024-06-04T10:00:00Z","entries":[
+		{"exerciseId":"`+dipID+`","setDetails":[{"reps":8,"weight":30},{"reps":6,"weight":30}]}
+	]}`), user.Tokens.AccessToken)
+	volumeData, volumeResp := ts.doRequest(http.MethodGet, "/api/v1/analytics/volume", nil, user.Tokens.AccessToken)
+
+	// Assert: warm-up and uncompleted sets do not count, and assistance is
+	// no load.
+	require.Equal(t, http.StatusCreated, dipResp.StatusCode, string(dipData))
+	require.Equal(t, http.StatusOK, volumeResp.StatusCode, string(volumeData))
+	var volume struct {
+		Exercises []struct {
+			ExerciseID string  `json:"exerciseId"`
+			Sets       int     `json:"sets"`
+			Reps       int     `json:"reps"`
+			Volume     float64 `json:"volume"`
+		} `json:"exercises"`
+	}
+	require.NoError(t, json.Unmarshal(volumeData, &volume))
+	require.NotEmpty(t, volume.Exercises)
+	require.Equal(t, benchID, volume.Exercises[0].ExerciseID)
+	require.Equal(t, 3, volume.Exercises[0].Sets)
+	require.Equal(t, 18, volume.Exercises[0].Reps)
+	require.Equal(t, 800.0+660+480, volume.Exercises[0].Volume)
+	require.Len(t, volume.Exercises, 3)
+	for _, exercise := range volume.Exercises[1:] {
+		require.Zero(t, exercise.Volume)
+		if exercise.ExerciseID == dipID {
+			require.Equal(t, 14, exercise.Reps)
+		}
+	}
+
+	// Act
+	invalidBody := []byte(`{"entries":[
+		{"exerciseId":"` + benchID + `","setDetails":[
+			{"type":"cluster","reps":5,"weight":100},
+			{"reps":0,"weight":100,"rpe":11,"tempo":"slow"},
+			{"reps":5,"rir":-1,"restSeconds":-30,"completedAt":"2999-01-01T00:00:00Z"}
+		]},
+		{"exerciseId":"` + plankID + `","setDetails":[{"reps":3}]}
+	]}`)
+	invalidData, invalidResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", invalidBody, user.Tokens.AccessToken)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.ElementsMatch(t, []string{
+		"entries[0].setDetails[0].type",
+		"entries[0].setDetails[1].reps",
+		"entries[0].setDetails[1].rpe",
+		"entries[0].setDetails[1].tempo",
+		"entries[0].setDetails[2].rir",
+		"entries[0].setDetails[2].restSeconds",
+		"entries[0].setDetails[2].completedAt",
+		"entries[1].setDetails[0].reps",
+		"entries[1].setDetails[0].durationSeconds",
+	}, invalid.fieldNames())
+}
+
+func TestIdempotentWorkoutSubmission(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	user := ts.registerAthlete()
+	token := user.Tokens.AccessToken
+	body := []byte(`{"startedAt":"2024-06-03T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]}`)
+	keyed := http.Header{"Idempotency-Key": {"sync-7f3a"}}
+	var first, second struct {
+		ID string `json:"id"`
+	}
+
+	// Act
+	firstData, firstResp := ts.doRequestWithHeaders(http.MethodPost, "/api/v1/workouts", body, token, keyed)
+	secondData, secondResp := ts.doRequestWithHeaders(http.MethodPost, "/api/v1/workouts", body, token, keyed)
+
+	// Assert: the retry returns the original session instead of a new one.
+	require.Equal(t, http.StatusCreated, firstResp.StatusCode, string(firstData))
+	require.Equal(t, http.StatusOK, secondResp.StatusCode, string(secondData))
+	require.Equal(t, "true", secondResp.Header.Get("Idempotent-Replayed"))
+	require.NoError(t, json.Unmarshal(firstData, &first))
+	require.NoError(t, json.Unmarshal(secondData, &second))
+	require.Equal(t, first.ID, second.ID)
+	require.JSONEq(t, string(firstData), string(secondData))
+
+	// Act
+	changed := []byte(`{"startedAt":"2024-06-03T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":105}]}`)
+	conflictData, conflictResp := ts.doRequestWithHeaders(http.MethodPost, "/api/v1/workouts", changed, token, keyed)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, conflictResp.StatusCode, string(conflictData))
+
+	// Act: a client-generated session id works as the key on its own.
+	clientID := "5b0c2a6e-8f3d-4c61-9a57-2f6d1e0b9c44"
+	withID := []byte(`{"id":"` + clientID + `","startedAt":"2024-06-04T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","reps":5,"weight":100}]}`)
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", withID, token)
+	retriedData, retriedResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", withID, token)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode, string(createdData))
+	require.Equal(t, http.StatusOK, retriedResp.StatusCode, string(retriedData))
+	require.NoError(t, json.Unmarshal(createdData, &first))
+	require.Equal(t, clientID, first.ID)
+
+	listData, listResp := ts.doRequest(http.MethodGet, "/api/v1/workouts", nil, token)
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+	var sessions []struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(listData, &sessions))
+	require.Len(t, sessions, 2)
+
+	// Act
+	invalidData, invalidResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(`{"id":"not-a-uuid","entries":[]}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	var invalid fieldErrorResponse
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.Equal(t, []string{"id"}, invalid.fieldNames())
+
+	// Act: keys are scoped to the user.
+	_, registerResp := ts.doRequest(http.MethodPost, "/api/v1/auth/register", []byte(`{"email":"partner@example.com","password":"TrainHard123!"}`), "")
+	require.Equal(t, http.StatusCreated, registerResp.StatusCode)
+	partner := ts.login("partner@example.com", "TrainHard123!")
+	partnerData, partnerResp := ts.doRequestWithHeaders(http.MethodPost, "/api/v1/workouts", changed, partner.Tokens.AccessToken, keyed)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, partnerResp.StatusCode, string(partnerData))
+}
+
+func TestWorkoutBatchSync(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	user := ts.registerAthlete()
+	token := user.Tokens.AccessToken
+	earlier := []byte(`{"startedAt":"2024-06-01T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]}`)
+	_, earlierResp := ts.doRequestWithHeaders(http.MethodPost, "/api/v1/workouts", earlier, token, http.Header{"Idempotency-Key": {"queued-1"}})
+	require.Equal(t, http.StatusCreated, earlierResp.StatusCode)
+	batch := []byte(`{"workouts":[
+		{"idempotencyKey":"queued-1","startedAt":"2024-06-01T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]},
+		{"idempotencyKey":"queued-2","startedAt":"2024-06-02T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":102.5}]},
+		{"idempotencyKey":"queued-3","startedAt":"2024-06-03T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":-5,"weight":100}]},
+		{"idempotencyKey":"queued-1","startedAt":"2024-06-01T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":90}]},
+		{"idempotencyKey":"queued-5","entries":"not-a-list"}
+	]}`)
+	var result struct {
+		Results []struct {
+			Index   int    `json:"index"`
+			Key     string `json:"key"`
+			Status  string `json:"status"`
+			Session *struct {
+				ID string `json:"id"`
+			} `json:"session"`
+			Fields []struct {
+				Field string `json:"field"`
+			} `json:"fields"`
+		} `json:"results"`
+	}
+
+	// Act
+	data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts/batch", batch, token)
+
+	// Assert: each workout is judged on its own.
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.Len(t, result.Results, 5)
+	statuses := make([]string, 0, len(result.Results))
+	for i, item := range result.Results {
+		require.Equal(t, i, item.Index)
+		statuses = append(statuses, item.Status)
+	}
+	require.Equal(t, []string{"duplicate", "created", "invalid", "conflict", "invalid"}, statuses)
+	require.Equal(t, "queued-2", result.Results[1].Key)
+	require.NotNil(t, result.Results[1].Session)
+	require.Len(t, result.Results[2].Fields, 1)
+	require.Equal(t, "entries[0].reps", result.Results[2].Fields[0].Field)
+
+	listData, listResp := ts.doRequest(http.MethodGet, "/api/v1/workouts", nil, token)
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+	var sessions []struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(listData, &sessions))
+	require.Len(t, sessions, 2)
+
+	// Act: the test config allows five workouts per batch.
+	tooMany := []byte(`{"workouts":[{},{},{},{},{},{}]}`)
+	tooManyData, tooManyResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/batch", tooMany, token)
+	emptyData, emptyResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/batch", []byte(`{"workouts":[]}`), token)
+	oversized := []byte(`{"workouts":[{"notes":"` + strings.Repeat("x", 32<<10) + `"}]}`)
+	oversizedData, oversizedResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/batch", oversized, token)
+
+	// Assert
+	require.Equal(t, http.StatusRequestEntityTooLarge, tooManyResp.StatusCode, string(tooManyData))
+	require.Equal(t, http.StatusBadRequest, emptyResp.StatusCode, string(emptyData))
+	require.Equal(t, http.StatusRequestEntityTooLarge, oversizedResp.StatusCode, string(oversizedData))
+}
+
+type editedWorkout struct {
+	ID        string     `json:"id"`
+	StartedAt time.Time  `json:"startedAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt"`
+	Entries   []struct {
+		ID         string    `json:"id"`
+		ExerciseID string    `json:"exerciseId"`
+		Sets       int       `json:"sets"`
+		Reps       int       `json:"reps"`
+		Weight     float64   `json:"weight"`
+		UpdatedAt  time.Time `json:"updatedAt"`
+	} `json:"entries"`
+}
+
+func TestEditWorkoutSession(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	deadliftID := ts.exerciseIDByName("Deadlift")
+	squatID := ts.exerciseIDByName("Barbell Back Squat")
+	token := ts.registerAthlete().Tokens.AccessToken
+	body := []byte(`{"startedAt":"2024-06-03T10:00:00Z","entries":[
+		{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100},
+		{"exerciseId":"` + deadliftID + `","sets":1,"reps":5,"weight":1000}
+	]}`)
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", body, token)
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode, string(createdData))
+	var created editedWorkout
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	path := "/api/v1/workouts/" + created.ID
+	bench, deadlift := created.Entries[0], created.Entries[1]
+
+	// Act: fix the typo with an entry-level edit.
+	patch := []byte(`{"entries":[{"id":"` + deadlift.ID + `","exerciseId":"` + deadliftID + `","reps":5,"weight":100}]}`)
+	patchedData, patchedResp := ts.doRequest(http.MethodPatch, path, patch, token)
+
+	// Assert: only the edited entry changes.
+	require.Equal(t, http.StatusOK, patchedResp.StatusCode, string(patchedData))
+	var patched editedWorkout
+	require.NoError(t, json.Unmarshal(patchedData, &patched))
+	require.Len(t, patched.Entries, 2)
+	require.Equal(t, deadlift.ID, patched.Entries[1].ID)
+	require.Equal(t, 100.0, patched.Entries[1].Weight)
+	require.True(t, patched.Entries[1].UpdatedAt.After(deadlift.UpdatedAt))
+	require.Equal(t, bench.UpdatedAt, patched.Entries[0].UpdatedAt)
+	require.True(t, patched.UpdatedAt.After(created.UpdatedAt))
+
+	getData, getResp := ts.doRequest(http.MethodGet, path, nil, token)
+	require.Equal(t, http.StatusOK, getResp.StatusCode)
+	require.JSONEq(t, string(patchedData), string(getData))
+
+	// Act: replace the session, keeping the bench entry and swapping the deadlift for squats.
+	put := []byte(`{"startedAt":"2024-06-03T09:30:00Z","entries":[
+		{"exerciseId":"` + squatID + `","sets":5,"reps":5,"weight":120},
+		{"id":"` + bench.ID + `","exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}
+	]}`)
+	putData, putResp := ts.doRequest(http.MethodPut, path, put, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, putResp.StatusCode, string(putData))
+	var replaced editedWorkout
+	require.NoError(t, json.Unmarshal(putData, &replaced))
+	require.Len(t, replaced.Entries, 2)
+	require.Equal(t, squatID, replaced.Entries[0].ExerciseID)
+	require.NotEmpty(t, replaced.Entries[0].ID)
+	require.Equal(t, bench.ID, replaced.Entries[1].ID)
+	require.Equal(t, bench.UpdatedAt, replaced.Entries[1].UpdatedAt)
+	require.Equal(t, "2024-06-03T09:30:00Z", replaced.StartedAt.Format(time.RFC3339))
+
+	// Act: remove an entry, and try to edit one that belongs elsewhere.
+	removeData, removeResp := ts.doRequest(http.MethodPatch, path, []byte(`{"removeEntries":["`+bench.ID+`"]}`), token)
+	foreignData, foreignResp := ts.doRequest(http.MethodPatch, path, []byte(`{"entries":[{"id":"`+deadlift.ID+`","exerciseId":"`+deadliftID+`","reps":1}]}`), token)
+	invalidData, invalidResp := ts.doRequest(http.MethodPatch, path, []byte(`{"entries":[{"id":"`+replaced.Entries[0].ID+`","exerciseId":"`+squatID+`","reps":-1}]}`), token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, removeResp.StatusCode, string(removeData))
+	var removed editedWorkout
+	require.NoError(t, json.Unmarshal(removeData, &removed))
+	require.Len(t, removed.Entries, 1)
+	require.Equal(t, squatID, removed.Entries[0].ExerciseID)
+	var foreign, invalid fieldErrorResponse
+	require.Equal(t, http.StatusBadRequest, foreignResp.StatusCode)
+	require.NoError(t, json.Unmarshal(foreignData, &foreign))
+	require.Equal(t, []string{"entries[0].id"}, foreign.fieldNames())
+	require.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+	require.NoError(t, json.Unmarshal(invalidData, &invalid))
+	require.Equal(t, []string{"entries[0].reps"}, invalid.fieldNames())
+
+	// Act: other athletes can neither see nor change the session.
+	_, registerResp := ts.doRequest(http.MethodPost, "/api/v1/auth/register", []byte(`{"email":"partner@example.com","password":"TrainHard123!"}`), "")
+	require.Equal(t, http.StatusCreated, registerResp.StatusCode)
+	partner := ts.login("partner@example.com", "TrainHard123!").Tokens.AccessToken
+	_, partnerGet := ts.doRequest(http.MethodGet, path, nil, partner)
+	_, partnerPut := ts.doRequest(http.MethodPut, path, put, partner)
+	_, partnerDelete := ts.doRequest(http.MethodDelete, path, nil, partner)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, partnerGet.StatusCode)
+	require.Equal(t, http.StatusNotFound, partnerPut.StatusCode)
+	require.Equal(t, http.StatusNotFound, partnerDelete.StatusCode)
+
+	// Act: ids that are not UUIDs name no session.
+	malformed := "/api/v1/workouts/not-a-uuid"
+	_, malformedGet := ts.doRequest(http.MethodGet, malformed, nil, token)
+	_, malformedPut := ts.doRequest(http.MethodPut, malformed, put, token)
+	_, malformedPatch := ts.doRequest(http.MethodPatch, malformed, patch, token)
+	_, malformedDelete := ts.doRequest(http.MethodDelete, malformed, nil, token)
+	_, malformedRestore := ts.doRequest(http.MethodPost, malformed+"/restore", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, malformedGet.StatusCode)
+	require.Equal(t, http.StatusNotFound, malformedPut.StatusCode)
+	require.Equal(t, http.StatusNotFound, malformedPatch.StatusCode)
+	require.Equal(t, http.StatusNotFound, malformedDelete.StatusCode)
+	require.Equal(t, http.StatusNotFound, malformedRestore.StatusCode)
+}
+
+func TestWorkoutTrash(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	token := ts.registerAthlete().Tokens.AccessToken
+	body := []byte(`{"startedAt":"2024-06-03T10:00:00Z","entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]}`)
+	createdData, createdResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", body, token)
+	require.Equal(t, http.StatusCreated, createdResp.StatusCode)
+	var created editedWorkout
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	path := "/api/v1/workouts/" + created.ID
+
+	// Act
+	_, deleteResp := ts.doRequest(http.MethodDelete, path, nil, token)
+
+	// Assert: the session leaves the history but sits in the trash.
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	_, getResp := ts.doRequest(http.MethodGet, path, nil, token)
+	require.Equal(t, http.StatusNotFound, getResp.StatusCode)
+	listData, _ := ts.doRequest(http.MethodGet, "/api/v1/workouts", nil, token)
+	require.JSONEq(t, `[]`, string(listData))
+	trashData, trashResp := ts.doRequest(http.MethodGet, "/api/v1/workouts/trash", nil, token)
+	require.Equal(t, http.StatusOK, trashResp.StatusCode)
+	var trash []editedWorkout
+	require.NoError(t, json.Unmarshal(trashData, &trash))
+	require.Len(t, trash, 1)
+	require.Equal(t, created.ID, trash[0].ID)
+	require.NotNil(t, trash[0].DeletedAt)
+
+	// Act
+	restoreData, restoreResp := ts.doRequest(http.MethodPost, path+"/restore", nil, token)
+
+	// Assert
+	require.Equal(t, http.StatusOK, restoreResp.StatusCode, string(restoreData))
+	var restored editedWorkout
+	require.NoError(t, json.Unmarshal(restoreData, &restored))
+	require.Nil(t, restored.DeletedAt)
+	_, getResp = ts.doRequest(http.MethodGet, path, nil, token)
+	require.Equal(t, http.StatusOK, getResp.StatusCode)
+	_, restoreAgain := ts.doRequest(http.MethodPost, path+"/restore", nil, token)
+	require.Equal(t, http.StatusNotFound, restoreAgain.StatusCode)
+}
+
+func TestWorkoutTrashRetention(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.WorkoutTrashRetention = 50 * time.Millisecond
+	ts := startTestServer(t, cfg, newMemoryRepository())
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	token := ts.registerAthlete().Tokens.AccessToken
+	body := []byte(`{"entries":[{"exerciseId":"` + benchID + `","sets":3,"reps":5,"weight":100}]}`)
+	createdData, _ := ts.doRequest(http.MethodPost, "/api/v1/workouts", body, token)
+	var created editedWorkout
+	require.NoError(t, json.Unmarshal(createdData, &created))
+	_, deleteResp := ts.doRequest(http.MethodDelete, "/api/v1/workouts/"+created.ID, nil, token)
+	require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+	time.Sleep(2 * cfg.WorkoutTrashRetention)
+
+	// Act
+	_, restoreResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/"+created.ID+"/restore", nil, token)
+	trashData, _ := ts.doRequest(http.MethodGet, "/api/v1/workouts/trash", nil, token)
+
+	// Assert: the session expired out of the trash.
+	require.Equal(t, http.StatusGone, restoreResp.StatusCode)
+	require.JSONEq(t, `[]`, string(trashData))
+}
+
+func TestWorkoutHistoryPagination(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange: seven sessions, two of them starting at the same time.
+	benchID := ts.exerciseIDByName("Bench Press")
+	squatID := ts.exerciseIDByName("Barbell Back Squat")
+	token := ts.registerAthlete().Tokens.AccessToken
+	starts := []string{"2024-05-01", "2024-05-03", "2024-05-03", "2024-05-06", "2024-05-08", "2024-05-10", "2024-05-13"}
+	for i, day := range starts {
+		exerciseID, notes := benchID, ""
+		if i%2 == 1 {
+			exerciseID = squatID
+		}
+		if i == 4 {
+			notes = "Left knee PAIN on the way up"
+		}
+		body := []byte(fmt.Sprintf(`{"startedAt":"%sT18:00:00Z","entries":[{"exerciseId":"%s","sets":3,"reps":5,"weight":100,"notes":%q}]}`, day, exerciseID, notes))
+		data, resp := ts.doRequest(http.MethodPost, "/api/v1/workouts", body, token)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+	}
+	type listed struct {
+		ID        string    `json:"id"`
+		StartedAt time.Time `json:"startedAt"`
+	}
+	list := func(query string) ([]listed, *http.Response) {
+		data, resp := ts.doRequest(http.MethodGet, "/api/v1/workouts"+query, nil, token)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+		var sessions []listed
+		require.NoError(t, json.Unmarshal(data, &sessions))
+		return sessions, resp
+	}
+
+	// Act: walk the whole history three at a time.
+	var all []listed
+	pages := 0
+	query := "?limit=3&count=true"
+	for {
+		page, resp := list(query)
+		pages++
+		require.Equal(t, "7", resp.Header.Get("X-Total-Count"))
+		all = append(all, page...)
+		cursor := resp.Header.Get("X-Next-Cursor")
+		if cursor == "" {
+			break
+		}
+		query = "?limit=3&count=true&cursor=" + url.QueryEscape(cursor)
+	}
+
+	// Assert: every session once, newest first.
+	require.Equal(t, 3, pages)
+	require.Len(t, all, 7)
+	seen := map[string]bool{}
+	for i, session := range all {
+		require.False(t, seen[session.ID])
+		seen[session.ID] = true
+		if i > 0 {
+			require.False(t, session.StartedAt.After(all[i-1].StartedAt))
+		}
+	}
+
+	// Act
+	ranged, _ := list("?from=2024-05-03&to=2024-05-08")
+	squats, _ := list("?exerciseId=" + squatID)
+	chest, _ := list("?muscleGroup=chest")
+	knee, _ := list("?q=knee%20pain")
+	none, noneResp := list("?exerciseId=" + squatID + "&q=knee")
+	defaultPage, defaultResp := list("")
+
+	// Assert
+	require.Len(t, ranged, 3)
+	require.Len(t, squats, 3)
+	require.Len(t, chest, 4)
+	require.Len(t, knee, 1)
+	require.Equal(t, "2024-05-08", knee[0].StartedAt.Format(time.DateOnly))
+	require.Empty(t, none)
+	require.Empty(t, noneResp.Header.Get("X-Total-Count"))
+	require.Len(t, defaultPage, 7)
+	require.Empty(t, defaultResp.Header.Get("X-Next-Cursor"))
+
+	// Act
+	badCursorData, badCursorResp := ts.doRequest(http.MethodGet, "/api/v1/workouts?cursor=nope", nil, token)
+	badLimitData, badLimitResp := ts.doRequest(http.MethodGet, "/api/v1/workouts?limit=1000", nil, token)
+
+	// Assert
+	var badCursor, badLimit fieldErrorResponse
+	require.Equal(t, http.StatusBadRequest, badCursorResp.StatusCode)
+	require.NoError(t, json.Unmarshal(badCursorData, &badCursor))
+	require.Equal(t, []string{"cursor"}, badCursor.fieldNames())
+	require.Equal(t, http.StatusBadRequest, badLimitResp.StatusCode)
+	require.NoError(t, json.Unmarshal(badLimitData, &badLimit))
+	require.Equal(t, []string{"limit"}, badLimit.fieldNames())
+}
+
+func TestWorkoutSubmissionValidation(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Arrange
+	benchID := ts.exerciseIDByName("Bench Press")
+	token := ts.registerAthlete().Tokens.AccessToken
+	bench := func(fields string) string {
+		return `{"exerciseId":"` + benchID + `"` + fields + `}`
+	}
+	cases := []struct {
+		name    string
+		workout string
+		fields  []string
+	}{
+		{"no entries", `{"startedAt":"2024-06-03T10:00:00Z","entries":[]}`, []string{"entries"}},
+		{"completed before started", `{"startedAt":"2024-06-03T10:00:00Z","completedAt":"2024-06-03T09:00:00Z","entries":[` + bench(`,"reps":5`) + `]}`, []string{"completedAt"}},
+		{"dated in 2099", `{"startedAt":"2099-01-01T10:00:00Z","entries":[` + bench(`,"reps":5`) + `]}`, []string{"startedAt"}},
+		{"dated in 1999", `{"startedAt":"1999-12-31T10:00:00Z","entries":[` + bench(`,"reps":5`) + `]}`, []string{"startedAt"}},
+		{"longer than a day", `{"startedAt":"2024-06-03T10:00:00Z","completedAt":"2024-06-04T16:00:00Z","entries":[` + bench(`,"reps":5`) + `]}`, []string{"completedAt"}},
+		{"unknown exercise", `{"entries":[{"exerciseId":"0b0e8b4e-5d53-4c1e-9a1a-1f2d3c4b5a69","reps":5}]}`, []string{"entries[0].exerciseId"}},
+		{"malformed exercise id", `{"entries":[{"exerciseId":"bench","reps":5}]}`, []string{"entries[0].exerciseId"}},
+		{"missing exercise id", `{"entries":[{"reps":5}]}`, []string{"entries[0].exerciseId"}},
+		{"negative reps", `{"entries":[` + bench(`,"reps":5`) + `,` + bench(`,"reps":3`) + `,` + bench(`,"reps":-1`) + `]}`, []string{"entries[2].reps"}},
+		{"implausible weight", `{"entries":[` + bench(`,"reps":1,"weight":5000`) + `]}`, []string{"entries[0].weight"}},
+		{"too many sets", `{"entries":[` + bench(`,"sets":101,"reps":5`) + `]}`, []string{"entries[0].sets"}},
+		{"bad set", `{"entries":[` + bench(`,"setDetails":[{"reps":5,"weight":100},{"reps":5000,"weight":100,"rpe":11}]`) + `]}`, []string{"entries[0].setDetails[1].reps", "entries[0].setDetails[1].rpe"}},
+		{"several problems", `{"startedAt":"2099-01-01T10:00:00Z","entries":[` + bench(`,"reps":-5,"notes":"`+strings.Repeat("n", 2001)+`"`) + `]}`, []string{"startedAt", "entries[0].notes", "entries[0].reps"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			singleData, singleResp := ts.doRequest(http.MethodPost, "/api/v1/workouts", []byte(tc.workout), token)
+			batchData, batchResp := ts.doRequest(http.MethodPost, "/api/v1/workouts/batch", []byte(`{"workouts":[`+tc.workout+`]}`), token)
+
+			// Assert: both paths reject the workout with the same fields.
+			require.Equal(t, http.StatusBadRequest, singleResp.StatusCode, string(singleData))
+			var single fieldErrorResponse
+			require.NoError(t, json.Unmarshal(singleData, &single))
+			require.Equal(t, tc.fields, single.fieldNames())
+
+			require.Equal(t, http.StatusOK, batchResp.StatusCode, string(batchData))
+			var batch struct {
+				Results []struct {
+					Status string `json:"status"`
+					fieldErrorResponse
+				} `json:"results"`
+			}
+			require.NoError(t, json.Unmarshal(batchData, &batch))
+			require.Len(t, batch.Results, 1)
+			require.Equal(t, "invalid", batch.Results[0].Status)
+			require.Equal(t, single.Fields, batch.Results[0].Fields)
+		})
+	}
+
+	// Assert: nothing was stored.
+	listData, _ := ts.doRequest(http.MethodGet, "/api/v1/workouts", nil, token)
+	require.JSONEq(t, `[]`, string(listData))
+}
@@ -0,0 +1,100 @@
+// Package catalogue ships the default exercise library as embedded data.
+package catalogue
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/search"
+)
+
+//go:embed exercises.json
+var embedded []byte
+
+// Catalogue is a versioned list of exercises. Bump Version whenever entries
+// are added so existing installations pick them up on their next start.
+type Catalogue struct {
+	Version   int     `json:"version"`
+	Exercises []Entry `json:"exercises"`
+}
+
+// Entry is one shipped exercise. Key identifies it across releases, so names
+// and descriptions can be corrected without seeding the exercise again.
+type Entry struct {
+	Key             string                 `json:"key"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	MuscleGroup     string                 `json:"muscleGroup"`
+	Equipment       string                 `json:"equipment"`
+	MeasurementType domain.MeasurementType `json:"measurementType"`
+	Unilateral      bool                   `json:"unilateral"`
+	PrimaryMuscles  []string               `json:"primaryMuscles"`
+	MovementPattern string                 `json:"movementPattern"`
+	Aliases         []string               `json:"aliases"`
+}
+
+// Default returns the catalogue compiled into the binary.
+func Default() (*Catalogue, error) {
+	return Parse(embedded)
+}
+
+// Load reads a catalogue from path, or returns the embedded one when path is
+// empty.
+func Load(path string) (*Catalogue, error) {
+	if path == "" {
+		return Default()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read exercise catalogue: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a catalogue document.
+func Parse(data []byte) (*Catalogue, error) {
+	var cat Catalogue
+	if err := json.Unmarshal(data, &cat); err != nil {
+		return nil, fmt.Errorf("decode exercise catalogue: %w", err)
+	}
+	if cat.Version < 1 {
+		return nil, fmt.Errorf("exercise catalogue version must be positive, got %d", cat.Version)
+	}
+	keys := make(map[string]bool, len(cat.Exercises))
+	// terms holds normalized names and aliases, which must not collide since
+	// both are used to find exercises.
+	terms := make(map[string]string, len(cat.Exercises))
+	for i := range cat.Exercises {
+		entry := &cat.Exercises[i]
+		if entry.Key == "" || entry.Name == "" {
+			return nil, fmt.Errorf("exercise catalogue entry %d: key and name are required", i)
+		}
+		if keys[entry.Key] {
+			return nil, fmt.Errorf("exercise catalogue entry %q: duplicate key", entry.Key)
+		}
+		keys[entry.Key] = true
+		for _, term := range append([]string{entry.Name}, entry.Aliases...) {
+			normalized := search.Normalize(term)
+			if normalized == "" {
+				return nil, fmt.Errorf("exercise catalogue entry %q: invalid name or alias %q", entry.Key, term)
+			}
+			if owner, taken := terms[normalized]; taken {
+				return nil, fmt.Errorf("exercise catalogue entry %q: %q is already used by %q", entry.Key, term, owner)
+			}
+			terms[normalized] = entry.Key
+		}
+		if entry.MeasurementType == "" {
+			entry.MeasurementType = domain.MeasurementWeightReps
+		}
+		if !entry.MeasurementType.Valid() {
+			return nil, fmt.Errorf("exercise catalogue entry %q: invalid measurement type %q", entry.Key, entry.MeasurementType)
+		}
+		if !domain.ValidMovementPattern(entry.MovementPattern) {
+			return nil, fmt.Errorf("exercise catalogue entry %q: invalid movement pattern %q", entry.Key, entry.MovementPattern)
+		}
+	}
+	return &cat, nil
+}
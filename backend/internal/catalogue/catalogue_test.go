@@ -0,0 +1,52 @@
+package catalogue_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/catalogue"
+	"github.com/musclementour/app/internal/domain"
+)
+
+func TestDefaultCatalogue(t *testing.T) {
+	cat, err := catalogue.Default()
+	require.NoError(t, err)
+
+	require.GreaterOrEqual(t, cat.Version, 1)
+	require.GreaterOrEqual(t, len(cat.Exercises), 200)
+	for _, entry := range cat.Exercises {
+		require.NotEmpty(t, entry.Description, entry.Key)
+		require.NotEmpty(t, entry.MuscleGroup, entry.Key)
+		require.NotEmpty(t, entry.Equipment, entry.Key)
+		require.NotEmpty(t, entry.PrimaryMuscles, entry.Key)
+		require.NotEmpty(t, entry.MovementPattern, entry.Key)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{name: "missing version", doc: `{"exercises": []}`, err: "version must be positive"},
+		{name: "missing key", doc: `{"version": 1, "exercises": [{"name": "Squat"}]}`, err: "key and name are required"},
+		{name: "duplicate key", doc: `{"version": 1, "exercises": [{"key": "a", "name": "Squat"}, {"key": "a", "name": "Row"}]}`, err: "duplicate key"},
+		{name: "duplicate name", doc: `{"version": 1, "exercises": [{"key": "a", "name": "Pull-Up"}, {"key": "b", "name": "pullup"}]}`, err: "already used"},
+		{name: "alias clashes with name", doc: `{"version": 1, "exercises": [{"key": "a", "name": "Squat"}, {"key": "b", "name": "Row", "aliases": ["squat"]}]}`, err: "already used"},
+		{name: "bad measurement", doc: `{"version": 1, "exercises": [{"key": "a", "name": "Squat", "measurementType": "laps"}]}`, err: "invalid measurement type"},
+		{name: "bad pattern", doc: `{"version": 1, "exercises": [{"key": "a", "name": "Squat", "movementPattern": "twist"}]}`, err: "invalid movement pattern"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := catalogue.Parse([]byte(tc.doc))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	cat, err := catalogue.Parse([]byte(`{"version": 2, "exercises": [{"key": "squat", "name": "Squat"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, 2, cat.Version)
+	require.Equal(t, domain.MeasurementWeightReps, cat.Exercises[0].MeasurementType)
+}
@@ -18,6 +18,25 @@ type Config struct {
 	AdminEmail         string
 	AdminPassword      string
 	AllowedOrigins     []string
+	MediaDir           string
+	MediaBaseURL       string
+	MaxImageBytes      int64
+	MaxVideoBytes      int64
+	// ExerciseCatalogue is a JSON file replacing the embedded exercise
+	// catalogue. Empty means the embedded one.
+	ExerciseCatalogue string
+	// WorkoutBatchMaxItems and WorkoutBatchMaxBytes bound POST /workouts/batch.
+	WorkoutBatchMaxItems int
+	WorkoutBatchMaxBytes int64
+	// WorkoutTrashRetention is how long deleted workouts can be restored
+	// before they are purged.
+	WorkoutTrashRetention time.Duration
+	// LiveWorkoutTimeout is how long a live workout may go without activity
+	// before it is closed automatically. Zero keeps live workouts open.
+	LiveWorkoutTimeout time.Duration
+	// LiveWorkoutSweepInterval is how often idle live workouts are looked
+	// for. Zero only closes them at startup and when they are accessed.
+	LiveWorkoutSweepInterval time.Duration
 }
 
 func Load() (*Config, error) {
@@ -51,6 +70,38 @@ func Load() (*Config, error) {
 		cfg.AllowedOrigins = splitAndTrim(origins)
 	}
 
+	cfg.MediaDir = getEnv("MEDIA_DIR", "./data/media")
+	cfg.MediaBaseURL = getEnv("MEDIA_BASE_URL", "/api/v1/media")
+	cfg.MaxImageBytes, err = strconv.ParseInt(getEnv("MEDIA_MAX_IMAGE_BYTES", "5242880"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MEDIA_MAX_IMAGE_BYTES: %w", err)
+	}
+	cfg.MaxVideoBytes, err = strconv.ParseInt(getEnv("MEDIA_MAX_VIDEO_BYTES", "52428800"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MEDIA_MAX_VIDEO_BYTES: %w", err)
+	}
+	cfg.ExerciseCatalogue = os.Getenv("EXERCISE_CATALOGUE")
+	cfg.WorkoutBatchMaxItems, err = strconv.Atoi(getEnv("WORKOUT_BATCH_MAX_ITEMS", "100"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WORKOUT_BATCH_MAX_ITEMS: %w", err)
+	}
+	cfg.WorkoutBatchMaxBytes, err = strconv.ParseInt(getEnv("WORKOUT_BATCH_MAX_BYTES", "2097152"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WORKOUT_BATCH_MAX_BYTES: %w", err)
+	}
+	cfg.WorkoutTrashRetention, err = time.ParseDuration(getEnv("WORKOUT_TRASH_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WORKOUT_TRASH_RETENTION: %w", err)
+	}
+	cfg.LiveWorkoutTimeout, err = time.ParseDuration(getEnv("LIVE_WORKOUT_TIMEOUT", "4h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LIVE_WORKOUT_TIMEOUT: %w", err)
+	}
+	cfg.LiveWorkoutSweepInterval, err = time.ParseDuration(getEnv("LIVE_WORKOUT_SWEEP_INTERVAL", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LIVE_WORKOUT_SWEEP_INTERVAL: %w", err)
+	}
+
 	return cfg, nil
 }
 
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS exercise_revisions (
+    id UUID PRIMARY KEY,
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    action TEXT NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reverted_from INT,
+    changes JSONB NOT NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (exercise_id, revision)
+);
@@ -0,0 +1,15 @@
+CREATE SEQUENCE IF NOT EXISTS exercise_change_seq;
+
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT nextval('exercise_change_seq');
+
+CREATE INDEX IF NOT EXISTS exercises_change_seq_idx ON exercises (change_seq);
+
+CREATE TABLE IF NOT EXISTS exercise_tombstones (
+    exercise_id UUID PRIMARY KEY,
+    reason TEXT NOT NULL,
+    removed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    change_seq BIGINT NOT NULL DEFAULT nextval('exercise_change_seq')
+);
+
+CREATE INDEX IF NOT EXISTS exercise_tombstones_change_seq_idx ON exercise_tombstones (change_seq);
@@ -0,0 +1,17 @@
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS measurement_type TEXT;
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS unilateral BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE exercises
+SET measurement_type = CASE
+        WHEN lower(name) IN ('plank', 'side plank', 'wall sit', 'dead hang') THEN 'time'
+        WHEN lower(name) IN ('pull-up', 'chin-up', 'push-up', 'dip') THEN 'bodyweight_reps'
+        ELSE 'weight_reps'
+    END,
+    change_seq = nextval('exercise_change_seq')
+WHERE measurement_type IS NULL;
+
+ALTER TABLE exercises ALTER COLUMN measurement_type SET DEFAULT 'weight_reps';
+ALTER TABLE exercises ALTER COLUMN measurement_type SET NOT NULL;
+
+ALTER TABLE workout_entries ADD COLUMN IF NOT EXISTS distance_meters DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE workout_entries ADD COLUMN IF NOT EXISTS side TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS exercise_aliases (
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    alias TEXT NOT NULL,
+    normalized TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (exercise_id, normalized)
+);
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS exercise_translations (
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    locale TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (exercise_id, locale)
+);
+
+CREATE INDEX IF NOT EXISTS exercise_translations_locale_idx ON exercise_translations (locale);
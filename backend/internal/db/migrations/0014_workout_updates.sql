@@ -0,0 +1,19 @@
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workout_entries ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workout_entries ADD COLUMN IF NOT EXISTS position INTEGER;
+
+UPDATE workout_sessions SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE workout_entries SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE workout_entries e SET position = ordered.position
+FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY created_at, id) - 1 AS position FROM workout_entries) ordered
+WHERE e.id = ordered.id AND e.position IS NULL;
+
+ALTER TABLE workout_sessions ALTER COLUMN updated_at SET DEFAULT NOW();
+ALTER TABLE workout_sessions ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE workout_entries ALTER COLUMN updated_at SET DEFAULT NOW();
+ALTER TABLE workout_entries ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE workout_entries ALTER COLUMN position SET DEFAULT 0;
+ALTER TABLE workout_entries ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS workout_sessions_trash_idx ON workout_sessions (deleted_at) WHERE deleted_at IS NOT NULL;
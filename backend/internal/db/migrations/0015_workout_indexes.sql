@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS workout_sessions_user_started_idx ON workout_sessions (user_id, started_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS workout_entries_session_idx ON workout_entries (session_id, position);
+CREATE INDEX IF NOT EXISTS workout_entries_exercise_idx ON workout_entries (exercise_id);
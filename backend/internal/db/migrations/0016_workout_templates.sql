@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS workout_templates (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS workout_templates_user_name_idx ON workout_templates (user_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS workout_template_exercises (
+    template_id UUID NOT NULL REFERENCES workout_templates(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    exercise_id UUID NOT NULL REFERENCES exercises(id),
+    target_sets INTEGER NOT NULL DEFAULT 0,
+    target_reps INTEGER NOT NULL DEFAULT 0,
+    target_weight DOUBLE PRECISION NOT NULL DEFAULT 0,
+    target_duration_seconds INTEGER NOT NULL DEFAULT 0,
+    target_distance_meters DOUBLE PRECISION NOT NULL DEFAULT 0,
+    rest_seconds INTEGER NOT NULL DEFAULT 0,
+    notes TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (template_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS workout_template_exercises_exercise_idx ON workout_template_exercises (exercise_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS personal_records (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    weight DOUBLE PRECISION NOT NULL DEFAULT 0,
+    value DOUBLE PRECISION NOT NULL,
+    reps INTEGER NOT NULL DEFAULT 0,
+    session_id UUID NOT NULL REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    achieved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, exercise_id, type, weight)
+);
+
+CREATE INDEX IF NOT EXISTS personal_records_exercise_idx ON personal_records (exercise_id);
+
+CREATE INDEX IF NOT EXISTS personal_records_session_idx ON personal_records (session_id);
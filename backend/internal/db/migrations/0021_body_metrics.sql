@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS body_metrics (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    measured_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    bodyweight DOUBLE PRECISION,
+    body_fat DOUBLE PRECISION,
+    circumferences JSONB NOT NULL DEFAULT '{}',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS body_metrics_user_idx ON body_metrics (user_id, measured_at DESC);
+
+CREATE TABLE IF NOT EXISTS body_metric_submissions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    metric_id UUID NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, key)
+);
@@ -0,0 +1,115 @@
+package domain
+
+import "time"
+
+// ExerciseVolume sums the work logged for one exercise in a date range.
+// Volume is sets × reps × weight; entries without load only add sets and reps,
+// and so do assisted entries, whose weight is the assistance.
+type ExerciseVolume struct {
+	ExerciseID string  `json:"exerciseId"`
+	Name       string  `json:"name"`
+	Sets       int     `json:"sets"`
+	Reps       int     `json:"reps"`
+	Volume     float64 `json:"volume"`
+	// Includes lists the variations whose volume was rolled up into this
+	// exercise. It is empty unless variation rollup was requested.
+	Includes []string `json:"includes,omitempty"`
+}
+
+type VolumeReport struct {
+	From      *time.Time       `json:"from,omitempty"`
+	To        *time.Time       `json:"to,omitempty"`
+	Rollup    bool             `json:"rollup"`
+	Exercises []ExerciseVolume `json:"exercises"`
+}
+
+// Bucket is the length of the periods a time series groups workouts into.
+// Buckets are in UTC and weeks start on Monday.
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+var Buckets = []Bucket{BucketDay, BucketWeek, BucketMonth}
+
+func (b Bucket) Valid() bool {
+	for _, known := range Buckets {
+		if b == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Start returns the start of the bucket containing t.
+func (b Bucket) Start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch b {
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// Add moves a bucket start n buckets forward, or backward for negative n.
+func (b Bucket) Add(start time.Time, n int) time.Time {
+	switch b {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7*n)
+	case BucketMonth:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// Series is a time series of points over the sessions that started from
+// From up to but excluding To.
+type Series[P any] struct {
+	Bucket Bucket    `json:"bucket"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Points []P       `json:"points"`
+}
+
+// WorkloadPoint sums the working sets of one bucket. Reps count the volume
+// of work and Tonnage weighs it as reps × weight. Assistance on assisted
+// exercises is not load and adds no tonnage.
+type WorkloadPoint struct {
+	Start   time.Time `json:"start"`
+	Sets    int       `json:"sets"`
+	Reps    int       `json:"reps"`
+	Tonnage float64   `json:"tonnage"`
+}
+
+// MuscleGroupPoint counts the working sets of one bucket on exercises for a
+// muscle group.
+type MuscleGroupPoint struct {
+	Start       time.Time `json:"start"`
+	MuscleGroup string    `json:"muscleGroup"`
+	Sets        int       `json:"sets"`
+}
+
+// OneRepMaxPoint is the best estimated one-rep max of one bucket, with the
+// set it was estimated from.
+type OneRepMaxPoint struct {
+	Start              time.Time `json:"start"`
+	EstimatedOneRepMax float64   `json:"estimated1rm"`
+	Weight             float64   `json:"weight"`
+	Reps               int       `json:"reps"`
+}
+
+// FrequencyPoint counts the workouts of one bucket and the distinct days
+// they were on.
+type FrequencyPoint struct {
+	Start    time.Time `json:"start"`
+	Sessions int       `json:"sessions"`
+	Days     int       `json:"days"`
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// CircumferenceSite names where a circumference was measured.
+type CircumferenceSite string
+
+const (
+	SiteNeck         CircumferenceSite = "neck"
+	SiteShoulders    CircumferenceSite = "shoulders"
+	SiteChest        CircumferenceSite = "chest"
+	SiteWaist        CircumferenceSite = "waist"
+	SiteHips         CircumferenceSite = "hips"
+	SiteLeftArm      CircumferenceSite = "left_arm"
+	SiteRightArm     CircumferenceSite = "right_arm"
+	SiteLeftForearm  CircumferenceSite = "left_forearm"
+	SiteRightForearm CircumferenceSite = "right_forearm"
+	SiteLeftThigh    CircumferenceSite = "left_thigh"
+	SiteRightThigh   CircumferenceSite = "right_thigh"
+	SiteLeftCalf     CircumferenceSite = "left_calf"
+	SiteRightCalf    CircumferenceSite = "right_calf"
+)
+
+var CircumferenceSites = []CircumferenceSite{
+	SiteNeck, SiteShoulders, SiteChest, SiteWaist, SiteHips,
+	SiteLeftArm, SiteRightArm, SiteLeftForearm, SiteRightForearm,
+	SiteLeftThigh, SiteRightThigh, SiteLeftCalf, SiteRightCalf,
+}
+
+func (s CircumferenceSite) Valid() bool {
+	for _, known := range CircumferenceSites {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+type WeightUnit string
+
+const (
+	Kilograms WeightUnit = "kg"
+	Pounds    WeightUnit = "lb"
+)
+
+func (u WeightUnit) Valid() bool {
+	return u == Kilograms || u == Pounds
+}
+
+type LengthUnit string
+
+const (
+	Centimeters LengthUnit = "cm"
+	Inches      LengthUnit = "in"
+)
+
+func (u LengthUnit) Valid() bool {
+	return u == Centimeters || u == Inches
+}
+
+const (
+	kilogramsPerPound  = 0.45359237
+	centimetersPerInch = 2.54
+)
+
+// ToKilograms converts weight from u to kilograms.
+func (u WeightUnit) ToKilograms(weight float64) float64 {
+	if u == Pounds {
+		return weight * kilogramsPerPound
+	}
+	return weight
+}
+
+// FromKilograms converts kilograms to u, rounded to two decimal places.
+func (u WeightUnit) FromKilograms(kg float64) float64 {
+	if u == Pounds {
+		kg /= kilogramsPerPound
+	}
+	return math.Round(kg*100) / 100
+}
+
+// ToCentimeters converts length from u to centimeters.
+func (u LengthUnit) ToCentimeters(length float64) float64 {
+	if u == Inches {
+		return length * centimetersPerInch
+	}
+	return length
+}
+
+// FromCentimeters converts centimeters to u, rounded to two decimal places.
+func (u LengthUnit) FromCentimeters(cm float64) float64 {
+	if u == Inches {
+		cm /= centimetersPerInch
+	}
+	return math.Round(cm*100) / 100
+}
+
+// BodyUnits are the units body metrics are reported in.
+type BodyUnits struct {
+	Weight WeightUnit `json:"weight"`
+	Length LengthUnit `json:"length"`
+}
+
+// MetricUnits are the units body metrics are stored in.
+var MetricUnits = BodyUnits{Weight: Kilograms, Length: Centimeters}
+
+// BodyMetric is one time-stamped set of body measurements. Any of bodyweight,
+// body fat percentage and circumferences may be missing. They are stored in
+// kilograms and centimeters, and Units says which units a copy handed out is
+// in.
+type BodyMetric struct {
+	ID             string                        `json:"id"`
+	UserID         string                        `json:"-"`
+	MeasuredAt     time.Time                     `json:"measuredAt"`
+	Bodyweight     *float64                      `json:"bodyweight,omitempty"`
+	BodyFat        *float64                      `json:"bodyFat,omitempty"`
+	Circumferences map[CircumferenceSite]float64 `json:"circumferences,omitempty"`
+	Note           string                        `json:"note,omitempty"`
+	Units          BodyUnits                     `json:"units"`
+	CreatedAt      time.Time                     `json:"createdAt"`
+	UpdatedAt      time.Time                     `json:"updatedAt"`
+}
+
+// In returns a copy of a stored metric converted to units.
+func (m BodyMetric) In(units BodyUnits) BodyMetric {
+	converted := m
+	converted.Units = units
+	if m.Bodyweight != nil {
+		weight := units.Weight.FromKilograms(*m.Bodyweight)
+		converted.Bodyweight = &weight
+	}
+	if m.Circumferences != nil {
+		converted.Circumferences = make(map[CircumferenceSite]float64, len(m.Circumferences))
+		for site, cm := range m.Circumferences {
+			converted.Circumferences[site] = units.Length.FromCentimeters(cm)
+		}
+	}
+	return converted
+}
+
+// BodyMetricSubmission remembers a body metric created under an idempotency
+// key, like WorkoutSubmission does for workouts.
+type BodyMetricSubmission struct {
+	UserID      string
+	Key         string
+	RequestHash string
+	MetricID    string
+	Response    []byte
+	CreatedAt   time.Time
+}
+
+// BodyTrendPoint is a measurement with the moving average of the
+// measurements taken in the window ending with it.
+type BodyTrendPoint struct {
+	MeasuredAt time.Time `json:"measuredAt"`
+	Value      float64   `json:"value"`
+	Average    float64   `json:"average"`
+}
+
+// BodyTrend is a body metric over time, in Unit. WindowDays is the length of
+// the moving average and Change the difference between the last and first
+// averages.
+type BodyTrend struct {
+	Metric     string           `json:"metric"`
+	Site       string           `json:"site,omitempty"`
+	Unit       string           `json:"unit"`
+	WindowDays int              `json:"windowDays"`
+	From       *time.Time       `json:"from,omitempty"`
+	To         *time.Time       `json:"to,omitempty"`
+	Change     float64          `json:"change"`
+	Points     []BodyTrendPoint `json:"points"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// CatalogueSeedReport describes what seeding the shipped exercise catalogue
+// changed at startup.
+type CatalogueSeedReport struct {
+	FromVersion int `json:"fromVersion"`
+	ToVersion   int `json:"toVersion"`
+	// Created lists exercises added to the library.
+	Created []string `json:"created"`
+	// Matched lists catalogue entries that already existed under the same
+	// name or alias and were adopted instead of created.
+	Matched []string `json:"matched"`
+	// Removed counts previously seeded exercises that admins have since
+	// deleted or merged. They are not created again.
+	Removed  int       `json:"removed"`
+	SeededAt time.Time `json:"seededAt"`
+}
@@ -1,13 +1,267 @@
 package domain
 
-import "time"
+import (
+	"reflect"
+	"time"
+)
 
 type Exercise struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	MuscleGroup string    `json:"muscleGroup"`
-	Equipment   string    `json:"equipment"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Locale is set when Name and Description were localized for the reader.
+	Locale          string          `json:"locale,omitempty"`
+	MuscleGroup     string          `json:"muscleGroup"`
+	Equipment       string          `json:"equipment"`
+	MeasurementType MeasurementType `json:"measurementType"`
+	Unilateral      bool            `json:"unilateral"`
+	PrimaryMuscles  []string        `json:"primaryMuscles"`
+	MovementPattern string          `json:"movementPattern"`
+	Aliases         []string        `json:"aliases"`
+	Media           []ExerciseMedia `json:"media"`
+	ArchivedAt      *time.Time      `json:"archivedAt,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+}
+
+// MovementPatterns lists the movement patterns used to group exercises that
+// can stand in for each other.
+var MovementPatterns = []string{
+	"squat",
+	"hinge",
+	"lunge",
+	"horizontal_push",
+	"vertical_push",
+	"horizontal_pull",
+	"vertical_pull",
+	"carry",
+	"core",
+	"isolation",
+	"conditioning",
+}
+
+func ValidMovementPattern(pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	for _, known := range MovementPatterns {
+		if pattern == known {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetMuscles returns the primary muscles, falling back to the muscle
+// group for exercises that have not been annotated in detail.
+func (e *Exercise) TargetMuscles() []string {
+	if len(e.PrimaryMuscles) > 0 {
+		return e.PrimaryMuscles
+	}
+	if e.MuscleGroup != "" {
+		return []string{e.MuscleGroup}
+	}
+	return nil
+}
+
+// ExerciseAlternative is a ranked substitute for another exercise.
+type ExerciseAlternative struct {
+	Exercise Exercise `json:"exercise"`
+	Score    float64  `json:"score"`
+	Pinned   bool     `json:"pinned"`
+	Reasons  []string `json:"reasons"`
+}
+
+// MeasurementType declares which workout entry fields make sense for an
+// exercise.
+type MeasurementType string
+
+const (
+	MeasurementWeightReps     MeasurementType = "weight_reps"
+	MeasurementBodyweightReps MeasurementType = "bodyweight_reps"
+	MeasurementAssisted       MeasurementType = "assisted"
+	MeasurementTime           MeasurementType = "time"
+	MeasurementDistance       MeasurementType = "distance"
+	MeasurementDistanceTime   MeasurementType = "distance_time"
+)
+
+var MeasurementTypes = []MeasurementType{
+	MeasurementWeightReps,
+	MeasurementBodyweightReps,
+	MeasurementAssisted,
+	MeasurementTime,
+	MeasurementDistance,
+	MeasurementDistanceTime,
+}
+
+func (m MeasurementType) Valid() bool {
+	for _, known := range MeasurementTypes {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CountsReps reports whether entries for this type are logged in reps.
+func (m MeasurementType) CountsReps() bool {
+	return m == MeasurementWeightReps || m == MeasurementBodyweightReps || m == MeasurementAssisted
+}
+
+func (m MeasurementType) RequiresDuration() bool {
+	return m == MeasurementTime || m == MeasurementDistanceTime
+}
+
+func (m MeasurementType) RequiresDistance() bool {
+	return m == MeasurementDistance || m == MeasurementDistanceTime
+}
+
+// ExerciseMedia is an image or video attached to an exercise. Keys point into
+// the blob store; URLs are filled in when the exercise is served.
+type ExerciseMedia struct {
+	ID           string    `json:"id"`
+	ExerciseID   string    `json:"exerciseId"`
+	Kind         string    `json:"kind"`
+	ContentType  string    `json:"contentType"`
+	SizeBytes    int64     `json:"sizeBytes"`
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (e *Exercise) Archived() bool {
+	return e.ArchivedAt != nil
+}
+
+type TombstoneReason string
+
+const (
+	TombstoneDeleted  TombstoneReason = "deleted"
+	TombstoneArchived TombstoneReason = "archived"
+	TombstoneMerged   TombstoneReason = "merged"
+)
+
+// ExerciseTombstone tells offline clients to drop an exercise they may have
+// cached earlier.
+type ExerciseTombstone struct {
+	ID        string          `json:"id"`
+	Reason    TombstoneReason `json:"reason"`
+	RemovedAt time.Time       `json:"removedAt"`
+	// ReplacedBy is the exercise a merged duplicate was folded into, so
+	// clients can re-point cached references.
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+// ExerciseMerge folds a duplicate exercise into a canonical one. Everything
+// referencing the duplicate is moved to the canonical exercise, the
+// duplicate is removed and Revision is recorded on the canonical exercise,
+// all in one transaction.
+type ExerciseMerge struct {
+	CanonicalID string
+	DuplicateID string
+	// Alias keeps the duplicate's name searchable. It is empty when the name
+	// already matches the canonical exercise.
+	Alias           string
+	NormalizedAlias string
+	Revision        *ExerciseRevision
+	// MovedEntries is set by the repository to the number of workout
+	// entries re-pointed to the canonical exercise.
+	MovedEntries int64
+}
+
+// ExerciseChangeFeed lists everything that changed after a cursor. Seq is the
+// last change included and Cursor the position to pass on the next request.
+type ExerciseChangeFeed struct {
+	Seq        int64               `json:"-"`
+	Cursor     string              `json:"cursor"`
+	Exercises  []Exercise          `json:"exercises"`
+	Tombstones []ExerciseTombstone `json:"tombstones"`
+}
+
+// ExerciseSnapshot captures the admin-editable fields of an exercise at a
+// given revision so that it can be diffed against or restored later.
+type ExerciseSnapshot struct {
+	Name            string          `json:"name"`
+	Description     string          `json:"description"`
+	MuscleGroup     string          `json:"muscleGroup"`
+	Equipment       string          `json:"equipment"`
+	MeasurementType MeasurementType `json:"measurementType"`
+	Unilateral      bool            `json:"unilateral"`
+	PrimaryMuscles  []string        `json:"primaryMuscles"`
+	MovementPattern string          `json:"movementPattern"`
+}
+
+func (e *Exercise) Snapshot() ExerciseSnapshot {
+	return ExerciseSnapshot{
+		Name:            e.Name,
+		Description:     e.Description,
+		MuscleGroup:     e.MuscleGroup,
+		Equipment:       e.Equipment,
+		MeasurementType: e.MeasurementType,
+		Unilateral:      e.Unilateral,
+		PrimaryMuscles:  append([]string{}, e.PrimaryMuscles...),
+		MovementPattern: e.MovementPattern,
+	}
+}
+
+func (e *Exercise) ApplySnapshot(s ExerciseSnapshot) {
+	e.Name = s.Name
+	e.Description = s.Description
+	e.MuscleGroup = s.MuscleGroup
+	e.Equipment = s.Equipment
+	e.MeasurementType = s.MeasurementType
+	e.Unilateral = s.Unilateral
+	e.PrimaryMuscles = append([]string{}, s.PrimaryMuscles...)
+	e.MovementPattern = s.MovementPattern
+}
+
+type RevisionAction string
+
+const (
+	RevisionCreate   RevisionAction = "create"
+	RevisionBaseline RevisionAction = "baseline"
+	RevisionUpdate   RevisionAction = "update"
+	RevisionRevert   RevisionAction = "revert"
+	RevisionMerge    RevisionAction = "merge"
+)
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type ExerciseRevision struct {
+	ID           string           `json:"id"`
+	ExerciseID   string           `json:"exerciseId"`
+	Number       int              `json:"number"`
+	Action       RevisionAction   `json:"action"`
+	AuthorID     string           `json:"authorId,omitempty"`
+	RevertedFrom int              `json:"revertedFrom,omitempty"`
+	Changes      []FieldChange    `json:"changes"`
+	Snapshot     ExerciseSnapshot `json:"snapshot"`
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+// DiffSnapshots lists the fields that differ between two snapshots in a
+// stable order.
+func DiffSnapshots(from, to ExerciseSnapshot) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			changes = append(changes, FieldChange{Field: field, From: a, To: b})
+		}
+	}
+	add("name", from.Name, to.Name)
+	add("description", from.Description, to.Description)
+	add("muscleGroup", from.MuscleGroup, to.MuscleGroup)
+	add("equipment", from.Equipment, to.Equipment)
+	add("measurementType", from.MeasurementType, to.MeasurementType)
+	add("unilateral", from.Unilateral, to.Unilateral)
+	// nil and empty lists both mean "none"; only report real differences.
+	add("primaryMuscles", append([]string{}, from.PrimaryMuscles...), append([]string{}, to.PrimaryMuscles...))
+	add("movementPattern", from.MovementPattern, to.MovementPattern)
+	return changes
 }
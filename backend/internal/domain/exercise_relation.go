@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// RelationType describes how one exercise relates to another. A relation
+// reads "From <type> To", e.g. "Close-Grip Bench Press variation_of Bench
+// Press" or "Knee Push-Up progression_to Push-Up".
+type RelationType string
+
+const (
+	RelationVariationOf   RelationType = "variation_of"
+	RelationProgressionTo RelationType = "progression_to"
+	RelationRegressionTo  RelationType = "regression_to"
+)
+
+func (t RelationType) Valid() bool {
+	switch t {
+	case RelationVariationOf, RelationProgressionTo, RelationRegressionTo:
+		return true
+	}
+	return false
+}
+
+type ExerciseRelation struct {
+	ID        string       `json:"id"`
+	FromID    string       `json:"fromId"`
+	ToID      string       `json:"toId"`
+	Type      RelationType `json:"type"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+// HarderEdge returns the relation as an "easier -> harder" pair so
+// progressions and regressions can be checked for cycles together.
+func (r ExerciseRelation) HarderEdge() (easier, harder string, ok bool) {
+	switch r.Type {
+	case RelationProgressionTo:
+		return r.FromID, r.ToID, true
+	case RelationRegressionTo:
+		return r.ToID, r.FromID, true
+	}
+	return "", "", false
+}
+
+// ExerciseGraph is the neighbourhood of an exercise in the relation graph.
+type ExerciseGraph struct {
+	RootID string              `json:"rootId"`
+	Nodes  []ExerciseGraphNode `json:"nodes"`
+	Edges  []ExerciseRelation  `json:"edges"`
+}
+
+type ExerciseGraphNode struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+	// Depth is the number of relations between this node and the root.
+	Depth int `json:"depth"`
+}
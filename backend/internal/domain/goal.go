@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// GoalType names what a goal measures.
+type GoalType string
+
+const (
+	// GoalStrength targets lifting Target kilograms for Reps reps on an
+	// exercise.
+	GoalStrength GoalType = "strength"
+	// GoalFrequency targets training Target times a week.
+	GoalFrequency GoalType = "frequency"
+	// GoalBodyweight targets a bodyweight of Target kilograms, up or down.
+	GoalBodyweight GoalType = "bodyweight"
+)
+
+var GoalTypes = []GoalType{GoalStrength, GoalFrequency, GoalBodyweight}
+
+func (t GoalType) Valid() bool {
+	for _, known := range GoalTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Goal is a target an athlete wants to reach by Deadline, a date at midnight
+// UTC. AchievedAt is set once the goal is first reached and stays set.
+type Goal struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Type       GoalType   `json:"type"`
+	ExerciseID string     `json:"exerciseId,omitempty"`
+	Target     float64    `json:"target"`
+	Reps       int        `json:"reps,omitempty"`
+	Deadline   time.Time  `json:"deadline"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	AchievedAt *time.Time `json:"achievedAt,omitempty"`
+}
+
+type GoalStatus string
+
+const (
+	GoalOnTrack  GoalStatus = "on_track"
+	GoalBehind   GoalStatus = "behind"
+	GoalAchieved GoalStatus = "achieved"
+)
+
+// GoalProgress is a goal evaluated against the athlete's history. Start,
+// Current and TargetValue share a unit: estimated one-rep maxes for strength
+// goals, workouts a week averaged over four weeks for frequency goals and
+// kilograms for bodyweight goals. Start and Current are nil until there is
+// data to evaluate.
+type GoalProgress struct {
+	Goal
+	Status      GoalStatus      `json:"status"`
+	TargetValue float64         `json:"targetValue"`
+	Start       *float64        `json:"start"`
+	Current     *float64        `json:"current"`
+	Percent     float64         `json:"percent"`
+	Projection  *GoalProjection `json:"projection"`
+}
+
+// GoalProjection is when the trend of recent progress reaches the target.
+type GoalProjection struct {
+	Model string    `json:"model"`
+	Date  time.Time `json:"date"`
+}
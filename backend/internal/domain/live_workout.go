@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// LiveWorkout is a workout in progress. Each user has at most one, which any
+// of their devices can pick up. Sets are timed on the server as they are
+// logged. Finishing the workout saves it as a WorkoutSession with the same
+// id. Revision increases with every change so concurrent edits from two
+// devices cannot overwrite each other.
+type LiveWorkout struct {
+	ID               string      `json:"id"`
+	UserID           string      `json:"-"`
+	StartedAt        time.Time   `json:"startedAt"`
+	LastActivityAt   time.Time   `json:"lastActivityAt"`
+	PlannedSessionID string      `json:"plannedSessionId,omitempty"`
+	Entries          []LiveEntry `json:"entries"`
+	Revision         int         `json:"revision"`
+	// ClosesAt is when the workout is closed automatically unless there is
+	// activity before then. It is not stored.
+	ClosesAt *time.Time `json:"closesAt,omitempty"`
+}
+
+// LiveEntry is an exercise of a live workout with the sets logged so far.
+type LiveEntry struct {
+	ID         string       `json:"id"`
+	ExerciseID string       `json:"exerciseId"`
+	Side       Side         `json:"side,omitempty"`
+	Notes      string       `json:"notes,omitempty"`
+	Sets       []WorkoutSet `json:"sets"`
+}
+
+// LastSetAt returns when the most recent set of the workout was logged.
+func (w LiveWorkout) LastSetAt() *time.Time {
+	var last *time.Time
+	for _, entry := range w.Entries {
+		for _, set := range entry.Sets {
+			if set.CompletedAt != nil && (last == nil || set.CompletedAt.After(*last)) {
+				last = set.CompletedAt
+			}
+		}
+	}
+	return last
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// DefaultLocale is the language of Exercise.Name and Exercise.Description.
+// Other locales are stored as translations and fall back to it.
+const DefaultLocale = "en"
+
+// SupportedLocales lists the languages exercise content can be served in.
+var SupportedLocales = []string{"en", "de", "es"}
+
+func SupportedLocale(locale string) bool {
+	for _, known := range SupportedLocales {
+		if known == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// ExerciseTranslation holds the name and description of an exercise in a
+// locale other than the default.
+type ExerciseTranslation struct {
+	ExerciseID  string    `json:"exerciseId"`
+	Locale      string    `json:"locale"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TranslationCoverage reports how much of the active catalogue is translated
+// into a locale.
+type TranslationCoverage struct {
+	Locale     string              `json:"locale"`
+	Total      int                 `json:"total"`
+	Translated int                 `json:"translated"`
+	Percent    float64             `json:"percent"`
+	Missing    []TranslationTarget `json:"missing"`
+}
+
+type TranslationTarget struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// Program is a coach's multi-week plan. Each of its Weeks repeats Days, with
+// the template targets raised week by week according to Progression.
+type Program struct {
+	ID          string       `json:"id"`
+	AuthorID    string       `json:"authorId"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Weeks       int          `json:"weeks"`
+	Days        []ProgramDay `json:"days"`
+	Progression Progression  `json:"progression"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// ProgramDay schedules one of the author's templates on a day of the program
+// week. Day 1 falls on the weekday the athlete starts the program.
+type ProgramDay struct {
+	Day        int    `json:"day"`
+	TemplateID string `json:"templateId"`
+}
+
+// Progression is added to template targets once for every week after the
+// first. It only raises targets that are set.
+type Progression struct {
+	WeightIncrement float64 `json:"weightIncrement"`
+	RepsIncrement   int     `json:"repsIncrement"`
+}
+
+// ProgramEnrollment is an athlete following a program from StartDate, a date
+// at midnight UTC. EndedAt is set once they leave it.
+type ProgramEnrollment struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	ProgramID string     `json:"programId"`
+	StartDate time.Time  `json:"startDate"`
+	CreatedAt time.Time  `json:"createdAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+}
+
+// PlannedSession is one workout an enrollment schedules. The plan is laid
+// out when the athlete enrolls, so later edits to the program's weeks and
+// days do not move it. SessionID is the logged workout that completed it.
+type PlannedSession struct {
+	ID           string    `json:"id"`
+	EnrollmentID string    `json:"enrollmentId"`
+	TemplateID   string    `json:"templateId"`
+	Week         int       `json:"week"`
+	Day          int       `json:"day"`
+	ScheduledOn  time.Time `json:"scheduledOn"`
+	SessionID    string    `json:"sessionId,omitempty"`
+}
+
+type PlannedStatus string
+
+const (
+	PlannedStatusPlanned   PlannedStatus = "planned"
+	PlannedStatusCompleted PlannedStatus = "completed"
+	PlannedStatusMissed    PlannedStatus = "missed"
+)
+
+// Status reports whether the planned session was done, given today's date.
+func (p PlannedSession) Status(today time.Time) PlannedStatus {
+	switch {
+	case p.SessionID != "":
+		return PlannedStatusCompleted
+	case p.ScheduledOn.Before(today):
+		return PlannedStatusMissed
+	default:
+		return PlannedStatusPlanned
+	}
+}
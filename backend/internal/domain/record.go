@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// RecordType names what a personal record measures.
+type RecordType string
+
+const (
+	// RecordHeaviestWeight is the heaviest working set, whatever the reps.
+	RecordHeaviestWeight RecordType = "heaviest_weight"
+	// RecordEstimatedOneRepMax is the best one-rep max estimated from a
+	// single working set.
+	RecordEstimatedOneRepMax RecordType = "estimated_1rm"
+	// RecordMostReps is the most reps done in one set at a given weight.
+	RecordMostReps RecordType = "most_reps"
+	// RecordBestVolume is the most reps × weight logged for the exercise in
+	// one session.
+	RecordBestVolume RecordType = "best_volume"
+	// RecordLongestDuration is the longest timed set.
+	RecordLongestDuration RecordType = "longest_duration"
+)
+
+// PersonalRecord is the best performance of an athlete on an exercise for
+// one record type, and the session it was first achieved in. Value is in
+// kilograms, reps or seconds depending on the type. Weight and Reps describe
+// the set behind the record; for most-reps records there is one record per
+// weight.
+type PersonalRecord struct {
+	UserID     string     `json:"-"`
+	ExerciseID string     `json:"exerciseId"`
+	Type       RecordType `json:"type"`
+	Value      float64    `json:"value"`
+	Weight     float64    `json:"weight"`
+	Reps       int        `json:"reps"`
+	SessionID  string     `json:"sessionId"`
+	AchievedAt time.Time  `json:"achievedAt"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// WorkoutTemplate is a user's reusable routine such as "Push Day": an ordered
+// list of exercises with targets to start a session from.
+type WorkoutTemplate struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"userId"`
+	Name      string             `json:"name"`
+	Notes     string             `json:"notes"`
+	Exercises []TemplateExercise `json:"exercises"`
+	CreatedAt time.Time          `json:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+}
+
+// TemplateExercise is one exercise of a template. Which targets apply
+// depends on the exercise's MeasurementType, as for workout entries.
+// TargetPercent prescribes the load as a percentage of the athlete's
+// training max instead of TargetWeight. RestSeconds is the planned rest
+// between sets.
+type TemplateExercise struct {
+	ExerciseID            string  `json:"exerciseId"`
+	TargetSets            int     `json:"targetSets"`
+	TargetReps            int     `json:"targetReps"`
+	TargetWeight          float64 `json:"targetWeight"`
+	TargetPercent         float64 `json:"targetPercent,omitempty"`
+	TargetDurationSeconds int     `json:"targetDurationSeconds,omitempty"`
+	TargetDistanceMeters  float64 `json:"targetDistanceMeters,omitempty"`
+	RestSeconds           int     `json:"restSeconds"`
+	Notes                 string  `json:"notes"`
+}
+
+// WorkoutDraft is a session prefilled from a template. It is not stored; the
+// client edits it and submits it like any other workout. Drafts of program
+// workouts carry the planned session they complete.
+type WorkoutDraft struct {
+	TemplateID       string       `json:"templateId"`
+	PlannedSessionID string       `json:"plannedSessionId,omitempty"`
+	Name             string       `json:"name"`
+	StartedAt        time.Time    `json:"startedAt"`
+	Entries          []DraftEntry `json:"entries"`
+}
+
+// DraftEntry uses the field names of a workout entry submission, plus the
+// planned rest. Percentage-based entries also carry the percentage and the
+// training max their Weight was worked out from; Weight stays 0 while the
+// athlete has no training max for the exercise.
+type DraftEntry struct {
+	ExerciseID      string  `json:"exerciseId"`
+	Sets            int     `json:"sets"`
+	Reps            int     `json:"reps"`
+	Weight          float64 `json:"weight"`
+	TargetPercent   float64 `json:"targetPercent,omitempty"`
+	TrainingMax     float64 `json:"trainingMax,omitempty"`
+	DurationSeconds int     `json:"durationSeconds,omitempty"`
+	DistanceMeters  float64 `json:"distanceMeters,omitempty"`
+	RestSeconds     int     `json:"restSeconds"`
+	Notes           string  `json:"notes"`
+}
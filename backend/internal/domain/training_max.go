@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// TrainingMax is the weight an athlete bases percentage prescriptions on
+// for one exercise, typically a little below their one-rep max.
+type TrainingMax struct {
+	UserID     string    `json:"-"`
+	ExerciseID string    `json:"exerciseId"`
+	Weight     float64   `json:"weight"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
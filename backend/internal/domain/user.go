@@ -10,9 +10,22 @@ const (
 )
 
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Role         Role      `json:"role"`
-	CreatedAt    time.Time `json:"createdAt"`
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	Role         Role   `json:"role"`
+	Locale       string `json:"locale,omitempty"`
+	// Loading is the equipment prescribed loads are rounded to. Nil means
+	// a standard 20 kg bar with metric plates.
+	Loading   *LoadingSettings `json:"loading,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// LoadingSettings describe what an athlete can put on the bar: a bar with
+// pairs of the listed plate sizes or, without plates, steps of Increment as
+// on dumbbell racks and machines. All weights are in kilograms.
+type LoadingSettings struct {
+	BarWeight float64   `json:"barWeight"`
+	Plates    []float64 `json:"plates"`
+	Increment float64   `json:"increment"`
 }
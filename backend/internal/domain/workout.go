@@ -2,23 +2,125 @@ package domain
 
 import "time"
 
+// WorkoutSession is a logged workout. DeletedAt is set while the session is
+// in the trash, from where it can be restored until it is purged.
 type WorkoutSession struct {
-	ID          string         `json:"id"`
-	UserID      string         `json:"userId"`
-	StartedAt   time.Time      `json:"startedAt"`
-	CompletedAt time.Time      `json:"completedAt"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	Entries     []WorkoutEntry `json:"entries"`
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt time.Time  `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	// PlannedSessionID links the session to the program workout it
+	// completes, if any.
+	PlannedSessionID string         `json:"plannedSessionId,omitempty"`
+	Entries          []WorkoutEntry `json:"entries"`
+	// Records lists the personal records the session holds. It is filled
+	// in when a single session is saved or fetched, not stored with it.
+	Records []PersonalRecord `json:"records,omitempty"`
 }
 
+// WorkoutEntry records one exercise within a session. Which of the numeric
+// fields are meaningful depends on the exercise's MeasurementType. For
+// assisted exercises Weight is the assistance, not the load.
+//
+// SetDetails holds the individual sets in the order they were performed.
+// Sets, Reps and Weight summarize them for older clients: the number of
+// working sets and the heaviest of them.
 type WorkoutEntry struct {
-	ID              string    `json:"id"`
-	SessionID       string    `json:"sessionId"`
-	ExerciseID      string    `json:"exerciseId"`
-	Sets            int       `json:"sets"`
-	Reps            int       `json:"reps"`
-	Weight          float64   `json:"weight"`
-	DurationSeconds int       `json:"durationSeconds"`
-	Notes           string    `json:"notes"`
-	CreatedAt       time.Time `json:"createdAt"`
+	ID              string       `json:"id"`
+	SessionID       string       `json:"sessionId"`
+	ExerciseID      string       `json:"exerciseId"`
+	Sets            int          `json:"sets"`
+	Reps            int          `json:"reps"`
+	Weight          float64      `json:"weight"`
+	DurationSeconds int          `json:"durationSeconds"`
+	DistanceMeters  float64      `json:"distanceMeters"`
+	Side            Side         `json:"side,omitempty"`
+	Notes           string       `json:"notes"`
+	SetDetails      []WorkoutSet `json:"setDetails"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	UpdatedAt       time.Time    `json:"updatedAt"`
+}
+
+// WorkingSets returns the sets that count towards totals, i.e. completed
+// sets that are not warm-ups. Entries without set details are treated as
+// Sets identical sets.
+func (e WorkoutEntry) WorkingSets() []WorkoutSet {
+	if len(e.SetDetails) == 0 {
+		sets := make([]WorkoutSet, e.Sets)
+		for i := range sets {
+			sets[i] = WorkoutSet{Type: SetWorking, Reps: e.Reps, Weight: e.Weight, Completed: true}
+		}
+		return sets
+	}
+	working := make([]WorkoutSet, 0, len(e.SetDetails))
+	for _, set := range e.SetDetails {
+		if set.Completed && set.Type != SetWarmup {
+			working = append(working, set)
+		}
+	}
+	return working
+}
+
+// SetType classifies a set within an entry.
+type SetType string
+
+const (
+	SetWarmup  SetType = "warmup"
+	SetWorking SetType = "working"
+	SetDrop    SetType = "drop"
+	SetFailure SetType = "failure"
+)
+
+var SetTypes = []SetType{SetWarmup, SetWorking, SetDrop, SetFailure}
+
+func (t SetType) Valid() bool {
+	for _, known := range SetTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkoutSet is one set of an entry. RPE (rate of perceived exertion, 1-10)
+// and RIR (reps in reserve) are optional effort ratings. Tempo is written as
+// eccentric, bottom pause, concentric and top pause, e.g. "3-1-X-0".
+// CompletedAt is when the set was logged and RestSeconds the rest since the
+// set before it in the workout; live workouts time them on the server.
+type WorkoutSet struct {
+	Type            SetType    `json:"type"`
+	Reps            int        `json:"reps"`
+	Weight          float64    `json:"weight"`
+	DurationSeconds int        `json:"durationSeconds,omitempty"`
+	DistanceMeters  float64    `json:"distanceMeters,omitempty"`
+	RPE             *float64   `json:"rpe,omitempty"`
+	RIR             *int       `json:"rir,omitempty"`
+	Tempo           string     `json:"tempo,omitempty"`
+	Completed       bool       `json:"completed"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	RestSeconds     *int       `json:"restSeconds,omitempty"`
+}
+
+// Side says which limb a unilateral entry was performed with.
+type Side string
+
+const (
+	SideLeft  Side = "left"
+	SideRight Side = "right"
+	SideBoth  Side = "both"
+)
+
+// WorkoutSubmission remembers a workout created under an idempotency key,
+// together with the response sent, so a retried request can be answered
+// without creating the session again.
+type WorkoutSubmission struct {
+	UserID      string
+	Key         string
+	RequestHash string
+	SessionID   string
+	Response    []byte
+	CreatedAt   time.Time
 }
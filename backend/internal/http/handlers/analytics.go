@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+type AnalyticsHandler struct {
+	analytics *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(svc *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analytics: svc}
+}
+
+// Volume reports per-exercise volume. Query parameters: from and to (dates or
+// RFC 3339 timestamps) and rollup=variations.
+func (h *AnalyticsHandler) Volume(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := services.VolumeQuery{RollupVariations: r.URL.Query().Get("rollup") == "variations"}
+	var err error
+	if query.From, err = parseTimeParam(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.To, err = parseTimeParam(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	report, err := h.analytics.Volume(ctx.UserID, query)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// seriesInput reads the query parameters shared by the time series: from and
+// to (dates or RFC 3339 timestamps), bucket and exerciseId.
+func seriesInput(r *http.Request) (services.SeriesInput, error) {
+	input := services.SeriesInput{
+		Bucket:     domain.Bucket(r.URL.Query().Get("bucket")),
+		ExerciseID: r.URL.Query().Get("exerciseId"),
+	}
+	var err error
+	if input.From, err = parseTimeParam(r, "from"); err != nil {
+		return input, err
+	}
+	input.To, err = parseTimeParam(r, "to")
+	return input, err
+}
+
+// Workload reports sets, reps and tonnage per bucket.
+func (h *AnalyticsHandler) Workload(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	input, err := seriesInput(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	series, err := h.analytics.Workload(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, series)
+}
+
+// MuscleGroups reports working sets per muscle group and bucket.
+func (h *AnalyticsHandler) MuscleGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	input, err := seriesInput(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	series, err := h.analytics.MuscleGroupSets(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, series)
+}
+
+// OneRepMax reports the estimated one-rep max trend of the exercise given as
+// exerciseId.
+func (h *AnalyticsHandler) OneRepMax(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	input, err := seriesInput(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	series, err := h.analytics.OneRepMax(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, series)
+}
+
+// Frequency reports workouts and training days per bucket.
+func (h *AnalyticsHandler) Frequency(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	input, err := seriesInput(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	series, err := h.analytics.Frequency(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, series)
+}
+
+// parseTimeParam reads an optional query parameter as a date (YYYY-MM-DD,
+// midnight UTC) or an RFC 3339 timestamp.
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: use YYYY-MM-DD or RFC 3339", name)
+}
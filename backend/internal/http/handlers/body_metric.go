@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+type BodyMetricHandler struct {
+	metrics *services.BodyMetricService
+}
+
+func NewBodyMetricHandler(svc *services.BodyMetricService) *BodyMetricHandler {
+	return &BodyMetricHandler{metrics: svc}
+}
+
+// unitsParam reads the weightUnit and lengthUnit query parameters that
+// responses are converted to, defaulting to kilograms and centimeters.
+func unitsParam(r *http.Request) domain.BodyUnits {
+	units := domain.MetricUnits
+	if unit := r.URL.Query().Get("weightUnit"); unit != "" {
+		units.Weight = domain.WeightUnit(unit)
+	}
+	if unit := r.URL.Query().Get("lengthUnit"); unit != "" {
+		units.Length = domain.LengthUnit(unit)
+	}
+	return units
+}
+
+// Create records body measurements. Like workouts, it is idempotent with an
+// Idempotency-Key header or a client id.
+func (h *BodyMetricHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.BodyMetricInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	input.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	metric, replayed, err := h.metrics.Create(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+		writeJSON(w, http.StatusOK, metric)
+		return
+	}
+	writeJSON(w, http.StatusCreated, metric)
+}
+
+// List returns the user's measurements, most recent first. Query parameters:
+// from and to (dates or RFC 3339 timestamps), weightUnit and lengthUnit.
+func (h *BodyMetricHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	metrics, err := h.metrics.List(ctx.UserID, from, to, unitsParam(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, metrics)
+}
+
+func (h *BodyMetricHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	metric, err := h.metrics.Get(ctx.UserID, chi.URLParam(r, "id"), unitsParam(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, metric)
+}
+
+func (h *BodyMetricHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.BodyMetricInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	metric, err := h.metrics.Update(ctx.UserID, chi.URLParam(r, "id"), input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, metric)
+}
+
+func (h *BodyMetricHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.metrics.Delete(ctx.UserID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// Trend reports a body metric with its moving average. Query parameters:
+// metric (bodyweight, bodyFat or circumference), site for circumferences,
+// window in days, from, to, weightUnit and lengthUnit.
+func (h *BodyMetricHandler) Trend(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	input := services.BodyTrendInput{
+		Metric: r.URL.Query().Get("metric"),
+		Site:   domain.CircumferenceSite(r.URL.Query().Get("site")),
+		Units:  unitsParam(r),
+	}
+	var err error
+	if window := r.URL.Query().Get("window"); window != "" {
+		if input.WindowDays, err = strconv.Atoi(window); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid window"))
+			return
+		}
+	}
+	if input.From, err = parseTimeParam(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if input.To, err = parseTimeParam(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	trend, err := h.metrics.Trend(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, trend)
+}
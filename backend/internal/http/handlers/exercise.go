@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
@@ -20,12 +22,45 @@ func NewExerciseHandler(svc *services.ExerciseService) *ExerciseHandler {
 }
 
 func (h *ExerciseHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("since") {
+		h.changes(w, r)
+		return
+	}
+	if query := r.URL.Query().Get("q"); query != "" {
+		h.search(w, r, query)
+		return
+	}
 	exercises, err := h.exercises.List()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, exercises)
+	h.writeLocalized(w, r, exercises)
+}
+
+// changes serves the incremental feed used by the offline exercise cache.
+func (h *ExerciseHandler) changes(w http.ResponseWriter, r *http.Request) {
+	locale := h.locale(w, r)
+	feed, err := h.exercises.Changes(r.URL.Query().Get("since"), locale)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	feed.Exercises, err = h.exercises.Localize(feed.Exercises, locale)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, feed)
+}
+
+func (h *ExerciseHandler) search(w http.ResponseWriter, r *http.Request, query string) {
+	exercises, err := h.exercises.Search(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.writeLocalized(w, r, exercises)
 }
 
 func (h *ExerciseHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -39,9 +74,9 @@ func (h *ExerciseHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
-	ex, err := h.exercises.Create(input)
+	ex, err := h.exercises.Create(ctx.UserID, input)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeServiceError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, ex)
@@ -53,15 +88,15 @@ func (h *ExerciseHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "forbidden", http.StatusForbidden)
 		return
 	}
-	var input services.ExerciseInput
+	var input services.ExerciseUpdateInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 	input.ID = chi.URLParam(r, "id")
-	ex, err := h.exercises.Update(input)
+	ex, err := h.exercises.Update(ctx.UserID, input)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeServiceError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, ex)
@@ -80,3 +115,100 @@ func (h *ExerciseHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusNoContent, nil)
 }
+
+func (h *ExerciseHandler) History(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.exercises.History(chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+func (h *ExerciseHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil || ctx.Role != domain.RoleAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || number < 1 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid revision number"))
+		return
+	}
+	ex, err := h.exercises.Revert(ctx.UserID, chi.URLParam(r, "id"), number)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ex)
+}
+
+func (h *ExerciseHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	ex, err := h.exercises.Archive(chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ex)
+}
+
+func (h *ExerciseHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	ex, err := h.exercises.Unarchive(chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ex)
+}
+
+type aliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+func (h *ExerciseHandler) AddAlias(w http.ResponseWriter, r *http.Request) {
+	var req aliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ex, err := h.exercises.AddAlias(chi.URLParam(r, "id"), req.Alias)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ex)
+}
+
+func (h *ExerciseHandler) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	ex, err := h.exercises.RemoveAlias(chi.URLParam(r, "id"), chi.URLParam(r, "alias"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ex)
+}
+
+type mergeRequest struct {
+	DuplicateID string `json:"duplicateId"`
+}
+
+// Merge folds the exercise named in the body into {id}.
+func (h *ExerciseHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil || ctx.Role != domain.RoleAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	var req mergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.exercises.Merge(ctx.UserID, chi.URLParam(r, "id"), req.DuplicateID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/services"
+)
+
+type pinnedAlternativesRequest struct {
+	ExerciseIDs []string `json:"exerciseIds"`
+}
+
+type pinnedAlternativesResponse struct {
+	ExerciseIDs []string `json:"exerciseIds"`
+}
+
+// Alternatives lists substitutes for an exercise. The optional equipment
+// query parameter is a comma-separated list of what the athlete has access to.
+func (h *ExerciseHandler) Alternatives(w http.ResponseWriter, r *http.Request) {
+	query := services.AlternativesQuery{}
+	if equipment := r.URL.Query().Get("equipment"); equipment != "" {
+		query.Equipment = strings.Split(equipment, ",")
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+		query.Limit = n
+	}
+	alternatives, err := h.exercises.Alternatives(chi.URLParam(r, "id"), query)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	exercises := make([]domain.Exercise, len(alternatives))
+	for i, alt := range alternatives {
+		exercises[i] = alt.Exercise
+	}
+	if exercises, err = h.exercises.Localize(exercises, h.locale(w, r)); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range alternatives {
+		alternatives[i].Exercise = exercises[i]
+	}
+	writeJSON(w, http.StatusOK, alternatives)
+}
+
+func (h *ExerciseHandler) PinAlternatives(w http.ResponseWriter, r *http.Request) {
+	var req pinnedAlternativesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ids, err := h.exercises.PinAlternatives(chi.URLParam(r, "id"), req.ExerciseIDs)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pinnedAlternativesResponse{ExerciseIDs: ids})
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+)
+
+// CatalogueReport returns what seeding the exercise catalogue changed when
+// the server started.
+func (h *ExerciseHandler) CatalogueReport(w http.ResponseWriter, r *http.Request) {
+	report := h.exercises.SeedReport()
+	if report == nil {
+		writeError(w, http.StatusNotFound, errors.New("exercise catalogue has not been seeded"))
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/services"
+)
+
+func (h *ExerciseHandler) AddRelation(w http.ResponseWriter, r *http.Request) {
+	var input services.RelationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	relation, err := h.exercises.AddRelation(chi.URLParam(r, "id"), input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, relation)
+}
+
+func (h *ExerciseHandler) RemoveRelation(w http.ResponseWriter, r *http.Request) {
+	if err := h.exercises.RemoveRelation(chi.URLParam(r, "id"), chi.URLParam(r, "relationID")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// Graph returns the exercises related to {id}, up to the optional depth.
+func (h *ExerciseHandler) Graph(w http.ResponseWriter, r *http.Request) {
+	depth := 0
+	if value := r.URL.Query().Get("depth"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid depth"))
+			return
+		}
+		depth = n
+	}
+	graph, err := h.exercises.Graph(chi.URLParam(r, "id"), depth)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, graph)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+// locale negotiates the content language for r and advertises it in the
+// response headers so caches keep one copy per language.
+func (h *ExerciseHandler) locale(w http.ResponseWriter, r *http.Request) string {
+	preferred := ""
+	if ctx := middleware.GetAuthContext(r); ctx != nil {
+		preferred = h.exercises.PreferredLocale(ctx.UserID)
+	}
+	locale := services.NegotiateLocale(r.URL.Query().Get("lang"), preferred, r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
+}
+
+func (h *ExerciseHandler) writeLocalized(w http.ResponseWriter, r *http.Request, exercises []domain.Exercise) {
+	localized, err := h.exercises.Localize(exercises, h.locale(w, r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, localized)
+}
+
+func (h *ExerciseHandler) Translations(w http.ResponseWriter, r *http.Request) {
+	translations, err := h.exercises.Translations(chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, translations)
+}
+
+func (h *ExerciseHandler) SetTranslation(w http.ResponseWriter, r *http.Request) {
+	var input services.TranslationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	translation, err := h.exercises.SetTranslation(chi.URLParam(r, "id"), chi.URLParam(r, "locale"), input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, translation)
+}
+
+func (h *ExerciseHandler) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	if err := h.exercises.DeleteTranslation(chi.URLParam(r, "id"), chi.URLParam(r, "locale")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *ExerciseHandler) TranslationCoverage(w http.ResponseWriter, r *http.Request) {
+	coverage, err := h.exercises.TranslationCoverage()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, coverage)
+}
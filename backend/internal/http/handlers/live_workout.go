@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+type LiveWorkoutHandler struct {
+	live *services.LiveWorkoutService
+}
+
+func NewLiveWorkoutHandler(svc *services.LiveWorkoutService) *LiveWorkoutHandler {
+	return &LiveWorkoutHandler{live: svc}
+}
+
+// Start begins a live workout. The body is optional.
+func (h *LiveWorkoutHandler) Start(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.LiveStartInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	live, err := h.live.Start(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, live)
+}
+
+// Current returns the live workout in progress, for resuming it.
+func (h *LiveWorkoutHandler) Current(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	live, err := h.live.Current(ctx.UserID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, live)
+}
+
+func (h *LiveWorkoutHandler) AddEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.LiveEntryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	live, err := h.live.AddEntry(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, live)
+}
+
+func (h *LiveWorkoutHandler) RemoveEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	live, err := h.live.RemoveEntry(ctx.UserID, chi.URLParam(r, "entryID"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, live)
+}
+
+func (h *LiveWorkoutHandler) AddSet(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.WorkoutSetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	live, err := h.live.AddSet(ctx.UserID, chi.URLParam(r, "entryID"), input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, live)
+}
+
+func (h *LiveWorkoutHandler) UpdateSet(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	index, err := setIndexParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var input services.WorkoutSetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	live, err := h.live.UpdateSet(ctx.UserID, chi.URLParam(r, "entryID"), index, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, live)
+}
+
+func (h *LiveWorkoutHandler) RemoveSet(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	index, err := setIndexParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	live, err := h.live.RemoveSet(ctx.UserID, chi.URLParam(r, "entryID"), index)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, live)
+}
+
+// setIndexParam reads the zero-based position of a set in its entry.
+func setIndexParam(r *http.Request) (int, error) {
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil {
+		return 0, errors.New("invalid set index")
+	}
+	return index, nil
+}
+
+// Finish saves the live workout to the history.
+func (h *LiveWorkoutHandler) Finish(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	session, err := h.live.Finish(ctx.UserID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, session)
+}
+
+func (h *LiveWorkoutHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.live.Discard(ctx.UserID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/services"
+	"github.com/musclementour/app/internal/storage/blob"
+)
+
+// multipartMemory is how much of an upload is buffered in memory before the
+// rest spills to a temporary file.
+const multipartMemory = 8 << 20
+
+func (h *ExerciseHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.exercises.MaxUploadBytes())
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, services.ErrMediaTooLarge)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("multipart field \"file\" is required"))
+		return
+	}
+	defer file.Close()
+
+	item, err := h.exercises.AddMedia(chi.URLParam(r, "id"), file, header.Size)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (h *ExerciseHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	if err := h.exercises.DeleteMedia(chi.URLParam(r, "id"), chi.URLParam(r, "mediaID")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// ServeMedia streams stored media. Keys embed a fresh id per upload, so the
+// response can be cached forever by browsers and the service worker.
+func (h *ExerciseHandler) ServeMedia(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	rc, err := h.exercises.OpenMedia(key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer rc.Close()
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if rs, ok := rc.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, path.Base(key), time.Time{}, rs)
+		return
+	}
+	_, _ = io.Copy(w, rc)
+}
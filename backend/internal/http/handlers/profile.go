@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/musclementour/app/internal/domain"
 	"github.com/musclementour/app/internal/http/middleware"
 	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/services"
 )
 
 type ProfileHandler struct {
@@ -28,3 +32,55 @@ func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, user)
 }
+
+type profileUpdateRequest struct {
+	Locale  *string         `json:"locale"`
+	Loading json.RawMessage `json:"loading"`
+}
+
+// UpdateProfile changes user preferences: the content locale, where an
+// empty string clears it, and the loading equipment, where null clears it.
+// Omitted preferences are left alone.
+func (h *ProfileHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req profileUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Locale != nil {
+		if *req.Locale != "" && !domain.SupportedLocale(*req.Locale) {
+			writeError(w, http.StatusBadRequest, errors.New("unsupported locale"))
+			return
+		}
+		if err := h.repo.Users.SetLocale(ctx.UserID, *req.Locale); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if len(req.Loading) > 0 {
+		var loading *domain.LoadingSettings
+		if err := json.Unmarshal(req.Loading, &loading); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := services.CheckLoading(loading); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		if err := h.repo.Users.SetLoading(ctx.UserID, loading); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	user, err := h.repo.Users.GetByID(ctx.UserID)
+	if err != nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
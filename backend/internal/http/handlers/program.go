@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+type ProgramHandler struct {
+	programs *services.ProgramService
+}
+
+func NewProgramHandler(svc *services.ProgramService) *ProgramHandler {
+	return &ProgramHandler{programs: svc}
+}
+
+func (h *ProgramHandler) List(w http.ResponseWriter, r *http.Request) {
+	programs, err := h.programs.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, programs)
+}
+
+func (h *ProgramHandler) Get(w http.ResponseWriter, r *http.Request) {
+	program, err := h.programs.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, program)
+}
+
+func (h *ProgramHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.ProgramInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	program, err := h.programs.Create(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, program)
+}
+
+func (h *ProgramHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var input services.ProgramInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	program, err := h.programs.Update(chi.URLParam(r, "id"), input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, program)
+}
+
+func (h *ProgramHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.programs.Delete(chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// Enroll starts the caller on a program. The body is optional.
+func (h *ProgramHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var input services.EnrollmentInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	enrollment, err := h.programs.Enroll(ctx.UserID, chi.URLParam(r, "id"), input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, enrollment)
+}
+
+func (h *ProgramHandler) Enrollments(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	enrollments, err := h.programs.Enrollments(ctx.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, enrollments)
+}
+
+func (h *ProgramHandler) Enrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	enrollment, err := h.programs.Enrollment(ctx.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+// Leave ends an enrollment.
+func (h *ProgramHandler) Leave(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.programs.Leave(ctx.UserID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// Schedule returns today's and upcoming planned sessions. The optional date
+// parameter lets clients ask for their local date, and days how far ahead
+// to look.
+func (h *ProgramHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := services.ScheduleQuery{Date: r.URL.Query().Get("date")}
+	if days := r.URL.Query().Get("days"); days != "" {
+		var err error
+		if query.Days, err = strconv.Atoi(days); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid days"))
+			return
+		}
+	}
+	schedule, err := h.programs.Schedule(ctx.UserID, query)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, schedule)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+type RecordHandler struct {
+	records *services.RecordService
+}
+
+func NewRecordHandler(svc *services.RecordService) *RecordHandler {
+	return &RecordHandler{records: svc}
+}
+
+// List returns the caller's personal records, optionally only those on the
+// exercise given as exerciseId.
+func (h *RecordHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	held, err := h.records.List(ctx.UserID, r.URL.Query().Get("exerciseId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, held)
+}
@@ -2,11 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/media"
+	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/services"
 )
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error      string                `json:"error"`
+	Fields     []services.FieldError `json:"fields,omitempty"`
+	Candidates []domain.Exercise     `json:"candidates,omitempty"`
 }
 
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -21,3 +29,38 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 func writeError(w http.ResponseWriter, status int, err error) {
 	writeJSON(w, status, ErrorResponse{Error: err.Error()})
 }
+
+// writeServiceError maps well-known service and repository errors to their
+// HTTP status. Anything else is a server failure.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var verr *services.ValidationError
+	var duplicate *services.DuplicateError
+	switch {
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: verr.Error(), Fields: verr.Fields})
+	case errors.Is(err, services.ErrNameRequired), errors.Is(err, services.ErrInvalidMeasurementType),
+		errors.Is(err, services.ErrInvalidMovementPattern), errors.Is(err, services.ErrInvalidCursor),
+		errors.Is(err, services.ErrInvalidAlias), errors.Is(err, services.ErrUnsupportedLocale),
+		errors.Is(err, services.ErrInvalidRelationType), errors.Is(err, services.ErrSelfRelation),
+		errors.Is(err, services.ErrSelfMerge), errors.Is(err, services.ErrInvalidImage),
+		errors.Is(err, services.ErrBatchEmpty):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.As(err, &duplicate):
+		writeJSON(w, http.StatusConflict, ErrorResponse{Error: duplicate.Error(), Candidates: duplicate.Candidates})
+	case errors.Is(err, repository.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, repository.ErrConflict), errors.Is(err, services.ErrRevisionConflict),
+		errors.Is(err, services.ErrRelationCycle), errors.Is(err, services.ErrIdempotencyConflict),
+		errors.Is(err, services.ErrMeasurementTypeInUse),
+		errors.Is(err, services.ErrIncompatibleMerge):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, services.ErrMediaTooLarge), errors.Is(err, services.ErrBatchTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, services.ErrTrashExpired):
+		writeError(w, http.StatusGone, err)
+	case errors.Is(err, media.ErrUnsupportedType):
+		writeError(w, http.StatusUnsupportedMediaType, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/musclementour/app/internal/http/middleware"
+	"github.com/musclementour/app/internal/services"
+)
+
+type TrainingMaxHandler struct {
+	trainingMaxes *services.TrainingMaxService
+}
+
+func NewTrainingMaxHandler(svc *services.TrainingMaxService) *TrainingMaxHandler {
+	return &TrainingMaxHandler{trainingMaxes: svc}
+}
+
+func (h *TrainingMaxHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	maxes, err := h.trainingMaxes.List(ctx.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, maxes)
+}
+
+type trainingMaxRequest struct {
+	Weight float64 `json:"weight"`
+}
+
+// Set creates or replaces the caller's training max for an exercise.
+func (h *TrainingMaxHandler) Set(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req trainingMaxRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	trainingMax, err := h.trainingMaxes.Set(ctx.UserID, chi.URLParam(r, "exerciseID"), req.Weight)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, trainingMax)
+}
+
+func (h *TrainingMaxHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.trainingMaxes.Delete(ctx.UserID, chi.URLParam(r, "exerciseID")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/musclementour/app/internal/http/middleware"
 	"github.com/musclementour/app/internal/services"
 )
@@ -18,9 +22,75 @@ func NewWorkoutHandler(svc *services.WorkoutService) *WorkoutHandler {
 }
 
 type workoutRequest struct {
-	StartedAt   *time.Time                   `json:"startedAt"`
-	CompletedAt *time.Time                   `json:"completedAt"`
-	Entries     []services.WorkoutEntryInput `json:"entries"`
+	ID               string                       `json:"id"`
+	StartedAt        *time.Time                   `json:"startedAt"`
+	CompletedAt      *time.Time                   `json:"completedAt"`
+	Entries          []services.WorkoutEntryInput `json:"entries"`
+	PlannedSessionID string                       `json:"plannedSessionId"`
+}
+
+func (req workoutRequest) input() services.WorkoutSessionInput {
+	input := services.WorkoutSessionInput{ID: req.ID, Entries: req.Entries, PlannedSessionID: req.PlannedSessionID}
+	if req.StartedAt != nil {
+		input.StartedAt = *req.StartedAt
+	}
+	if req.CompletedAt != nil {
+		input.CompletedAt = *req.CompletedAt
+	}
+	return input
+}
+
+// batchItemRequest is a workout within a batch. IdempotencyKey plays the
+// role of the Idempotency-Key header of single submissions.
+type batchItemRequest struct {
+	workoutRequest
+	IdempotencyKey string `json:"idempotencyKey"`
+}
+
+type batchRequest struct {
+	Workouts []json.RawMessage `json:"workouts"`
+}
+
+type batchResponse struct {
+	Results []services.BatchResult `json:"results"`
+}
+
+// CreateBatch stores several workouts in one request. Items are decoded one
+// by one so a malformed workout only fails itself.
+func (h *WorkoutHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.workouts.MaxBatchBytes())
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, services.ErrBatchTooLarge)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	items := make([]services.BatchItem, 0, len(req.Workouts))
+	for _, raw := range req.Workouts {
+		var itemReq batchItemRequest
+		if err := json.Unmarshal(raw, &itemReq); err != nil {
+			items = append(items, services.BatchItem{DecodeError: err})
+			continue
+		}
+		input := itemReq.input()
+		input.IdempotencyKey = itemReq.IdempotencyKey
+		items = append(items, services.BatchItem{Input: &input})
+	}
+	results, err := h.workouts.CreateBatch(ctx.UserID, items)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, batchResponse{Results: results})
 }
 
 func (h *WorkoutHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -34,28 +104,167 @@ func (h *WorkoutHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
-	input := services.WorkoutSessionInput{Entries: req.Entries}
-	if req.StartedAt != nil {
-		input.StartedAt = *req.StartedAt
+	input := req.input()
+	input.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	session, replayed, err := h.workouts.Create(ctx.UserID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
 	}
-	if req.CompletedAt != nil {
-		input.CompletedAt = *req.CompletedAt
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+		writeJSON(w, http.StatusOK, session)
+		return
 	}
-	session, err := h.workouts.Create(ctx.UserID, input)
+	writeJSON(w, http.StatusCreated, session)
+}
+
+// List returns a page of the user's history as an array. Query parameters:
+// from and to (dates or RFC 3339 timestamps), exerciseId, muscleGroup, q
+// (text in entry notes), limit, cursor and count=true. The cursor of the next
+// page is sent in X-Next-Cursor and the total count in X-Total-Count.
+func (h *WorkoutHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	params := r.URL.Query()
+	query := services.WorkoutListQuery{
+		ExerciseID:   params.Get("exerciseId"),
+		MuscleGroup:  params.Get("muscleGroup"),
+		Notes:        params.Get("q"),
+		Cursor:       params.Get("cursor"),
+		IncludeTotal: params.Get("count") == "true",
+	}
+	var err error
+	if query.From, err = parseTimeParam(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.To, err = parseTimeParam(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+	}
+	page, err := h.workouts.List(ctx.UserID, query)
 	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	if page.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(*page.Total))
+	}
+	writeJSON(w, http.StatusOK, page.Sessions)
+}
+
+func (h *WorkoutHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	session, err := h.workouts.Get(ctx.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+func (h *WorkoutHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req workoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, session)
+	session, err := h.workouts.Replace(ctx.UserID, chi.URLParam(r, "id"), req.input())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
 }
 
-func (h *WorkoutHandler) List(w http.ResponseWriter, r *http.Request) {
+type workoutPatchRequest struct {
+	StartedAt     *time.Time                   `json:"startedAt"`
+	CompletedAt   *time.Time                   `json:"completedAt"`
+	Entries       []services.WorkoutEntryInput `json:"entries"`
+	RemoveEntries []string                     `json:"removeEntries"`
+}
+
+func (h *WorkoutHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req workoutPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	session, err := h.workouts.Patch(ctx.UserID, chi.URLParam(r, "id"), services.WorkoutSessionPatch{
+		StartedAt:     req.StartedAt,
+		CompletedAt:   req.CompletedAt,
+		Entries:       req.Entries,
+		RemoveEntries: req.RemoveEntries,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+// Delete moves a session to the trash; see Restore.
+func (h *WorkoutHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.workouts.Delete(ctx.UserID, chi.URLParam(r, "id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (h *WorkoutHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := middleware.GetAuthContext(r)
+	if ctx == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	session, err := h.workouts.Restore(ctx.UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+func (h *WorkoutHandler) Trash(w http.ResponseWriter, r *http.Request) {
 	ctx := middleware.GetAuthContext(r)
 	if ctx == nil {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
-	sessions, err := h.workouts.List(ctx.UserID)
+	sessions, err := h.workouts.Trash(ctx.UserID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
@@ -49,6 +49,25 @@ func WithAuth(cfg *config.Config) func(http.Handler) http.Handler {
 	}
 }
 
+// OptionalAuth attaches the auth context when a valid bearer token is sent
+// and lets anonymous requests through unchanged, for endpoints that are
+// public but personalise their response for signed-in users.
+func OptionalAuth(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+			if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+				if claims, err := auth.ParseToken(parts[1], cfg.AccessTokenSecret); err == nil {
+					ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+					ctx = context.WithValue(ctx, roleKey, domain.Role(claims.Role))
+					r = r.WithContext(ctx)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func RequireRole(role domain.Role) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,116 @@
+// Package media validates uploaded exercise media and generates thumbnails.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+type Kind string
+
+const (
+	KindImage Kind = "image"
+	KindVideo Kind = "video"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrTooManyPixels   = errors.New("image has too many pixels")
+)
+
+// contentTypes maps sniffed MIME types to their kind and file extension.
+var contentTypes = map[string]struct {
+	kind Kind
+	ext  string
+}{
+	"image/jpeg": {KindImage, ".jpg"},
+	"image/png":  {KindImage, ".png"},
+	"image/gif":  {KindImage, ".gif"},
+	"video/mp4":  {KindVideo, ".mp4"},
+	"video/webm": {KindVideo, ".webm"},
+}
+
+// Classify returns the kind and file extension for a sniffed content type.
+func Classify(contentType string) (Kind, string, error) {
+	info, ok := contentTypes[contentType]
+	if !ok {
+		return "", "", ErrUnsupportedType
+	}
+	return info.kind, info.ext, nil
+}
+
+// ThumbnailSize is the longest edge of generated thumbnails in pixels.
+const ThumbnailSize = 320
+
+// MaxPixels bounds the images Thumbnail decodes. A few kilobytes of PNG can
+// declare dimensions that take gigabytes to decode.
+const MaxPixels = 50_000_000
+
+// Thumbnail decodes an image and returns a JPEG that fits in a
+// ThumbnailSize square. Images that are already small are only re-encoded.
+// The header is checked first so oversized images are never decoded.
+func Thumbnail(r io.ReadSeeker) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(src, ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown shrinks src with a box filter so its longest edge is at most
+// maxSide, keeping the aspect ratio.
+func scaleDown(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	dw, dh := maxSide, h*maxSide/w
+	if h > w {
+		dw, dh = w*maxSide/h, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + y*h/dh
+		y1 := max(b.Min.Y+(y+1)*h/dh, y0+1)
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + x*w/dw
+			x1 := max(b.Min.X+(x+1)*w/dw, x0+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
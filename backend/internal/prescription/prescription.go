@@ -0,0 +1,129 @@
+// Package prescription turns load prescriptions such as "3×5 @ 75% of the
+// training max, +2.5 kg a week" into weights an athlete can actually load.
+// It has no dependencies on storage or HTTP.
+package prescription
+
+import (
+	"math"
+	"slices"
+)
+
+// Rule prescribes the load of one exercise. A positive Percent takes that
+// share of the training max; otherwise Weight is the load. WeeklyIncrement
+// is added once for every week after the first.
+type Rule struct {
+	Weight          float64
+	Percent         float64
+	WeeklyIncrement float64
+}
+
+// Load returns the unrounded load for week, counted from 1. It reports false
+// when the rule is percentage based and no training max is known.
+func (r Rule) Load(trainingMax float64, week int) (float64, bool) {
+	base := r.Weight
+	if r.Percent > 0 {
+		if trainingMax <= 0 {
+			return 0, false
+		}
+		base = trainingMax * r.Percent / 100
+	}
+	return base + float64(max(week-1, 0))*r.WeeklyIncrement, true
+}
+
+// Equipment describes what an athlete can load. With Plates, loads are the
+// bar plus pairs of plates, one of each pair per side, in any number of
+// each size. Without plates, loads are multiples of Increment. Loads are
+// left alone when neither is set.
+type Equipment struct {
+	BarWeight float64
+	Plates    []float64
+	Increment float64
+}
+
+// Standard is a 20 kg barbell with the usual metric plates.
+var Standard = Equipment{
+	BarWeight: 20,
+	Plates:    []float64{25, 20, 15, 10, 5, 2.5, 1.25},
+}
+
+// unitsPerKg sets the precision of the rounding arithmetic to 10 grams.
+const unitsPerKg = 100
+
+// Round returns the loadable weight closest to load, preferring the lighter
+// one on ties. Loads below the empty bar round up to the bar.
+func (e Equipment) Round(load float64) float64 {
+	switch {
+	case len(e.Plates) > 0:
+		return e.roundToPlates(load)
+	case e.Increment > 0:
+		step := units(e.Increment)
+		lower := units(load) / step * step
+		return fromUnits(nearest(units(load), lower, lower+step))
+	default:
+		return load
+	}
+}
+
+func (e Equipment) roundToPlates(load float64) float64 {
+	if load <= e.BarWeight {
+		return e.BarWeight
+	}
+	// Plates go on in pairs, so each size adds twice its weight.
+	pairs := make([]int, 0, len(e.Plates))
+	for _, plate := range e.Plates {
+		if p := 2 * units(plate); p > 0 {
+			pairs = append(pairs, p)
+		}
+	}
+	if len(pairs) == 0 {
+		return e.BarWeight
+	}
+	target := units(load - e.BarWeight)
+	limit := target + slices.Max(pairs)
+	reachable := make([]bool, limit+1)
+	reachable[0] = true
+	for total := 1; total <= limit; total++ {
+		for _, p := range pairs {
+			if p <= total && reachable[total-p] {
+				reachable[total] = true
+				break
+			}
+		}
+	}
+	lower := target
+	for !reachable[lower] {
+		lower--
+	}
+	upper := target
+	for !reachable[upper] {
+		upper++
+	}
+	return e.BarWeight + fromUnits(nearest(target, lower, upper))
+}
+
+// Prescribe applies rule for week and rounds the load to what equipment
+// allows. It reports false when a training max is needed but missing.
+func Prescribe(rule Rule, trainingMax float64, week int, equipment Equipment) (float64, bool) {
+	load, ok := rule.Load(trainingMax, week)
+	if !ok {
+		return 0, false
+	}
+	return equipment.Round(load), true
+}
+
+// nearest picks lower or upper, whichever is closer to value, and lower on
+// ties.
+func nearest(value, lower, upper int) int {
+	if upper-value < value-lower {
+		return upper
+	}
+	return lower
+}
+
+func units(kg float64) int {
+	return int(math.Round(kg * unitsPerKg))
+}
+
+func fromUnits(n int) float64 {
+	return float64(n) / unitsPerKg
+}
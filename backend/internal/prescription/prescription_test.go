@@ -0,0 +1,78 @@
+package prescription_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/prescription"
+)
+
+func TestRuleLoad(t *testing.T) {
+	cases := []struct {
+		name        string
+		rule        prescription.Rule
+		trainingMax float64
+		week        int
+		want        float64
+		ok          bool
+	}{
+		{name: "percentage of training max", rule: prescription.Rule{Percent: 75}, trainingMax: 140, week: 1, want: 105, ok: true},
+		{name: "weekly increment", rule: prescription.Rule{Percent: 75, WeeklyIncrement: 2.5}, trainingMax: 140, week: 3, want: 110, ok: true},
+		{name: "fixed weight", rule: prescription.Rule{Weight: 60, WeeklyIncrement: 5}, week: 2, want: 65, ok: true},
+		{name: "fixed weight ignores training max", rule: prescription.Rule{Weight: 60}, trainingMax: 200, week: 1, want: 60, ok: true},
+		{name: "missing training max", rule: prescription.Rule{Percent: 80}, week: 1, want: 0, ok: false},
+		{name: "week zero counts as the first", rule: prescription.Rule{Weight: 50, WeeklyIncrement: 5}, week: 0, want: 50, ok: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.rule.Load(tc.trainingMax, tc.week)
+			require.Equal(t, tc.ok, ok)
+			require.InDelta(t, tc.want, got, 1e-9)
+		})
+	}
+}
+
+func TestEquipmentRound(t *testing.T) {
+	oddPlates := prescription.Equipment{BarWeight: 20, Plates: []float64{10, 4}}
+	cases := []struct {
+		name      string
+		equipment prescription.Equipment
+		load      float64
+		want      float64
+	}{
+		{name: "already loadable", equipment: prescription.Standard, load: 102.5, want: 102.5},
+		{name: "rounds down to nearest", equipment: prescription.Standard, load: 103.7, want: 102.5},
+		{name: "rounds up to nearest", equipment: prescription.Standard, load: 104.1, want: 105},
+		{name: "ties round down", equipment: prescription.Standard, load: 103.75, want: 102.5},
+		{name: "below the bar", equipment: prescription.Standard, load: 12, want: 20},
+		{name: "heavy loads", equipment: prescription.Standard, load: 301.2, want: 300},
+		{name: "uneven plate sizes", equipment: oddPlates, load: 31, want: 28},
+		{name: "uneven plate sizes above", equipment: oddPlates, load: 33, want: 36},
+		{name: "uneven plate sizes tie", equipment: oddPlates, load: 32, want: 28},
+		{name: "light gym without small plates", equipment: prescription.Equipment{BarWeight: 15, Plates: []float64{5}}, load: 31, want: 35},
+		{name: "increments", equipment: prescription.Equipment{Increment: 2}, load: 23.1, want: 24},
+		{name: "increment ties round down", equipment: prescription.Equipment{Increment: 2.5}, load: 18.75, want: 17.5},
+		{name: "no equipment keeps the load", equipment: prescription.Equipment{}, load: 33.3, want: 33.3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.want, tc.equipment.Round(tc.load), 1e-9)
+		})
+	}
+}
+
+func TestPrescribe(t *testing.T) {
+	// 3×5 @ 75% of a 150 kg training max, +2.5 kg a week.
+	rule := prescription.Rule{Percent: 75, WeeklyIncrement: 2.5}
+	weeks := []float64{}
+	for week := 1; week <= 4; week++ {
+		load, ok := prescription.Prescribe(rule, 150, week, prescription.Standard)
+		require.True(t, ok)
+		weeks = append(weeks, load)
+	}
+	require.Equal(t, []float64{112.5, 115, 117.5, 120}, weeks)
+
+	_, ok := prescription.Prescribe(rule, 0, 1, prescription.Standard)
+	require.False(t, ok)
+}
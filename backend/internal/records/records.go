@@ -0,0 +1,192 @@
+// Package records works out an athlete's personal records on an exercise
+// from the sets they logged. It has no dependencies on storage or HTTP.
+package records
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/musclementour/app/internal/domain"
+)
+
+// Epley estimates a one-rep max as weight × (1 + reps / 30).
+func Epley(weight float64, reps int) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// Brzycki estimates a one-rep max as weight × 36 / (37 - reps). It is only
+// meaningful for low rep counts and breaks down as reps approach 37.
+func Brzycki(weight float64, reps int) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	return weight * 36 / (37 - float64(reps))
+}
+
+// brzyckiMaxReps is where EstimateOneRepMax switches formulas. Both give
+// the same estimate at ten reps, so the switch is seamless.
+const brzyckiMaxReps = 10
+
+// EstimateOneRepMax estimates a one-rep max with Brzycki's formula up to ten
+// reps, where it is the more accurate, and Epley's beyond. Sets without
+// weight or reps have no estimate.
+func EstimateOneRepMax(weight float64, reps int) float64 {
+	switch {
+	case weight <= 0 || reps <= 0:
+		return 0
+	case reps <= brzyckiMaxReps:
+		return Brzycki(weight, reps)
+	default:
+		return Epley(weight, reps)
+	}
+}
+
+// Performance is the working sets of one exercise logged in one session.
+type Performance struct {
+	SessionID string
+	At        time.Time
+	Sets      []domain.WorkoutSet
+}
+
+// Compute returns the records set across performances of an exercise
+// measured by mt. A record belongs to the earliest performance that reached
+// it; matching it later does not take it over. Records come back ordered by
+// type and, for most-reps records, weight. UserID and ExerciseID are left
+// for the caller to fill in.
+func Compute(mt domain.MeasurementType, performances []Performance) []domain.PersonalRecord {
+	ordered := make([]Performance, len(performances))
+	copy(ordered, performances)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if !a.At.Equal(b.At) {
+			return a.At.Before(b.At)
+		}
+		return a.SessionID < b.SessionID
+	})
+
+	best := map[domain.RecordType]*domain.PersonalRecord{}
+	mostReps := map[float64]*domain.PersonalRecord{}
+	improve := func(current *domain.PersonalRecord, candidate domain.PersonalRecord) *domain.PersonalRecord {
+		if candidate.Value <= 0 || (current != nil && candidate.Value <= current.Value) {
+			return current
+		}
+		return &candidate
+	}
+	for _, p := range ordered {
+		record := func(recordType domain.RecordType, value, weight float64, reps int) domain.PersonalRecord {
+			return domain.PersonalRecord{Type: recordType, Value: value, Weight: weight, Reps: reps, SessionID: p.SessionID, AchievedAt: p.At}
+		}
+		var volume float64
+		for _, set := range p.Sets {
+			if mt.CountsReps() {
+				mostReps[set.Weight] = improve(mostReps[set.Weight], record(domain.RecordMostReps, float64(set.Reps), set.Weight, set.Reps))
+			}
+			switch {
+			case mt == domain.MeasurementWeightReps && set.Reps > 0:
+				best[domain.RecordHeaviestWeight] = improve(best[domain.RecordHeaviestWeight],
+					record(domain.RecordHeaviestWeight, set.Weight, set.Weight, set.Reps))
+				best[domain.RecordEstimatedOneRepMax] = improve(best[domain.RecordEstimatedOneRepMax],
+					record(domain.RecordEstimatedOneRepMax, round(EstimateOneRepMax(set.Weight, set.Reps)), set.Weight, set.Reps))
+				volume += float64(set.Reps) * set.Weight
+			case mt.RequiresDuration():
+				best[domain.RecordLongestDuration] = improve(best[domain.RecordLongestDuration],
+					record(domain.RecordLongestDuration, float64(set.DurationSeconds), set.Weight, set.Reps))
+			}
+		}
+		if mt == domain.MeasurementWeightReps {
+			best[domain.RecordBestVolume] = improve(best[domain.RecordBestVolume], record(domain.RecordBestVolume, round(volume), 0, 0))
+		}
+	}
+
+	result := []domain.PersonalRecord{}
+	for _, recordType := range []domain.RecordType{domain.RecordHeaviestWeight, domain.RecordEstimatedOneRepMax} {
+		if r := best[recordType]; r != nil {
+			result = append(result, *r)
+		}
+	}
+	weights := make([]float64, 0, len(mostReps))
+	for weight, r := range mostReps {
+		if r != nil {
+			weights = append(weights, weight)
+		}
+	}
+	sort.Float64s(weights)
+	for _, weight := range weights {
+		result = append(result, *mostReps[weight])
+	}
+	for _, recordType := range []domain.RecordType{domain.RecordBestVolume, domain.RecordLongestDuration} {
+		if r := best[recordType]; r != nil {
+			result = append(result, *r)
+		}
+	}
+	return result
+}
+
+// Merge returns the records held after further performances set found,
+// both as Compute returns them. Performances only add sets, so the result
+// is what Compute would return for all of them without reading the earlier
+// ones again. Ties go to the earlier record, as in Compute.
+func Merge(held, found []domain.PersonalRecord) []domain.PersonalRecord {
+	type key struct {
+		recordType domain.RecordType
+		weight     float64
+	}
+	keyOf := func(r domain.PersonalRecord) key {
+		if r.Type == domain.RecordMostReps {
+			return key{r.Type, r.Weight}
+		}
+		return key{recordType: r.Type}
+	}
+	best := map[key]domain.PersonalRecord{}
+	for _, r := range held {
+		best[keyOf(r)] = r
+	}
+	for _, r := range found {
+		current, ok := best[keyOf(r)]
+		if !ok || beats(r, current) {
+			best[keyOf(r)] = r
+		}
+	}
+
+	result := make([]domain.PersonalRecord, 0, len(best))
+	for _, r := range best {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if typeOrder[a.Type] != typeOrder[b.Type] {
+			return typeOrder[a.Type] < typeOrder[b.Type]
+		}
+		return a.Weight < b.Weight
+	})
+	return result
+}
+
+// typeOrder is the order Compute returns record types in.
+var typeOrder = map[domain.RecordType]int{
+	domain.RecordHeaviestWeight:     0,
+	domain.RecordEstimatedOneRepMax: 1,
+	domain.RecordMostReps:           2,
+	domain.RecordBestVolume:         3,
+	domain.RecordLongestDuration:    4,
+}
+
+// beats reports whether candidate takes a record over from current.
+func beats(candidate, current domain.PersonalRecord) bool {
+	if candidate.Value != current.Value {
+		return candidate.Value > current.Value
+	}
+	if !candidate.AchievedAt.Equal(current.AchievedAt) {
+		return candidate.AchievedAt.Before(current.AchievedAt)
+	}
+	return candidate.SessionID < current.SessionID
+}
+
+// round keeps two decimals so estimates compare and store cleanly.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
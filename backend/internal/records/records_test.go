@@ -0,0 +1,116 @@
+package records_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/records"
+)
+
+func TestEstimateOneRepMax(t *testing.T) {
+	cases := []struct {
+		name   string
+		weight float64
+		reps   int
+		want   float64
+	}{
+		{name: "single rep is the max", weight: 140, reps: 1, want: 140},
+		{name: "brzycki for low reps", weight: 100, reps: 5, want: 112.5},
+		{name: "formulas agree at ten reps", weight: 90, reps: 10, want: 120},
+		{name: "epley for high reps", weight: 60, reps: 15, want: 90},
+		{name: "no weight", weight: 0, reps: 10, want: 0},
+		{name: "no reps", weight: 100, reps: 0, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.want, records.EstimateOneRepMax(tc.weight, tc.reps), 1e-9)
+		})
+	}
+	require.InDelta(t, records.Epley(90, 10), records.Brzycki(90, 10), 1e-9)
+}
+
+func working(reps int, weight float64) domain.WorkoutSet {
+	return domain.WorkoutSet{Type: domain.SetWorking, Reps: reps, Weight: weight, Completed: true}
+}
+
+func TestCompute(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 9, 0, 0, 0, time.UTC) }
+
+	t.Run("weight and reps", func(t *testing.T) {
+		got := records.Compute(domain.MeasurementWeightReps, []records.Performance{
+			{SessionID: "second", At: day(2), Sets: []domain.WorkoutSet{working(3, 110), working(8, 100)}},
+			{SessionID: "first", At: day(1), Sets: []domain.WorkoutSet{working(5, 100), working(5, 100), working(5, 100)}},
+			{SessionID: "third", At: day(3), Sets: []domain.WorkoutSet{working(3, 110)}},
+		})
+
+		type summary struct {
+			Type    domain.RecordType
+			Value   float64
+			Weight  float64
+			Session string
+		}
+		summaries := make([]summary, 0, len(got))
+		for _, r := range got {
+			summaries = append(summaries, summary{r.Type, r.Value, r.Weight, r.SessionID})
+		}
+		require.Equal(t, []summary{
+			{domain.RecordHeaviestWeight, 110, 110, "second"},
+			{domain.RecordEstimatedOneRepMax, 124.14, 100, "second"},
+			{domain.RecordMostReps, 8, 100, "second"},
+			{domain.RecordMostReps, 3, 110, "second"},
+			{domain.RecordBestVolume, 1500, 0, "first"},
+		}, summaries)
+		require.Equal(t, day(2), got[0].AchievedAt)
+	})
+
+	t.Run("timed sets", func(t *testing.T) {
+		got := records.Compute(domain.MeasurementTime, []records.Performance{
+			{SessionID: "a", At: day(1), Sets: []domain.WorkoutSet{{Type: domain.SetWorking, DurationSeconds: 60, Completed: true}}},
+			{SessionID: "b", At: day(2), Sets: []domain.WorkoutSet{{Type: domain.SetWorking, DurationSeconds: 90, Completed: true}}},
+		})
+		require.Len(t, got, 1)
+		require.Equal(t, domain.RecordLongestDuration, got[0].Type)
+		require.Equal(t, 90.0, got[0].Value)
+		require.Equal(t, "b", got[0].SessionID)
+	})
+
+	t.Run("bodyweight reps", func(t *testing.T) {
+		got := records.Compute(domain.MeasurementBodyweightReps, []records.Performance{
+			{SessionID: "a", At: day(1), Sets: []domain.WorkoutSet{working(12, 0), working(5, 10)}},
+		})
+		require.Len(t, got, 2)
+		require.Equal(t, domain.RecordMostReps, got[0].Type)
+		require.Equal(t, 12.0, got[0].Value)
+		require.Equal(t, 10.0, got[1].Weight)
+	})
+
+	t.Run("nothing logged", func(t *testing.T) {
+		require.Empty(t, records.Compute(domain.MeasurementWeightReps, nil))
+		require.Empty(t, records.Compute(domain.MeasurementDistance, []records.Performance{
+			{SessionID: "a", At: day(1), Sets: []domain.WorkoutSet{{Type: domain.SetWorking, DistanceMeters: 5000, Completed: true}}},
+		}))
+	})
+}
+
+func TestMerge(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 9, 0, 0, 0, time.UTC) }
+	performances := []records.Performance{
+		{SessionID: "first", At: day(1), Sets: []domain.WorkoutSet{working(5, 100), working(5, 100), working(5, 100)}},
+		{SessionID: "second", At: day(2), Sets: []domain.WorkoutSet{working(3, 110), working(8, 100)}},
+		{SessionID: "third", At: day(3), Sets: []domain.WorkoutSet{working(3, 110), working(12, 60)}},
+		// Logged late for an earlier day: it takes over the records it ties.
+		{SessionID: "backdated", At: day(1).Add(-time.Hour), Sets: []domain.WorkoutSet{working(3, 110)}},
+	}
+
+	held := records.Compute(domain.MeasurementWeightReps, performances[:1])
+	for i := 1; i < len(performances); i++ {
+		found := records.Compute(domain.MeasurementWeightReps, performances[i:i+1])
+		held = records.Merge(held, found)
+		require.Equal(t, records.Compute(domain.MeasurementWeightReps, performances[:i+1]), held)
+	}
+	require.Equal(t, "backdated", held[0].SessionID)
+	require.Empty(t, records.Merge(nil, nil))
+}
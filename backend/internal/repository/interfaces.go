@@ -1,16 +1,28 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
 	"github.com/musclementour/app/internal/domain"
 )
 
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	// ErrPlannedSessionTaken reports that another workout already completed
+	// the planned session.
+	ErrPlannedSessionTaken = errors.New("planned session was already completed")
+)
+
 type UserRepository interface {
 	Create(user *domain.User) error
 	GetByEmail(email string) (*domain.User, error)
 	GetByID(id string) (*domain.User, error)
 	CountAdmins() (int, error)
+	SetLocale(userID, locale string) error
+	// SetLoading stores the user's loading equipment; nil clears it.
+	SetLoading(userID string, loading *domain.LoadingSettings) error
 }
 
 type RefreshTokenRepository interface {
@@ -21,21 +33,316 @@ type RefreshTokenRepository interface {
 }
 
 type ExerciseRepository interface {
+	// List returns every exercise, including archived ones.
 	List() ([]domain.Exercise, error)
-	Create(ex *domain.Exercise) error
-	Update(ex *domain.Exercise) error
+	// Create and Update write the exercise together with rev, when set, in
+	// one transaction. They return ErrConflict and write nothing when the
+	// revision number is already taken.
+	Create(ex *domain.Exercise, rev *domain.ExerciseRevision) error
+	Update(ex *domain.Exercise, rev *domain.ExerciseRevision) error
+	// Delete removes the exercise and leaves a tombstone in the change feed.
 	Delete(id string) error
 	GetByID(id string) (*domain.Exercise, error)
+	SetArchived(id string, archivedAt *time.Time) error
+	// AddAlias returns ErrConflict when an alias with the same normalized
+	// spelling already exists on any exercise.
+	AddAlias(exerciseID, alias, normalized string) error
+	RemoveAlias(exerciseID, normalized string) error
+	// Changes returns exercises created, updated, archived or deleted after
+	// the given cursor. Cursors only ever grow.
+	Changes(since int64) (*domain.ExerciseChangeFeed, error)
+	// Merge applies merge atomically. It returns ErrConflict when the merge
+	// revision number is already taken.
+	Merge(merge *domain.ExerciseMerge) error
+}
+
+type ExerciseRevisionRepository interface {
+	// Create stores a new revision. It returns ErrConflict when a revision
+	// with the same number already exists for the exercise.
+	Create(rev *domain.ExerciseRevision) error
+	ListByExercise(exerciseID string) ([]domain.ExerciseRevision, error)
+	Get(exerciseID string, number int) (*domain.ExerciseRevision, error)
+	Latest(exerciseID string) (*domain.ExerciseRevision, error)
+}
+
+type ExerciseMediaRepository interface {
+	Create(media *domain.ExerciseMedia) error
+	Get(id string) (*domain.ExerciseMedia, error)
+	ListByExercise(exerciseID string) ([]domain.ExerciseMedia, error)
+	ListAll() ([]domain.ExerciseMedia, error)
+	Delete(id string) error
+}
+
+type ExerciseSubstitutionRepository interface {
+	// ListPinned returns the curated substitutes of an exercise in order.
+	ListPinned(exerciseID string) ([]string, error)
+	SetPinned(exerciseID string, substituteIDs []string) error
+}
+
+// ExerciseTranslationRepository stores per-locale exercise names and
+// descriptions. Writes count as exercise changes for the change feed.
+type ExerciseTranslationRepository interface {
+	Upsert(translation *domain.ExerciseTranslation) error
+	Delete(exerciseID, locale string) error
+	ListByExercise(exerciseID string) ([]domain.ExerciseTranslation, error)
+	ListByLocale(locale string) ([]domain.ExerciseTranslation, error)
+}
+
+type ExerciseRelationRepository interface {
+	// Create returns ErrConflict if the same typed relation already exists.
+	Create(relation *domain.ExerciseRelation) error
+	Get(id string) (*domain.ExerciseRelation, error)
+	Delete(id string) error
+	List() ([]domain.ExerciseRelation, error)
+}
+
+// ExerciseSeedRepository remembers which catalogue entries have been seeded,
+// so exercises an admin deleted are not created again on upgrade.
+type ExerciseSeedRepository interface {
+	// Version returns the catalogue version last applied, 0 if none.
+	Version() (int, error)
+	SetVersion(version int) error
+	// Seeded maps catalogue keys to the exercise created or matched for them.
+	Seeded() (map[string]string, error)
+	Record(key, exerciseID string) error
+}
+
+// SessionFilter selects a user's sessions. From is inclusive and To
+// exclusive on the start time. ExerciseID, MuscleGroup and Notes must all
+// match the same entry; MuscleGroup is compared case-insensitively and Notes
+// matches case-insensitively anywhere in the entry's notes.
+type SessionFilter struct {
+	UserID      string
+	From        *time.Time
+	To          *time.Time
+	ExerciseID  string
+	MuscleGroup string
+	Notes       string
+}
+
+// SessionKey is a session's position in listing order.
+type SessionKey struct {
+	StartedAt time.Time
+	ID        string
 }
 
 type WorkoutRepository interface {
+	// CreateSession stores session. It returns ErrPlannedSessionTaken when
+	// another session outside the trash completed its planned session.
 	CreateSession(session *domain.WorkoutSession) error
-	ListSessions(userID string) ([]domain.WorkoutSession, error)
+	// CreateSessionOnce stores session and records it under submission.Key in
+	// one transaction, filling in submission.Response. It returns ErrConflict
+	// and stores nothing when the user already used the key or the session
+	// id is taken, and ErrPlannedSessionTaken as CreateSession does.
+	CreateSessionOnce(session *domain.WorkoutSession, submission *domain.WorkoutSubmission) error
+	GetSubmission(userID, key string) (*domain.WorkoutSubmission, error)
+	// ListSessions returns sessions matching filter that are not in the
+	// trash, newest first and ordered by id on ties. With after set the
+	// listing continues past that session. A limit of 0 means no limit.
+	ListSessions(filter SessionFilter, after *SessionKey, limit int) ([]domain.WorkoutSession, error)
+	// CountSessions counts the sessions ListSessions would return without
+	// a cursor or limit.
+	CountSessions(filter SessionFilter) (int, error)
+	// LogsExercise reports whether any session, trashed ones included, has
+	// an entry for the exercise.
+	LogsExercise(exerciseID string) (bool, error)
+	// GetSession returns a session with its entries, trashed or not.
+	GetSession(id string) (*domain.WorkoutSession, error)
+	// UpdateSession stores the session's times and entries. Entries without
+	// an id are added and stored entries missing from session are removed.
+	UpdateSession(session *domain.WorkoutSession) error
+	// TrashSession moves a session to the trash and RestoreSession takes it
+	// back out. Both record at as the session's update time. Restoring
+	// returns ErrPlannedSessionTaken when another session completed the
+	// planned session meanwhile.
+	TrashSession(id string, at time.Time) error
+	RestoreSession(id string, at time.Time) error
+	// ListTrash returns the user's trashed sessions, most recently deleted
+	// first.
+	ListTrash(userID string) ([]domain.WorkoutSession, error)
+	// PurgeTrash permanently deletes sessions trashed before cutoff and
+	// returns how many were removed.
+	PurgeTrash(cutoff time.Time) (int, error)
+}
+
+// SeriesQuery selects the user's sessions outside the trash that started
+// from From up to but excluding To, grouped by Bucket. ExerciseID narrows
+// series about a single exercise.
+type SeriesQuery struct {
+	UserID     string
+	From       time.Time
+	To         time.Time
+	Bucket     domain.Bucket
+	ExerciseID string
+}
+
+// VolumeQuery selects a user's sessions to total. From is inclusive and To
+// is exclusive; nil bounds are open.
+type VolumeQuery struct {
+	UserID string
+	From   *time.Time
+	To     *time.Time
+}
+
+// AnalyticsRepository aggregates workouts into totals and time series. Only
+// working sets count. Points are ordered by bucket start, and buckets
+// without workouts are left out.
+type AnalyticsRepository interface {
+	// ExerciseVolume totals sets and reps per exercise, in no particular
+	// order. Only weight and reps exercises add volume: the weight of an
+	// assisted set is the assistance, not the load.
+	ExerciseVolume(query VolumeQuery) ([]domain.ExerciseVolume, error)
+	Workload(query SeriesQuery) ([]domain.WorkloadPoint, error)
+	// MuscleGroupSets counts sets per lower-cased muscle group of the
+	// exercise, ordered by muscle group within a bucket.
+	MuscleGroupSets(query SeriesQuery) ([]domain.MuscleGroupPoint, error)
+	// OneRepMax returns the set of query.ExerciseID with the best estimated
+	// one-rep max in each bucket, using records.EstimateOneRepMax. Ties go to
+	// the heavier set. Like records.Compute, it only estimates weight and
+	// reps exercises.
+	OneRepMax(query SeriesQuery) ([]domain.OneRepMaxPoint, error)
+	Frequency(query SeriesQuery) ([]domain.FrequencyPoint, error)
+}
+
+// TemplateRepository stores workout templates. Create and Update return
+// ErrConflict when the user already has a template with the same name.
+type TemplateRepository interface {
+	Create(template *domain.WorkoutTemplate) error
+	Get(id string) (*domain.WorkoutTemplate, error)
+	// List returns the user's templates ordered by name.
+	List(userID string) ([]domain.WorkoutTemplate, error)
+	Update(template *domain.WorkoutTemplate) error
+	// Delete returns ErrConflict while a program or planned session still
+	// uses the template.
+	Delete(id string) error
+}
+
+// TrainingMaxRepository stores one training max per user and exercise.
+type TrainingMaxRepository interface {
+	// List returns the user's training maxes.
+	List(userID string) ([]domain.TrainingMax, error)
+	// Set creates or replaces the training max for its user and exercise.
+	Set(trainingMax *domain.TrainingMax) error
+	Delete(userID, exerciseID string) error
+}
+
+// RecordRepository stores the personal records computed from each user's
+// workouts.
+type RecordRepository interface {
+	// List returns the user's records ordered by exercise and type.
+	List(userID string) ([]domain.PersonalRecord, error)
+	// Update replaces the user's records on the exercise with those compute
+	// returns given the records held now. Updates of the same user and
+	// exercise run one at a time, so compute sees every workout saved
+	// before it was called.
+	Update(userID, exerciseID string, compute func(held []domain.PersonalRecord) ([]domain.PersonalRecord, error)) error
+	// Holders returns the users with records for the exercise.
+	Holders(exerciseID string) ([]string, error)
+	// Unscored returns the users who have workouts outside the trash but
+	// no records, such as those who trained before records were kept,
+	// leaving out those already marked scored.
+	Unscored() ([]string, error)
+	// MarkScored keeps the user out of Unscored, even when their workouts
+	// set no records.
+	MarkScored(userID string) error
+}
+
+type GoalRepository interface {
+	Create(goal *domain.Goal) error
+	Get(id string) (*domain.Goal, error)
+	// List returns the user's goals by deadline.
+	List(userID string) ([]domain.Goal, error)
+	// Update stores the goal's type, exercise, target, reps, deadline and
+	// achievement time.
+	Update(goal *domain.Goal) error
+	Delete(id string) error
+	// Achieve records when the goal was reached, unless it already was.
+	Achieve(id string, at time.Time) error
+}
+
+// LiveWorkoutRepository stores workouts in progress, at most one per user.
+type LiveWorkoutRepository interface {
+	// Create returns ErrConflict when the user already has a live workout.
+	Create(live *domain.LiveWorkout) error
+	GetByUser(userID string) (*domain.LiveWorkout, error)
+	// Update stores the workout if nobody changed it since live.Revision was
+	// read, and returns ErrConflict otherwise. It advances live.Revision.
+	Update(live *domain.LiveWorkout) error
+	// Delete removes the workout if nobody changed it since revision was
+	// read. It returns ErrConflict when somebody did and ErrNotFound when
+	// the workout is gone.
+	Delete(id string, revision int) error
+	// Idle returns the live workouts with no activity since before.
+	Idle(before time.Time) ([]domain.LiveWorkout, error)
+}
+
+// BodyMetricFilter selects a user's body metrics measured in [From, To).
+type BodyMetricFilter struct {
+	UserID string
+	From   *time.Time
+	To     *time.Time
+}
+
+type BodyMetricRepository interface {
+	Create(metric *domain.BodyMetric) error
+	// CreateOnce stores metric and records it under submission.Key in one
+	// transaction, filling in submission.Response. It returns ErrConflict and
+	// stores nothing when the user already used the key or the metric id is
+	// taken.
+	CreateOnce(metric *domain.BodyMetric, submission *domain.BodyMetricSubmission) error
+	GetSubmission(userID, key string) (*domain.BodyMetricSubmission, error)
+	Get(id string) (*domain.BodyMetric, error)
+	// List returns the matching metrics, most recently measured first.
+	List(filter BodyMetricFilter) ([]domain.BodyMetric, error)
+	// Update stores the metric's measurement time, measurements and note.
+	Update(metric *domain.BodyMetric) error
+	Delete(id string) error
+}
+
+// ProgramRepository stores training programs, enrollments and the sessions
+// they plan. A planned session's SessionID is the earliest logged workout
+// linked to it that is not in the trash.
+type ProgramRepository interface {
+	Create(program *domain.Program) error
+	Get(id string) (*domain.Program, error)
+	// List returns all programs ordered by name.
+	List() ([]domain.Program, error)
+	Update(program *domain.Program) error
+	// Delete returns ErrConflict once anyone has enrolled in the program.
+	Delete(id string) error
+	// Enroll stores the enrollment together with its planned sessions. It
+	// returns ErrConflict when the user already follows the program.
+	Enroll(enrollment *domain.ProgramEnrollment, planned []domain.PlannedSession) error
+	GetEnrollment(id string) (*domain.ProgramEnrollment, error)
+	// ListEnrollments returns the user's enrollments, ended ones included,
+	// most recent first.
+	ListEnrollments(userID string) ([]domain.ProgramEnrollment, error)
+	EndEnrollment(id string, at time.Time) error
+	GetPlanned(id string) (*domain.PlannedSession, error)
+	// ListPlanned returns an enrollment's planned sessions by date.
+	ListPlanned(enrollmentID string) ([]domain.PlannedSession, error)
+	// Schedule returns the planned sessions of the user's active
+	// enrollments scheduled from from up to but excluding to, by date.
+	Schedule(userID string, from, to time.Time) ([]domain.PlannedSession, error)
 }
 
 type Repository struct {
-	Users         UserRepository
-	RefreshTokens RefreshTokenRepository
-	Exercises     ExerciseRepository
-	Workouts      WorkoutRepository
+	Users             UserRepository
+	RefreshTokens     RefreshTokenRepository
+	Exercises         ExerciseRepository
+	ExerciseRevisions ExerciseRevisionRepository
+	ExerciseMedia     ExerciseMediaRepository
+	Substitutions     ExerciseSubstitutionRepository
+	Translations      ExerciseTranslationRepository
+	Relations         ExerciseRelationRepository
+	Seeds             ExerciseSeedRepository
+	Workouts          WorkoutRepository
+	Templates         TemplateRepository
+	Programs          ProgramRepository
+	TrainingMaxes     TrainingMaxRepository
+	Records           RecordRepository
+	Analytics         AnalyticsRepository
+	Goals             GoalRepository
+	BodyMetrics       BodyMetricRepository
+	LiveWorkouts      LiveWorkoutRepository
 }
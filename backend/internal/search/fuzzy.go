@@ -0,0 +1,138 @@
+// Package search implements the typo-tolerant matching used for exercise
+// lookup and duplicate detection.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases s and drops everything that is not a letter or digit,
+// so "Chin-Up", "chin up" and "chinup" compare equal.
+func Normalize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Similarity returns a score between 0 and 1 for how alike two names are,
+// taking the better of trigram overlap and edit distance.
+func Similarity(a, b string) float64 {
+	na, nb := Normalize(a), Normalize(b)
+	return similarity(na, nb)
+}
+
+func similarity(na, nb string) float64 {
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	tri := trigramSimilarity(na, nb)
+	lev := 1 - float64(levenshtein(na, nb))/float64(max(runeLen(na), runeLen(nb)))
+	return max(tri, lev)
+}
+
+// MatchScore scores a search query against a candidate name. Unlike
+// Similarity it rewards queries that are contained in the candidate, so
+// "squat" finds "Barbell Back Squat".
+func MatchScore(query, candidate string) float64 {
+	nq, nc := Normalize(query), Normalize(candidate)
+	score := similarity(nq, nc)
+	if runeLen(nq) >= 3 && strings.Contains(nc, nq) {
+		contained := 0.6 + 0.4*float64(runeLen(nq))/float64(runeLen(nc))
+		score = max(score, contained)
+	}
+	return score
+}
+
+// Candidate is something that can be found by any of its terms, e.g. an
+// exercise name and its aliases.
+type Candidate struct {
+	ID    string
+	Terms []string
+}
+
+type Match struct {
+	ID    string
+	Term  string
+	Score float64
+}
+
+// Rank scores every candidate with score and returns those reaching the
+// threshold, best first. Ties are broken by ID so results are stable.
+func Rank(query string, candidates []Candidate, threshold float64, score func(query, term string) float64) []Match {
+	matches := []Match{}
+	for _, c := range candidates {
+		best := Match{ID: c.ID}
+		for _, term := range c.Terms {
+			if s := score(query, term); s > best.Score {
+				best.Score = s
+				best.Term = term
+			}
+		}
+		if best.Score >= threshold {
+			matches = append(matches, best)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+func trigrams(s string) map[string]struct{} {
+	runes := []rune("  " + s + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
+
+// trigramSimilarity is the Dice coefficient of the two trigram sets.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ta)+len(tb))
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
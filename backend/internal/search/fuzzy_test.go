@@ -0,0 +1,47 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/search"
+)
+
+func TestSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{a: "Pull-Up", b: "Pullup", min: 1, max: 1},
+		{a: "Bench Press", b: "benchpress", min: 1, max: 1},
+		{a: "Deadlift", b: "dedlift", min: 0.85, max: 1},
+		{a: "Pull-Up", b: "Push-Up", min: 0, max: 0.8},
+		{a: "Bench Press", b: "Incline Bench Press", min: 0, max: 0.8},
+		{a: "Front Squat", b: "Back Squat", min: 0, max: 0.8},
+		{a: "", b: "Squat", min: 0, max: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			score := search.Similarity(tc.a, tc.b)
+			require.GreaterOrEqual(t, score, tc.min)
+			require.LessOrEqual(t, score, tc.max)
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	candidates := []search.Candidate{
+		{ID: "squat", Terms: []string{"Barbell Back Squat"}},
+		{ID: "rdl", Terms: []string{"Romanian Deadlift", "RDL"}},
+		{ID: "chin", Terms: []string{"Chin-Up"}},
+		{ID: "bench", Terms: []string{"Bench Press"}},
+	}
+
+	require.Equal(t, "rdl", search.Rank("rdl", candidates, 0.5, search.MatchScore)[0].ID)
+	require.Equal(t, "chin", search.Rank("chin up", candidates, 0.5, search.MatchScore)[0].ID)
+	require.Equal(t, "bench", search.Rank("benchpress", candidates, 0.5, search.MatchScore)[0].ID)
+	require.Equal(t, "squat", search.Rank("squat", candidates, 0.5, search.MatchScore)[0].ID)
+	require.Empty(t, search.Rank("zercher", candidates, 0.5, search.MatchScore))
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type AnalyticsService struct {
+	repository repository.Repository
+}
+
+func NewAnalyticsService(repo repository.Repository) *AnalyticsService {
+	return &AnalyticsService{repository: repo}
+}
+
+// VolumeQuery selects the sessions to aggregate. From is inclusive and To is
+// exclusive; nil bounds are open. With RollupVariations, volume logged on a
+// variation counts towards the top-most exercise it is a variation of.
+type VolumeQuery struct {
+	From             *time.Time
+	To               *time.Time
+	RollupVariations bool
+}
+
+// Volume sums working sets, reps and load per exercise for a user's
+// sessions. Warm-up and uncompleted sets are left out, and only weight and
+// reps exercises add load.
+func (s *AnalyticsService) Volume(userID string, query VolumeQuery) (*domain.VolumeReport, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	volumes, err := s.repository.Analytics.ExerciseVolume(repository.VolumeQuery{UserID: userID, From: query.From, To: query.To})
+	if err != nil {
+		return nil, err
+	}
+	roots := map[string]string{}
+	if query.RollupVariations {
+		relations, err := s.repository.Relations.List()
+		if err != nil {
+			return nil, err
+		}
+		roots = variationRoots(relations)
+	}
+
+	totals := map[string]*domain.ExerciseVolume{}
+	includes := map[string]map[string]bool{}
+	for _, volume := range volumes {
+		target := volume.ExerciseID
+		if root, ok := roots[target]; ok {
+			target = root
+			if includes[root] == nil {
+				includes[root] = map[string]bool{}
+			}
+			includes[root][volume.ExerciseID] = true
+		}
+		total, ok := totals[target]
+		if !ok {
+			total = &domain.ExerciseVolume{ExerciseID: target}
+			totals[target] = total
+		}
+		total.Sets += volume.Sets
+		total.Reps += volume.Reps
+		total.Volume += volume.Volume
+	}
+
+	report := &domain.VolumeReport{From: query.From, To: query.To, Rollup: query.RollupVariations, Exercises: []domain.ExerciseVolume{}}
+	for id, total := range totals {
+		if ex, err := s.repository.Exercises.GetByID(id); err == nil {
+			total.Name = ex.Name
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		for variation := range includes[id] {
+			total.Includes = append(total.Includes, variation)
+		}
+		sort.Strings(total.Includes)
+		report.Exercises = append(report.Exercises, *total)
+	}
+	sort.Slice(report.Exercises, func(i, j int) bool {
+		a, b := report.Exercises[i], report.Exercises[j]
+		if a.Volume != b.Volume {
+			return a.Volume > b.Volume
+		}
+		return a.ExerciseID < b.ExerciseID
+	})
+	return report, nil
+}
+
+// SeriesInput selects a time series. Bucket defaults to week, To to now and
+// From to the start of the twelfth bucket back from To.
+type SeriesInput struct {
+	From       *time.Time
+	To         *time.Time
+	Bucket     domain.Bucket
+	ExerciseID string
+}
+
+const (
+	defaultSeriesBuckets = 12
+	maxSeriesBuckets     = 366
+)
+
+// seriesQuery validates input and resolves its defaults.
+func seriesQuery(userID string, input SeriesInput) (repository.SeriesQuery, error) {
+	query := repository.SeriesQuery{UserID: userID, Bucket: input.Bucket, ExerciseID: input.ExerciseID}
+	if userID == "" {
+		return query, errors.New("user id is required")
+	}
+	verr := &ValidationError{}
+	if query.Bucket == "" {
+		query.Bucket = domain.BucketWeek
+	} else if !query.Bucket.Valid() {
+		verr.Add("bucket", "must be one of day, week, month")
+		return query, verr.Err()
+	}
+	query.To = time.Now().UTC()
+	if input.To != nil {
+		query.To = *input.To
+	}
+	query.From = query.Bucket.Add(query.Bucket.Start(query.To), 1-defaultSeriesBuckets)
+	if input.From != nil {
+		query.From = *input.From
+	}
+	if !query.From.Before(query.To) {
+		verr.Add("to", "must be after from")
+	} else if query.Bucket.Add(query.Bucket.Start(query.From), maxSeriesBuckets).Before(query.To) {
+		verr.Add("from", "must be at most %d %ss before to", maxSeriesBuckets, query.Bucket)
+	}
+	return query, verr.Err()
+}
+
+// fillBuckets returns a point for every bucket the query covers: the one
+// found for it or, failing that, an empty one.
+func fillBuckets[P any](query repository.SeriesQuery, found []P, startOf func(P) time.Time, empty func(time.Time) P) []P {
+	byStart := make(map[int64]P, len(found))
+	for _, point := range found {
+		byStart[startOf(point).Unix()] = point
+	}
+	points := []P{}
+	for start := query.Bucket.Start(query.From); start.Before(query.To); start = query.Bucket.Add(start, 1) {
+		point, ok := byStart[start.Unix()]
+		if !ok {
+			point = empty(start)
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+func newSeries[P any](query repository.SeriesQuery, points []P) *domain.Series[P] {
+	return &domain.Series[P]{Bucket: query.Bucket, From: query.From, To: query.To, Points: points}
+}
+
+// Workload returns sets, reps and tonnage per bucket, with a zero point for
+// buckets without workouts.
+func (s *AnalyticsService) Workload(userID string, input SeriesInput) (*domain.Series[domain.WorkloadPoint], error) {
+	query, err := seriesQuery(userID, input)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.repository.Analytics.Workload(query)
+	if err != nil {
+		return nil, err
+	}
+	points := fillBuckets(query, found,
+		func(p domain.WorkloadPoint) time.Time { return p.Start },
+		func(start time.Time) domain.WorkloadPoint { return domain.WorkloadPoint{Start: start} })
+	for i := range points {
+		points[i].Tonnage = roundTenth(points[i].Tonnage)
+	}
+	return newSeries(query, points), nil
+}
+
+// MuscleGroupSets returns working sets per muscle group and bucket. Buckets
+// and muscle groups without sets are left out.
+func (s *AnalyticsService) MuscleGroupSets(userID string, input SeriesInput) (*domain.Series[domain.MuscleGroupPoint], error) {
+	query, err := seriesQuery(userID, input)
+	if err != nil {
+		return nil, err
+	}
+	points, err := s.repository.Analytics.MuscleGroupSets(query)
+	if err != nil {
+		return nil, err
+	}
+	return newSeries(query, points), nil
+}
+
+// OneRepMax returns the trend of an exercise's best estimated one-rep max,
+// one point per bucket in which it was trained with weight.
+func (s *AnalyticsService) OneRepMax(userID string, input SeriesInput) (*domain.Series[domain.OneRepMaxPoint], error) {
+	query, err := seriesQuery(userID, input)
+	if err != nil {
+		return nil, err
+	}
+	if query.ExerciseID == "" {
+		verr := &ValidationError{}
+		verr.Add("exerciseId", "is required")
+		return nil, verr.Err()
+	}
+	if _, err := s.repository.Exercises.GetByID(query.ExerciseID); err != nil {
+		return nil, err
+	}
+	points, err := s.repository.Analytics.OneRepMax(query)
+	if err != nil {
+		return nil, err
+	}
+	for i := range points {
+		points[i].EstimatedOneRepMax = math.Round(points[i].EstimatedOneRepMax*100) / 100
+	}
+	return newSeries(query, points), nil
+}
+
+// Frequency returns workouts and training days per bucket, with a zero point
+// for buckets without workouts.
+func (s *AnalyticsService) Frequency(userID string, input SeriesInput) (*domain.Series[domain.FrequencyPoint], error) {
+	query, err := seriesQuery(userID, input)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.repository.Analytics.Frequency(query)
+	if err != nil {
+		return nil, err
+	}
+	points := fillBuckets(query, found,
+		func(p domain.FrequencyPoint) time.Time { return p.Start },
+		func(start time.Time) domain.FrequencyPoint { return domain.FrequencyPoint{Start: start} })
+	return newSeries(query, points), nil
+}
+
+// roundTenth rounds sums of floating point loads to a tenth of a kilogram.
+func roundTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
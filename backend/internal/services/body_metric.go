@@ -0,0 +1,374 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type BodyMetricService struct {
+	repository repository.Repository
+}
+
+func NewBodyMetricService(repo repository.Repository) *BodyMetricService {
+	return &BodyMetricService{repository: repo}
+}
+
+// BodyMetricInput records measurements in WeightUnit and LengthUnit, which
+// default to kilograms and centimeters. MeasuredAt defaults to now.
+type BodyMetricInput struct {
+	// ID is an optional client-generated id. Without an explicit
+	// IdempotencyKey it also serves as the key.
+	ID             string                               `json:"id"`
+	MeasuredAt     time.Time                            `json:"measuredAt"`
+	Bodyweight     *float64                             `json:"bodyweight"`
+	BodyFat        *float64                             `json:"bodyFat"`
+	Circumferences map[domain.CircumferenceSite]float64 `json:"circumferences"`
+	Note           string                               `json:"note"`
+	WeightUnit     domain.WeightUnit                    `json:"weightUnit"`
+	LengthUnit     domain.LengthUnit                    `json:"lengthUnit"`
+	// IdempotencyKey makes retries safe, as for workouts.
+	IdempotencyKey string `json:"-"`
+}
+
+// units returns the units the input is in, which responses to it use too.
+func (input BodyMetricInput) units() domain.BodyUnits {
+	units := domain.MetricUnits
+	if input.WeightUnit != "" {
+		units.Weight = input.WeightUnit
+	}
+	if input.LengthUnit != "" {
+		units.Length = input.LengthUnit
+	}
+	return units
+}
+
+// Bounds on body metrics, in kilograms, percent and centimeters.
+const (
+	minBodyFat       = 2
+	maxBodyFat       = 75
+	minCircumference = 10
+	maxCircumference = 250
+)
+
+// Create stores a body metric and returns it in the units of the input.
+// Retries under the same idempotency key (or client id) return the stored
+// metric with replayed set, as workout submissions do.
+func (s *BodyMetricService) Create(userID string, input BodyMetricInput) (metric *domain.BodyMetric, replayed bool, err error) {
+	if userID == "" {
+		return nil, false, errors.New("user id is required")
+	}
+	verr := &ValidationError{}
+	request, err := newIdempotentCreate(userID, input.ID, input.IdempotencyKey, input, s.submission, verr)
+	if err != nil {
+		return nil, false, err
+	}
+	addUnitErrors(input.units(), verr)
+	if err := verr.Err(); err != nil {
+		return nil, false, err
+	}
+	var previous domain.BodyMetric
+	found, err := request.replay(&previous)
+	if err != nil {
+		return nil, false, err
+	}
+	if found {
+		return inUnits(&previous, input.units()), true, nil
+	}
+
+	metric = &domain.BodyMetric{ID: input.ID, UserID: userID}
+	if err := applyBodyMetric(metric, input, time.Now().UTC()); err != nil {
+		return nil, false, err
+	}
+	if !request.tracked() {
+		if err := s.repository.BodyMetrics.Create(metric); err != nil {
+			return nil, false, err
+		}
+		return inUnits(metric, input.units()), false, nil
+	}
+	metric.ID = request.id(metric.ID, "body-metrics")
+	submission := &domain.BodyMetricSubmission{UserID: userID, Key: request.key, RequestHash: request.hash}
+	replayed, err = request.store(func() error {
+		return s.repository.BodyMetrics.CreateOnce(metric, submission)
+	}, &previous, "body metric")
+	if err != nil {
+		return nil, false, err
+	}
+	if replayed {
+		return inUnits(&previous, input.units()), true, nil
+	}
+	return inUnits(metric, input.units()), false, nil
+}
+
+// submission looks up the body metric submitted under key.
+func (s *BodyMetricService) submission(userID, key string) (string, []byte, error) {
+	submission, err := s.repository.BodyMetrics.GetSubmission(userID, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return submission.RequestHash, submission.Response, nil
+}
+
+// List returns the user's metrics measured in [from, to), most recent first.
+func (s *BodyMetricService) List(userID string, from, to *time.Time, units domain.BodyUnits) ([]domain.BodyMetric, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	if err := checkUnits(units); err != nil {
+		return nil, err
+	}
+	metrics, err := s.repository.BodyMetrics.List(repository.BodyMetricFilter{UserID: userID, From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	for i := range metrics {
+		metrics[i] = metrics[i].In(units)
+	}
+	return metrics, nil
+}
+
+func (s *BodyMetricService) Get(userID, id string, units domain.BodyUnits) (*domain.BodyMetric, error) {
+	if err := checkUnits(units); err != nil {
+		return nil, err
+	}
+	metric, err := s.owned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return inUnits(metric, units), nil
+}
+
+// Update replaces a metric's measurements.
+func (s *BodyMetricService) Update(userID, id string, input BodyMetricInput) (*domain.BodyMetric, error) {
+	metric, err := s.owned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyBodyMetric(metric, input, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.repository.BodyMetrics.Update(metric); err != nil {
+		return nil, err
+	}
+	return inUnits(metric, input.units()), nil
+}
+
+func (s *BodyMetricService) Delete(userID, id string) error {
+	if _, err := s.owned(userID, id); err != nil {
+		return err
+	}
+	return s.repository.BodyMetrics.Delete(id)
+}
+
+func (s *BodyMetricService) owned(userID, id string) (*domain.BodyMetric, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	metric, err := s.repository.BodyMetrics.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if metric.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return metric, nil
+}
+
+// applyBodyMetric validates input and copies it onto metric in metric
+// units.
+func applyBodyMetric(metric *domain.BodyMetric, input BodyMetricInput, now time.Time) error {
+	verr := &ValidationError{}
+	units := input.units()
+	addUnitErrors(units, verr)
+	measuredAt := input.MeasuredAt.UTC()
+	if input.MeasuredAt.IsZero() {
+		measuredAt = now
+	}
+	switch {
+	case measuredAt.Before(earliestWorkout):
+		verr.Add("measuredAt", "must not be before %s", earliestWorkout.Format(time.DateOnly))
+	case measuredAt.After(now.Add(futureTolerance)):
+		verr.Add("measuredAt", "must not be in the future")
+	}
+	if input.Bodyweight == nil && input.BodyFat == nil && len(input.Circumferences) == 0 {
+		verr.Add("bodyweight", "or bodyFat or circumferences are required")
+	}
+	var bodyweight *float64
+	if input.Bodyweight != nil {
+		kg := units.Weight.ToKilograms(*input.Bodyweight)
+		if kg < minBodyweight || kg > maxBodyweight {
+			verr.Add("bodyweight", "must be between %g and %g %s",
+				units.Weight.FromKilograms(minBodyweight), units.Weight.FromKilograms(maxBodyweight), units.Weight)
+		}
+		bodyweight = &kg
+	}
+	if input.BodyFat != nil && (*input.BodyFat < minBodyFat || *input.BodyFat > maxBodyFat) {
+		verr.Add("bodyFat", "must be between %d and %d percent", minBodyFat, maxBodyFat)
+	}
+	var circumferences map[domain.CircumferenceSite]float64
+	for site, length := range input.Circumferences {
+		field := "circumferences." + string(site)
+		cm := units.Length.ToCentimeters(length)
+		switch {
+		case !site.Valid():
+			verr.Add(field, "is not a known measurement site")
+		case cm < minCircumference || cm > maxCircumference:
+			verr.Add(field, "must be between %g and %g %s",
+				units.Length.FromCentimeters(minCircumference), units.Length.FromCentimeters(maxCircumference), units.Length)
+		}
+		if circumferences == nil {
+			circumferences = make(map[domain.CircumferenceSite]float64, len(input.Circumferences))
+		}
+		circumferences[site] = cm
+	}
+	if utf8.RuneCountInString(input.Note) > maxNotesLength {
+		verr.Add("note", "must be at most %d characters", maxNotesLength)
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+	metric.MeasuredAt, metric.Bodyweight, metric.BodyFat, metric.Circumferences, metric.Note =
+		measuredAt, bodyweight, input.BodyFat, circumferences, input.Note
+	metric.Units = domain.MetricUnits
+	return nil
+}
+
+func checkUnits(units domain.BodyUnits) error {
+	verr := &ValidationError{}
+	addUnitErrors(units, verr)
+	return verr.Err()
+}
+
+func addUnitErrors(units domain.BodyUnits, verr *ValidationError) {
+	if !units.Weight.Valid() {
+		verr.Add("weightUnit", "must be %s or %s", domain.Kilograms, domain.Pounds)
+	}
+	if !units.Length.Valid() {
+		verr.Add("lengthUnit", "must be %s or %s", domain.Centimeters, domain.Inches)
+	}
+}
+
+func inUnits(metric *domain.BodyMetric, units domain.BodyUnits) *domain.BodyMetric {
+	converted := metric.In(units)
+	return &converted
+}
+
+// Trend metrics.
+const (
+	TrendBodyweight    = "bodyweight"
+	TrendBodyFat       = "bodyFat"
+	TrendCircumference = "circumference"
+)
+
+// BodyTrendInput selects a body metric trend. Site names the circumference
+// for TrendCircumference. WindowDays is the moving average length and
+// defaults to seven days.
+type BodyTrendInput struct {
+	Metric     string
+	Site       domain.CircumferenceSite
+	From       *time.Time
+	To         *time.Time
+	WindowDays int
+	Units      domain.BodyUnits
+}
+
+const (
+	defaultTrendWindowDays = 7
+	maxTrendWindowDays     = 90
+)
+
+// Trend returns one body metric over time, oldest first, each measurement
+// with the average of those taken in the WindowDays days up to it.
+// Measurements before From still count towards the first averages.
+func (s *BodyMetricService) Trend(userID string, input BodyTrendInput) (*domain.BodyTrend, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	verr := &ValidationError{}
+	if input.WindowDays == 0 {
+		input.WindowDays = defaultTrendWindowDays
+	}
+	if input.WindowDays < 1 || input.WindowDays > maxTrendWindowDays {
+		verr.Add("window", "must be between 1 and %d days", maxTrendWindowDays)
+	}
+	trend := &domain.BodyTrend{Metric: input.Metric, WindowDays: input.WindowDays, From: input.From, To: input.To, Points: []domain.BodyTrendPoint{}}
+	var value func(domain.BodyMetric) (float64, bool)
+	switch input.Metric {
+	case TrendBodyweight:
+		trend.Unit = string(input.Units.Weight)
+		value = func(m domain.BodyMetric) (float64, bool) {
+			if m.Bodyweight == nil {
+				return 0, false
+			}
+			return *m.Bodyweight, true
+		}
+	case TrendBodyFat:
+		trend.Unit = "%"
+		value = func(m domain.BodyMetric) (float64, bool) {
+			if m.BodyFat == nil {
+				return 0, false
+			}
+			return *m.BodyFat, true
+		}
+	case TrendCircumference:
+		trend.Unit, trend.Site = string(input.Units.Length), string(input.Site)
+		if !input.Site.Valid() {
+			verr.Add("site", "is not a known measurement site")
+		}
+		value = func(m domain.BodyMetric) (float64, bool) {
+			length, ok := m.Circumferences[input.Site]
+			return length, ok
+		}
+	default:
+		verr.Add("metric", "must be one of %s, %s, %s", TrendBodyweight, TrendBodyFat, TrendCircumference)
+	}
+	if input.From != nil && input.To != nil && !input.To.After(*input.From) {
+		verr.Add("to", "must be after from")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	if err := checkUnits(input.Units); err != nil {
+		return nil, err
+	}
+
+	filter := repository.BodyMetricFilter{UserID: userID, To: input.To}
+	if input.From != nil {
+		from := input.From.AddDate(0, 0, -input.WindowDays)
+		filter.From = &from
+	}
+	metrics, err := s.repository.BodyMetrics.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	var readings []domain.BodyTrendPoint
+	for i := len(metrics) - 1; i >= 0; i-- {
+		converted := metrics[i].In(input.Units)
+		if v, ok := value(converted); ok {
+			readings = append(readings, domain.BodyTrendPoint{MeasuredAt: converted.MeasuredAt, Value: v})
+		}
+	}
+	window := time.Duration(input.WindowDays) * 24 * time.Hour
+	first := 0
+	var sum float64
+	for i, reading := range readings {
+		sum += reading.Value
+		for reading.MeasuredAt.Sub(readings[first].MeasuredAt) >= window {
+			sum -= readings[first].Value
+			first++
+		}
+		if input.From != nil && reading.MeasuredAt.Before(*input.From) {
+			continue
+		}
+		reading.Average = math.Round(sum/float64(i-first+1)*100) / 100
+		trend.Points = append(trend.Points, reading)
+	}
+	if n := len(trend.Points); n > 0 {
+		trend.Change = math.Round((trend.Points[n-1].Average-trend.Points[0].Average)*100) / 100
+	}
+	return trend, nil
+}
@@ -2,83 +2,182 @@ package services
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/musclementour/app/internal/domain"
 	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/storage/blob"
 )
 
 type ExerciseService struct {
 	repository repository.Repository
+	blobs      blob.BlobStore
+	limits     MediaLimits
+	// seedReport is the outcome of the last SeedCatalogue call.
+	seedReport *domain.CatalogueSeedReport
 }
 
-func NewExerciseService(repo repository.Repository) *ExerciseService {
-	return &ExerciseService{repository: repo}
-}
-
-var DefaultExercises = []ExerciseInput{
-	{
-		Name:        "Barbell Back Squat",
-		Description: "Compound lower-body lift targeting quads and glutes.",
-		MuscleGroup: "Legs",
-		Equipment:   "Barbell",
-	},
-	{
-		Name:        "Bench Press",
-		Description: "Pressing movement focusing on chest, triceps, and shoulders.",
-		MuscleGroup: "Chest",
-		Equipment:   "Barbell",
-	},
-	{
-		Name:        "Deadlift",
-		Description: "Full-body posterior chain pull from the floor.",
-		MuscleGroup: "Back",
-		Equipment:   "Barbell",
-	},
-	{
-		Name:        "Pull-Up",
-		Description: "Bodyweight vertical pull emphasizing lats and biceps.",
-		MuscleGroup: "Back",
-		Equipment:   "Bodyweight",
-	},
-	{
-		Name:        "Plank",
-		Description: "Isometric core stabilization exercise.",
-		MuscleGroup: "Core",
-		Equipment:   "Bodyweight",
-	},
+func NewExerciseService(repo repository.Repository, blobs blob.BlobStore, limits MediaLimits) *ExerciseService {
+	return &ExerciseService{repository: repo, blobs: blobs, limits: limits}
 }
 
 type ExerciseInput struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	MuscleGroup string `json:"muscleGroup"`
-	Equipment   string `json:"equipment"`
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	MuscleGroup     string                 `json:"muscleGroup"`
+	Equipment       string                 `json:"equipment"`
+	MeasurementType domain.MeasurementType `json:"measurementType"`
+	Unilateral      bool                   `json:"unilateral"`
+	PrimaryMuscles  []string               `json:"primaryMuscles"`
+	MovementPattern string                 `json:"movementPattern"`
+	// Force skips the duplicate check for names that only look similar to
+	// an existing exercise.
+	Force bool `json:"force,omitempty"`
 }
 
+// List returns the active exercise library. Archived exercises stay in the
+// database for history but are hidden from athletes.
 func (s *ExerciseService) List() ([]domain.Exercise, error) {
-	return s.repository.Exercises.List()
+	all, err := s.repository.Exercises.List()
+	if err != nil {
+		return nil, err
+	}
+	exercises := make([]domain.Exercise, 0, len(all))
+	for _, ex := range all {
+		if !ex.Archived() {
+			exercises = append(exercises, ex)
+		}
+	}
+	if err := s.attachMedia(exercises); err != nil {
+		return nil, err
+	}
+	return exercises, nil
 }
 
-func (s *ExerciseService) Create(input ExerciseInput) (*domain.Exercise, error) {
+// Changes returns the exercise change feed after the given cursor for
+// content served in locale. Cursors remember their locale, and one issued
+// for another locale starts over from the beginning like an empty cursor, so
+// a client that switches language gets every exercise in the new one.
+func (s *ExerciseService) Changes(cursor, locale string) (*domain.ExerciseChangeFeed, error) {
+	var since int64
+	if cursor != "" {
+		raw, cursorLocale, _ := strings.Cut(cursor, ".")
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			return nil, ErrInvalidCursor
+		}
+		if cursorLocale == locale {
+			since = parsed
+		}
+	}
+	feed, err := s.repository.Exercises.Changes(since)
+	if err != nil {
+		return nil, err
+	}
+	feed.Cursor = strconv.FormatInt(feed.Seq, 10) + "." + locale
+	if err := s.attachMedia(feed.Exercises); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+func (s *ExerciseService) Archive(id string) (*domain.Exercise, error) {
+	now := time.Now().UTC()
+	return s.setArchived(id, &now)
+}
+
+func (s *ExerciseService) Unarchive(id string) (*domain.Exercise, error) {
+	return s.setArchived(id, nil)
+}
+
+func (s *ExerciseService) setArchived(id string, archivedAt *time.Time) (*domain.Exercise, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	if err := s.repository.Exercises.SetArchived(id, archivedAt); err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// ExerciseUpdateInput describes a partial update. Nil fields are left
+// untouched. When BaseRevision is set the update is rejected if another
+// revision was recorded in the meantime.
+type ExerciseUpdateInput struct {
+	ID              string                  `json:"id"`
+	Name            *string                 `json:"name"`
+	Description     *string                 `json:"description"`
+	MuscleGroup     *string                 `json:"muscleGroup"`
+	Equipment       *string                 `json:"equipment"`
+	MeasurementType *domain.MeasurementType `json:"measurementType"`
+	Unilateral      *bool                   `json:"unilateral"`
+	PrimaryMuscles  *[]string               `json:"primaryMuscles"`
+	MovementPattern *string                 `json:"movementPattern"`
+	BaseRevision    *int                    `json:"baseRevision"`
+}
+
+var (
+	ErrRevisionConflict       = errors.New("exercise was modified since the base revision")
+	ErrNameRequired           = errors.New("name is required")
+	ErrInvalidMeasurementType = errors.New("unknown measurement type")
+	ErrInvalidMovementPattern = errors.New("unknown movement pattern")
+	ErrInvalidCursor          = errors.New("invalid cursor")
+	// ErrMeasurementTypeInUse keeps logged entries valid: they were checked
+	// against the measurement type they were logged with.
+	ErrMeasurementTypeInUse = errors.New("the measurement type cannot change once workouts log the exercise")
+)
+
+func (s *ExerciseService) Create(actorID string, input ExerciseInput) (*domain.Exercise, error) {
 	if input.Name == "" {
-		return nil, errors.New("name is required")
+		return nil, ErrNameRequired
+	}
+	if input.MeasurementType == "" {
+		input.MeasurementType = domain.MeasurementWeightReps
+	}
+	if !input.MeasurementType.Valid() {
+		return nil, ErrInvalidMeasurementType
+	}
+	if !domain.ValidMovementPattern(input.MovementPattern) {
+		return nil, ErrInvalidMovementPattern
+	}
+	if !input.Force {
+		duplicates, err := s.FindDuplicates(input.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(duplicates) > 0 {
+			return nil, &DuplicateError{Candidates: duplicates}
+		}
 	}
 	ex := &domain.Exercise{
-		Name:        input.Name,
-		Description: input.Description,
-		MuscleGroup: input.MuscleGroup,
-		Equipment:   input.Equipment,
+		Name:            input.Name,
+		Description:     input.Description,
+		MuscleGroup:     input.MuscleGroup,
+		Equipment:       input.Equipment,
+		MeasurementType: input.MeasurementType,
+		Unilateral:      input.Unilateral,
+		PrimaryMuscles:  normalizeMuscles(input.PrimaryMuscles),
+		MovementPattern: input.MovementPattern,
+		Aliases:         []string{},
+		Media:           []domain.ExerciseMedia{},
+	}
+	rev := &domain.ExerciseRevision{
+		Number:   1,
+		Action:   domain.RevisionCreate,
+		AuthorID: actorID,
+		Changes:  domain.DiffSnapshots(domain.ExerciseSnapshot{}, ex.Snapshot()),
+		Snapshot: ex.Snapshot(),
 	}
-	if err := s.repository.Exercises.Create(ex); err != nil {
+	if err := s.repository.Exercises.Create(ex, rev); err != nil {
 		return nil, err
 	}
 	return ex, nil
 }
 
-func (s *ExerciseService) Update(input ExerciseInput) (*domain.Exercise, error) {
+func (s *ExerciseService) Update(actorID string, input ExerciseUpdateInput) (*domain.Exercise, error) {
 	if input.ID == "" {
 		return nil, errors.New("id is required")
 	}
@@ -86,42 +185,147 @@ func (s *ExerciseService) Update(input ExerciseInput) (*domain.Exercise, error)
 	if err != nil {
 		return nil, err
 	}
-	if input.Name != "" {
-		ex.Name = input.Name
+	next := ex.Snapshot()
+	if input.Name != nil {
+		if *input.Name == "" {
+			return nil, ErrNameRequired
+		}
+		next.Name = *input.Name
 	}
-	ex.Description = input.Description
-	ex.MuscleGroup = input.MuscleGroup
-	ex.Equipment = input.Equipment
-	ex.UpdatedAt = time.Now().UTC()
-	if err := s.repository.Exercises.Update(ex); err != nil {
+	if input.Description != nil {
+		next.Description = *input.Description
+	}
+	if input.MuscleGroup != nil {
+		next.MuscleGroup = *input.MuscleGroup
+	}
+	if input.Equipment != nil {
+		next.Equipment = *input.Equipment
+	}
+	if input.MeasurementType != nil {
+		if !input.MeasurementType.Valid() {
+			return nil, ErrInvalidMeasurementType
+		}
+		next.MeasurementType = *input.MeasurementType
+	}
+	if input.Unilateral != nil {
+		next.Unilateral = *input.Unilateral
+	}
+	if input.PrimaryMuscles != nil {
+		next.PrimaryMuscles = normalizeMuscles(*input.PrimaryMuscles)
+	}
+	if input.MovementPattern != nil {
+		if !domain.ValidMovementPattern(*input.MovementPattern) {
+			return nil, ErrInvalidMovementPattern
+		}
+		next.MovementPattern = *input.MovementPattern
+	}
+	return s.applySnapshot(actorID, ex, next, domain.RevisionUpdate, 0, input.BaseRevision)
+}
+
+// Revert restores the exercise to the state captured by the given revision.
+// The revert itself is recorded as a new revision so it can be undone too.
+func (s *ExerciseService) Revert(actorID, exerciseID string, number int) (*domain.Exercise, error) {
+	ex, err := s.repository.Exercises.GetByID(exerciseID)
+	if err != nil {
 		return nil, err
 	}
-	return ex, nil
+	target, err := s.repository.ExerciseRevisions.Get(exerciseID, number)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := target.Snapshot
+	if !snapshot.MeasurementType.Valid() {
+		// Revisions recorded before measurement types existed.
+		snapshot.MeasurementType = ex.MeasurementType
+	}
+	return s.applySnapshot(actorID, ex, snapshot, domain.RevisionRevert, number, nil)
 }
 
-func (s *ExerciseService) Delete(id string) error {
-	if id == "" {
-		return errors.New("id is required")
+func (s *ExerciseService) History(exerciseID string) ([]domain.ExerciseRevision, error) {
+	if _, err := s.repository.Exercises.GetByID(exerciseID); err != nil {
+		return nil, err
 	}
-	return s.repository.Exercises.Delete(id)
+	return s.repository.ExerciseRevisions.ListByExercise(exerciseID)
 }
 
-func (s *ExerciseService) EnsureDefaults() error {
-	existing, err := s.repository.Exercises.List()
+func (s *ExerciseService) applySnapshot(actorID string, ex *domain.Exercise, next domain.ExerciseSnapshot, action domain.RevisionAction, revertedFrom int, baseRevision *int) (*domain.Exercise, error) {
+	latest, err := s.latestRevision(ex)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if baseRevision != nil && *baseRevision != latest.Number {
+		return nil, ErrRevisionConflict
 	}
-	existingNames := make(map[string]struct{}, len(existing))
-	for _, ex := range existing {
-		existingNames[strings.ToLower(ex.Name)] = struct{}{}
+	changes := domain.DiffSnapshots(ex.Snapshot(), next)
+	if len(changes) == 0 {
+		return s.withMedia(ex)
 	}
-	for _, input := range DefaultExercises {
-		if _, ok := existingNames[strings.ToLower(input.Name)]; ok {
-			continue
+	if next.MeasurementType != ex.MeasurementType {
+		logged, err := s.repository.Workouts.LogsExercise(ex.ID)
+		if err != nil {
+			return nil, err
 		}
-		if _, err := s.Create(input); err != nil {
-			return err
+		if logged {
+			return nil, ErrMeasurementTypeInUse
+		}
+	}
+	rev := &domain.ExerciseRevision{
+		ExerciseID:   ex.ID,
+		Number:       latest.Number + 1,
+		Action:       action,
+		AuthorID:     actorID,
+		RevertedFrom: revertedFrom,
+		Changes:      changes,
+		Snapshot:     next,
+	}
+	ex.ApplySnapshot(next)
+	ex.UpdatedAt = time.Now().UTC()
+	// The revision number is unique per exercise, so a concurrent edit based
+	// on the same state fails here instead of silently overwriting this one.
+	if err := s.repository.Exercises.Update(ex, rev); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrRevisionConflict
 		}
+		return nil, err
+	}
+	return s.withMedia(ex)
+}
+
+// latestRevision returns the newest revision of an exercise. Exercises that
+// predate revision tracking get a baseline revision recorded on first use so
+// their original state can still be restored.
+func (s *ExerciseService) latestRevision(ex *domain.Exercise) (*domain.ExerciseRevision, error) {
+	latest, err := s.repository.ExerciseRevisions.Latest(ex.ID)
+	if err == nil {
+		return latest, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	baseline := &domain.ExerciseRevision{
+		ExerciseID: ex.ID,
+		Number:     1,
+		Action:     domain.RevisionBaseline,
+		Changes:    []domain.FieldChange{},
+		Snapshot:   ex.Snapshot(),
+	}
+	if err := s.repository.ExerciseRevisions.Create(baseline); err != nil && !errors.Is(err, repository.ErrConflict) {
+		return nil, err
+	}
+	return s.repository.ExerciseRevisions.Latest(ex.ID)
+}
+
+func (s *ExerciseService) Delete(id string) error {
+	if id == "" {
+		return errors.New("id is required")
+	}
+	media, err := s.repository.ExerciseMedia.ListByExercise(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.Exercises.Delete(id); err != nil {
+		return err
 	}
+	s.deleteBlobs(media)
 	return nil
 }
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// Weights of the alternative ranking. Shared muscles dominate so that a leg
+// press is a better squat substitute than a lunge of a different pattern.
+const (
+	alternativeMuscleWeight  = 0.6
+	alternativePatternWeight = 0.3
+	alternativeGroupWeight   = 0.1
+
+	defaultAlternativeLimit = 10
+)
+
+// bodyweightEquipment is always considered available.
+var bodyweightEquipment = map[string]bool{"": true, "bodyweight": true, "none": true}
+
+// AlternativesQuery narrows the alternatives to what the athlete can use.
+// An empty Equipment list means no restriction.
+type AlternativesQuery struct {
+	Equipment []string
+	Limit     int
+}
+
+// Alternatives ranks other active exercises as substitutes for exerciseID.
+// Admin-pinned substitutions come first in their curated order, followed by
+// the best matches on primary muscles, movement pattern and muscle group.
+// Ties are broken by name and id so results are fully deterministic.
+func (s *ExerciseService) Alternatives(exerciseID string, query AlternativesQuery) ([]domain.ExerciseAlternative, error) {
+	source, err := s.repository.Exercises.GetByID(exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	exercises, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	pinned, err := s.repository.Substitutions.ListPinned(exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	pinnedRank := make(map[string]int, len(pinned))
+	for i, id := range pinned {
+		pinnedRank[id] = i
+	}
+	available := map[string]bool{}
+	for _, equipment := range query.Equipment {
+		if trimmed := strings.ToLower(strings.TrimSpace(equipment)); trimmed != "" {
+			available[trimmed] = true
+		}
+	}
+
+	alternatives := []domain.ExerciseAlternative{}
+	for _, candidate := range exercises {
+		if candidate.ID == source.ID {
+			continue
+		}
+		equipment := strings.ToLower(strings.TrimSpace(candidate.Equipment))
+		if len(available) > 0 && !available[equipment] && !bodyweightEquipment[equipment] {
+			continue
+		}
+		alt := scoreAlternative(source, candidate)
+		if _, ok := pinnedRank[candidate.ID]; ok {
+			alt.Pinned = true
+			alt.Reasons = append([]string{"recommended by coaches"}, alt.Reasons...)
+		} else if alt.Score == 0 {
+			continue
+		}
+		alternatives = append(alternatives, alt)
+	}
+
+	sort.Slice(alternatives, func(i, j int) bool {
+		a, b := alternatives[i], alternatives[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if a.Pinned {
+			return pinnedRank[a.Exercise.ID] < pinnedRank[b.Exercise.ID]
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Exercise.Name != b.Exercise.Name {
+			return a.Exercise.Name < b.Exercise.Name
+		}
+		return a.Exercise.ID < b.Exercise.ID
+	})
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAlternativeLimit
+	}
+	if len(alternatives) > limit {
+		alternatives = alternatives[:limit]
+	}
+	return alternatives, nil
+}
+
+// PinAlternatives replaces the curated substitutions for an exercise. The
+// order of ids is the order they are recommended in.
+func (s *ExerciseService) PinAlternatives(exerciseID string, ids []string) ([]string, error) {
+	if _, err := s.repository.Exercises.GetByID(exerciseID); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	unique := []string{}
+	var verr ValidationError
+	for i, id := range ids {
+		field := fmt.Sprintf("exerciseIds[%d]", i)
+		if id == exerciseID {
+			verr.Add(field, "an exercise cannot be its own alternative")
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+		if _, err := s.repository.Exercises.GetByID(id); errors.Is(err, repository.ErrNotFound) {
+			verr.Add(field, "exercise does not exist")
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Substitutions.SetPinned(exerciseID, unique); err != nil {
+		return nil, err
+	}
+	return unique, nil
+}
+
+func scoreAlternative(source *domain.Exercise, candidate domain.Exercise) domain.ExerciseAlternative {
+	alt := domain.ExerciseAlternative{Exercise: candidate, Reasons: []string{}}
+
+	sourceMuscles := muscleSet(source.TargetMuscles())
+	candidateMuscles := muscleSet(candidate.TargetMuscles())
+	shared := []string{}
+	for muscle := range sourceMuscles {
+		if candidateMuscles[muscle] {
+			shared = append(shared, muscle)
+		}
+	}
+	if len(shared) > 0 {
+		sort.Strings(shared)
+		union := len(sourceMuscles) + len(candidateMuscles) - len(shared)
+		alt.Score += alternativeMuscleWeight * float64(len(shared)) / float64(union)
+		alt.Reasons = append(alt.Reasons, "works "+strings.Join(shared, ", "))
+	}
+	if source.MovementPattern != "" && source.MovementPattern == candidate.MovementPattern {
+		alt.Score += alternativePatternWeight
+		alt.Reasons = append(alt.Reasons, "same movement pattern ("+source.MovementPattern+")")
+	}
+	if source.MuscleGroup != "" && strings.EqualFold(source.MuscleGroup, candidate.MuscleGroup) {
+		alt.Score += alternativeGroupWeight
+		alt.Reasons = append(alt.Reasons, "same muscle group")
+	}
+	// Round so that floating point noise never decides the order.
+	alt.Score = float64(int(alt.Score*1000+0.5)) / 1000
+	return alt
+}
+
+func muscleSet(muscles []string) map[string]bool {
+	set := make(map[string]bool, len(muscles))
+	for _, m := range muscles {
+		set[strings.ToLower(strings.TrimSpace(m))] = true
+	}
+	return set
+}
+
+// normalizeMuscles lowercases, trims and de-duplicates muscle names while
+// keeping their order.
+func normalizeMuscles(muscles []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, m := range muscles {
+		m = strings.ToLower(strings.TrimSpace(m))
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	return out
+}
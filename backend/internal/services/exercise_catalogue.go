@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/musclementour/app/internal/catalogue"
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/search"
+)
+
+// SeedCatalogue brings the library up to the catalogue's version. Entries are
+// tracked by key: each is created (or adopted, if an exercise with the same
+// name or alias already exists) exactly once, so exercises that admins later
+// delete or merge away stay gone. Catalogues no newer than the last one
+// applied are skipped.
+func (s *ExerciseService) SeedCatalogue(cat *catalogue.Catalogue) (*domain.CatalogueSeedReport, error) {
+	version, err := s.repository.Seeds.Version()
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.CatalogueSeedReport{
+		FromVersion: version,
+		ToVersion:   version,
+		Created:     []string{},
+		Matched:     []string{},
+		SeededAt:    time.Now().UTC(),
+	}
+	if version >= cat.Version {
+		s.seedReport = report
+		return report, nil
+	}
+
+	seeded, err := s.repository.Seeds.Seeded()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repository.Exercises.List()
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(existing))
+	byTerm := make(map[string]string)
+	for _, ex := range existing {
+		exists[ex.ID] = true
+		byTerm[search.Normalize(ex.Name)] = ex.ID
+	}
+	for _, ex := range existing {
+		for _, alias := range ex.Aliases {
+			if _, taken := byTerm[search.Normalize(alias)]; !taken {
+				byTerm[search.Normalize(alias)] = ex.ID
+			}
+		}
+	}
+	linked := make(map[string]bool, len(seeded))
+	for _, id := range seeded {
+		linked[id] = true
+	}
+
+	for _, entry := range cat.Exercises {
+		if id, ok := seeded[entry.Key]; ok {
+			if !exists[id] {
+				report.Removed++
+			}
+			continue
+		}
+		if id := matchCatalogueEntry(entry, byTerm, linked); id != "" {
+			if err := s.repository.Seeds.Record(entry.Key, id); err != nil {
+				return nil, err
+			}
+			linked[id] = true
+			report.Matched = append(report.Matched, entry.Name)
+			continue
+		}
+		ex, err := s.createCatalogueEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repository.Seeds.Record(entry.Key, ex.ID); err != nil {
+			return nil, err
+		}
+		linked[ex.ID] = true
+		report.Created = append(report.Created, entry.Name)
+	}
+
+	if err := s.repository.Seeds.SetVersion(cat.Version); err != nil {
+		return nil, err
+	}
+	report.ToVersion = cat.Version
+	s.seedReport = report
+	return report, nil
+}
+
+// SeedReport returns what the last catalogue seeding changed, or nil if the
+// catalogue has not been seeded by this process.
+func (s *ExerciseService) SeedReport() *domain.CatalogueSeedReport {
+	return s.seedReport
+}
+
+// matchCatalogueEntry returns an existing exercise that the entry's name or
+// one of its aliases already refers to, unless that exercise belongs to
+// another catalogue entry.
+func matchCatalogueEntry(entry catalogue.Entry, byTerm map[string]string, linked map[string]bool) string {
+	for _, term := range append([]string{entry.Name}, entry.Aliases...) {
+		if id, ok := byTerm[search.Normalize(term)]; ok && !linked[id] {
+			return id
+		}
+	}
+	return ""
+}
+
+func (s *ExerciseService) createCatalogueEntry(entry catalogue.Entry) (*domain.Exercise, error) {
+	ex, err := s.Create("", ExerciseInput{
+		Name:            entry.Name,
+		Description:     entry.Description,
+		MuscleGroup:     entry.MuscleGroup,
+		Equipment:       entry.Equipment,
+		MeasurementType: entry.MeasurementType,
+		Unilateral:      entry.Unilateral,
+		PrimaryMuscles:  entry.PrimaryMuscles,
+		MovementPattern: entry.MovementPattern,
+		Force:           true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range entry.Aliases {
+		// An admin may already use the alias elsewhere; theirs wins.
+		if _, err := s.AddAlias(ex.ID, alias); err != nil && !errors.Is(err, repository.ErrConflict) {
+			return nil, err
+		}
+	}
+	return ex, nil
+}
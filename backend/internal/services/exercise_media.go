@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/media"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// MediaLimits caps upload sizes per media kind.
+type MediaLimits struct {
+	MaxImageBytes int64
+	MaxVideoBytes int64
+}
+
+var (
+	ErrMediaTooLarge = errors.New("media file is too large")
+	ErrInvalidImage  = errors.New("image could not be decoded")
+)
+
+// Get returns a single exercise including its media URLs.
+func (s *ExerciseService) Get(id string) (*domain.Exercise, error) {
+	ex, err := s.repository.Exercises.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withMedia(ex)
+}
+
+// AddMedia validates and stores an uploaded image or video. The content type
+// is sniffed from the data rather than trusted from the client, and images
+// get a JPEG thumbnail for list views and offline caching.
+func (s *ExerciseService) AddMedia(exerciseID string, file io.ReadSeeker, size int64) (*domain.ExerciseMedia, error) {
+	if _, err := s.repository.Exercises.GetByID(exerciseID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	kind, ext, err := media.Classify(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, contentType)
+	}
+	limit := s.limits.MaxImageBytes
+	if kind == media.KindVideo {
+		limit = s.limits.MaxVideoBytes
+	}
+	if size > limit {
+		return nil, fmt.Errorf("%w: %s uploads are limited to %d bytes", ErrMediaTooLarge, kind, limit)
+	}
+
+	item := &domain.ExerciseMedia{
+		ID:          uuid.NewString(),
+		ExerciseID:  exerciseID,
+		Kind:        string(kind),
+		ContentType: contentType,
+		SizeBytes:   size,
+	}
+	item.Key = fmt.Sprintf("exercises/%s/%s%s", exerciseID, item.ID, ext)
+
+	var thumbnail []byte
+	if kind == media.KindImage {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		thumbnail, err = media.Thumbnail(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		item.ThumbnailKey = fmt.Sprintf("exercises/%s/%s_thumb.jpg", exerciseID, item.ID)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(item.Key, file); err != nil {
+		return nil, fmt.Errorf("store media: %w", err)
+	}
+	if thumbnail != nil {
+		if err := s.blobs.Put(item.ThumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+			s.deleteBlobs([]domain.ExerciseMedia{*item})
+			return nil, fmt.Errorf("store thumbnail: %w", err)
+		}
+	}
+	if err := s.repository.ExerciseMedia.Create(item); err != nil {
+		s.deleteBlobs([]domain.ExerciseMedia{*item})
+		return nil, err
+	}
+	s.presentMedia(item)
+	return item, nil
+}
+
+func (s *ExerciseService) DeleteMedia(exerciseID, mediaID string) error {
+	item, err := s.repository.ExerciseMedia.Get(mediaID)
+	if err != nil {
+		return err
+	}
+	if item.ExerciseID != exerciseID {
+		return fmt.Errorf("%w: media %s does not belong to exercise %s", repository.ErrNotFound, mediaID, exerciseID)
+	}
+	if err := s.repository.ExerciseMedia.Delete(mediaID); err != nil {
+		return err
+	}
+	s.deleteBlobs([]domain.ExerciseMedia{*item})
+	return nil
+}
+
+// OpenMedia streams a stored blob for the media route.
+func (s *ExerciseService) OpenMedia(key string) (io.ReadCloser, error) {
+	return s.blobs.Open(key)
+}
+
+func (s *ExerciseService) withMedia(ex *domain.Exercise) (*domain.Exercise, error) {
+	items, err := s.repository.ExerciseMedia.ListByExercise(ex.ID)
+	if err != nil {
+		return nil, err
+	}
+	ex.Media = items
+	for i := range ex.Media {
+		s.presentMedia(&ex.Media[i])
+	}
+	return ex, nil
+}
+
+// attachMedia fills in media for a list of exercises with a single lookup.
+func (s *ExerciseService) attachMedia(exercises []domain.Exercise) error {
+	items, err := s.repository.ExerciseMedia.ListAll()
+	if err != nil {
+		return err
+	}
+	byExercise := map[string][]domain.ExerciseMedia{}
+	for _, item := range items {
+		s.presentMedia(&item)
+		byExercise[item.ExerciseID] = append(byExercise[item.ExerciseID], item)
+	}
+	for i := range exercises {
+		exercises[i].Media = byExercise[exercises[i].ID]
+		if exercises[i].Media == nil {
+			exercises[i].Media = []domain.ExerciseMedia{}
+		}
+	}
+	return nil
+}
+
+func (s *ExerciseService) presentMedia(item *domain.ExerciseMedia) {
+	item.URL = s.blobs.URL(item.Key)
+	if item.ThumbnailKey != "" {
+		item.ThumbnailURL = s.blobs.URL(item.ThumbnailKey)
+	}
+}
+
+// deleteBlobs removes stored files on a best-effort basis; a leftover file
+// is harmless while a failed request is not.
+func (s *ExerciseService) deleteBlobs(items []domain.ExerciseMedia) {
+	for _, item := range items {
+		for _, key := range []string{item.Key, item.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := s.blobs.Delete(key); err != nil {
+				log.Printf("delete media blob %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// MaxUploadBytes bounds the request body of a media upload, leaving room for
+// multipart framing around the largest allowed file.
+func (s *ExerciseService) MaxUploadBytes() int64 {
+	return max(s.limits.MaxImageBytes, s.limits.MaxVideoBytes) + 1<<20
+}
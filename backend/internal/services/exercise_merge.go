@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/search"
+)
+
+var (
+	ErrSelfMerge = errors.New("an exercise cannot be merged into itself")
+	// ErrIncompatibleMerge keeps moved entries valid: they were checked
+	// against the duplicate's measurement type and sidedness.
+	ErrIncompatibleMerge = errors.New("only exercises with the same measurement type and sidedness can be merged")
+)
+
+// MergeResult reports what a merge did.
+type MergeResult struct {
+	Exercise     *domain.Exercise `json:"exercise"`
+	MergedID     string           `json:"mergedId"`
+	MovedEntries int64            `json:"movedEntries"`
+}
+
+// Merge folds duplicateID into canonicalID. Workout entries, aliases, media,
+// translations, relations and pinned substitutions move to the canonical
+// exercise, the duplicate's name is kept as an alias and the duplicate is
+// removed with a "merged" tombstone. Personal records on the canonical
+// exercise are recomputed. The merge is recorded as a revision of the
+// canonical exercise. Exercises measured differently cannot be merged, nor
+// can merges that would close a cycle in the relation graph.
+func (s *ExerciseService) Merge(actorID, canonicalID, duplicateID string) (*MergeResult, error) {
+	if canonicalID == duplicateID {
+		return nil, ErrSelfMerge
+	}
+	canonical, err := s.repository.Exercises.GetByID(canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := s.repository.Exercises.GetByID(duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate.MeasurementType != canonical.MeasurementType || duplicate.Unilateral != canonical.Unilateral {
+		return nil, ErrIncompatibleMerge
+	}
+	relations, err := s.repository.Relations.List()
+	if err != nil {
+		return nil, err
+	}
+	if hasRelationCycle(mergedRelations(relations, canonicalID, duplicateID)) {
+		return nil, ErrRelationCycle
+	}
+	latest, err := s.latestRevision(canonical)
+	if err != nil {
+		return nil, err
+	}
+
+	merge := &domain.ExerciseMerge{
+		CanonicalID: canonicalID,
+		DuplicateID: duplicateID,
+		Revision: &domain.ExerciseRevision{
+			ExerciseID: canonicalID,
+			Number:     latest.Number + 1,
+			Action:     domain.RevisionMerge,
+			AuthorID:   actorID,
+			Changes: []domain.FieldChange{{
+				Field: "mergedFrom",
+				To:    map[string]string{"id": duplicate.ID, "name": duplicate.Name},
+			}},
+			Snapshot: canonical.Snapshot(),
+		},
+	}
+	alias := strings.TrimSpace(duplicate.Name)
+	normalized := search.Normalize(alias)
+	if normalized != "" && normalized != search.Normalize(canonical.Name) && !hasAlias(canonical, normalized) {
+		merge.Alias = alias
+		merge.NormalizedAlias = normalized
+	}
+
+	holders, err := s.repository.Records.Holders(duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.Exercises.Merge(merge); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrRevisionConflict
+		}
+		return nil, err
+	}
+	// The duplicate's records went with it; the moved workouts may beat the
+	// canonical exercise's.
+	for _, userID := range holders {
+		if err := refreshRecords(s.repository, userID, []string{canonicalID}); err != nil {
+			return nil, err
+		}
+	}
+	merged, err := s.Get(canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	return &MergeResult{Exercise: merged, MergedID: duplicateID, MovedEntries: merge.MovedEntries}, nil
+}
+
+// mergedRelations predicts the relations left after duplicateID is merged
+// into canonicalID, the way the repository applies the merge: relations
+// between the two are dropped, the duplicate's others move to the canonical
+// exercise and those that would collide with an existing one are dropped.
+func mergedRelations(relations []domain.ExerciseRelation, canonicalID, duplicateID string) []domain.ExerciseRelation {
+	merged := make([]domain.ExerciseRelation, 0, len(relations))
+	var fromDuplicate, toDuplicate []domain.ExerciseRelation
+	for _, rel := range relations {
+		switch {
+		case rel.FromID == duplicateID && rel.ToID == canonicalID, rel.FromID == canonicalID && rel.ToID == duplicateID:
+		case rel.FromID == duplicateID:
+			fromDuplicate = append(fromDuplicate, rel)
+		case rel.ToID == duplicateID:
+			toDuplicate = append(toDuplicate, rel)
+		default:
+			merged = append(merged, rel)
+		}
+	}
+	moved := make([]domain.ExerciseRelation, 0, len(fromDuplicate))
+	for _, rel := range fromDuplicate {
+		rel.FromID = canonicalID
+		if !collidesWith(merged, rel) {
+			moved = append(moved, rel)
+		}
+	}
+	merged = append(merged, moved...)
+	for _, rel := range toDuplicate {
+		rel.ToID = canonicalID
+		if !collidesWith(merged, rel) {
+			merged = append(merged, rel)
+		}
+	}
+	return merged
+}
+
+// collidesWith reports whether rel repeats one of relations or gives an
+// exercise a second variation parent.
+func collidesWith(relations []domain.ExerciseRelation, rel domain.ExerciseRelation) bool {
+	for _, existing := range relations {
+		if existing.FromID == rel.FromID && existing.Type == rel.Type &&
+			(existing.ToID == rel.ToID || rel.Type == domain.RelationVariationOf) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRelationCycle reports whether an exercise is its own variation ancestor
+// or sits on both sides of the easier-to-harder ordering.
+func hasRelationCycle(relations []domain.ExerciseRelation) bool {
+	parents := variationParents(relations)
+	for id := range parents {
+		for ancestor, hops := parents[id], 0; ancestor != "" && hops <= len(parents); ancestor, hops = parents[ancestor], hops+1 {
+			if ancestor == id {
+				return true
+			}
+		}
+	}
+	edges := harderEdges(relations)
+	for _, rel := range relations {
+		if easier, harder, ok := rel.HarderEdge(); ok && reachable(edges, harder, easier) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAlias(ex *domain.Exercise, normalized string) bool {
+	for _, alias := range ex.Aliases {
+		if search.Normalize(alias) == normalized {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+var (
+	ErrInvalidRelationType = errors.New("unknown relation type")
+	ErrRelationCycle       = errors.New("relation would create a cycle")
+	ErrSelfRelation        = errors.New("an exercise cannot be related to itself")
+)
+
+const (
+	defaultGraphDepth = 2
+	maxGraphDepth     = 5
+)
+
+type RelationInput struct {
+	Type     domain.RelationType `json:"type"`
+	TargetID string              `json:"targetId"`
+}
+
+// AddRelation links exerciseID to input.TargetID. Variations form a tree, so
+// an exercise has at most one parent and may not become its own ancestor.
+// Progressions and regressions are checked together as one easier-to-harder
+// ordering, so "A progression_to B" and "A regression_to B" cannot coexist.
+func (s *ExerciseService) AddRelation(exerciseID string, input RelationInput) (*domain.ExerciseRelation, error) {
+	if !input.Type.Valid() {
+		return nil, ErrInvalidRelationType
+	}
+	if exerciseID == input.TargetID {
+		return nil, ErrSelfRelation
+	}
+	for _, id := range []string{exerciseID, input.TargetID} {
+		if _, err := s.repository.Exercises.GetByID(id); err != nil {
+			return nil, err
+		}
+	}
+	relations, err := s.repository.Relations.List()
+	if err != nil {
+		return nil, err
+	}
+	relation := &domain.ExerciseRelation{FromID: exerciseID, ToID: input.TargetID, Type: input.Type}
+
+	if relation.Type == domain.RelationVariationOf {
+		parents := variationParents(relations)
+		if parent, ok := parents[exerciseID]; ok && parent != input.TargetID {
+			return nil, fmt.Errorf("%w: exercise is already a variation of %s", repository.ErrConflict, parent)
+		}
+		for id, seen := input.TargetID, 0; id != "" && seen <= len(parents); id, seen = parents[id], seen+1 {
+			if id == exerciseID {
+				return nil, ErrRelationCycle
+			}
+		}
+	} else {
+		easier, harder, _ := relation.HarderEdge()
+		if reachable(harderEdges(relations), harder, easier) {
+			return nil, ErrRelationCycle
+		}
+	}
+
+	if err := s.repository.Relations.Create(relation); err != nil {
+		return nil, err
+	}
+	return relation, nil
+}
+
+// RemoveRelation deletes a relation that touches exerciseID.
+func (s *ExerciseService) RemoveRelation(exerciseID, relationID string) error {
+	relation, err := s.repository.Relations.Get(relationID)
+	if err != nil {
+		return err
+	}
+	if relation.FromID != exerciseID && relation.ToID != exerciseID {
+		return repository.ErrNotFound
+	}
+	return s.repository.Relations.Delete(relationID)
+}
+
+// Graph returns every exercise within depth relations of exerciseID, following
+// relations in both directions, together with the relations between them.
+func (s *ExerciseService) Graph(exerciseID string, depth int) (*domain.ExerciseGraph, error) {
+	if depth <= 0 {
+		depth = defaultGraphDepth
+	}
+	depth = min(depth, maxGraphDepth)
+	if _, err := s.repository.Exercises.GetByID(exerciseID); err != nil {
+		return nil, err
+	}
+	exercises, err := s.repository.Exercises.List()
+	if err != nil {
+		return nil, err
+	}
+	relations, err := s.repository.Relations.List()
+	if err != nil {
+		return nil, err
+	}
+	neighbours := map[string][]string{}
+	for _, rel := range relations {
+		neighbours[rel.FromID] = append(neighbours[rel.FromID], rel.ToID)
+		neighbours[rel.ToID] = append(neighbours[rel.ToID], rel.FromID)
+	}
+
+	depths := map[string]int{exerciseID: 0}
+	frontier := []string{exerciseID}
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		next := []string{}
+		for _, id := range frontier {
+			for _, neighbour := range neighbours[id] {
+				if _, seen := depths[neighbour]; !seen {
+					depths[neighbour] = level
+					next = append(next, neighbour)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	graph := &domain.ExerciseGraph{RootID: exerciseID, Nodes: []domain.ExerciseGraphNode{}, Edges: []domain.ExerciseRelation{}}
+	for _, ex := range exercises {
+		if d, ok := depths[ex.ID]; ok {
+			graph.Nodes = append(graph.Nodes, domain.ExerciseGraphNode{ID: ex.ID, Name: ex.Name, Archived: ex.Archived(), Depth: d})
+		}
+	}
+	sort.SliceStable(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Depth != graph.Nodes[j].Depth {
+			return graph.Nodes[i].Depth < graph.Nodes[j].Depth
+		}
+		return graph.Nodes[i].Name < graph.Nodes[j].Name
+	})
+	for _, rel := range relations {
+		_, fromIn := depths[rel.FromID]
+		_, toIn := depths[rel.ToID]
+		if fromIn && toIn {
+			graph.Edges = append(graph.Edges, rel)
+		}
+	}
+	return graph, nil
+}
+
+// variationParents maps each exercise to the exercise it is a variation of.
+func variationParents(relations []domain.ExerciseRelation) map[string]string {
+	parents := map[string]string{}
+	for _, rel := range relations {
+		if rel.Type == domain.RelationVariationOf {
+			parents[rel.FromID] = rel.ToID
+		}
+	}
+	return parents
+}
+
+// variationRoots maps every variation to the top-most exercise of its tree.
+// Exercises that are not variations are absent from the map.
+func variationRoots(relations []domain.ExerciseRelation) map[string]string {
+	parents := variationParents(relations)
+	roots := make(map[string]string, len(parents))
+	for id := range parents {
+		root := id
+		// The hop limit guards against cycles inserted behind the service.
+		for hops := 0; hops <= len(parents); hops++ {
+			parent, ok := parents[root]
+			if !ok {
+				break
+			}
+			root = parent
+		}
+		roots[id] = root
+	}
+	return roots
+}
+
+func harderEdges(relations []domain.ExerciseRelation) map[string][]string {
+	edges := map[string][]string{}
+	for _, rel := range relations {
+		if easier, harder, ok := rel.HarderEdge(); ok {
+			edges[easier] = append(edges[easier], harder)
+		}
+	}
+	return edges
+}
+
+func reachable(edges map[string][]string, from, to string) bool {
+	seen := map[string]bool{from: true}
+	stack := []string{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		for _, next := range edges[id] {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
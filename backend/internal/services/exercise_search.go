@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/search"
+)
+
+const (
+	// searchThreshold is the minimum match score for search results.
+	searchThreshold = 0.5
+	// duplicateThreshold is stricter: it only flags names that are spelled
+	// almost the same, e.g. "Pull-Up" and "Pullup".
+	duplicateThreshold = 0.8
+)
+
+var ErrInvalidAlias = errors.New("alias must contain letters or digits")
+
+// DuplicateError is returned when a new exercise looks like one that already
+// exists. Callers can retry with Force to create it anyway.
+type DuplicateError struct {
+	Candidates []domain.Exercise
+}
+
+func (e *DuplicateError) Error() string {
+	names := make([]string, 0, len(e.Candidates))
+	for _, c := range e.Candidates {
+		names = append(names, fmt.Sprintf("%q", c.Name))
+	}
+	return "likely duplicate of " + strings.Join(names, ", ")
+}
+
+// Search returns active exercises whose name or aliases match the query,
+// best match first.
+func (s *ExerciseService) Search(query string) ([]domain.Exercise, error) {
+	exercises, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return rankExercises(query, exercises, searchThreshold, search.MatchScore), nil
+}
+
+// FindDuplicates returns exercises, archived ones included, whose name or
+// aliases are nearly identical to name.
+func (s *ExerciseService) FindDuplicates(name string) ([]domain.Exercise, error) {
+	exercises, err := s.repository.Exercises.List()
+	if err != nil {
+		return nil, err
+	}
+	return rankExercises(name, exercises, duplicateThreshold, search.Similarity), nil
+}
+
+func (s *ExerciseService) AddAlias(exerciseID, alias string) (*domain.Exercise, error) {
+	alias = strings.TrimSpace(alias)
+	normalized := search.Normalize(alias)
+	if normalized == "" {
+		return nil, ErrInvalidAlias
+	}
+	ex, err := s.repository.Exercises.GetByID(exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.repository.Exercises.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range all {
+		if search.Normalize(other.Name) != normalized {
+			continue
+		}
+		if other.ID == ex.ID {
+			return nil, fmt.Errorf("%w: alias repeats the exercise name", repository.ErrConflict)
+		}
+		return nil, fmt.Errorf("%w: alias matches exercise %q", repository.ErrConflict, other.Name)
+	}
+	if err := s.repository.Exercises.AddAlias(ex.ID, alias, normalized); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, fmt.Errorf("%w: alias is already in use", repository.ErrConflict)
+		}
+		return nil, err
+	}
+	return s.Get(ex.ID)
+}
+
+func (s *ExerciseService) RemoveAlias(exerciseID, alias string) (*domain.Exercise, error) {
+	if err := s.repository.Exercises.RemoveAlias(exerciseID, search.Normalize(alias)); err != nil {
+		return nil, err
+	}
+	return s.Get(exerciseID)
+}
+
+func rankExercises(query string, exercises []domain.Exercise, threshold float64, score func(query, term string) float64) []domain.Exercise {
+	byID := make(map[string]domain.Exercise, len(exercises))
+	candidates := make([]search.Candidate, 0, len(exercises))
+	for _, ex := range exercises {
+		byID[ex.ID] = ex
+		candidates = append(candidates, search.Candidate{
+			ID:    ex.ID,
+			Terms: append([]string{ex.Name}, ex.Aliases...),
+		})
+	}
+	matches := search.Rank(query, candidates, threshold, score)
+	ranked := make([]domain.Exercise, 0, len(matches))
+	for _, m := range matches {
+		ranked = append(ranked, byID[m.ID])
+	}
+	return ranked
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/musclementour/app/internal/domain"
+)
+
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+type TranslationInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// NegotiateLocale picks the locale exercise content is served in. An explicit
+// choice (query parameter) wins over the user's saved preference, which wins
+// over the Accept-Language header. Unsupported values are skipped and the
+// default locale is the final fallback.
+func NegotiateLocale(explicit, preferred, acceptLanguage string) string {
+	for _, candidate := range []string{explicit, preferred} {
+		if locale := matchLocale(candidate); locale != "" {
+			return locale
+		}
+	}
+	for _, candidate := range parseAcceptLanguage(acceptLanguage) {
+		if locale := matchLocale(candidate); locale != "" {
+			return locale
+		}
+	}
+	return domain.DefaultLocale
+}
+
+// matchLocale reduces a language tag such as "de-AT" to a supported locale.
+func matchLocale(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return ""
+	}
+	if domain.SupportedLocale(tag) {
+		return tag
+	}
+	if base, _, found := strings.Cut(tag, "-"); found && domain.SupportedLocale(base) {
+		return base
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the tags of an Accept-Language header ordered by
+// their quality value. Tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		out = append(out, t.tag)
+	}
+	return out
+}
+
+// PreferredLocale returns the saved locale of a user, or "" for anonymous
+// readers and users without a preference.
+func (s *ExerciseService) PreferredLocale(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	user, err := s.repository.Users.GetByID(userID)
+	if err != nil {
+		return ""
+	}
+	return user.Locale
+}
+
+// Localize replaces names and descriptions with their translation in locale.
+// Exercises without a translation keep the default-locale text, and a
+// translation without a description falls back to the default description.
+func (s *ExerciseService) Localize(exercises []domain.Exercise, locale string) ([]domain.Exercise, error) {
+	if locale == "" || locale == domain.DefaultLocale {
+		return exercises, nil
+	}
+	translations, err := s.repository.Translations.ListByLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	byExercise := make(map[string]domain.ExerciseTranslation, len(translations))
+	for _, t := range translations {
+		byExercise[t.ExerciseID] = t
+	}
+	for i := range exercises {
+		t, ok := byExercise[exercises[i].ID]
+		if !ok {
+			continue
+		}
+		exercises[i].Name = t.Name
+		if t.Description != "" {
+			exercises[i].Description = t.Description
+		}
+		exercises[i].Locale = locale
+	}
+	return exercises, nil
+}
+
+func (s *ExerciseService) Translations(exerciseID string) ([]domain.ExerciseTranslation, error) {
+	if _, err := s.repository.Exercises.GetByID(exerciseID); err != nil {
+		return nil, err
+	}
+	return s.repository.Translations.ListByExercise(exerciseID)
+}
+
+// SetTranslation creates or replaces the translation of an exercise. The
+// default locale cannot be translated; edit the exercise itself instead.
+func (s *ExerciseService) SetTranslation(exerciseID, locale string, input TranslationInput) (*domain.ExerciseTranslation, error) {
+	if !domain.SupportedLocale(locale) || locale == domain.DefaultLocale {
+		return nil, ErrUnsupportedLocale
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+	if _, err := s.repository.Exercises.GetByID(exerciseID); err != nil {
+		return nil, err
+	}
+	translation := &domain.ExerciseTranslation{
+		ExerciseID:  exerciseID,
+		Locale:      locale,
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+	}
+	if err := s.repository.Translations.Upsert(translation); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+func (s *ExerciseService) DeleteTranslation(exerciseID, locale string) error {
+	return s.repository.Translations.Delete(exerciseID, locale)
+}
+
+// TranslationCoverage reports, for every non-default locale, how many active
+// exercises are translated and which ones are still missing.
+func (s *ExerciseService) TranslationCoverage() ([]domain.TranslationCoverage, error) {
+	exercises, err := s.repository.Exercises.List()
+	if err != nil {
+		return nil, err
+	}
+	coverage := []domain.TranslationCoverage{}
+	for _, locale := range domain.SupportedLocales {
+		if locale == domain.DefaultLocale {
+			continue
+		}
+		translations, err := s.repository.Translations.ListByLocale(locale)
+		if err != nil {
+			return nil, err
+		}
+		translated := make(map[string]bool, len(translations))
+		for _, t := range translations {
+			translated[t.ExerciseID] = true
+		}
+		report := domain.TranslationCoverage{Locale: locale, Missing: []domain.TranslationTarget{}}
+		for _, ex := range exercises {
+			if ex.Archived() {
+				continue
+			}
+			report.Total++
+			if translated[ex.ID] {
+				report.Translated++
+			} else {
+				report.Missing = append(report.Missing, domain.TranslationTarget{ID: ex.ID, Name: ex.Name})
+			}
+		}
+		if report.Total > 0 {
+			report.Percent = math.Round(float64(report.Translated)/float64(report.Total)*1000) / 10
+		}
+		coverage = append(coverage, report)
+	}
+	return coverage, nil
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/records"
+	"github.com/musclementour/app/internal/repository"
+	"github.com/musclementour/app/internal/trend"
+)
+
+type GoalService struct {
+	repository repository.Repository
+}
+
+func NewGoalService(repo repository.Repository) *GoalService {
+	return &GoalService{repository: repo}
+}
+
+// GoalInput creates or replaces a goal. Deadline is a YYYY-MM-DD date.
+// ExerciseID and Reps only apply to strength goals, where Reps defaults
+// to 1.
+type GoalInput struct {
+	Type       domain.GoalType `json:"type"`
+	ExerciseID string          `json:"exerciseId"`
+	Target     float64         `json:"target"`
+	Reps       int             `json:"reps"`
+	Deadline   string          `json:"deadline"`
+}
+
+const (
+	maxGoalReps          = 30
+	maxSessionsPerWeek   = 14
+	minBodyweight        = 20
+	maxBodyweight        = 400
+	maxGoalHorizonYears  = 5
+	goalTrendWindow      = 12 * 7 * 24 * time.Hour
+	frequencyWindowWeeks = 4
+)
+
+// List returns the user's goals by deadline, each evaluated against their
+// history.
+func (s *GoalService) List(userID string) ([]domain.GoalProgress, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	goals, err := s.repository.Goals.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	evaluated := make([]domain.GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		progress, err := s.evaluate(goal, now)
+		if err != nil {
+			return nil, err
+		}
+		evaluated = append(evaluated, *progress)
+	}
+	return evaluated, nil
+}
+
+// Get returns one of the user's goals, evaluated. Other users' goals are
+// reported as not found.
+func (s *GoalService) Get(userID, id string) (*domain.GoalProgress, error) {
+	goal, err := s.owned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluate(*goal, time.Now().UTC())
+}
+
+func (s *GoalService) Create(userID string, input GoalInput) (*domain.GoalProgress, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	now := time.Now().UTC()
+	goal := &domain.Goal{UserID: userID}
+	if err := s.apply(goal, input, dateOf(now)); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Goals.Create(goal); err != nil {
+		return nil, err
+	}
+	return s.evaluate(*goal, now)
+}
+
+// Update replaces a goal's type, target and deadline. The goal is evaluated
+// afresh, so an achieved goal whose target moved has to be reached again.
+func (s *GoalService) Update(userID, id string, input GoalInput) (*domain.GoalProgress, error) {
+	goal, err := s.owned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(goal, input, dateOf(goal.CreatedAt)); err != nil {
+		return nil, err
+	}
+	goal.AchievedAt = nil
+	if err := s.repository.Goals.Update(goal); err != nil {
+		return nil, err
+	}
+	return s.evaluate(*goal, time.Now().UTC())
+}
+
+func (s *GoalService) Delete(userID, id string) error {
+	if _, err := s.owned(userID, id); err != nil {
+		return err
+	}
+	return s.repository.Goals.Delete(id)
+}
+
+func (s *GoalService) owned(userID, id string) (*domain.Goal, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	goal, err := s.repository.Goals.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if goal.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return goal, nil
+}
+
+// apply validates input and copies it onto goal. The deadline must fall
+// after since, the day the goal was set.
+func (s *GoalService) apply(goal *domain.Goal, input GoalInput, since time.Time) error {
+	verr := &ValidationError{}
+	switch input.Type {
+	case domain.GoalStrength:
+		if input.ExerciseID == "" {
+			verr.Add("exerciseId", "is required for strength goals")
+		} else if ex, err := s.repository.Exercises.GetByID(input.ExerciseID); errors.Is(err, repository.ErrNotFound) {
+			verr.Add("exerciseId", "does not exist")
+		} else if err != nil {
+			return err
+		} else if mt := ex.MeasurementType; mt.Valid() && mt != domain.MeasurementWeightReps {
+			verr.Add("exerciseId", "must be a %s exercise", domain.MeasurementWeightReps)
+		}
+		if input.Target <= 0 || input.Target > maxWeight {
+			verr.Add("target", "must be greater than 0 and at most %g", maxWeight)
+		}
+		if input.Reps == 0 {
+			input.Reps = 1
+		} else if input.Reps < 1 || input.Reps > maxGoalReps {
+			verr.Add("reps", "must be between 1 and %d", maxGoalReps)
+		}
+	case domain.GoalFrequency, domain.GoalBodyweight:
+		if input.ExerciseID != "" {
+			verr.Add("exerciseId", "must be empty for %s goals", input.Type)
+		}
+		if input.Reps != 0 {
+			verr.Add("reps", "must be empty for %s goals", input.Type)
+		}
+		if input.Type == domain.GoalFrequency && (input.Target < 1 || input.Target > maxSessionsPerWeek) {
+			verr.Add("target", "must be between 1 and %d workouts a week", maxSessionsPerWeek)
+		}
+		if input.Type == domain.GoalBodyweight && (input.Target < minBodyweight || input.Target > maxBodyweight) {
+			verr.Add("target", "must be between %d and %d", minBodyweight, maxBodyweight)
+		}
+	default:
+		verr.Add("type", "must be one of strength, frequency, bodyweight")
+	}
+	deadline, err := time.Parse(time.DateOnly, input.Deadline)
+	switch {
+	case input.Deadline == "":
+		verr.Add("deadline", "is required")
+	case err != nil:
+		verr.Add("deadline", "must be a date in YYYY-MM-DD format")
+	case !deadline.After(since):
+		verr.Add("deadline", "must be after %s", since.Format(time.DateOnly))
+	case deadline.After(since.AddDate(maxGoalHorizonYears, 0, 0)):
+		verr.Add("deadline", "must be within %d years", maxGoalHorizonYears)
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+	goal.Type, goal.ExerciseID, goal.Target, goal.Reps, goal.Deadline = input.Type, input.ExerciseID, input.Target, input.Reps, deadline
+	return nil
+}
+
+// goalSeries is what a goal is measured by. Points are ordered by time.
+type goalSeries struct {
+	points  []trend.Point
+	start   *float64
+	current *float64
+	target  float64
+	rising  bool
+}
+
+// evaluate measures goal against the athlete's history as of now, records
+// when it was first reached and projects when it will be otherwise.
+func (s *GoalService) evaluate(goal domain.Goal, now time.Time) (*domain.GoalProgress, error) {
+	var series *goalSeries
+	var err error
+	switch goal.Type {
+	case domain.GoalStrength:
+		series, err = s.strengthSeries(goal, now)
+	case domain.GoalFrequency:
+		series, err = s.frequencySeries(goal, now)
+	default:
+		series, err = s.bodyweightSeries(goal)
+	}
+	if err != nil {
+		return nil, err
+	}
+	progress := &domain.GoalProgress{Goal: goal, TargetValue: series.target, Start: series.start, Current: series.current}
+	reached := func(value float64) bool {
+		if series.rising {
+			return value >= series.target
+		}
+		return value <= series.target
+	}
+
+	if progress.AchievedAt == nil {
+		var achievedAt *time.Time
+		if series.start != nil && reached(*series.start) {
+			achievedAt = &goal.CreatedAt
+		}
+		for _, p := range series.points {
+			if achievedAt == nil && !p.At.Before(goal.CreatedAt) && reached(p.Value) {
+				at := p.At
+				achievedAt = &at
+			}
+		}
+		if achievedAt != nil {
+			if err := s.repository.Goals.Achieve(goal.ID, *achievedAt); err != nil {
+				return nil, err
+			}
+			progress.AchievedAt = achievedAt
+		}
+	}
+	if progress.AchievedAt != nil {
+		progress.Status, progress.Percent = domain.GoalAchieved, 100
+		return progress, nil
+	}
+
+	if series.start != nil && series.current != nil && series.target != *series.start {
+		percent := (*series.current - *series.start) / (series.target - *series.start) * 100
+		progress.Percent = math.Round(math.Max(0, math.Min(100, percent))*10) / 10
+	}
+	var recent []trend.Point
+	for _, p := range series.points {
+		if now.Sub(p.At) <= goalTrendWindow {
+			recent = append(recent, p)
+		}
+	}
+	if model, ok := trend.Fit(recent); ok {
+		if date, ok := model.Reach(series.target, series.rising, now, now.AddDate(maxGoalHorizonYears, 0, 0)); ok {
+			progress.Projection = &domain.GoalProjection{Model: string(model.Kind), Date: date}
+		}
+	}
+	progress.Status = domain.GoalBehind
+	deadlineEnd := goal.Deadline.AddDate(0, 0, 1)
+	if progress.Projection != nil && progress.Projection.Date.Before(deadlineEnd) {
+		progress.Status = domain.GoalOnTrack
+	}
+	return progress, nil
+}
+
+// strengthSeries measures each workout by its best estimated one-rep max on
+// the exercise. The goal starts from the best estimate before it was set and
+// stands at the best of the last twelve weeks, or the latest estimate when
+// the exercise was not trained in that time.
+func (s *GoalService) strengthSeries(goal domain.Goal, now time.Time) (*goalSeries, error) {
+	sessions, err := s.repository.Workouts.ListSessions(repository.SessionFilter{UserID: goal.UserID, ExerciseID: goal.ExerciseID}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	series := &goalSeries{target: roundHundredth(records.EstimateOneRepMax(goal.Target, goal.Reps)), rising: true}
+	for i := len(sessions) - 1; i >= 0; i-- {
+		var best float64
+		for _, entry := range sessions[i].Entries {
+			if entry.ExerciseID != goal.ExerciseID {
+				continue
+			}
+			for _, set := range entry.WorkingSets() {
+				best = math.Max(best, records.EstimateOneRepMax(set.Weight, set.Reps))
+			}
+		}
+		if best > 0 {
+			series.points = append(series.points, trend.Point{At: sessions[i].StartedAt, Value: roundHundredth(best)})
+		}
+	}
+	var before, recent *float64
+	for _, p := range series.points {
+		value := p.Value
+		if !p.At.After(goal.CreatedAt) && (before == nil || value > *before) {
+			before = &value
+		}
+		if now.Sub(p.At) <= goalTrendWindow && (recent == nil || value > *recent) {
+			recent = &value
+		}
+	}
+	if len(series.points) > 0 {
+		first, last := series.points[0].Value, series.points[len(series.points)-1].Value
+		series.start, series.current = &first, &last
+	}
+	if before != nil {
+		series.start = before
+	}
+	if recent != nil {
+		series.current = recent
+	}
+	return series, nil
+}
+
+// frequencySeries samples the average number of workouts a week over the
+// four weeks before each of the last twelve weeks, and before the goal was
+// set.
+func (s *GoalService) frequencySeries(goal domain.Goal, now time.Time) (*goalSeries, error) {
+	earliest := now.Add(-goalTrendWindow)
+	if goal.CreatedAt.Before(earliest) {
+		earliest = goal.CreatedAt
+	}
+	from := earliest.AddDate(0, 0, -7*frequencyWindowWeeks)
+	sessions, err := s.repository.Workouts.ListSessions(repository.SessionFilter{UserID: goal.UserID, From: &from}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	starts := make([]time.Time, 0, len(sessions))
+	for _, session := range sessions {
+		starts = append(starts, session.StartedAt)
+	}
+	weeklyAverage := func(at time.Time) float64 {
+		windowStart := at.AddDate(0, 0, -7*frequencyWindowWeeks)
+		count := 0
+		for _, started := range starts {
+			if started.After(windowStart) && !started.After(at) {
+				count++
+			}
+		}
+		return float64(count) / frequencyWindowWeeks
+	}
+
+	series := &goalSeries{target: goal.Target, rising: true}
+	for weeksAgo := int(goalTrendWindow / (7 * 24 * time.Hour)); weeksAgo >= 0; weeksAgo-- {
+		at := now.AddDate(0, 0, -7*weeksAgo)
+		series.points = append(series.points, trend.Point{At: at, Value: weeklyAverage(at)})
+	}
+	start, current := weeklyAverage(goal.CreatedAt), weeklyAverage(now)
+	series.start, series.current = &start, &current
+	sort.Slice(series.points, func(i, j int) bool { return series.points[i].At.Before(series.points[j].At) })
+	return series, nil
+}
+
+// bodyweightSeries measures bodyweight goals by the logged bodyweight. The
+// goal starts from the last weighing before it was set, or the first one
+// after, and the direction to the target is taken from there.
+func (s *GoalService) bodyweightSeries(goal domain.Goal) (*goalSeries, error) {
+	metrics, err := s.repository.BodyMetrics.List(repository.BodyMetricFilter{UserID: goal.UserID})
+	if err != nil {
+		return nil, err
+	}
+	series := &goalSeries{target: goal.Target}
+	for i := len(metrics) - 1; i >= 0; i-- {
+		if weight := metrics[i].Bodyweight; weight != nil {
+			series.points = append(series.points, trend.Point{At: metrics[i].MeasuredAt, Value: roundHundredth(*weight)})
+		}
+	}
+	if len(series.points) == 0 {
+		return series, nil
+	}
+	start, current := series.points[0].Value, series.points[len(series.points)-1].Value
+	for _, p := range series.points {
+		if !p.At.After(goal.CreatedAt) {
+			start = p.Value
+		}
+	}
+	series.start, series.current = &start, &current
+	series.rising = goal.Target > start
+	return series, nil
+}
+
+// roundHundredth rounds one-rep max estimates to two decimal places.
+func roundHundredth(value float64) float64 {
+	return math.Round(value*100) / 100
+}
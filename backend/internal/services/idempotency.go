@@ -0,0 +1,121 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/repository"
+)
+
+// maxIdempotencyKeyLength bounds client-chosen keys.
+const maxIdempotencyKeyLength = 255
+
+var ErrIdempotencyConflict = errors.New("idempotency key was already used for a different request")
+
+// submissionLookup returns the request hash and response stored under a
+// user's idempotency key, or repository.ErrNotFound.
+type submissionLookup func(userID, key string) (hash string, response []byte, err error)
+
+// idempotentCreate is a create request a client may retry. Its key is the
+// explicit idempotency key or, without one, the client-chosen id. Requests
+// with neither are not tracked.
+type idempotentCreate struct {
+	userID string
+	key    string
+	hash   string
+	lookup submissionLookup
+}
+
+// newIdempotentCreate checks the client's id and key, adding problems to
+// verr, and fingerprints input when there is a key.
+func newIdempotentCreate(userID, id, key string, input any, lookup submissionLookup, verr *ValidationError) (*idempotentCreate, error) {
+	if key == "" {
+		key = id
+	}
+	if id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			verr.Add("id", "must be a UUID")
+		}
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		verr.Add("idempotencyKey", "must be at most %d characters", maxIdempotencyKeyLength)
+	}
+	request := &idempotentCreate{userID: userID, key: key, lookup: lookup}
+	if key != "" {
+		hash, err := requestHash(input)
+		if err != nil {
+			return nil, err
+		}
+		request.hash = hash
+	}
+	return request, nil
+}
+
+// tracked reports whether the request has a key.
+func (c *idempotentCreate) tracked() bool {
+	return c.key != ""
+}
+
+// id returns id, or when the client chose none one derived from the key so
+// that concurrent retries collide. scope keeps kinds of resources apart.
+func (c *idempotentCreate) id(id, scope string) string {
+	if id != "" {
+		return id
+	}
+	name := c.userID + "/" + c.key
+	if scope != "" {
+		name = c.userID + "/" + scope + "/" + c.key
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// replay decodes the response stored under the key into out and reports
+// whether there was one. A key used for a different request fails with
+// ErrIdempotencyConflict.
+func (c *idempotentCreate) replay(out any) (bool, error) {
+	if !c.tracked() {
+		return false, nil
+	}
+	hash, response, err := c.lookup(c.userID, c.key)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if hash != c.hash {
+		return false, ErrIdempotencyConflict
+	}
+	return true, json.Unmarshal(response, out)
+}
+
+// store runs create, which stores the resource together with its
+// submission and fails with repository.ErrConflict when either exists. When
+// a retry of the same request won the race, its response is decoded into
+// out and replayed is true; otherwise the resource's id is taken.
+func (c *idempotentCreate) store(create func() error, out any, resource string) (replayed bool, err error) {
+	err = create()
+	if !errors.Is(err, repository.ErrConflict) {
+		return false, err
+	}
+	if replayed, err := c.replay(out); err != nil || replayed {
+		return replayed, err
+	}
+	return false, fmt.Errorf("%w: %s id is already in use", repository.ErrConflict, resource)
+}
+
+// requestHash fingerprints a submission so retries can be told apart from
+// different submissions reusing a key.
+func requestHash(input any) (string, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// LiveWorkoutService runs workouts in progress: it times sets as they are
+// logged and saves the workout through WorkoutService when it is finished.
+type LiveWorkoutService struct {
+	repository repository.Repository
+	workouts   *WorkoutService
+	// timeout is how long a live workout may go without activity before it
+	// is closed. Zero disables closing.
+	timeout time.Duration
+}
+
+func NewLiveWorkoutService(repo repository.Repository, workouts *WorkoutService, timeout time.Duration) *LiveWorkoutService {
+	return &LiveWorkoutService{repository: repo, workouts: workouts, timeout: timeout}
+}
+
+// LiveStartInput starts a live workout, optionally for one of the user's
+// planned program sessions.
+type LiveStartInput struct {
+	PlannedSessionID string `json:"plannedSessionId"`
+}
+
+// LiveEntryInput adds an exercise to a live workout.
+type LiveEntryInput struct {
+	ExerciseID string      `json:"exerciseId"`
+	Side       domain.Side `json:"side"`
+	Notes      string      `json:"notes"`
+}
+
+// maxLiveAttempts bounds how often a change is retried when another device
+// changed the workout at the same time.
+const maxLiveAttempts = 3
+
+var errLiveWorkoutChanged = fmt.Errorf("%w: the live workout changed on another device, try again", repository.ErrConflict)
+
+// Start begins a live workout. A user can only have one at a time.
+func (s *LiveWorkoutService) Start(userID string, input LiveStartInput) (*domain.LiveWorkout, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	if err := s.closeIdle(userID); err != nil {
+		return nil, err
+	}
+	if input.PlannedSessionID != "" {
+		verr := &ValidationError{}
+		if err := s.workouts.checkPlanned(userID, input.PlannedSessionID, verr); err != nil {
+			return nil, err
+		}
+		if err := verr.Err(); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().UTC()
+	live := &domain.LiveWorkout{
+		ID:               uuid.NewString(),
+		UserID:           userID,
+		StartedAt:        now,
+		LastActivityAt:   now,
+		PlannedSessionID: input.PlannedSessionID,
+		Entries:          []domain.LiveEntry{},
+	}
+	if err := s.repository.LiveWorkouts.Create(live); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, fmt.Errorf("%w: a live workout is already in progress", repository.ErrConflict)
+		}
+		return nil, err
+	}
+	return s.present(live), nil
+}
+
+// Current returns the user's live workout, so it can be resumed on any
+// device.
+func (s *LiveWorkoutService) Current(userID string) (*domain.LiveWorkout, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	if err := s.closeIdle(userID); err != nil {
+		return nil, err
+	}
+	live, err := s.repository.LiveWorkouts.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.present(live), nil
+}
+
+func (s *LiveWorkoutService) AddEntry(userID string, input LiveEntryInput) (*domain.LiveWorkout, error) {
+	return s.change(userID, func(live *domain.LiveWorkout, now time.Time) error {
+		verr := &ValidationError{}
+		ex, err := newExerciseLookup(s.repository.Exercises).check(input.ExerciseID, "exerciseId", verr)
+		if err != nil {
+			return err
+		}
+		entry := domain.LiveEntry{ID: uuid.NewString(), ExerciseID: input.ExerciseID, Notes: input.Notes, Sets: []domain.WorkoutSet{}}
+		if ex != nil {
+			entry.Side = checkSide(ex, input.Side, "side", verr)
+		}
+		if len(live.Entries) >= maxEntriesPerSession {
+			verr.Add("entries", "must contain at most %d entries", maxEntriesPerSession)
+		}
+		if err := verr.Err(); err != nil {
+			return err
+		}
+		live.Entries = append(live.Entries, entry)
+		return nil
+	})
+}
+
+func (s *LiveWorkoutService) RemoveEntry(userID, entryID string) (*domain.LiveWorkout, error) {
+	return s.change(userID, func(live *domain.LiveWorkout, now time.Time) error {
+		for i, entry := range live.Entries {
+			if entry.ID == entryID {
+				live.Entries = append(live.Entries[:i], live.Entries[i+1:]...)
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+}
+
+// AddSet logs a set on an entry. The server stamps when it was completed and
+// the rest taken since the previous set of the workout; timings sent by the
+// client are ignored.
+func (s *LiveWorkoutService) AddSet(userID, entryID string, input WorkoutSetInput) (*domain.LiveWorkout, error) {
+	return s.change(userID, func(live *domain.LiveWorkout, now time.Time) error {
+		entry, err := liveEntry(live, entryID)
+		if err != nil {
+			return err
+		}
+		input.CompletedAt, input.RestSeconds = nil, nil
+		set, err := s.liveSet(entry, input)
+		if err != nil {
+			return err
+		}
+		if len(entry.Sets) >= maxSetsPerEntry {
+			verr := &ValidationError{}
+			verr.Add("sets", "must contain at most %d sets", maxSetsPerEntry)
+			return verr.Err()
+		}
+		if last := live.LastSetAt(); last != nil {
+			rest := int(now.Sub(*last) / time.Second)
+			set.RestSeconds = &rest
+		}
+		set.CompletedAt = &now
+		entry.Sets = append(entry.Sets, set)
+		return nil
+	})
+}
+
+// UpdateSet corrects a logged set, keeping its timing.
+func (s *LiveWorkoutService) UpdateSet(userID, entryID string, index int, input WorkoutSetInput) (*domain.LiveWorkout, error) {
+	return s.change(userID, func(live *domain.LiveWorkout, now time.Time) error {
+		entry, err := liveEntry(live, entryID)
+		if err != nil {
+			return err
+		}
+		if index < 0 || index >= len(entry.Sets) {
+			return repository.ErrNotFound
+		}
+		input.CompletedAt, input.RestSeconds = nil, nil
+		set, err := s.liveSet(entry, input)
+		if err != nil {
+			return err
+		}
+		set.CompletedAt, set.RestSeconds = entry.Sets[index].CompletedAt, entry.Sets[index].RestSeconds
+		entry.Sets[index] = set
+		return nil
+	})
+}
+
+func (s *LiveWorkoutService) RemoveSet(userID, entryID string, index int) (*domain.LiveWorkout, error) {
+	return s.change(userID, func(live *domain.LiveWorkout, now time.Time) error {
+		entry, err := liveEntry(live, entryID)
+		if err != nil {
+			return err
+		}
+		if index < 0 || index >= len(entry.Sets) {
+			return repository.ErrNotFound
+		}
+		entry.Sets = append(entry.Sets[:index], entry.Sets[index+1:]...)
+		return nil
+	})
+}
+
+// Finish saves the live workout as a workout session with the same id,
+// completed now, and ends it. Entries without sets are left out.
+func (s *LiveWorkoutService) Finish(userID string) (*domain.WorkoutSession, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	live, err := s.repository.LiveWorkouts.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if live.LastSetAt() == nil {
+		verr := &ValidationError{}
+		verr.Add("entries", "must contain at least one set; discard the workout instead")
+		return nil, verr.Err()
+	}
+	now := time.Now().UTC()
+	return s.save(live, func(live *domain.LiveWorkout) time.Time {
+		if s.idle(live, now) {
+			return live.LastActivityAt
+		}
+		return now
+	})
+}
+
+// Discard ends the live workout without saving it. Sets logged on another
+// device since it was read make it fail, so they are not thrown away unseen.
+func (s *LiveWorkoutService) Discard(userID string) error {
+	if userID == "" {
+		return errors.New("user id is required")
+	}
+	live, err := s.repository.LiveWorkouts.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+	err = s.repository.LiveWorkouts.Delete(live.ID, live.Revision)
+	if errors.Is(err, repository.ErrConflict) {
+		return errLiveWorkoutChanged
+	}
+	return err
+}
+
+// CloseIdle closes every live workout that has been idle longer than the
+// timeout and returns how many were closed.
+func (s *LiveWorkoutService) CloseIdle() (int, error) {
+	if s.timeout <= 0 {
+		return 0, nil
+	}
+	idle, err := s.repository.LiveWorkouts.Idle(time.Now().UTC().Add(-s.timeout))
+	if err != nil {
+		return 0, err
+	}
+	closed := 0
+	for i := range idle {
+		ok, err := s.close(&idle[i])
+		if err != nil {
+			return closed, err
+		}
+		if ok {
+			closed++
+		}
+	}
+	return closed, nil
+}
+
+// Sweep runs CloseIdle every interval until ctx is done, so abandoned
+// workouts are closed even when nobody accesses them. Failures are logged
+// and retried on the next tick.
+func (s *LiveWorkoutService) Sweep(ctx context.Context, interval time.Duration) {
+	if s.timeout <= 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			closed, err := s.CloseIdle()
+			if err != nil {
+				log.Printf("close idle live workouts: %v", err)
+			}
+			if closed > 0 {
+				log.Printf("closed %d idle live workouts", closed)
+			}
+		}
+	}
+}
+
+// closeIdle closes the user's live workout if it has been idle longer than
+// the timeout.
+func (s *LiveWorkoutService) closeIdle(userID string) error {
+	if s.timeout <= 0 {
+		return nil
+	}
+	live, err := s.repository.LiveWorkouts.GetByUser(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !s.idle(live, time.Now().UTC()) {
+		return nil
+	}
+	_, err = s.close(live)
+	return err
+}
+
+func (s *LiveWorkoutService) idle(live *domain.LiveWorkout, now time.Time) bool {
+	return s.timeout > 0 && now.Sub(live.LastActivityAt) >= s.timeout
+}
+
+// close ends an abandoned live workout as of its last activity: saved when
+// sets were logged and discarded otherwise. A workout that cannot be saved
+// even without its planned session is discarded too, so it is not retried
+// on every sweep; when it changed meanwhile it is left for the next one and
+// close reports false.
+func (s *LiveWorkoutService) close(live *domain.LiveWorkout) (bool, error) {
+	_, err := s.save(live, func(live *domain.LiveWorkout) time.Time { return live.LastActivityAt })
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return err == nil, err
+	}
+	log.Printf("live workout %s could not be saved and is discarded: %v", live.ID, verr)
+	err = s.repository.LiveWorkouts.Delete(live.ID, live.Revision)
+	if errors.Is(err, repository.ErrConflict) || errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// save stores the sets of live as a workout session completed at the time
+// completedAt gives and removes the live workout. It only removes the
+// revision it saved: when another device changed the workout meanwhile,
+// what it holds now is saved over the session. A planned session that can
+// no longer be completed, say because another workout completed it, is
+// dropped rather than losing the sets. It returns nil without saving
+// anything when no sets were logged.
+func (s *LiveWorkoutService) save(live *domain.LiveWorkout, completedAt func(*domain.LiveWorkout) time.Time) (*domain.WorkoutSession, error) {
+	var session *domain.WorkoutSession
+	stored := false
+	for attempt := 0; attempt < maxLiveAttempts; attempt++ {
+		input := liveSessionInput(live, completedAt(live))
+		var err error
+		switch {
+		case stored:
+			session, err = s.workouts.Replace(live.UserID, live.ID, input)
+		case len(input.Entries) > 0:
+			session, _, err = s.workouts.Create(live.UserID, input)
+			if input.PlannedSessionID != "" && onlyField(err, "plannedSessionId") {
+				input.PlannedSessionID = ""
+				session, _, err = s.workouts.Create(live.UserID, input)
+			}
+			if errors.Is(err, repository.ErrConflict) || errors.Is(err, ErrIdempotencyConflict) {
+				// Another device finished the workout first.
+				session, err = s.workouts.Get(live.UserID, live.ID)
+			}
+			stored = err == nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repository.LiveWorkouts.Delete(live.ID, live.Revision)
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			return session, nil
+		}
+		if !errors.Is(err, repository.ErrConflict) {
+			return nil, err
+		}
+		current, err := s.repository.LiveWorkouts.GetByUser(live.UserID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && current.ID != live.ID) {
+			return session, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		live = current
+	}
+	return nil, errLiveWorkoutChanged
+}
+
+// onlyField reports whether err is a ValidationError about field alone.
+func onlyField(err error, field string) bool {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	for _, f := range verr.Fields {
+		if f.Field != field {
+			return false
+		}
+	}
+	return true
+}
+
+// liveSessionInput describes the sets of live as a workout session. A
+// workout left open for longer than a session may last ends when it could
+// have at the latest.
+func liveSessionInput(live *domain.LiveWorkout, completedAt time.Time) WorkoutSessionInput {
+	if latest := live.StartedAt.Add(maxSessionLength); completedAt.After(latest) {
+		completedAt = latest
+	}
+	input := WorkoutSessionInput{
+		ID:               live.ID,
+		StartedAt:        live.StartedAt,
+		CompletedAt:      completedAt,
+		PlannedSessionID: live.PlannedSessionID,
+	}
+	for _, entry := range live.Entries {
+		if len(entry.Sets) == 0 {
+			continue
+		}
+		entryInput := WorkoutEntryInput{ExerciseID: entry.ExerciseID, Side: entry.Side, Notes: entry.Notes}
+		for _, set := range entry.Sets {
+			completed := set.Completed
+			entryInput.SetDetails = append(entryInput.SetDetails, WorkoutSetInput{
+				Type:            set.Type,
+				Reps:            set.Reps,
+				Weight:          set.Weight,
+				DurationSeconds: set.DurationSeconds,
+				DistanceMeters:  set.DistanceMeters,
+				RPE:             set.RPE,
+				RIR:             set.RIR,
+				Tempo:           set.Tempo,
+				Completed:       &completed,
+				CompletedAt:     set.CompletedAt,
+				RestSeconds:     set.RestSeconds,
+			})
+		}
+		input.Entries = append(input.Entries, entryInput)
+	}
+	return input
+}
+
+// change applies fn to the user's live workout and stores it, retrying when
+// another device changed the workout in the meantime.
+func (s *LiveWorkoutService) change(userID string, fn func(live *domain.LiveWorkout, now time.Time) error) (*domain.LiveWorkout, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	if err := s.closeIdle(userID); err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < maxLiveAttempts; attempt++ {
+		live, err := s.repository.LiveWorkouts.GetByUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		if err := fn(live, now); err != nil {
+			return nil, err
+		}
+		live.LastActivityAt = now
+		err = s.repository.LiveWorkouts.Update(live)
+		if errors.Is(err, repository.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.present(live), nil
+	}
+	return nil, errLiveWorkoutChanged
+}
+
+// liveSet validates a set for the exercise of entry.
+func (s *LiveWorkoutService) liveSet(entry *domain.LiveEntry, input WorkoutSetInput) (domain.WorkoutSet, error) {
+	ex, err := s.repository.Exercises.GetByID(entry.ExerciseID)
+	if err != nil {
+		return domain.WorkoutSet{}, err
+	}
+	mt := ex.MeasurementType
+	if !mt.Valid() {
+		mt = domain.MeasurementWeightReps
+	}
+	verr := &ValidationError{}
+	set := normalizeSet(mt, input, "set", verr)
+	return set, verr.Err()
+}
+
+func liveEntry(live *domain.LiveWorkout, entryID string) (*domain.LiveEntry, error) {
+	for i := range live.Entries {
+		if live.Entries[i].ID == entryID {
+			return &live.Entries[i], nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// present fills in when the workout will be closed if left idle.
+func (s *LiveWorkoutService) present(live *domain.LiveWorkout) *domain.LiveWorkout {
+	if live.Entries == nil {
+		live.Entries = []domain.LiveEntry{}
+	}
+	for i := range live.Entries {
+		if live.Entries[i].Sets == nil {
+			live.Entries[i].Sets = []domain.WorkoutSet{}
+		}
+	}
+	live.ClosesAt = nil
+	if s.timeout > 0 {
+		closesAt := live.LastActivityAt.Add(s.timeout)
+		live.ClosesAt = &closesAt
+	}
+	return live
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/prescription"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type TrainingMaxService struct {
+	repository repository.Repository
+}
+
+func NewTrainingMaxService(repo repository.Repository) *TrainingMaxService {
+	return &TrainingMaxService{repository: repo}
+}
+
+const (
+	maxTargetPercent = 150
+	maxBarWeight     = 100
+	maxPlateWeight   = 50
+	maxPlateSizes    = 20
+	maxLoadIncrement = 50
+)
+
+func (s *TrainingMaxService) List(userID string) ([]domain.TrainingMax, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	return s.repository.TrainingMaxes.List(userID)
+}
+
+// Set records the user's training max for a weight and reps exercise.
+func (s *TrainingMaxService) Set(userID, exerciseID string, weight float64) (*domain.TrainingMax, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	ex, err := s.repository.Exercises.GetByID(exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	verr := &ValidationError{}
+	if mt := ex.MeasurementType; mt.Valid() && mt != domain.MeasurementWeightReps {
+		verr.Add("exerciseId", "must be a %s exercise", domain.MeasurementWeightReps)
+	}
+	if weight <= 0 || weight > maxWeight {
+		verr.Add("weight", "must be greater than 0 and at most %g", maxWeight)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	trainingMax := &domain.TrainingMax{UserID: userID, ExerciseID: ex.ID, Weight: weight}
+	if err := s.repository.TrainingMaxes.Set(trainingMax); err != nil {
+		return nil, err
+	}
+	return trainingMax, nil
+}
+
+func (s *TrainingMaxService) Delete(userID, exerciseID string) error {
+	if userID == "" {
+		return errors.New("user id is required")
+	}
+	return s.repository.TrainingMaxes.Delete(userID, exerciseID)
+}
+
+// CheckLoading validates loading settings before they are stored on a
+// user's profile.
+func CheckLoading(loading *domain.LoadingSettings) error {
+	if loading == nil {
+		return nil
+	}
+	verr := &ValidationError{}
+	if loading.BarWeight < 0 || loading.BarWeight > maxBarWeight {
+		verr.Add("loading.barWeight", "must be between 0 and %d", maxBarWeight)
+	}
+	if len(loading.Plates) > maxPlateSizes {
+		verr.Add("loading.plates", "must list at most %d sizes", maxPlateSizes)
+	}
+	for i, plate := range loading.Plates {
+		if plate <= 0 || plate > maxPlateWeight {
+			verr.Add(fmt.Sprintf("loading.plates[%d]", i), "must be greater than 0 and at most %d", maxPlateWeight)
+		}
+	}
+	if loading.Increment < 0 || loading.Increment > maxLoadIncrement {
+		verr.Add("loading.increment", "must be between 0 and %d", maxLoadIncrement)
+	}
+	return verr.Err()
+}
+
+// prescriber works out draft loads for one athlete from their training
+// maxes and loading equipment.
+type prescriber struct {
+	trainingMaxes map[string]float64
+	equipment     prescription.Equipment
+}
+
+func newPrescriber(repo repository.Repository, userID string) (*prescriber, error) {
+	user, err := repo.Users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	maxes, err := repo.TrainingMaxes.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	p := &prescriber{trainingMaxes: make(map[string]float64, len(maxes)), equipment: prescription.Standard}
+	for _, m := range maxes {
+		p.trainingMaxes[m.ExerciseID] = m.Weight
+	}
+	if loading := user.Loading; loading != nil {
+		p.equipment = prescription.Equipment{BarWeight: loading.BarWeight, Plates: loading.Plates, Increment: loading.Increment}
+	}
+	return p, nil
+}
+
+// prescribe fills in the loads of a draft built from template for a program
+// week, counted from 1, adding progression for each week after the first.
+// Fixed weights are kept as written in the first week. Loads worked out
+// from a percentage or raised by progression are rounded to the equipment.
+func (p *prescriber) prescribe(draft *domain.WorkoutDraft, template *domain.WorkoutTemplate, progression domain.Progression, week int) {
+	steps := max(week-1, 0)
+	for i := range draft.Entries {
+		entry := &draft.Entries[i]
+		target := template.Exercises[i]
+		if entry.Reps > 0 {
+			entry.Reps = min(entry.Reps+steps*progression.RepsIncrement, maxReps)
+		}
+		rule := prescription.Rule{Weight: target.TargetWeight, Percent: target.TargetPercent, WeeklyIncrement: progression.WeightIncrement}
+		switch {
+		case target.TargetPercent > 0:
+			entry.TrainingMax = p.trainingMaxes[target.ExerciseID]
+			entry.Weight, _ = prescription.Prescribe(rule, entry.TrainingMax, week, p.equipment)
+		case target.TargetWeight > 0 && steps > 0 && progression.WeightIncrement > 0:
+			entry.Weight, _ = prescription.Prescribe(rule, 0, week, p.equipment)
+		}
+		entry.Weight = min(entry.Weight, maxWeight)
+	}
+}
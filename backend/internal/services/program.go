@@ -0,0 +1,473 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type ProgramService struct {
+	repository repository.Repository
+}
+
+func NewProgramService(repo repository.Repository) *ProgramService {
+	return &ProgramService{repository: repo}
+}
+
+// ProgramInput creates or replaces a program. Days name templates of the
+// program's author.
+type ProgramInput struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Weeks       int                 `json:"weeks"`
+	Days        []domain.ProgramDay `json:"days"`
+	Progression domain.Progression  `json:"progression"`
+}
+
+// EnrollmentInput starts a program. StartDate is a YYYY-MM-DD date and
+// defaults to today.
+type EnrollmentInput struct {
+	StartDate string `json:"startDate"`
+}
+
+// ScheduleQuery selects the schedule around Date, a YYYY-MM-DD date that
+// defaults to today in UTC, looking Days days ahead.
+type ScheduleQuery struct {
+	Date string
+	Days int
+}
+
+// ScheduledWorkout is a planned session as the athlete sees it. Draft is the
+// workout to start, with the week's progression applied. It is left out once
+// the session is completed.
+type ScheduledWorkout struct {
+	domain.PlannedSession
+	ProgramID    string               `json:"programId"`
+	ProgramName  string               `json:"programName"`
+	TemplateName string               `json:"templateName"`
+	Status       domain.PlannedStatus `json:"status"`
+	Draft        *domain.WorkoutDraft `json:"draft,omitempty"`
+}
+
+// Schedule lists the planned sessions of Date and of the days after it.
+type Schedule struct {
+	Date     time.Time          `json:"date"`
+	Today    []ScheduledWorkout `json:"today"`
+	Upcoming []ScheduledWorkout `json:"upcoming"`
+}
+
+// EnrollmentProgress compares what an enrollment planned with what was
+// done. Sessions is only filled in for a single enrollment.
+type EnrollmentProgress struct {
+	domain.ProgramEnrollment
+	ProgramName string             `json:"programName"`
+	Planned     int                `json:"planned"`
+	Completed   int                `json:"completed"`
+	Missed      int                `json:"missed"`
+	Sessions    []ScheduledWorkout `json:"sessions,omitempty"`
+}
+
+const (
+	maxProgramNameLength = 100
+	maxProgramWeeks      = 52
+	maxWeightIncrement   = 50
+	maxRepsIncrement     = 20
+	defaultScheduleDays  = 7
+	maxScheduleDays      = 56
+	// maxEnrollmentOffset bounds how far a start date may lie in the past
+	// or the future.
+	maxEnrollmentOffset = 365
+)
+
+// List returns all programs by name.
+func (s *ProgramService) List() ([]domain.Program, error) {
+	return s.repository.Programs.List()
+}
+
+func (s *ProgramService) Get(id string) (*domain.Program, error) {
+	return s.repository.Programs.Get(id)
+}
+
+func (s *ProgramService) Create(authorID string, input ProgramInput) (*domain.Program, error) {
+	if authorID == "" {
+		return nil, errors.New("author id is required")
+	}
+	program := &domain.Program{AuthorID: authorID}
+	if err := s.apply(program, input); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Programs.Create(program); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// Update replaces a program. Athletes already enrolled keep the weeks and
+// days laid out when they enrolled, but see template and progression
+// changes.
+func (s *ProgramService) Update(id string, input ProgramInput) (*domain.Program, error) {
+	program, err := s.repository.Programs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(program, input); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Programs.Update(program); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+func (s *ProgramService) Delete(id string) error {
+	err := s.repository.Programs.Delete(id)
+	if errors.Is(err, repository.ErrConflict) {
+		return fmt.Errorf("%w: athletes have enrolled in this program", repository.ErrConflict)
+	}
+	return err
+}
+
+// apply validates input and copies it onto program.
+func (s *ProgramService) apply(program *domain.Program, input ProgramInput) error {
+	verr := &ValidationError{}
+	name := strings.TrimSpace(input.Name)
+	switch {
+	case name == "":
+		verr.Add("name", "is required")
+	case utf8.RuneCountInString(name) > maxProgramNameLength:
+		verr.Add("name", "must be at most %d characters", maxProgramNameLength)
+	}
+	if utf8.RuneCountInString(input.Description) > maxNotesLength {
+		verr.Add("description", "must be at most %d characters", maxNotesLength)
+	}
+	if input.Weeks < 1 || input.Weeks > maxProgramWeeks {
+		verr.Add("weeks", "must be between 1 and %d", maxProgramWeeks)
+	}
+	if len(input.Days) == 0 {
+		verr.Add("days", "must contain at least one day")
+	}
+	seen := map[int]bool{}
+	for i, day := range input.Days {
+		path := fmt.Sprintf("days[%d]", i)
+		switch {
+		case day.Day < 1 || day.Day > 7:
+			verr.Add(path+".day", "must be between 1 and 7")
+		case seen[day.Day]:
+			verr.Add(path+".day", "appears more than once")
+		}
+		seen[day.Day] = true
+		if err := s.checkTemplate(program.AuthorID, day.TemplateID, path+".templateId", verr); err != nil {
+			return err
+		}
+	}
+	progression := input.Progression
+	if progression.WeightIncrement < 0 || progression.WeightIncrement > maxWeightIncrement {
+		verr.Add("progression.weightIncrement", "must be between 0 and %d", maxWeightIncrement)
+	}
+	if progression.RepsIncrement < 0 || progression.RepsIncrement > maxRepsIncrement {
+		verr.Add("progression.repsIncrement", "must be between 0 and %d", maxRepsIncrement)
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+	days := append([]domain.ProgramDay{}, input.Days...)
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	program.Name = name
+	program.Description = input.Description
+	program.Weeks = input.Weeks
+	program.Days = days
+	program.Progression = progression
+	return nil
+}
+
+// checkTemplate reports whether id names a template of the program author.
+func (s *ProgramService) checkTemplate(authorID, id, field string, verr *ValidationError) error {
+	if id == "" {
+		verr.Add(field, "is required")
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		verr.Add(field, "must be a UUID")
+		return nil
+	}
+	template, err := s.repository.Templates.Get(id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && template.UserID != authorID) {
+		verr.Add(field, "must be a template of the program author")
+		return nil
+	}
+	return err
+}
+
+// Enroll starts the user on a program, laying out its planned sessions from
+// the start date.
+func (s *ProgramService) Enroll(userID, programID string, input EnrollmentInput) (*domain.ProgramEnrollment, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	program, err := s.repository.Programs.Get(programID)
+	if err != nil {
+		return nil, err
+	}
+	verr := &ValidationError{}
+	today := dateOf(time.Now())
+	startDate := today
+	if input.StartDate != "" {
+		if startDate, err = time.Parse(time.DateOnly, input.StartDate); err != nil {
+			verr.Add("startDate", "must be a date (YYYY-MM-DD)")
+		} else if startDate.Before(today.AddDate(0, 0, -maxEnrollmentOffset)) || startDate.After(today.AddDate(0, 0, maxEnrollmentOffset)) {
+			verr.Add("startDate", "must be within %d days of today", maxEnrollmentOffset)
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	enrollment := &domain.ProgramEnrollment{UserID: userID, ProgramID: program.ID, StartDate: startDate}
+	planned := make([]domain.PlannedSession, 0, program.Weeks*len(program.Days))
+	for week := 1; week <= program.Weeks; week++ {
+		for _, day := range program.Days {
+			planned = append(planned, domain.PlannedSession{
+				TemplateID:  day.TemplateID,
+				Week:        week,
+				Day:         day.Day,
+				ScheduledOn: startDate.AddDate(0, 0, (week-1)*7+day.Day-1),
+			})
+		}
+	}
+	err = s.repository.Programs.Enroll(enrollment, planned)
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, fmt.Errorf("%w: you are already enrolled in this program", repository.ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// Enrollments lists the user's enrollments with their progress.
+func (s *ProgramService) Enrollments(userID string) ([]EnrollmentProgress, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	enrollments, err := s.repository.Programs.ListEnrollments(userID)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newPlannedResolver(s.repository, nil)
+	today := dateOf(time.Now())
+	progress := make([]EnrollmentProgress, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		p, _, err := s.progress(resolver, enrollment, today)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, *p)
+	}
+	return progress, nil
+}
+
+// Enrollment returns one of the user's enrollments with every planned
+// session and its status.
+func (s *ProgramService) Enrollment(userID, id string) (*EnrollmentProgress, error) {
+	enrollment, err := s.ownedEnrollment(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newPlannedResolver(s.repository, nil)
+	today := dateOf(time.Now())
+	progress, planned, err := s.progress(resolver, *enrollment, today)
+	if err != nil {
+		return nil, err
+	}
+	progress.Sessions = make([]ScheduledWorkout, 0, len(planned))
+	for _, p := range planned {
+		scheduled, err := resolver.resolve(p, today)
+		if err != nil {
+			return nil, err
+		}
+		progress.Sessions = append(progress.Sessions, scheduled)
+	}
+	return progress, nil
+}
+
+// Leave ends one of the user's enrollments. Its sessions no longer appear
+// on the schedule.
+func (s *ProgramService) Leave(userID, id string) error {
+	enrollment, err := s.ownedEnrollment(userID, id)
+	if err != nil {
+		return err
+	}
+	if enrollment.EndedAt != nil {
+		return fmt.Errorf("%w: the enrollment has already ended", repository.ErrConflict)
+	}
+	return s.repository.Programs.EndEnrollment(id, time.Now().UTC())
+}
+
+func (s *ProgramService) ownedEnrollment(userID, id string) (*domain.ProgramEnrollment, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	enrollment, err := s.repository.Programs.GetEnrollment(id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return enrollment, nil
+}
+
+func (s *ProgramService) progress(resolver *plannedResolver, enrollment domain.ProgramEnrollment, today time.Time) (*EnrollmentProgress, []domain.PlannedSession, error) {
+	program, err := resolver.program(enrollment.ProgramID)
+	if err != nil {
+		return nil, nil, err
+	}
+	planned, err := s.repository.Programs.ListPlanned(enrollment.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	progress := &EnrollmentProgress{ProgramEnrollment: enrollment, ProgramName: program.Name, Planned: len(planned)}
+	for _, p := range planned {
+		switch p.Status(today) {
+		case domain.PlannedStatusCompleted:
+			progress.Completed++
+		case domain.PlannedStatusMissed:
+			progress.Missed++
+		}
+	}
+	return progress, planned, nil
+}
+
+// Schedule returns the user's planned sessions for the query date and the
+// days after it, across all programs they follow.
+func (s *ProgramService) Schedule(userID string, query ScheduleQuery) (*Schedule, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	verr := &ValidationError{}
+	today := dateOf(time.Now())
+	if query.Date != "" {
+		date, err := time.Parse(time.DateOnly, query.Date)
+		if err != nil {
+			verr.Add("date", "must be a date (YYYY-MM-DD)")
+		}
+		today = date
+	}
+	days := query.Days
+	switch {
+	case days == 0:
+		days = defaultScheduleDays
+	case days < 0 || days > maxScheduleDays:
+		verr.Add("days", "must be between 1 and %d", maxScheduleDays)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	planned, err := s.repository.Programs.Schedule(userID, today, today.AddDate(0, 0, days+1))
+	if err != nil {
+		return nil, err
+	}
+	prescriber, err := newPrescriber(s.repository, userID)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newPlannedResolver(s.repository, prescriber)
+	schedule := &Schedule{Date: today, Today: []ScheduledWorkout{}, Upcoming: []ScheduledWorkout{}}
+	for _, p := range planned {
+		scheduled, err := resolver.resolve(p, today)
+		if err != nil {
+			return nil, err
+		}
+		if p.ScheduledOn.Equal(today) {
+			schedule.Today = append(schedule.Today, scheduled)
+		} else {
+			schedule.Upcoming = append(schedule.Upcoming, scheduled)
+		}
+	}
+	return schedule, nil
+}
+
+// plannedResolver looks up what planned sessions refer to, loading each
+// enrollment, program and template once.
+type plannedResolver struct {
+	repository repository.Repository
+	// prescriber works out drafts. Without one, no drafts are returned.
+	prescriber  *prescriber
+	enrollments map[string]*domain.ProgramEnrollment
+	programs    map[string]*domain.Program
+	templates   map[string]*domain.WorkoutTemplate
+}
+
+func newPlannedResolver(repo repository.Repository, prescriber *prescriber) *plannedResolver {
+	return &plannedResolver{
+		repository:  repo,
+		prescriber:  prescriber,
+		enrollments: map[string]*domain.ProgramEnrollment{},
+		programs:    map[string]*domain.Program{},
+		templates:   map[string]*domain.WorkoutTemplate{},
+	}
+}
+
+func (r *plannedResolver) resolve(planned domain.PlannedSession, today time.Time) (ScheduledWorkout, error) {
+	enrollment, ok := r.enrollments[planned.EnrollmentID]
+	if !ok {
+		var err error
+		if enrollment, err = r.repository.Programs.GetEnrollment(planned.EnrollmentID); err != nil {
+			return ScheduledWorkout{}, err
+		}
+		r.enrollments[planned.EnrollmentID] = enrollment
+	}
+	program, err := r.program(enrollment.ProgramID)
+	if err != nil {
+		return ScheduledWorkout{}, err
+	}
+	template, ok := r.templates[planned.TemplateID]
+	if !ok {
+		if template, err = r.repository.Templates.Get(planned.TemplateID); err != nil {
+			return ScheduledWorkout{}, err
+		}
+		r.templates[planned.TemplateID] = template
+	}
+
+	scheduled := ScheduledWorkout{
+		PlannedSession: planned,
+		ProgramID:      program.ID,
+		ProgramName:    program.Name,
+		TemplateName:   template.Name,
+		Status:         planned.Status(today),
+	}
+	if r.prescriber != nil && scheduled.Status != domain.PlannedStatusCompleted {
+		draft := draftFromTemplate(template, time.Now().UTC())
+		draft.PlannedSessionID = planned.ID
+		r.prescriber.prescribe(draft, template, program.Progression, planned.Week)
+		scheduled.Draft = draft
+	}
+	return scheduled, nil
+}
+
+func (r *plannedResolver) program(id string) (*domain.Program, error) {
+	if program, ok := r.programs[id]; ok {
+		return program, nil
+	}
+	program, err := r.repository.Programs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	r.programs[id] = program
+	return program, nil
+}
+
+// dateOf returns t's calendar date in UTC at midnight.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
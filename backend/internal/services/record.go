@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/records"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type RecordService struct {
+	repository repository.Repository
+}
+
+func NewRecordService(repo repository.Repository) *RecordService {
+	return &RecordService{repository: repo}
+}
+
+// List returns the user's personal records, only those on exerciseID when
+// it is set.
+func (s *RecordService) List(userID, exerciseID string) ([]domain.PersonalRecord, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	held, err := s.repository.Records.List(userID)
+	if err != nil || exerciseID == "" {
+		return held, err
+	}
+	matching := make([]domain.PersonalRecord, 0, len(held))
+	for _, record := range held {
+		if record.ExerciseID == exerciseID {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+// Backfill computes the records of users who logged workouts before records
+// were kept and returns how many users it scored.
+func (s *RecordService) Backfill() (int, error) {
+	users, err := s.repository.Records.Unscored()
+	if err != nil {
+		return 0, err
+	}
+	scored := 0
+	for _, userID := range users {
+		sessions, err := s.repository.Workouts.ListSessions(repository.SessionFilter{UserID: userID}, nil, 0)
+		if err != nil {
+			return scored, err
+		}
+		if err := refreshRecords(s.repository, userID, exercisesOf(sessions...)); err != nil {
+			return scored, err
+		}
+		if err := s.repository.Records.MarkScored(userID); err != nil {
+			return scored, err
+		}
+		scored++
+	}
+	return scored, nil
+}
+
+// refreshRecords recomputes the user's records on each exercise from all of
+// their workouts outside the trash.
+func refreshRecords(repo repository.Repository, userID string, exerciseIDs []string) error {
+	for _, exerciseID := range exerciseIDs {
+		mt, err := recordMeasurement(repo, exerciseID)
+		if errors.Is(err, repository.ErrNotFound) {
+			// Removed exercises take their records with them.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = repo.Records.Update(userID, exerciseID, func([]domain.PersonalRecord) ([]domain.PersonalRecord, error) {
+			sessions, err := repo.Workouts.ListSessions(repository.SessionFilter{UserID: userID, ExerciseID: exerciseID}, nil, 0)
+			if err != nil {
+				return nil, err
+			}
+			performances := make([]records.Performance, 0, len(sessions))
+			for _, session := range sessions {
+				performances = append(performances, performanceOf(session, exerciseID))
+			}
+			return records.Compute(mt, performances), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRecords adds what a newly stored session sets to its user's records.
+// Added workouts can only raise records, so the rest of the history is not
+// read again.
+func addRecords(repo repository.Repository, session domain.WorkoutSession) error {
+	for _, exerciseID := range exercisesOf(session) {
+		mt, err := recordMeasurement(repo, exerciseID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found := records.Compute(mt, []records.Performance{performanceOf(session, exerciseID)})
+		err = repo.Records.Update(session.UserID, exerciseID, func(held []domain.PersonalRecord) ([]domain.PersonalRecord, error) {
+			return records.Merge(held, found), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordMeasurement returns how records on the exercise are measured.
+func recordMeasurement(repo repository.Repository, exerciseID string) (domain.MeasurementType, error) {
+	ex, err := repo.Exercises.GetByID(exerciseID)
+	if err != nil {
+		return "", err
+	}
+	if !ex.MeasurementType.Valid() {
+		return domain.MeasurementWeightReps, nil
+	}
+	return ex.MeasurementType, nil
+}
+
+// performanceOf returns the working sets of the exercise logged in session.
+func performanceOf(session domain.WorkoutSession, exerciseID string) records.Performance {
+	performance := records.Performance{SessionID: session.ID, At: session.StartedAt}
+	for _, entry := range session.Entries {
+		if entry.ExerciseID == exerciseID {
+			performance.Sets = append(performance.Sets, entry.WorkingSets()...)
+		}
+	}
+	return performance
+}
+
+// exercisesOf returns the distinct exercises logged in sessions, sorted.
+func exercisesOf(sessions ...domain.WorkoutSession) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, session := range sessions {
+		for _, entry := range session.Entries {
+			if !seen[entry.ExerciseID] {
+				seen[entry.ExerciseID] = true
+				ids = append(ids, entry.ExerciseID)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
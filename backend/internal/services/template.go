@@ -0,0 +1,274 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+type TemplateService struct {
+	repository repository.Repository
+}
+
+func NewTemplateService(repo repository.Repository) *TemplateService {
+	return &TemplateService{repository: repo}
+}
+
+// TemplateInput creates or replaces a template. Exercises are kept in the
+// given order.
+type TemplateInput struct {
+	Name      string                    `json:"name"`
+	Notes     string                    `json:"notes"`
+	Exercises []domain.TemplateExercise `json:"exercises"`
+}
+
+const (
+	maxTemplateNameLength = 100
+	maxRestSeconds        = 60 * 60
+)
+
+func (s *TemplateService) List(userID string) ([]domain.WorkoutTemplate, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	return s.repository.Templates.List(userID)
+}
+
+// Get returns one of the user's templates. Other users' templates are
+// reported as not found.
+func (s *TemplateService) Get(userID, id string) (*domain.WorkoutTemplate, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	template, err := s.repository.Templates.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if template.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return template, nil
+}
+
+func (s *TemplateService) Create(userID string, input TemplateInput) (*domain.WorkoutTemplate, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	template := &domain.WorkoutTemplate{UserID: userID}
+	if err := s.apply(template, input); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Templates.Create(template); err != nil {
+		return nil, templateNameConflict(err)
+	}
+	return template, nil
+}
+
+// Update replaces a template's name, notes and exercises.
+func (s *TemplateService) Update(userID, id string, input TemplateInput) (*domain.WorkoutTemplate, error) {
+	template, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(template, input); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Templates.Update(template); err != nil {
+		return nil, templateNameConflict(err)
+	}
+	return template, nil
+}
+
+func (s *TemplateService) Delete(userID, id string) error {
+	if _, err := s.Get(userID, id); err != nil {
+		return err
+	}
+	err := s.repository.Templates.Delete(id)
+	if errors.Is(err, repository.ErrConflict) {
+		return fmt.Errorf("%w: the template is used by a training program", repository.ErrConflict)
+	}
+	return err
+}
+
+// FromSession saves one of the user's sessions as a template, taking each
+// entry's sets, reps and load as targets. An empty name is derived from the
+// session date.
+func (s *TemplateService) FromSession(userID, sessionID, name string) (*domain.WorkoutTemplate, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, repository.ErrNotFound
+	}
+	session, err := s.repository.Workouts.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID || session.DeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "Workout on " + session.StartedAt.Format(time.DateOnly)
+	}
+	input := TemplateInput{Name: name, Exercises: make([]domain.TemplateExercise, 0, len(session.Entries))}
+	for _, entry := range session.Entries {
+		ex, err := s.repository.Exercises.GetByID(entry.ExerciseID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		target := domain.TemplateExercise{
+			ExerciseID:   entry.ExerciseID,
+			TargetSets:   entry.Sets,
+			TargetReps:   entry.Reps,
+			TargetWeight: entry.Weight,
+		}
+		if ex != nil && ex.MeasurementType.RequiresDuration() {
+			target.TargetDurationSeconds = entry.DurationSeconds
+		}
+		if ex != nil && ex.MeasurementType.RequiresDistance() {
+			target.TargetDistanceMeters = entry.DistanceMeters
+		}
+		input.Exercises = append(input.Exercises, target)
+	}
+	return s.Create(userID, input)
+}
+
+// Start returns a draft session prefilled from one of the user's templates,
+// with percentage targets worked out from the user's training maxes.
+func (s *TemplateService) Start(userID, id string) (*domain.WorkoutDraft, error) {
+	template, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	prescriber, err := newPrescriber(s.repository, userID)
+	if err != nil {
+		return nil, err
+	}
+	draft := draftFromTemplate(template, time.Now().UTC())
+	prescriber.prescribe(draft, template, domain.Progression{}, 1)
+	return draft, nil
+}
+
+func draftFromTemplate(template *domain.WorkoutTemplate, startedAt time.Time) *domain.WorkoutDraft {
+	draft := &domain.WorkoutDraft{
+		TemplateID: template.ID,
+		Name:       template.Name,
+		StartedAt:  startedAt,
+		Entries:    make([]domain.DraftEntry, 0, len(template.Exercises)),
+	}
+	for _, ex := range template.Exercises {
+		draft.Entries = append(draft.Entries, domain.DraftEntry{
+			ExerciseID:      ex.ExerciseID,
+			Sets:            max(ex.TargetSets, 1),
+			Reps:            ex.TargetReps,
+			Weight:          ex.TargetWeight,
+			TargetPercent:   ex.TargetPercent,
+			DurationSeconds: ex.TargetDurationSeconds,
+			DistanceMeters:  ex.TargetDistanceMeters,
+			RestSeconds:     ex.RestSeconds,
+			Notes:           ex.Notes,
+		})
+	}
+	return draft
+}
+
+// apply validates input and copies it onto template.
+func (s *TemplateService) apply(template *domain.WorkoutTemplate, input TemplateInput) error {
+	verr := &ValidationError{}
+	name := strings.TrimSpace(input.Name)
+	switch {
+	case name == "":
+		verr.Add("name", "is required")
+	case utf8.RuneCountInString(name) > maxTemplateNameLength:
+		verr.Add("name", "must be at most %d characters", maxTemplateNameLength)
+	}
+	if utf8.RuneCountInString(input.Notes) > maxNotesLength {
+		verr.Add("notes", "must be at most %d characters", maxNotesLength)
+	}
+	switch {
+	case len(input.Exercises) == 0:
+		verr.Add("exercises", "must contain at least one exercise")
+	case len(input.Exercises) > maxEntriesPerSession:
+		verr.Add("exercises", "must contain at most %d exercises", maxEntriesPerSession)
+	}
+	lookup := newExerciseLookup(s.repository.Exercises)
+	for i, target := range input.Exercises {
+		path := fmt.Sprintf("exercises[%d]", i)
+		ex, err := lookup.check(target.ExerciseID, path+".exerciseId", verr)
+		if err != nil {
+			return err
+		}
+		if ex != nil {
+			checkTargets(ex.MeasurementType, target, path, verr)
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+	template.Name = name
+	template.Notes = input.Notes
+	template.Exercises = input.Exercises
+	return nil
+}
+
+// checkTargets applies the bounds of logged sets to a template's targets.
+// Targets may be left open, but not set where the measurement type has no
+// use for them.
+func checkTargets(mt domain.MeasurementType, target domain.TemplateExercise, path string, verr *ValidationError) {
+	if !mt.Valid() {
+		mt = domain.MeasurementWeightReps
+	}
+	if target.TargetSets < 0 || target.TargetSets > maxSetsPerEntry {
+		verr.Add(path+".targetSets", "must be between 0 and %d", maxSetsPerEntry)
+	}
+	switch {
+	case target.TargetReps < 0 || target.TargetReps > maxReps:
+		verr.Add(path+".targetReps", "must be between 0 and %d", maxReps)
+	case !mt.CountsReps() && target.TargetReps != 0:
+		verr.Add(path+".targetReps", "must be empty for %s exercises", mt)
+	}
+	switch {
+	case target.TargetWeight < 0 || target.TargetWeight > maxWeight:
+		verr.Add(path+".targetWeight", "must be between 0 and %g", maxWeight)
+	case target.TargetWeight != 0 && target.TargetPercent != 0:
+		verr.Add(path+".targetWeight", "must be empty when targetPercent is set")
+	}
+	switch {
+	case target.TargetPercent < 0 || target.TargetPercent > maxTargetPercent:
+		verr.Add(path+".targetPercent", "must be between 0 and %d", maxTargetPercent)
+	case target.TargetPercent != 0 && mt != domain.MeasurementWeightReps:
+		verr.Add(path+".targetPercent", "must be empty for %s exercises", mt)
+	}
+	switch {
+	case target.TargetDurationSeconds < 0 || target.TargetDurationSeconds > maxDurationSeconds:
+		verr.Add(path+".targetDurationSeconds", "must be between 0 and %d", maxDurationSeconds)
+	case !mt.RequiresDuration() && target.TargetDurationSeconds != 0:
+		verr.Add(path+".targetDurationSeconds", "must be empty for %s exercises", mt)
+	}
+	switch {
+	case target.TargetDistanceMeters < 0 || target.TargetDistanceMeters > maxDistanceMeters:
+		verr.Add(path+".targetDistanceMeters", "must be between 0 and %g", maxDistanceMeters)
+	case !mt.RequiresDistance() && target.TargetDistanceMeters != 0:
+		verr.Add(path+".targetDistanceMeters", "must be empty for %s exercises", mt)
+	}
+	if target.RestSeconds < 0 || target.RestSeconds > maxRestSeconds {
+		verr.Add(path+".restSeconds", "must be between 0 and %d", maxRestSeconds)
+	}
+	if utf8.RuneCountInString(target.Notes) > maxNotesLength {
+		verr.Add(path+".notes", "must be at most %d characters", maxNotesLength)
+	}
+}
+
+func templateNameConflict(err error) error {
+	if errors.Is(err, repository.ErrConflict) {
+		return fmt.Errorf("%w: a template with this name already exists", repository.ErrConflict)
+	}
+	return err
+}
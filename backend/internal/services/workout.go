@@ -1,66 +1,363 @@
 package services
 
 import (
+	"encoding/base64"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/musclementour/app/internal/domain"
 	"github.com/musclementour/app/internal/repository"
 )
 
 type WorkoutService struct {
-	repository repository.Repository
+	repository  repository.Repository
+	batchLimits BatchLimits
+	// trashRetention is how long deleted sessions can be restored.
+	trashRetention time.Duration
 }
 
-func NewWorkoutService(repo repository.Repository) *WorkoutService {
-	return &WorkoutService{repository: repo}
+func NewWorkoutService(repo repository.Repository, batchLimits BatchLimits, trashRetention time.Duration) *WorkoutService {
+	return &WorkoutService{repository: repo, batchLimits: batchLimits, trashRetention: trashRetention}
 }
 
+// WorkoutEntryInput describes an entry either set by set with SetDetails or,
+// as older clients do, with Sets identical sets of Reps at Weight. When
+// editing a session, ID names the stored entry being changed. It is ignored
+// when creating one.
 type WorkoutEntryInput struct {
-	ExerciseID      string  `json:"exerciseId"`
-	Sets            int     `json:"sets"`
-	Reps            int     `json:"reps"`
-	Weight          float64 `json:"weight"`
-	DurationSeconds int     `json:"durationSeconds"`
-	Notes           string  `json:"notes"`
+	ID              string            `json:"id,omitempty"`
+	ExerciseID      string            `json:"exerciseId"`
+	Sets            int               `json:"sets"`
+	Reps            int               `json:"reps"`
+	Weight          float64           `json:"weight"`
+	DurationSeconds int               `json:"durationSeconds"`
+	DistanceMeters  float64           `json:"distanceMeters"`
+	Side            domain.Side       `json:"side"`
+	Notes           string            `json:"notes"`
+	SetDetails      []WorkoutSetInput `json:"setDetails"`
+}
+
+// WorkoutSetInput is one logged set. Type defaults to working and Completed
+// to true. CompletedAt and RestSeconds are optional timings; they are left
+// out of the request hash when empty so keys used before they existed still
+// match their retries.
+type WorkoutSetInput struct {
+	Type            domain.SetType `json:"type"`
+	Reps            int            `json:"reps"`
+	Weight          float64        `json:"weight"`
+	DurationSeconds int            `json:"durationSeconds"`
+	DistanceMeters  float64        `json:"distanceMeters"`
+	RPE             *float64       `json:"rpe"`
+	RIR             *int           `json:"rir"`
+	Tempo           string         `json:"tempo"`
+	Completed       *bool          `json:"completed"`
+	CompletedAt     *time.Time     `json:"completedAt,omitempty"`
+	RestSeconds     *int           `json:"restSeconds,omitempty"`
 }
 
 type WorkoutSessionInput struct {
+	// ID is an optional client-generated session id. Without an explicit
+	// IdempotencyKey it also serves as the key.
+	ID          string              `json:"id"`
 	StartedAt   time.Time           `json:"startedAt"`
 	CompletedAt time.Time           `json:"completedAt"`
 	Entries     []WorkoutEntryInput `json:"entries"`
+	// PlannedSessionID marks the workout as completing one of the user's
+	// planned program sessions.
+	PlannedSessionID string `json:"plannedSessionId,omitempty"`
+	// IdempotencyKey makes retries safe: a repeated request with the same
+	// key and payload returns the session created the first time.
+	IdempotencyKey string `json:"-"`
 }
 
-func (s *WorkoutService) Create(userID string, input WorkoutSessionInput) (*domain.WorkoutSession, error) {
+// Create stores a workout session. When the input carries an idempotency key
+// (or a client session id) that the user already submitted, the stored
+// session is returned with replayed set instead of creating another one, or
+// ErrIdempotencyConflict if the payload differs.
+func (s *WorkoutService) Create(userID string, input WorkoutSessionInput) (session *domain.WorkoutSession, replayed bool, err error) {
 	if userID == "" {
-		return nil, errors.New("user id is required")
+		return nil, false, errors.New("user id is required")
 	}
-	session := &domain.WorkoutSession{
-		UserID:      userID,
-		StartedAt:   input.StartedAt,
-		CompletedAt: input.CompletedAt,
+	verr := &ValidationError{}
+	request, err := newIdempotentCreate(userID, input.ID, input.IdempotencyKey, input, s.submission, verr)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := verr.Err(); err != nil {
+		return nil, false, err
+	}
+	if previous, err := s.replay(request); err != nil || previous != nil {
+		return previous, previous != nil, err
+	}
+
+	now := time.Now().UTC()
+	session = &domain.WorkoutSession{
+		ID:               input.ID,
+		UserID:           userID,
+		StartedAt:        input.StartedAt,
+		CompletedAt:      input.CompletedAt,
+		PlannedSessionID: input.PlannedSessionID,
+	}
+	if session.StartedAt.IsZero() {
+		session.StartedAt = now
+	}
+	if session.CompletedAt.IsZero() {
+		session.CompletedAt = session.StartedAt
+	}
+	checkSession(session.StartedAt, session.CompletedAt, len(input.Entries), now, verr)
+	if session.Entries, err = s.normalizeEntries(input.Entries, verr); err != nil {
+		return nil, false, err
+	}
+	if input.PlannedSessionID != "" {
+		if err := s.checkPlanned(userID, input.PlannedSessionID, verr); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if !request.tracked() {
+		if err := s.repository.Workouts.CreateSession(session); err != nil {
+			return nil, false, plannedTaken(err)
+		}
+		if err := s.updateRecords(session); err != nil {
+			return nil, false, err
+		}
+		return session, false, nil
+	}
+	session.ID = request.id(session.ID, "")
+	submission := &domain.WorkoutSubmission{UserID: userID, Key: request.key, RequestHash: request.hash}
+	var previous domain.WorkoutSession
+	replayed, err = request.store(func() error {
+		return s.repository.Workouts.CreateSessionOnce(session, submission)
+	}, &previous, "session")
+	if err != nil {
+		return nil, false, plannedTaken(err)
+	}
+	if replayed {
+		if err := s.attachRecords(&previous); err != nil {
+			return nil, false, err
+		}
+		return &previous, true, nil
 	}
+	if err := s.updateRecords(session); err != nil {
+		return nil, false, err
+	}
+	return session, false, nil
+}
 
-	for _, entry := range input.Entries {
-		session.Entries = append(session.Entries, domain.WorkoutEntry{
-			ExerciseID:      entry.ExerciseID,
-			Sets:            entry.Sets,
-			Reps:            entry.Reps,
-			Weight:          entry.Weight,
-			DurationSeconds: entry.DurationSeconds,
-			Notes:           entry.Notes,
-		})
+// updateRecords updates the user's records on the exercises logged in
+// session, and in previous when the session was edited, then attaches the
+// records session now holds. Edits may lower a record, so they recompute
+// from the whole history; new sessions only add to it.
+func (s *WorkoutService) updateRecords(session *domain.WorkoutSession, previous ...domain.WorkoutSession) error {
+	var err error
+	if len(previous) == 0 {
+		err = addRecords(s.repository, *session)
+	} else {
+		err = refreshRecords(s.repository, session.UserID, exercisesOf(append(previous, *session)...))
+	}
+	if err != nil {
+		return err
 	}
+	return s.attachRecords(session)
+}
 
-	if err := s.repository.Workouts.CreateSession(session); err != nil {
+// attachRecords fills in the personal records session holds.
+func (s *WorkoutService) attachRecords(session *domain.WorkoutSession) error {
+	held, err := s.repository.Records.List(session.UserID)
+	if err != nil {
+		return err
+	}
+	session.Records = nil
+	for _, record := range held {
+		if record.SessionID == session.ID {
+			session.Records = append(session.Records, record)
+		}
+	}
+	return nil
+}
+
+// replay returns the session stored for the request's key, or nil if the
+// key is unused.
+func (s *WorkoutService) replay(request *idempotentCreate) (*domain.WorkoutSession, error) {
+	var session domain.WorkoutSession
+	if found, err := request.replay(&session); err != nil || !found {
+		return nil, err
+	}
+	if err := s.attachRecords(&session); err != nil {
 		return nil, err
 	}
-	return session, nil
+	return &session, nil
 }
 
-func (s *WorkoutService) List(userID string) ([]domain.WorkoutSession, error) {
+// submission looks up the workout submitted under key.
+func (s *WorkoutService) submission(userID, key string) (string, []byte, error) {
+	submission, err := s.repository.Workouts.GetSubmission(userID, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return submission.RequestHash, submission.Response, nil
+}
+
+// checkPlanned reports whether id names a planned session the user can
+// still complete: one of an active enrollment of theirs that no workout has
+// completed yet.
+func (s *WorkoutService) checkPlanned(userID, id string, verr *ValidationError) error {
+	const field = "plannedSessionId"
+	if _, err := uuid.Parse(id); err != nil {
+		verr.Add(field, "must be a UUID")
+		return nil
+	}
+	planned, err := s.repository.Programs.GetPlanned(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		verr.Add(field, "is not one of your planned workouts")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	enrollment, err := s.repository.Programs.GetEnrollment(planned.EnrollmentID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case enrollment.UserID != userID:
+		verr.Add(field, "is not one of your planned workouts")
+	case enrollment.EndedAt != nil:
+		verr.Add(field, "belongs to a program you have left")
+	case planned.SessionID != "":
+		verr.Add(field, "was already completed")
+	}
+	return nil
+}
+
+// plannedTaken reports a planned session that another workout completed
+// after checkPlanned looked as the validation error checkPlanned gives.
+func plannedTaken(err error) error {
+	if !errors.Is(err, repository.ErrPlannedSessionTaken) {
+		return err
+	}
+	verr := &ValidationError{}
+	verr.Add("plannedSessionId", "was already completed")
+	return verr.Err()
+}
+
+// WorkoutListQuery selects a page of a user's history. The filters are
+// those of repository.SessionFilter. Cursor continues from a previous page
+// and IncludeTotal also counts all matching sessions.
+type WorkoutListQuery struct {
+	From         *time.Time
+	To           *time.Time
+	ExerciseID   string
+	MuscleGroup  string
+	Notes        string
+	Cursor       string
+	Limit        int
+	IncludeTotal bool
+}
+
+// WorkoutPage is one page of history. NextCursor is empty on the last page
+// and Total is only set when requested.
+type WorkoutPage struct {
+	Sessions   []domain.WorkoutSession
+	NextCursor string
+	Total      *int
+}
+
+const (
+	defaultWorkoutPageSize = 50
+	maxWorkoutPageSize     = 200
+)
+
+// List returns a page of the user's sessions, newest first.
+func (s *WorkoutService) List(userID string, query WorkoutListQuery) (*WorkoutPage, error) {
 	if userID == "" {
 		return nil, errors.New("user id is required")
 	}
-	return s.repository.Workouts.ListSessions(userID)
+	verr := &ValidationError{}
+	limit := query.Limit
+	switch {
+	case limit == 0:
+		limit = defaultWorkoutPageSize
+	case limit < 0 || limit > maxWorkoutPageSize:
+		verr.Add("limit", "must be between 1 and %d", maxWorkoutPageSize)
+	}
+	var after *repository.SessionKey
+	if query.Cursor != "" {
+		key, err := decodeSessionCursor(query.Cursor)
+		if err != nil {
+			verr.Add("cursor", "is not a valid cursor")
+		}
+		after = key
+	}
+	if query.ExerciseID != "" {
+		if _, err := uuid.Parse(query.ExerciseID); err != nil {
+			verr.Add("exerciseId", "must be a UUID")
+		}
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		verr.Add("to", "must be after from")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	filter := repository.SessionFilter{
+		UserID:      userID,
+		From:        query.From,
+		To:          query.To,
+		ExerciseID:  query.ExerciseID,
+		MuscleGroup: query.MuscleGroup,
+		Notes:       query.Notes,
+	}
+	// Fetch one extra session to learn whether another page follows.
+	sessions, err := s.repository.Workouts.ListSessions(filter, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &WorkoutPage{Sessions: sessions}
+	if len(sessions) > limit {
+		page.Sessions = sessions[:limit]
+		last := page.Sessions[limit-1]
+		page.NextCursor = encodeSessionCursor(repository.SessionKey{StartedAt: last.StartedAt, ID: last.ID})
+	}
+	if query.IncludeTotal {
+		total, err := s.repository.Workouts.CountSessions(filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// Session cursors are opaque to clients: the start time and id of the last
+// session of the previous page.
+func encodeSessionCursor(key repository.SessionKey) string {
+	raw := key.StartedAt.UTC().Format(time.RFC3339Nano) + "|" + key.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSessionCursor(cursor string) (*repository.SessionKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	startedAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+	key := &repository.SessionKey{ID: id}
+	if key.StartedAt, err = time.Parse(time.RFC3339Nano, startedAt); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, err
+	}
+	return key, nil
 }
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// BatchLimits bounds POST /workouts/batch requests.
+type BatchLimits struct {
+	MaxItems int
+	MaxBytes int64
+}
+
+var (
+	ErrBatchTooLarge = errors.New("workout batch is too large")
+	ErrBatchEmpty    = errors.New("workout batch is empty")
+)
+
+// BatchStatus is the outcome of one workout in a batch.
+type BatchStatus string
+
+const (
+	// BatchCreated means the workout was stored.
+	BatchCreated BatchStatus = "created"
+	// BatchDuplicate means the workout had been stored before under the same
+	// key. The original session is returned.
+	BatchDuplicate BatchStatus = "duplicate"
+	// BatchInvalid means the workout was rejected and retrying it unchanged
+	// will fail again.
+	BatchInvalid BatchStatus = "invalid"
+	// BatchConflict means the key was already used for a different workout.
+	BatchConflict BatchStatus = "conflict"
+	// BatchFailed means the workout could not be stored for another reason
+	// and may be retried.
+	BatchFailed BatchStatus = "failed"
+)
+
+// BatchItem is one workout of a batch. Input is nil when the item could not
+// be decoded, in which case DecodeError says why.
+type BatchItem struct {
+	Input       *WorkoutSessionInput
+	DecodeError error
+}
+
+// BatchResult reports what happened to the workout at Index. Key echoes the
+// item's idempotency key so clients can match results to their queue.
+type BatchResult struct {
+	Index   int                    `json:"index"`
+	Key     string                 `json:"key,omitempty"`
+	Status  BatchStatus            `json:"status"`
+	Session *domain.WorkoutSession `json:"session,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Fields  []FieldError           `json:"fields,omitempty"`
+}
+
+// MaxBatchBytes bounds the request body of a batch submission.
+func (s *WorkoutService) MaxBatchBytes() int64 {
+	return s.batchLimits.MaxBytes
+}
+
+// CreateBatch stores each workout independently, so one invalid workout does
+// not hold back the others. Every item gets a result in input order.
+func (s *WorkoutService) CreateBatch(userID string, items []BatchItem) ([]BatchResult, error) {
+	if len(items) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if len(items) > s.batchLimits.MaxItems {
+		return nil, fmt.Errorf("%w: at most %d workouts per batch, got %d", ErrBatchTooLarge, s.batchLimits.MaxItems, len(items))
+	}
+	results := make([]BatchResult, 0, len(items))
+	for i, item := range items {
+		result := BatchResult{Index: i}
+		if item.Input == nil {
+			result.Status = BatchInvalid
+			result.Error = item.DecodeError.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Key = item.Input.IdempotencyKey
+		if result.Key == "" {
+			result.Key = item.Input.ID
+		}
+
+		session, replayed, err := s.Create(userID, *item.Input)
+		var verr *ValidationError
+		switch {
+		case err == nil && replayed:
+			result.Status, result.Session = BatchDuplicate, session
+		case err == nil:
+			result.Status, result.Session = BatchCreated, session
+		case errors.As(err, &verr):
+			result.Status, result.Error, result.Fields = BatchInvalid, verr.Error(), verr.Fields
+		case errors.Is(err, ErrIdempotencyConflict), errors.Is(err, repository.ErrConflict):
+			result.Status, result.Error = BatchConflict, err.Error()
+		default:
+			result.Status, result.Error = BatchFailed, err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
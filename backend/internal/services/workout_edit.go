@@ -0,0 +1,296 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+var ErrTrashExpired = errors.New("workout was deleted too long ago to be restored")
+
+// WorkoutSessionPatch changes part of a session. Nil times are left alone.
+// Entries without an id are appended, entries with an id replace the stored
+// entry, and RemoveEntries lists entries to drop. Entries not mentioned are
+// kept as they are.
+type WorkoutSessionPatch struct {
+	StartedAt     *time.Time
+	CompletedAt   *time.Time
+	Entries       []WorkoutEntryInput
+	RemoveEntries []string
+}
+
+// Get returns one of the user's sessions. Sessions of other users and
+// trashed sessions are reported as not found.
+func (s *WorkoutService) Get(userID, id string) (*domain.WorkoutSession, error) {
+	session, err := s.owned(userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachRecords(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Replace overwrites a session with input, as PUT does. Entries keep their
+// identity when they carry the id of a stored entry and are otherwise added.
+// Stored entries missing from input are removed. Zero times keep the stored
+// ones.
+func (s *WorkoutService) Replace(userID, id string, input WorkoutSessionInput) (*domain.WorkoutSession, error) {
+	existing, err := s.owned(userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	verr := &ValidationError{}
+	entries, err := s.editedEntries(existing, input.Entries, verr)
+	if err != nil {
+		return nil, err
+	}
+	startedAt, completedAt := existing.StartedAt, existing.CompletedAt
+	if !input.StartedAt.IsZero() {
+		startedAt = input.StartedAt
+	}
+	if !input.CompletedAt.IsZero() {
+		completedAt = input.CompletedAt
+	}
+	checkSession(startedAt, completedAt, len(input.Entries), time.Now().UTC(), verr)
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return s.save(existing, startedAt, completedAt, entries)
+}
+
+// Patch applies an entry-level edit to a session.
+func (s *WorkoutService) Patch(userID, id string, patch WorkoutSessionPatch) (*domain.WorkoutSession, error) {
+	existing, err := s.owned(userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	verr := &ValidationError{}
+	removed := map[string]bool{}
+	stored := entriesByID(existing.Entries)
+	for i, entryID := range patch.RemoveEntries {
+		if _, ok := stored[entryID]; !ok {
+			verr.Add(fmt.Sprintf("removeEntries[%d]", i), "is not an entry of this workout")
+		}
+		removed[entryID] = true
+	}
+	changed, err := s.editedEntries(existing, patch.Entries, verr)
+	if err != nil {
+		return nil, err
+	}
+	startedAt, completedAt := existing.StartedAt, existing.CompletedAt
+	if patch.StartedAt != nil {
+		startedAt = *patch.StartedAt
+	}
+	if patch.CompletedAt != nil {
+		completedAt = *patch.CompletedAt
+	}
+	remaining := len(existing.Entries)
+	for entryID := range removed {
+		if _, ok := stored[entryID]; ok {
+			remaining--
+		}
+	}
+	for _, input := range patch.Entries {
+		if input.ID == "" {
+			remaining++
+		}
+	}
+	checkSession(startedAt, completedAt, remaining, time.Now().UTC(), verr)
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	replacements := map[string]domain.WorkoutEntry{}
+	var added []domain.WorkoutEntry
+	for _, entry := range changed {
+		if entry.ID == "" {
+			added = append(added, entry)
+		} else {
+			replacements[entry.ID] = entry
+		}
+	}
+	entries := make([]domain.WorkoutEntry, 0, len(existing.Entries)+len(added))
+	for _, entry := range existing.Entries {
+		if removed[entry.ID] {
+			continue
+		}
+		if replacement, ok := replacements[entry.ID]; ok {
+			entry = replacement
+		}
+		entries = append(entries, entry)
+	}
+	entries = append(entries, added...)
+	return s.save(existing, startedAt, completedAt, entries)
+}
+
+// Delete moves a session to the trash.
+func (s *WorkoutService) Delete(userID, id string) error {
+	session, err := s.owned(userID, id, false)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.Workouts.TrashSession(id, time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := refreshRecords(s.repository, userID, exercisesOf(*session)); err != nil {
+		return err
+	}
+	// Purging only reclaims space: expired sessions are already hidden, so
+	// a failure here can wait for the next attempt.
+	_, _ = s.PurgeTrash()
+	return nil
+}
+
+// Restore takes a session out of the trash, or fails with ErrTrashExpired
+// once the retention window has passed.
+func (s *WorkoutService) Restore(userID, id string) (*domain.WorkoutSession, error) {
+	session, err := s.owned(userID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if session.DeletedAt.Before(now.Add(-s.trashRetention)) {
+		return nil, ErrTrashExpired
+	}
+	err = s.repository.Workouts.RestoreSession(id, now)
+	if errors.Is(err, repository.ErrPlannedSessionTaken) {
+		return nil, fmt.Errorf("%w: another workout completed its planned workout", repository.ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.DeletedAt = nil
+	session.UpdatedAt = now
+	if err := s.updateRecords(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Trash lists the user's deleted sessions that can still be restored.
+func (s *WorkoutService) Trash(userID string) ([]domain.WorkoutSession, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	sessions, err := s.repository.Workouts.ListTrash(userID)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().UTC().Add(-s.trashRetention)
+	restorable := make([]domain.WorkoutSession, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.DeletedAt.Before(cutoff) {
+			restorable = append(restorable, session)
+		}
+	}
+	return restorable, nil
+}
+
+// PurgeTrash permanently deletes sessions that have been in the trash longer
+// than the retention window.
+func (s *WorkoutService) PurgeTrash() (int, error) {
+	return s.repository.Workouts.PurgeTrash(time.Now().UTC().Add(-s.trashRetention))
+}
+
+// owned loads a session of the user that is, or is not, in the trash. Ids
+// that are not UUIDs name no session.
+func (s *WorkoutService) owned(userID, id string, trashed bool) (*domain.WorkoutSession, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, repository.ErrNotFound
+	}
+	session, err := s.repository.Workouts.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID || (session.DeletedAt != nil) != trashed {
+		return nil, repository.ErrNotFound
+	}
+	return session, nil
+}
+
+// editedEntries validates entry inputs for an existing session, adding
+// problems to verr. Ids must name entries of that session, at most once each.
+func (s *WorkoutService) editedEntries(existing *domain.WorkoutSession, inputs []WorkoutEntryInput, verr *ValidationError) ([]domain.WorkoutEntry, error) {
+	stored := entriesByID(existing.Entries)
+	seen := map[string]bool{}
+	for i, input := range inputs {
+		if input.ID == "" {
+			continue
+		}
+		field := fmt.Sprintf("entries[%d].id", i)
+		if _, ok := stored[input.ID]; !ok {
+			verr.Add(field, "is not an entry of this workout")
+		} else if seen[input.ID] {
+			verr.Add(field, "appears more than once")
+		}
+		seen[input.ID] = true
+	}
+	entries, err := s.normalizeEntries(inputs, verr)
+	if err != nil || verr.Err() != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].ID = inputs[i].ID
+	}
+	return entries, nil
+}
+
+// save stores the edited session. Entries that did not change keep their
+// update time.
+func (s *WorkoutService) save(existing *domain.WorkoutSession, startedAt, completedAt time.Time, entries []domain.WorkoutEntry) (*domain.WorkoutSession, error) {
+	now := time.Now().UTC()
+	stored := entriesByID(existing.Entries)
+	for i := range entries {
+		entry := &entries[i]
+		previous, ok := stored[entry.ID]
+		if !ok {
+			continue
+		}
+		entry.SessionID = previous.SessionID
+		entry.CreatedAt = previous.CreatedAt
+		entry.UpdatedAt = now
+		if reflect.DeepEqual(entryContent(*entry), entryContent(previous)) {
+			entry.UpdatedAt = previous.UpdatedAt
+		}
+	}
+
+	session := *existing
+	session.StartedAt = startedAt
+	session.CompletedAt = completedAt
+	session.UpdatedAt = now
+	session.Entries = entries
+	if err := s.repository.Workouts.UpdateSession(&session); err != nil {
+		return nil, err
+	}
+	if err := s.updateRecords(&session, *existing); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func entriesByID(entries []domain.WorkoutEntry) map[string]domain.WorkoutEntry {
+	byID := make(map[string]domain.WorkoutEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+	return byID
+}
+
+// entryContent strips bookkeeping fields so entries can be compared by what
+// was logged.
+func entryContent(entry domain.WorkoutEntry) domain.WorkoutEntry {
+	entry.ID, entry.SessionID = "", ""
+	entry.CreatedAt, entry.UpdatedAt = time.Time{}, time.Time{}
+	return entry
+}
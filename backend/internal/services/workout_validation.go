@@ -0,0 +1,304 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// Bounds on submitted workouts. They are meant to catch typos and garbage
+// rather than to judge unusual training.
+const (
+	maxEntriesPerSession = 100
+	maxSetsPerEntry      = 100
+	maxReps              = 1000
+	maxWeight            = 1500.0
+	maxDurationSeconds   = 24 * 60 * 60
+	maxDistanceMeters    = 1000000.0
+	maxNotesLength       = 2000
+	maxSessionLength     = 24 * time.Hour
+	// futureTolerance allows for devices whose clocks run ahead.
+	futureTolerance = time.Hour
+)
+
+// earliestWorkout is the oldest start time accepted.
+var earliestWorkout = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// checkSession validates session-wide fields: the times and the number of
+// entries.
+func checkSession(startedAt, completedAt time.Time, entries int, now time.Time, verr *ValidationError) {
+	latest := now.Add(futureTolerance)
+	switch {
+	case startedAt.Before(earliestWorkout):
+		verr.Add("startedAt", "must not be before %s", earliestWorkout.Format(time.DateOnly))
+	case startedAt.After(latest):
+		verr.Add("startedAt", "must not be in the future")
+	}
+	switch {
+	case completedAt.Before(startedAt):
+		verr.Add("completedAt", "must not be before startedAt")
+	case completedAt.After(latest) && !startedAt.After(latest):
+		verr.Add("completedAt", "must not be in the future")
+	case completedAt.Sub(startedAt) > maxSessionLength:
+		verr.Add("completedAt", "must be within %s of startedAt", maxSessionLength)
+	}
+	switch {
+	case entries == 0:
+		verr.Add("entries", "must contain at least one entry")
+	case entries > maxEntriesPerSession:
+		verr.Add("entries", "must contain at most %d entries", maxEntriesPerSession)
+	}
+}
+
+// normalizeEntries checks every entry against the measurement type of its
+// exercise and returns the entries ready to be stored. Problems are added to
+// verr; the returned error is only set when the check itself failed.
+func (s *WorkoutService) normalizeEntries(inputs []WorkoutEntryInput, verr *ValidationError) ([]domain.WorkoutEntry, error) {
+	exercises := newExerciseLookup(s.repository.Exercises)
+	entries := make([]domain.WorkoutEntry, 0, len(inputs))
+	for i, input := range inputs {
+		path := fmt.Sprintf("entries[%d]", i)
+		ex, err := exercises.check(input.ExerciseID, path+".exerciseId", verr)
+		if err != nil {
+			return nil, err
+		}
+		if ex != nil {
+			entries = append(entries, normalizeEntry(ex, input, path, verr))
+		}
+	}
+	return entries, nil
+}
+
+// exerciseLookup resolves the exercises referenced by a submission, loading
+// each one once.
+type exerciseLookup struct {
+	exercises repository.ExerciseRepository
+	cache     map[string]*domain.Exercise
+}
+
+func newExerciseLookup(exercises repository.ExerciseRepository) *exerciseLookup {
+	return &exerciseLookup{exercises: exercises, cache: map[string]*domain.Exercise{}}
+}
+
+// check returns the exercise with id, or nil after adding a field error at
+// field when id is missing, malformed or unknown.
+func (l *exerciseLookup) check(id, field string, verr *ValidationError) (*domain.Exercise, error) {
+	if id == "" {
+		verr.Add(field, "is required")
+		return nil, nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		verr.Add(field, "must be a UUID")
+		return nil, nil
+	}
+	if ex, ok := l.cache[id]; ok {
+		return ex, nil
+	}
+	ex, err := l.exercises.GetByID(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		verr.Add(field, "unknown exercise")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.cache[id] = ex
+	return ex, nil
+}
+
+// normalizeEntry validates a single entry for the exercise's measurement
+// type. Unilateral entries without a side are treated as performed on both
+// sides. Entries given as aggregates are expanded into identical working sets
+// (one if Sets is empty); entries given set by set get their aggregates
+// derived from the sets.
+func normalizeEntry(ex *domain.Exercise, input WorkoutEntryInput, path string, verr *ValidationError) domain.WorkoutEntry {
+	entry := domain.WorkoutEntry{
+		ExerciseID:      input.ExerciseID,
+		Sets:            input.Sets,
+		Reps:            input.Reps,
+		Weight:          input.Weight,
+		DurationSeconds: input.DurationSeconds,
+		DistanceMeters:  input.DistanceMeters,
+		Side:            input.Side,
+		Notes:           input.Notes,
+	}
+	mt := ex.MeasurementType
+	if !mt.Valid() {
+		mt = domain.MeasurementWeightReps
+	}
+
+	if utf8.RuneCountInString(entry.Notes) > maxNotesLength {
+		verr.Add(path+".notes", "must be at most %d characters", maxNotesLength)
+	}
+	if len(input.SetDetails) == 0 {
+		switch {
+		case entry.Sets < 0:
+			verr.Add(path+".sets", "must be >= 0")
+		case entry.Sets > maxSetsPerEntry:
+			verr.Add(path+".sets", "must be <= %d", maxSetsPerEntry)
+			// Don't expand an absurd number of sets just to reject them.
+			entry.Sets = maxSetsPerEntry
+		case entry.Sets == 0:
+			entry.Sets = 1
+		}
+		checkMeasurement(mt, entry.Reps, entry.Weight, entry.DurationSeconds, entry.DistanceMeters, path, verr)
+		entry.SetDetails = expandSets(entry, mt)
+	} else {
+		if entry.DurationSeconds < 0 {
+			verr.Add(path+".durationSeconds", "must be >= 0")
+		}
+		if len(input.SetDetails) > maxSetsPerEntry {
+			verr.Add(path+".setDetails", "must contain at most %d sets", maxSetsPerEntry)
+		}
+		entry.SetDetails = make([]domain.WorkoutSet, 0, len(input.SetDetails))
+		for i, setInput := range input.SetDetails {
+			entry.SetDetails = append(entry.SetDetails, normalizeSet(mt, setInput, fmt.Sprintf("%s.setDetails[%d]", path, i), verr))
+		}
+		deriveAggregates(&entry)
+	}
+
+	entry.Side = checkSide(ex, entry.Side, path+".side", verr)
+	return entry
+}
+
+// checkSide validates the side of an entry for ex, defaulting unilateral
+// exercises to both sides.
+func checkSide(ex *domain.Exercise, side domain.Side, field string, verr *ValidationError) domain.Side {
+	switch {
+	case !ex.Unilateral && side != "":
+		verr.Add(field, "only applies to unilateral exercises")
+	case ex.Unilateral && side == "":
+		side = domain.SideBoth
+	case ex.Unilateral && side != domain.SideLeft && side != domain.SideRight && side != domain.SideBoth:
+		verr.Add(field, "must be one of left, right, both")
+	}
+	return side
+}
+
+// tempoPattern matches four tempo phases, each a number of seconds or X for
+// explosive, optionally separated by dashes: "3-1-X-0" or "31X0".
+var tempoPattern = regexp.MustCompile(`^([0-9]{1,2}|X)(-([0-9]{1,2}|X)){3}$|^[0-9X]{4}$`)
+
+func normalizeSet(mt domain.MeasurementType, input WorkoutSetInput, path string, verr *ValidationError) domain.WorkoutSet {
+	set := domain.WorkoutSet{
+		Type:            input.Type,
+		Reps:            input.Reps,
+		Weight:          input.Weight,
+		DurationSeconds: input.DurationSeconds,
+		DistanceMeters:  input.DistanceMeters,
+		RPE:             input.RPE,
+		RIR:             input.RIR,
+		Tempo:           strings.ToUpper(strings.TrimSpace(input.Tempo)),
+		Completed:       input.Completed == nil || *input.Completed,
+		CompletedAt:     input.CompletedAt,
+		RestSeconds:     input.RestSeconds,
+	}
+	if set.CompletedAt != nil {
+		completedAt := set.CompletedAt.UTC()
+		set.CompletedAt = &completedAt
+	}
+	if set.Type == "" {
+		set.Type = domain.SetWorking
+	} else if !set.Type.Valid() {
+		verr.Add(path+".type", "must be one of warmup, working, drop, failure")
+	}
+	checkMeasurement(mt, set.Reps, set.Weight, set.DurationSeconds, set.DistanceMeters, path, verr)
+	if set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10) {
+		verr.Add(path+".rpe", "must be between 1 and 10")
+	}
+	if set.RIR != nil && (*set.RIR < 0 || *set.RIR > maxReps) {
+		verr.Add(path+".rir", "must be between 0 and %d", maxReps)
+	}
+	if set.Tempo != "" && !tempoPattern.MatchString(set.Tempo) {
+		verr.Add(path+".tempo", "must have four phases such as 3-1-X-0")
+	}
+	if set.RestSeconds != nil && (*set.RestSeconds < 0 || *set.RestSeconds > maxDurationSeconds) {
+		verr.Add(path+".restSeconds", "must be between 0 and %d", maxDurationSeconds)
+	}
+	if set.CompletedAt != nil && (set.CompletedAt.Before(earliestWorkout) || set.CompletedAt.After(time.Now().Add(futureTolerance))) {
+		verr.Add(path+".completedAt", "must be between %s and now", earliestWorkout.Format(time.DateOnly))
+	}
+	return set
+}
+
+// checkMeasurement validates the numeric fields of an entry or set against
+// the measurement type.
+func checkMeasurement(mt domain.MeasurementType, reps int, weight float64, durationSeconds int, distanceMeters float64, path string, verr *ValidationError) {
+	if reps < 0 {
+		verr.Add(path+".reps", "must be >= 0")
+	} else if reps > maxReps {
+		verr.Add(path+".reps", "must be <= %d", maxReps)
+	} else if mt.CountsReps() && reps == 0 {
+		verr.Add(path+".reps", "is required for %s exercises", mt)
+	} else if !mt.CountsReps() && reps != 0 {
+		verr.Add(path+".reps", "must be empty for %s exercises", mt)
+	}
+	if weight < 0 {
+		verr.Add(path+".weight", "must be >= 0")
+	} else if weight > maxWeight {
+		verr.Add(path+".weight", "must be <= %g", maxWeight)
+	}
+	if durationSeconds < 0 {
+		verr.Add(path+".durationSeconds", "must be >= 0")
+	} else if durationSeconds > maxDurationSeconds {
+		verr.Add(path+".durationSeconds", "must be <= %d", maxDurationSeconds)
+	} else if mt.RequiresDuration() && durationSeconds == 0 {
+		verr.Add(path+".durationSeconds", "is required for %s exercises", mt)
+	}
+	if distanceMeters < 0 {
+		verr.Add(path+".distanceMeters", "must be >= 0")
+	} else if distanceMeters > maxDistanceMeters {
+		verr.Add(path+".distanceMeters", "must be <= %g", maxDistanceMeters)
+	} else if mt.RequiresDistance() && distanceMeters == 0 {
+		verr.Add(path+".distanceMeters", "is required for %s exercises", mt)
+	} else if !mt.RequiresDistance() && distanceMeters != 0 {
+		verr.Add(path+".distanceMeters", "must be empty for %s exercises", mt)
+	}
+}
+
+// expandSets turns an aggregate entry into identical working sets. Duration
+// and distance are per set only for exercises measured by them; for other
+// exercises the entry's duration is the time spent on the whole entry.
+func expandSets(entry domain.WorkoutEntry, mt domain.MeasurementType) []domain.WorkoutSet {
+	sets := make([]domain.WorkoutSet, 0, max(entry.Sets, 0))
+	for i := 0; i < entry.Sets; i++ {
+		set := domain.WorkoutSet{Type: domain.SetWorking, Reps: entry.Reps, Weight: entry.Weight, Completed: true}
+		if mt.RequiresDuration() {
+			set.DurationSeconds = entry.DurationSeconds
+		}
+		if mt.RequiresDistance() {
+			set.DistanceMeters = entry.DistanceMeters
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// deriveAggregates fills the entry's summary fields from its working sets:
+// the number of sets and the heaviest set, with ties going to more reps.
+// Duration and distance are the longest set's when sets record them.
+func deriveAggregates(entry *domain.WorkoutEntry) {
+	working := entry.WorkingSets()
+	entry.Sets, entry.Reps, entry.Weight = len(working), 0, 0
+	var duration int
+	var distance float64
+	for _, set := range working {
+		if set.Weight > entry.Weight || (set.Weight == entry.Weight && set.Reps > entry.Reps) {
+			entry.Weight, entry.Reps = set.Weight, set.Reps
+		}
+		duration = max(duration, set.DurationSeconds)
+		distance = max(distance, set.DistanceMeters)
+	}
+	if duration > 0 {
+		entry.DurationSeconds = duration
+	}
+	entry.DistanceMeters = distance
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// TestAnalyticsSeries checks the series SQL against a disposable database:
+//
+//	TEST_DATABASE_URL=postgres://... go test -run Analytics ./internal/storage/postgres
+func TestAnalyticsSeries(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	storage, err := New(context.Background(), dsn)
+	require.NoError(t, err)
+	defer storage.Close()
+	repo := storage.Repository()
+
+	// Arrange
+	user := &domain.User{Email: fmt.Sprintf("analytics-%s@example.com", uuid.NewString()), PasswordHash: "x", Role: domain.RoleUser}
+	require.NoError(t, repo.Users.Create(user))
+	press := &domain.Exercise{Name: "Analytics Press " + uuid.NewString(), MuscleGroup: "Chest", MeasurementType: domain.MeasurementWeightReps}
+	require.NoError(t, repo.Exercises.Create(press, nil))
+	dip := &domain.Exercise{Name: "Analytics Dip " + uuid.NewString(), MuscleGroup: "Triceps", MeasurementType: domain.MeasurementAssisted}
+	require.NoError(t, repo.Exercises.Create(dip, nil))
+	defer func() {
+		_, _ = storage.pool.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, user.ID)
+		_, _ = storage.pool.Exec(context.Background(), `DELETE FROM exercises WHERE id = ANY($1)`, []string{press.ID, dip.ID})
+	}()
+
+	working := func(reps int, weight float64) domain.WorkoutSet {
+		return domain.WorkoutSet{Type: domain.SetWorking, Reps: reps, Weight: weight, Completed: true}
+	}
+	log := func(startedAt time.Time, exercise *domain.Exercise, sets ...domain.WorkoutSet) {
+		t.Helper()
+		session := &domain.WorkoutSession{UserID: user.ID, StartedAt: startedAt, Entries: []domain.WorkoutEntry{{
+			ExerciseID: exercise.ID, Sets: len(sets), SetDetails: sets,
+		}}}
+		require.NoError(t, repo.Workouts.CreateSession(session))
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC) }
+
+	// Just before the range.
+	log(at(1, 0, 0).Add(-time.Second), press, working(5, 100))
+	// Sunday night, the last minutes of the week starting 23 February. The
+	// warm-up and the skipped set would be the heaviest if they counted.
+	log(at(1, 23, 30), press,
+		domain.WorkoutSet{Type: domain.SetWarmup, Reps: 1, Weight: 150, Completed: true},
+		domain.WorkoutSet{Type: domain.SetWorking, Reps: 5, Weight: 200},
+		working(5, 100), working(5, 100))
+	// Monday midnight opens the next week.
+	log(at(2, 0, 0), press, working(3, 110))
+	log(at(2, 18, 0), dip, working(8, 20))
+	log(at(2, 19, 0), press, working(8, 100))
+	// The end of the range is exclusive.
+	log(at(9, 0, 0), press, working(5, 100))
+
+	query := repository.SeriesQuery{UserID: user.ID, From: at(1, 0, 0), To: at(9, 0, 0), Bucket: domain.BucketWeek, ExerciseID: press.ID}
+	lastWeek := time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC)
+	thisWeek := at(2, 0, 0)
+
+	t.Run("exercise volume", func(t *testing.T) {
+		// Act
+		from := at(1, 0, 0)
+		totals, err := repo.Analytics.ExerciseVolume(repository.VolumeQuery{UserID: user.ID, From: &from})
+
+		// Assert: the assisted dips add reps but no volume.
+		require.NoError(t, err)
+		require.ElementsMatch(t, []domain.ExerciseVolume{
+			{ExerciseID: press.ID, Sets: 5, Reps: 26, Volume: 2630},
+			{ExerciseID: dip.ID, Sets: 1, Reps: 8},
+		}, totals)
+	})
+
+	t.Run("workload", func(t *testing.T) {
+		// Act
+		points, err := repo.Analytics.Workload(query)
+
+		// Assert: assistance on the dips adds reps but no tonnage.
+		require.NoError(t, err)
+		require.Equal(t, []domain.WorkloadPoint{
+			{Start: lastWeek, Sets: 2, Reps: 10, Tonnage: 1000},
+			{Start: thisWeek, Sets: 3, Reps: 19, Tonnage: 1130},
+		}, utcWorkload(points))
+	})
+
+	t.Run("muscle groups", func(t *testing.T) {
+		// Act
+		points, err := repo.Analytics.MuscleGroupSets(query)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, points, 3)
+		require.Equal(t, []string{"chest", "chest", "triceps"}, []string{points[0].MuscleGroup, points[1].MuscleGroup, points[2].MuscleGroup})
+		require.Equal(t, []int{2, 2, 1}, []int{points[0].Sets, points[1].Sets, points[2].Sets})
+		require.True(t, lastWeek.Equal(points[0].Start))
+		require.True(t, thisWeek.Equal(points[2].Start))
+	})
+
+	t.Run("one-rep max", func(t *testing.T) {
+		// Act
+		points, err := repo.Analytics.OneRepMax(query)
+
+		// Assert: 8 × 100 estimates higher than 3 × 110.
+		require.NoError(t, err)
+		require.Len(t, points, 2)
+		require.True(t, lastWeek.Equal(points[0].Start))
+		require.InDelta(t, 112.5, points[0].EstimatedOneRepMax, 1e-9)
+		require.Equal(t, 100.0, points[0].Weight)
+		require.InDelta(t, 124.14, points[1].EstimatedOneRepMax, 0.01)
+		require.Equal(t, 8, points[1].Reps)
+	})
+
+	t.Run("one-rep max of assisted sets", func(t *testing.T) {
+		// Act
+		assisted := query
+		assisted.ExerciseID = dip.ID
+		points, err := repo.Analytics.OneRepMax(assisted)
+
+		// Assert: the weight is the assistance, so there is nothing to estimate.
+		require.NoError(t, err)
+		require.Empty(t, points)
+	})
+
+	t.Run("frequency by day", func(t *testing.T) {
+		// Act
+		daily := query
+		daily.Bucket = domain.BucketDay
+		points, err := repo.Analytics.Frequency(daily)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, points, 2)
+		require.True(t, at(1, 0, 0).Equal(points[0].Start))
+		require.Equal(t, 1, points[0].Sessions)
+		require.True(t, at(2, 0, 0).Equal(points[1].Start))
+		require.Equal(t, 3, points[1].Sessions)
+		require.Equal(t, 1, points[1].Days)
+	})
+}
+
+// utcWorkload normalizes bucket starts so points compare with ==.
+func utcWorkload(points []domain.WorkloadPoint) []domain.WorkloadPoint {
+	for i := range points {
+		points[i].Start = points[i].Start.UTC()
+	}
+	return points
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/catalogue"
+	"github.com/musclementour/app/internal/services"
+)
+
+// TestExerciseCreate runs exercise creation and the catalogue seed against
+// a disposable database, where revisions must satisfy their foreign key:
+//
+//	TEST_DATABASE_URL=postgres://... go test -run ExerciseCreate ./internal/storage/postgres
+func TestExerciseCreate(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	storage, err := New(context.Background(), dsn)
+	require.NoError(t, err)
+	defer storage.Close()
+	repo := storage.Repository()
+	exercises := services.NewExerciseService(repo, nil, services.MediaLimits{})
+
+	t.Run("with its first revision", func(t *testing.T) {
+		// Act
+		created, err := exercises.Create("", services.ExerciseInput{Name: "Revision Press " + uuid.NewString()})
+		require.NoError(t, err)
+		defer func() {
+			_, _ = storage.pool.Exec(context.Background(), `DELETE FROM exercises WHERE id=$1`, created.ID)
+		}()
+
+		// Assert
+		revisions, err := repo.ExerciseRevisions.ListByExercise(created.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		require.Equal(t, 1, revisions[0].Number)
+	})
+
+	t.Run("catalogue seed", func(t *testing.T) {
+		// Arrange
+		cat, err := catalogue.Load("")
+		require.NoError(t, err)
+
+		// Act
+		_, err = exercises.SeedCatalogue(cat)
+		require.NoError(t, err)
+		again, err := exercises.SeedCatalogue(cat)
+
+		// Assert
+		require.NoError(t, err)
+		require.Empty(t, again.Created)
+	})
+}
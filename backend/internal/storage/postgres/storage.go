@@ -2,12 +2,15 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	appdb "github.com/musclementour/app/internal/db"
@@ -44,10 +47,24 @@ func (s *Storage) Close() {
 
 func (s *Storage) Repository() repository.Repository {
 	return repository.Repository{
-		Users:         &userRepository{pool: s.pool},
-		RefreshTokens: &refreshTokenRepository{pool: s.pool},
-		Exercises:     &exerciseRepository{pool: s.pool},
-		Workouts:      &workoutRepository{pool: s.pool},
+		Users:             &userRepository{pool: s.pool},
+		RefreshTokens:     &refreshTokenRepository{pool: s.pool},
+		Exercises:         &exerciseRepository{pool: s.pool},
+		ExerciseRevisions: &exerciseRevisionRepository{pool: s.pool},
+		ExerciseMedia:     &exerciseMediaRepository{pool: s.pool},
+		Substitutions:     &exerciseSubstitutionRepository{pool: s.pool},
+		Translations:      &exerciseTranslationRepository{pool: s.pool},
+		Relations:         &exerciseRelationRepository{pool: s.pool},
+		Seeds:             &exerciseSeedRepository{pool: s.pool},
+		Workouts:          &workoutRepository{pool: s.pool},
+		Templates:         &templateRepository{pool: s.pool},
+		Programs:          &programRepository{pool: s.pool},
+		TrainingMaxes:     &trainingMaxRepository{pool: s.pool},
+		Records:           &recordRepository{pool: s.pool},
+		Analytics:         &analyticsRepository{pool: s.pool},
+		Goals:             &goalRepository{pool: s.pool},
+		BodyMetrics:       &bodyMetricRepository{pool: s.pool},
+		LiveWorkouts:      &liveWorkoutRepository{pool: s.pool},
 	}
 }
 
@@ -70,32 +87,32 @@ func (r *userRepository) Create(user *domain.User) error {
 }
 
 func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
-	row := r.pool.QueryRow(context.Background(),
-		`SELECT id, email, password_hash, role, created_at FROM users WHERE email = $1`, email)
-	var u domain.User
-	var role string
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &role, &u.CreatedAt); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, err
-		}
-		return nil, err
-	}
-	u.Role = domain.Role(role)
-	return &u, nil
+	return scanUser(r.pool.QueryRow(context.Background(),
+		`SELECT `+userColumns+` FROM users WHERE email = $1`, email))
 }
 
 func (r *userRepository) GetByID(id string) (*domain.User, error) {
-	row := r.pool.QueryRow(context.Background(),
-		`SELECT id, email, password_hash, role, created_at FROM users WHERE id = $1`, id)
+	return scanUser(r.pool.QueryRow(context.Background(),
+		`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+const userColumns = `id, email, password_hash, role, locale, created_at, bar_weight, plates, load_increment`
+
+func scanUser(row pgx.Row) (*domain.User, error) {
 	var u domain.User
 	var role string
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &role, &u.CreatedAt); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, err
-		}
+	var barWeight, increment *float64
+	var plates []float64
+	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &role, &u.Locale, &u.CreatedAt, &barWeight, &plates, &increment); err != nil {
 		return nil, err
 	}
 	u.Role = domain.Role(role)
+	if barWeight != nil {
+		u.Loading = &domain.LoadingSettings{BarWeight: *barWeight, Plates: plates}
+		if increment != nil {
+			u.Loading.Increment = *increment
+		}
+	}
 	return &u, nil
 }
 
@@ -108,6 +125,37 @@ func (r *userRepository) CountAdmins() (int, error) {
 	return count, nil
 }
 
+func (r *userRepository) SetLocale(userID, locale string) error {
+	tag, err := r.pool.Exec(context.Background(), `UPDATE users SET locale = $1 WHERE id = $2`, locale, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) SetLoading(userID string, loading *domain.LoadingSettings) error {
+	var barWeight, increment *float64
+	var plates []float64
+	if loading != nil {
+		barWeight, increment, plates = &loading.BarWeight, &loading.Increment, loading.Plates
+		if plates == nil {
+			plates = []float64{}
+		}
+	}
+	tag, err := r.pool.Exec(context.Background(),
+		`UPDATE users SET bar_weight = $1, plates = $2, load_increment = $3 WHERE id = $4`, barWeight, plates, increment, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
 // Refresh token repository
 
 type refreshTokenRepository struct {
@@ -150,9 +198,18 @@ type exerciseRepository struct {
 	pool *pgxpool.Pool
 }
 
+const exerciseColumns = `id, name, COALESCE(description, ''), COALESCE(muscle_group, ''), COALESCE(equipment, ''), measurement_type, unilateral,
+    primary_muscles, movement_pattern, ARRAY(SELECT alias FROM exercise_aliases a WHERE a.exercise_id = exercises.id ORDER BY alias),
+    archived_at, created_at, updated_at`
+
+// exerciseChangeLock serialises exercise writes so change_seq values become
+// visible in the order they were allocated. Without it a slow transaction
+// could commit a lower sequence number after a client already synced past it.
+const exerciseChangeLock = 4201
+
 func (r *exerciseRepository) List() ([]domain.Exercise, error) {
 	rows, err := r.pool.Query(context.Background(),
-		`SELECT id, name, description, muscle_group, equipment, created_at, updated_at FROM exercises ORDER BY name`)
+		`SELECT `+exerciseColumns+` FROM exercises ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
@@ -160,146 +217,2323 @@ func (r *exerciseRepository) List() ([]domain.Exercise, error) {
 
 	exercises := []domain.Exercise{}
 	for rows.Next() {
-		var ex domain.Exercise
-		if err := rows.Scan(&ex.ID, &ex.Name, &ex.Description, &ex.MuscleGroup, &ex.Equipment, &ex.CreatedAt, &ex.UpdatedAt); err != nil {
+		ex, err := scanExercise(rows)
+		if err != nil {
 			return nil, err
 		}
-		exercises = append(exercises, ex)
+		exercises = append(exercises, *ex)
 	}
-	return exercises, nil
+	return exercises, rows.Err()
 }
 
-func (r *exerciseRepository) Create(ex *domain.Exercise) error {
+func (r *exerciseRepository) Create(ex *domain.Exercise, rev *domain.ExerciseRevision) error {
 	if ex.ID == "" {
 		ex.ID = uuid.NewString()
 	}
 	now := time.Now().UTC()
 	ex.CreatedAt = now
 	ex.UpdatedAt = now
-	_, err := r.pool.Exec(context.Background(),
-		`INSERT INTO exercises (id, name, description, muscle_group, equipment, created_at, updated_at)
-         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		ex.ID, ex.Name, ex.Description, ex.MuscleGroup, ex.Equipment, ex.CreatedAt, ex.UpdatedAt,
-	)
-	return err
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO exercises (id, name, description, muscle_group, equipment, measurement_type, unilateral, primary_muscles, movement_pattern, created_at, updated_at, change_seq)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, nextval('exercise_change_seq'))`,
+			ex.ID, ex.Name, ex.Description, ex.MuscleGroup, ex.Equipment, string(ex.MeasurementType), ex.Unilateral, primaryMuscles(ex), ex.MovementPattern, ex.CreatedAt, ex.UpdatedAt,
+		)
+		if err != nil || rev == nil {
+			return err
+		}
+		// The revision references the exercise, so it goes in second.
+		rev.ExerciseID = ex.ID
+		return insertExerciseRevision(tx, rev)
+	})
 }
 
-func (r *exerciseRepository) Update(ex *domain.Exercise) error {
+func (r *exerciseRepository) Update(ex *domain.Exercise, rev *domain.ExerciseRevision) error {
 	ex.UpdatedAt = time.Now().UTC()
-	_, err := r.pool.Exec(context.Background(),
-		`UPDATE exercises SET name=$1, description=$2, muscle_group=$3, equipment=$4, updated_at=$5 WHERE id=$6`,
-		ex.Name, ex.Description, ex.MuscleGroup, ex.Equipment, ex.UpdatedAt, ex.ID,
-	)
-	return err
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		if rev != nil {
+			if err := insertExerciseRevision(tx, rev); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(context.Background(),
+			`UPDATE exercises SET name=$1, description=$2, muscle_group=$3, equipment=$4, measurement_type=$5, unilateral=$6,
+                 primary_muscles=$7, movement_pattern=$8, updated_at=$9, change_seq=nextval('exercise_change_seq')
+             WHERE id=$10`,
+			ex.Name, ex.Description, ex.MuscleGroup, ex.Equipment, string(ex.MeasurementType), ex.Unilateral,
+			primaryMuscles(ex), ex.MovementPattern, ex.UpdatedAt, ex.ID,
+		)
+		return err
+	})
 }
 
 func (r *exerciseRepository) Delete(id string) error {
-	_, err := r.pool.Exec(context.Background(), `DELETE FROM exercises WHERE id=$1`, id)
-	return err
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `DELETE FROM exercises WHERE id=$1`, id)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		_, err = tx.Exec(context.Background(),
+			`INSERT INTO exercise_tombstones (exercise_id, reason, removed_at, change_seq)
+             VALUES ($1, $2, NOW(), nextval('exercise_change_seq'))
+             ON CONFLICT (exercise_id) DO UPDATE SET reason=EXCLUDED.reason, removed_at=EXCLUDED.removed_at, replaced_by=NULL, change_seq=EXCLUDED.change_seq`,
+			id, string(domain.TombstoneDeleted),
+		)
+		return err
+	})
 }
 
 func (r *exerciseRepository) GetByID(id string) (*domain.Exercise, error) {
 	row := r.pool.QueryRow(context.Background(),
-		`SELECT id, name, description, muscle_group, equipment, created_at, updated_at FROM exercises WHERE id=$1`, id)
-	var ex domain.Exercise
-	if err := row.Scan(&ex.ID, &ex.Name, &ex.Description, &ex.MuscleGroup, &ex.Equipment, &ex.CreatedAt, &ex.UpdatedAt); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, err
+		`SELECT `+exerciseColumns+` FROM exercises WHERE id=$1`, id)
+	return scanExercise(row)
+}
+
+func (r *exerciseRepository) SetArchived(id string, archivedAt *time.Time) error {
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(),
+			`UPDATE exercises SET archived_at=$1, updated_at=NOW(), change_seq=nextval('exercise_change_seq') WHERE id=$2`,
+			archivedAt, id,
+		)
+		if err != nil {
+			return err
 		}
-		return nil, err
-	}
-	return &ex, nil
+		if tag.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
 }
 
-// Workout repository
+func (r *exerciseRepository) AddAlias(exerciseID, alias, normalized string) error {
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(),
+			`UPDATE exercises SET updated_at=NOW(), change_seq=nextval('exercise_change_seq') WHERE id=$1`, exerciseID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+		_, err = tx.Exec(context.Background(),
+			`INSERT INTO exercise_aliases (exercise_id, alias, normalized, created_at) VALUES ($1, $2, $3, NOW())`,
+			exerciseID, alias, normalized,
+		)
+		if isUniqueViolation(err) {
+			return repository.ErrConflict
+		}
+		return err
+	})
+}
 
-type workoutRepository struct {
-	pool *pgxpool.Pool
+func (r *exerciseRepository) RemoveAlias(exerciseID, normalized string) error {
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(),
+			`DELETE FROM exercise_aliases WHERE exercise_id=$1 AND normalized=$2`, exerciseID, normalized)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE exercises SET updated_at=NOW(), change_seq=nextval('exercise_change_seq') WHERE id=$1`, exerciseID)
+		return err
+	})
 }
 
-func (r *workoutRepository) CreateSession(session *domain.WorkoutSession) error {
-	if session.ID == "" {
-		session.ID = uuid.NewString()
+func (r *exerciseRepository) Changes(since int64) (*domain.ExerciseChangeFeed, error) {
+	feed := &domain.ExerciseChangeFeed{
+		Seq:        since,
+		Exercises:  []domain.Exercise{},
+		Tombstones: []domain.ExerciseTombstone{},
 	}
-	if session.StartedAt.IsZero() {
-		session.StartedAt = time.Now().UTC()
+
+	// Both reads share one snapshot, otherwise a write committed between
+	// them could be skipped by a cursor advanced past it.
+	tx, err := r.pool.BeginTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
 	}
-	if session.CompletedAt.IsZero() {
-		session.CompletedAt = session.StartedAt
+	defer tx.Rollback(context.Background())
+
+	rows, err := tx.Query(context.Background(),
+		`SELECT `+exerciseColumns+`, change_seq FROM exercises WHERE change_seq > $1 ORDER BY change_seq`, since)
+	if err != nil {
+		return nil, err
 	}
-	session.CreatedAt = time.Now().UTC()
+	defer rows.Close()
+	for rows.Next() {
+		var ex domain.Exercise
+		var measurementType string
+		var seq int64
+		if err := rows.Scan(&ex.ID, &ex.Name, &ex.Description, &ex.MuscleGroup, &ex.Equipment, &measurementType, &ex.Unilateral, &ex.PrimaryMuscles, &ex.MovementPattern, &ex.Aliases, &ex.ArchivedAt, &ex.CreatedAt, &ex.UpdatedAt, &seq); err != nil {
+			return nil, err
+		}
+		ex.MeasurementType = domain.MeasurementType(measurementType)
+		if seq > feed.Seq {
+			feed.Seq = seq
+		}
+		if ex.Archived() {
+			feed.Tombstones = append(feed.Tombstones, domain.ExerciseTombstone{ID: ex.ID, Reason: domain.TombstoneArchived, RemovedAt: *ex.ArchivedAt})
+			continue
+		}
+		feed.Exercises = append(feed.Exercises, ex)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-	tx, err := r.pool.Begin(context.Background())
+	tombstoneRows, err := tx.Query(context.Background(),
+		`SELECT exercise_id, reason, removed_at, COALESCE(replaced_by::text, ''), change_seq FROM exercise_tombstones WHERE change_seq > $1 ORDER BY change_seq`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer tombstoneRows.Close()
+	for tombstoneRows.Next() {
+		var tombstone domain.ExerciseTombstone
+		var reason string
+		var seq int64
+		if err := tombstoneRows.Scan(&tombstone.ID, &reason, &tombstone.RemovedAt, &tombstone.ReplacedBy, &seq); err != nil {
+			return nil, err
+		}
+		tombstone.Reason = domain.TombstoneReason(reason)
+		if seq > feed.Seq {
+			feed.Seq = seq
+		}
+		feed.Tombstones = append(feed.Tombstones, tombstone)
+	}
+	if err := tombstoneRows.Err(); err != nil {
+		return nil, err
+	}
+	tombstoneRows.Close()
+	return feed, tx.Commit(context.Background())
+}
+
+// mergeStatements move everything that references the duplicate ($2) to the
+// canonical exercise ($1). Rows that would collide with an existing row of
+// the canonical exercise are left behind and removed with the duplicate.
+var mergeStatements = []string{
+	`UPDATE exercise_aliases SET exercise_id=$1 WHERE exercise_id=$2`,
+	`DELETE FROM exercise_relations WHERE (from_id=$1 AND to_id=$2) OR (from_id=$2 AND to_id=$1)`,
+	`UPDATE exercise_relations r SET from_id=$1 WHERE from_id=$2 AND NOT EXISTS (
+         SELECT 1 FROM exercise_relations o WHERE o.from_id=$1 AND o.type=r.type AND (o.to_id=r.to_id OR o.type='variation_of'))`,
+	`UPDATE exercise_relations r SET to_id=$1 WHERE to_id=$2 AND NOT EXISTS (
+         SELECT 1 FROM exercise_relations o WHERE o.to_id=$1 AND o.from_id=r.from_id AND o.type=r.type)`,
+	`UPDATE exercise_substitutions s SET substitute_id=$1 WHERE substitute_id=$2 AND exercise_id<>$1 AND NOT EXISTS (
+         SELECT 1 FROM exercise_substitutions o WHERE o.exercise_id=s.exercise_id AND o.substitute_id=$1)`,
+	`UPDATE exercise_translations t SET exercise_id=$1 WHERE exercise_id=$2 AND NOT EXISTS (
+         SELECT 1 FROM exercise_translations o WHERE o.exercise_id=$1 AND o.locale=t.locale)`,
+	`UPDATE exercise_media SET exercise_id=$1 WHERE exercise_id=$2`,
+	`UPDATE workout_template_exercises SET exercise_id=$1 WHERE exercise_id=$2`,
+	`UPDATE training_maxes t SET exercise_id=$1 WHERE exercise_id=$2 AND NOT EXISTS (
+         SELECT 1 FROM training_maxes o WHERE o.exercise_id=$1 AND o.user_id=t.user_id)`,
+	`UPDATE goals SET exercise_id=$1 WHERE exercise_id=$2`,
+	// Exercise ids are UUIDs, so replacing them in the entries' text cannot
+	// touch anything else.
+	`UPDATE live_workouts SET entries=REPLACE(entries::text, $2::text, $1::text)::jsonb, revision=revision+1
+         WHERE entries::text LIKE '%' || $2::text || '%'`,
+}
+
+func (r *exerciseRepository) Merge(merge *domain.ExerciseMerge) error {
+	return r.withChangeLock(func(tx pgx.Tx) error {
+		if err := insertExerciseRevision(tx, merge.Revision); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(context.Background(),
+			`UPDATE workout_entries SET exercise_id=$1 WHERE exercise_id=$2`, merge.CanonicalID, merge.DuplicateID)
+		if err != nil {
+			return err
+		}
+		merge.MovedEntries = tag.RowsAffected()
+		for _, stmt := range mergeStatements {
+			if _, err := tx.Exec(context.Background(), stmt, merge.CanonicalID, merge.DuplicateID); err != nil {
+				return err
+			}
+		}
+		if merge.Alias != "" {
+			if _, err := tx.Exec(context.Background(),
+				`INSERT INTO exercise_aliases (exercise_id, alias, normalized, created_at) VALUES ($1, $2, $3, NOW())
+                 ON CONFLICT (normalized) DO NOTHING`,
+				merge.CanonicalID, merge.Alias, merge.NormalizedAlias,
+			); err != nil {
+				return err
+			}
+		}
+		if tag, err := tx.Exec(context.Background(), `DELETE FROM exercises WHERE id=$1`, merge.DuplicateID); err != nil {
+			return err
+		} else if tag.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+		if _, err := tx.Exec(context.Background(),
+			`INSERT INTO exercise_tombstones (exercise_id, reason, removed_at, replaced_by, change_seq)
+             VALUES ($1, $2, NOW(), $3, nextval('exercise_change_seq'))
+             ON CONFLICT (exercise_id) DO UPDATE SET reason=EXCLUDED.reason, removed_at=EXCLUDED.removed_at,
+                 replaced_by=EXCLUDED.replaced_by, change_seq=EXCLUDED.change_seq`,
+			merge.DuplicateID, string(domain.TombstoneMerged), merge.CanonicalID,
+		); err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE exercises SET updated_at=NOW(), change_seq=nextval('exercise_change_seq') WHERE id=$1`, merge.CanonicalID)
+		return err
+	})
+}
+
+func (r *exerciseRepository) withChangeLock(fn func(tx pgx.Tx) error) error {
+	return withExerciseChangeLock(r.pool, fn)
+}
+
+func withExerciseChangeLock(pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(context.Background())
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(context.Background())
 
-	_, err = tx.Exec(context.Background(),
-		`INSERT INTO workout_sessions (id, user_id, started_at, completed_at, created_at)
-         VALUES ($1, $2, $3, $4, $5)`,
-		session.ID, session.UserID, session.StartedAt, session.CompletedAt, session.CreatedAt,
-	)
-	if err != nil {
+	if _, err := tx.Exec(context.Background(), `SELECT pg_advisory_xact_lock($1)`, exerciseChangeLock); err != nil {
 		return err
 	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
 
-	for i := range session.Entries {
-		entry := &session.Entries[i]
-		if entry.ID == "" {
-			entry.ID = uuid.NewString()
+func scanExercise(row pgx.Row) (*domain.Exercise, error) {
+	var ex domain.Exercise
+	var measurementType string
+	if err := row.Scan(&ex.ID, &ex.Name, &ex.Description, &ex.MuscleGroup, &ex.Equipment, &measurementType, &ex.Unilateral, &ex.PrimaryMuscles, &ex.MovementPattern, &ex.Aliases, &ex.ArchivedAt, &ex.CreatedAt, &ex.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
 		}
-		entry.SessionID = session.ID
-		entry.CreatedAt = time.Now().UTC()
-		_, err = tx.Exec(context.Background(),
-			`INSERT INTO workout_entries (id, session_id, exercise_id, sets, reps, weight, duration_seconds, notes, created_at)
-             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-			entry.ID, entry.SessionID, entry.ExerciseID, entry.Sets, entry.Reps, entry.Weight, entry.DurationSeconds, entry.Notes, entry.CreatedAt,
+		return nil, err
+	}
+	ex.MeasurementType = domain.MeasurementType(measurementType)
+	return &ex, nil
+}
+
+// primaryMuscles never writes NULL so the column keeps its '{}' default.
+func primaryMuscles(ex *domain.Exercise) []string {
+	if ex.PrimaryMuscles == nil {
+		return []string{}
+	}
+	return ex.PrimaryMuscles
+}
+
+// Exercise translation repository
+
+type exerciseTranslationRepository struct {
+	pool *pgxpool.Pool
+}
+
+const exerciseTranslationColumns = `exercise_id, locale, name, description, updated_at`
+
+func (r *exerciseTranslationRepository) Upsert(t *domain.ExerciseTranslation) error {
+	t.UpdatedAt = time.Now().UTC()
+	return withExerciseChangeLock(r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO exercise_translations (exercise_id, locale, name, description, updated_at)
+             VALUES ($1, $2, $3, $4, $5)
+             ON CONFLICT (exercise_id, locale) DO UPDATE SET name=EXCLUDED.name, description=EXCLUDED.description, updated_at=EXCLUDED.updated_at`,
+			t.ExerciseID, t.Locale, t.Name, t.Description, t.UpdatedAt,
 		)
 		if err != nil {
 			return err
 		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE exercises SET change_seq=nextval('exercise_change_seq') WHERE id=$1`, t.ExerciseID)
+		return err
+	})
+}
+
+func (r *exerciseTranslationRepository) Delete(exerciseID, locale string) error {
+	return withExerciseChangeLock(r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(),
+			`DELETE FROM exercise_translations WHERE exercise_id=$1 AND locale=$2`, exerciseID, locale)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE exercises SET change_seq=nextval('exercise_change_seq') WHERE id=$1`, exerciseID)
+		return err
+	})
+}
+
+func (r *exerciseTranslationRepository) ListByExercise(exerciseID string) ([]domain.ExerciseTranslation, error) {
+	return r.list(`SELECT `+exerciseTranslationColumns+` FROM exercise_translations WHERE exercise_id=$1 ORDER BY locale`, exerciseID)
+}
+
+func (r *exerciseTranslationRepository) ListByLocale(locale string) ([]domain.ExerciseTranslation, error) {
+	return r.list(`SELECT `+exerciseTranslationColumns+` FROM exercise_translations WHERE locale=$1 ORDER BY exercise_id`, locale)
+}
+
+func (r *exerciseTranslationRepository) list(query string, arg string) ([]domain.ExerciseTranslation, error) {
+	rows, err := r.pool.Query(context.Background(), query, arg)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return tx.Commit(context.Background())
+	translations := []domain.ExerciseTranslation{}
+	for rows.Next() {
+		var t domain.ExerciseTranslation
+		if err := rows.Scan(&t.ExerciseID, &t.Locale, &t.Name, &t.Description, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		translations = append(translations, t)
+	}
+	return translations, rows.Err()
 }
 
-func (r *workoutRepository) ListSessions(userID string) ([]domain.WorkoutSession, error) {
-	rows, err := r.pool.Query(context.Background(),
-		`SELECT id, user_id, started_at, completed_at, created_at FROM workout_sessions WHERE user_id=$1 ORDER BY started_at DESC LIMIT 50`,
-		userID,
+// Exercise relation repository
+
+type exerciseRelationRepository struct {
+	pool *pgxpool.Pool
+}
+
+const exerciseRelationColumns = `id, from_id, to_id, type, created_at`
+
+func (r *exerciseRelationRepository) Create(rel *domain.ExerciseRelation) error {
+	if rel.ID == "" {
+		rel.ID = uuid.NewString()
+	}
+	rel.CreatedAt = time.Now().UTC()
+	_, err := r.pool.Exec(context.Background(),
+		`INSERT INTO exercise_relations (id, from_id, to_id, type, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		rel.ID, rel.FromID, rel.ToID, string(rel.Type), rel.CreatedAt,
 	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+func (r *exerciseRelationRepository) Get(id string) (*domain.ExerciseRelation, error) {
+	row := r.pool.QueryRow(context.Background(),
+		`SELECT `+exerciseRelationColumns+` FROM exercise_relations WHERE id=$1`, id)
+	return scanExerciseRelation(row)
+}
+
+func (r *exerciseRelationRepository) Delete(id string) error {
+	tag, err := r.pool.Exec(context.Background(), `DELETE FROM exercise_relations WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *exerciseRelationRepository) List() ([]domain.ExerciseRelation, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+exerciseRelationColumns+` FROM exercise_relations ORDER BY created_at, id`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	sessions := []domain.WorkoutSession{}
+	relations := []domain.ExerciseRelation{}
 	for rows.Next() {
-		var s domain.WorkoutSession
-		if err := rows.Scan(&s.ID, &s.UserID, &s.StartedAt, &s.CompletedAt, &s.CreatedAt); err != nil {
+		rel, err := scanExerciseRelation(rows)
+		if err != nil {
 			return nil, err
 		}
+		relations = append(relations, *rel)
+	}
+	return relations, rows.Err()
+}
 
-		entryRows, err := r.pool.Query(context.Background(),
-			`SELECT id, session_id, exercise_id, sets, reps, weight, duration_seconds, notes, created_at FROM workout_entries WHERE session_id=$1`,
-			s.ID,
-		)
-		if err != nil {
+func scanExerciseRelation(row pgx.Row) (*domain.ExerciseRelation, error) {
+	var rel domain.ExerciseRelation
+	var relationType string
+	if err := row.Scan(&rel.ID, &rel.FromID, &rel.ToID, &relationType, &rel.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	rel.Type = domain.RelationType(relationType)
+	return &rel, nil
+}
+
+// Exercise substitution repository
+
+type exerciseSubstitutionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *exerciseSubstitutionRepository) ListPinned(exerciseID string) ([]string, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT substitute_id FROM exercise_substitutions WHERE exercise_id=$1 ORDER BY position`, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		entries := []domain.WorkoutEntry{}
-		for entryRows.Next() {
-			var e domain.WorkoutEntry
-			if err := entryRows.Scan(&e.ID, &e.SessionID, &e.ExerciseID, &e.Sets, &e.Reps, &e.Weight, &e.DurationSeconds, &e.Notes, &e.CreatedAt); err != nil {
-				entryRows.Close()
-				return nil, err
-			}
-			entries = append(entries, e)
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *exerciseSubstitutionRepository) SetPinned(exerciseID string, substituteIDs []string) error {
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(context.Background(), `DELETE FROM exercise_substitutions WHERE exercise_id=$1`, exerciseID); err != nil {
+		return err
+	}
+	for i, id := range substituteIDs {
+		if _, err := tx.Exec(context.Background(),
+			`INSERT INTO exercise_substitutions (exercise_id, substitute_id, position) VALUES ($1, $2, $3)`,
+			exerciseID, id, i,
+		); err != nil {
+			return err
 		}
-		entryRows.Close()
-		s.Entries = entries
-		sessions = append(sessions, s)
 	}
-	return sessions, nil
+	return tx.Commit(context.Background())
+}
+
+// Exercise seed repository
+
+type exerciseSeedRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *exerciseSeedRepository) Version() (int, error) {
+	var version int
+	err := r.pool.QueryRow(context.Background(), `SELECT version FROM exercise_catalogue`).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (r *exerciseSeedRepository) SetVersion(version int) error {
+	_, err := r.pool.Exec(context.Background(),
+		`INSERT INTO exercise_catalogue (id, version) VALUES (TRUE, $1)
+         ON CONFLICT (id) DO UPDATE SET version=EXCLUDED.version`, version)
+	return err
+}
+
+func (r *exerciseSeedRepository) Seeded() (map[string]string, error) {
+	rows, err := r.pool.Query(context.Background(), `SELECT key, exercise_id FROM exercise_seeds`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seeded := make(map[string]string)
+	for rows.Next() {
+		var key, exerciseID string
+		if err := rows.Scan(&key, &exerciseID); err != nil {
+			return nil, err
+		}
+		seeded[key] = exerciseID
+	}
+	return seeded, rows.Err()
+}
+
+func (r *exerciseSeedRepository) Record(key, exerciseID string) error {
+	_, err := r.pool.Exec(context.Background(),
+		`INSERT INTO exercise_seeds (key, exercise_id, seeded_at) VALUES ($1, $2, NOW())
+         ON CONFLICT (key) DO NOTHING`, key, exerciseID)
+	return err
+}
+
+// Exercise revision repository
+
+type exerciseRevisionRepository struct {
+	pool *pgxpool.Pool
+}
+
+const exerciseRevisionColumns = `id, exercise_id, revision, action, COALESCE(author_id::text, ''), COALESCE(reverted_from, 0), changes, snapshot, created_at`
+
+func (r *exerciseRevisionRepository) Create(rev *domain.ExerciseRevision) error {
+	return insertExerciseRevision(r.pool, rev)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// insertExerciseRevision writes rev with db, which may be the pool or a
+// transaction that records the revision together with the change itself.
+func insertExerciseRevision(db execer, rev *domain.ExerciseRevision) error {
+	if rev.ID == "" {
+		rev.ID = uuid.NewString()
+	}
+	rev.CreatedAt = time.Now().UTC()
+	changes, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(rev.Snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(context.Background(),
+		`INSERT INTO exercise_revisions (id, exercise_id, revision, action, author_id, reverted_from, changes, snapshot, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		rev.ID, rev.ExerciseID, rev.Number, string(rev.Action), nullableString(rev.AuthorID), nullableInt(rev.RevertedFrom), changes, snapshot, rev.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+func (r *exerciseRevisionRepository) ListByExercise(exerciseID string) ([]domain.ExerciseRevision, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+exerciseRevisionColumns+` FROM exercise_revisions WHERE exercise_id=$1 ORDER BY revision DESC`, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []domain.ExerciseRevision{}
+	for rows.Next() {
+		rev, err := scanExerciseRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *exerciseRevisionRepository) Get(exerciseID string, number int) (*domain.ExerciseRevision, error) {
+	row := r.pool.QueryRow(context.Background(),
+		`SELECT `+exerciseRevisionColumns+` FROM exercise_revisions WHERE exercise_id=$1 AND revision=$2`, exerciseID, number)
+	return scanExerciseRevision(row)
+}
+
+func (r *exerciseRevisionRepository) Latest(exerciseID string) (*domain.ExerciseRevision, error) {
+	row := r.pool.QueryRow(context.Background(),
+		`SELECT `+exerciseRevisionColumns+` FROM exercise_revisions WHERE exercise_id=$1 ORDER BY revision DESC LIMIT 1`, exerciseID)
+	return scanExerciseRevision(row)
+}
+
+func scanExerciseRevision(row pgx.Row) (*domain.ExerciseRevision, error) {
+	var rev domain.ExerciseRevision
+	var action string
+	var changes, snapshot []byte
+	if err := row.Scan(&rev.ID, &rev.ExerciseID, &rev.Number, &action, &rev.AuthorID, &rev.RevertedFrom, &changes, &snapshot, &rev.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	rev.Action = domain.RevisionAction(action)
+	if err := json.Unmarshal(changes, &rev.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &rev.Snapshot); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// Exercise media repository
+
+type exerciseMediaRepository struct {
+	pool *pgxpool.Pool
+}
+
+const exerciseMediaColumns = `id, exercise_id, kind, content_type, size_bytes, blob_key, COALESCE(thumbnail_key, ''), created_at`
+
+// Create and Delete bump the exercise's change_seq so offline clients pick
+// up new media URLs through the change feed.
+func (r *exerciseMediaRepository) Create(item *domain.ExerciseMedia) error {
+	if item.ID == "" {
+		item.ID = uuid.NewString()
+	}
+	item.CreatedAt = time.Now().UTC()
+	return withExerciseChangeLock(r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO exercise_media (id, exercise_id, kind, content_type, size_bytes, blob_key, thumbnail_key, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			item.ID, item.ExerciseID, item.Kind, item.ContentType, item.SizeBytes, item.Key, nullableString(item.ThumbnailKey), item.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE exercises SET updated_at=NOW(), change_seq=nextval('exercise_change_seq') WHERE id=$1`, item.ExerciseID)
+		return err
+	})
+}
+
+func (r *exerciseMediaRepository) Get(id string) (*domain.ExerciseMedia, error) {
+	row := r.pool.QueryRow(context.Background(),
+		`SELECT `+exerciseMediaColumns+` FROM exercise_media WHERE id=$1`, id)
+	return scanExerciseMedia(row)
+}
+
+func (r *exerciseMediaRepository) ListByExercise(exerciseID string) ([]domain.ExerciseMedia, error) {
+	return r.list(`SELECT `+exerciseMediaColumns+` FROM exercise_media WHERE exercise_id=$1 ORDER BY created_at, id`, exerciseID)
+}
+
+func (r *exerciseMediaRepository) ListAll() ([]domain.ExerciseMedia, error) {
+	return r.list(`SELECT ` + exerciseMediaColumns + ` FROM exercise_media ORDER BY created_at, id`)
+}
+
+func (r *exerciseMediaRepository) Delete(id string) error {
+	return withExerciseChangeLock(r.pool, func(tx pgx.Tx) error {
+		var exerciseID string
+		err := tx.QueryRow(context.Background(), `DELETE FROM exercise_media WHERE id=$1 RETURNING exercise_id`, id).Scan(&exerciseID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE exercises SET updated_at=NOW(), change_seq=nextval('exercise_change_seq') WHERE id=$1`, exerciseID)
+		return err
+	})
+}
+
+func (r *exerciseMediaRepository) list(query string, args ...interface{}) ([]domain.ExerciseMedia, error) {
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.ExerciseMedia{}
+	for rows.Next() {
+		item, err := scanExerciseMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func scanExerciseMedia(row pgx.Row) (*domain.ExerciseMedia, error) {
+	var item domain.ExerciseMedia
+	if err := row.Scan(&item.ID, &item.ExerciseID, &item.Kind, &item.ContentType, &item.SizeBytes, &item.Key, &item.ThumbnailKey, &item.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Workout repository
+
+type workoutRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *workoutRepository) CreateSession(session *domain.WorkoutSession) error {
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := insertSession(tx, session); err != nil {
+		if isPlannedSessionTaken(err) {
+			return repository.ErrPlannedSessionTaken
+		}
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+func (r *workoutRepository) CreateSessionOnce(session *domain.WorkoutSession, submission *domain.WorkoutSubmission) error {
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := insertSession(tx, session); err != nil {
+		if isPlannedSessionTaken(err) {
+			return repository.ErrPlannedSessionTaken
+		}
+		if isUniqueViolation(err) {
+			return repository.ErrConflict
+		}
+		return err
+	}
+	submission.SessionID = session.ID
+	submission.CreatedAt = session.CreatedAt
+	if submission.Response, err = json.Marshal(session); err != nil {
+		return err
+	}
+	// A concurrent request with the same key blocks here until the first
+	// one commits and then fails, rolling back its session.
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO workout_submissions (user_id, key, request_hash, session_id, response, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6)`,
+		submission.UserID, submission.Key, submission.RequestHash, submission.SessionID, submission.Response, submission.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+func (r *workoutRepository) GetSubmission(userID, key string) (*domain.WorkoutSubmission, error) {
+	var submission domain.WorkoutSubmission
+	err := r.pool.QueryRow(context.Background(),
+		`SELECT user_id, key, request_hash, session_id, response, created_at FROM workout_submissions WHERE user_id=$1 AND key=$2`,
+		userID, key,
+	).Scan(&submission.UserID, &submission.Key, &submission.RequestHash, &submission.SessionID, &submission.Response, &submission.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// insertSession writes a session with its entries and sets inside tx,
+// assigning ids and timestamps.
+func insertSession(tx pgx.Tx, session *domain.WorkoutSession) error {
+	if session.ID == "" {
+		session.ID = uuid.NewString()
+	}
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now().UTC()
+	}
+	if session.CompletedAt.IsZero() {
+		session.CompletedAt = session.StartedAt
+	}
+	session.CreatedAt = time.Now().UTC()
+	session.UpdatedAt = session.CreatedAt
+
+	_, err := tx.Exec(context.Background(),
+		`INSERT INTO workout_sessions (id, user_id, started_at, completed_at, created_at, updated_at, planned_session_id)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		session.ID, session.UserID, session.StartedAt, session.CompletedAt, session.CreatedAt, session.UpdatedAt,
+		nullableString(session.PlannedSessionID),
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range session.Entries {
+		if err := insertEntry(tx, session.ID, i, &session.Entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertEntry adds an entry with its sets to a stored session at position.
+func insertEntry(tx pgx.Tx, sessionID string, position int, entry *domain.WorkoutEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	entry.SessionID = sessionID
+	entry.CreatedAt = time.Now().UTC()
+	entry.UpdatedAt = entry.CreatedAt
+	_, err := tx.Exec(context.Background(),
+		`INSERT INTO workout_entries (id, session_id, position, exercise_id, sets, reps, weight, duration_seconds, distance_meters, side, notes, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		entry.ID, entry.SessionID, position, entry.ExerciseID, entry.Sets, entry.Reps, entry.Weight, entry.DurationSeconds, entry.DistanceMeters, string(entry.Side), entry.Notes, entry.CreatedAt, entry.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return insertSets(tx, entry)
+}
+
+func insertSets(tx pgx.Tx, entry *domain.WorkoutEntry) error {
+	for position, set := range entry.SetDetails {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO workout_sets (entry_id, position, set_type, reps, weight, duration_seconds, distance_meters, rpe, rir, tempo, completed, completed_at, rest_seconds)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			entry.ID, position, string(set.Type), set.Reps, set.Weight, set.DurationSeconds, set.DistanceMeters, set.RPE, set.RIR, set.Tempo, set.Completed,
+			set.CompletedAt, set.RestSeconds,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const sessionColumns = `id, user_id, started_at, completed_at, created_at, updated_at, deleted_at, planned_session_id`
+
+func scanSession(row pgx.Row) (domain.WorkoutSession, error) {
+	var s domain.WorkoutSession
+	var plannedSessionID *string
+	err := row.Scan(&s.ID, &s.UserID, &s.StartedAt, &s.CompletedAt, &s.CreatedAt, &s.UpdatedAt, &s.DeletedAt, &plannedSessionID)
+	if plannedSessionID != nil {
+		s.PlannedSessionID = *plannedSessionID
+	}
+	return s, err
+}
+
+func (r *workoutRepository) ListSessions(filter repository.SessionFilter, after *repository.SessionKey, limit int) ([]domain.WorkoutSession, error) {
+	where, args := sessionFilterClause(filter)
+	if after != nil {
+		args = append(args, after.StartedAt, after.ID)
+		where += fmt.Sprintf(` AND (s.started_at, s.id) < ($%d, $%d::uuid)`, len(args)-1, len(args))
+	}
+	query := `SELECT s.id, s.user_id, s.started_at, s.completed_at, s.created_at, s.updated_at, s.deleted_at, s.planned_session_id
+         FROM workout_sessions s WHERE ` + where + ` ORDER BY s.started_at DESC, s.id DESC`
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	return r.querySessions(query, args...)
+}
+
+func (r *workoutRepository) CountSessions(filter repository.SessionFilter) (int, error) {
+	where, args := sessionFilterClause(filter)
+	var count int
+	err := r.pool.QueryRow(context.Background(), `SELECT COUNT(*) FROM workout_sessions s WHERE `+where, args...).Scan(&count)
+	return count, err
+}
+
+func (r *workoutRepository) LogsExercise(exerciseID string) (bool, error) {
+	var logged bool
+	err := r.pool.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM workout_entries WHERE exercise_id=$1)`, exerciseID).Scan(&logged)
+	return logged, err
+}
+
+// sessionFilterClause builds the WHERE clause for filter over
+// workout_sessions aliased as s.
+func sessionFilterClause(filter repository.SessionFilter) (string, []interface{}) {
+	args := []interface{}{filter.UserID}
+	where := `s.user_id = $1 AND s.deleted_at IS NULL`
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		where += fmt.Sprintf(` AND s.started_at >= $%d`, len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		where += fmt.Sprintf(` AND s.started_at < $%d`, len(args))
+	}
+	var entryConds []string
+	if filter.ExerciseID != "" {
+		args = append(args, filter.ExerciseID)
+		entryConds = append(entryConds, fmt.Sprintf(`e.exercise_id = $%d`, len(args)))
+	}
+	if filter.MuscleGroup != "" {
+		args = append(args, filter.MuscleGroup)
+		entryConds = append(entryConds, fmt.Sprintf(`lower(x.muscle_group) = lower($%d)`, len(args)))
+	}
+	if filter.Notes != "" {
+		args = append(args, "%"+escapeLike(filter.Notes)+"%")
+		entryConds = append(entryConds, fmt.Sprintf(`e.notes ILIKE $%d`, len(args)))
+	}
+	if len(entryConds) > 0 {
+		where += ` AND EXISTS (SELECT 1 FROM workout_entries e JOIN exercises x ON x.id = e.exercise_id
+             WHERE e.session_id = s.id AND ` + strings.Join(entryConds, " AND ") + `)`
+	}
+	return where, args
+}
+
+// escapeLike makes value match literally inside a LIKE pattern.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func (r *workoutRepository) ListTrash(userID string) ([]domain.WorkoutSession, error) {
+	return r.querySessions(
+		`SELECT `+sessionColumns+` FROM workout_sessions WHERE user_id=$1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`,
+		userID,
+	)
+}
+
+// querySessions runs a session query and attaches entries and sets with one
+// further query each, after the session rows have been released.
+func (r *workoutRepository) querySessions(query string, args ...interface{}) ([]domain.WorkoutSession, error) {
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []domain.WorkoutSession{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadEntries(sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *workoutRepository) GetSession(id string) (*domain.WorkoutSession, error) {
+	s, err := scanSession(r.pool.QueryRow(context.Background(),
+		`SELECT `+sessionColumns+` FROM workout_sessions WHERE id=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sessions := []domain.WorkoutSession{s}
+	if err := r.loadEntries(sessions); err != nil {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// loadEntries attaches the ordered entries, with their sets, to sessions.
+func (r *workoutRepository) loadEntries(sessions []domain.WorkoutSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(sessions))
+	ids := make([]string, 0, len(sessions))
+	for i := range sessions {
+		index[sessions[i].ID] = i
+		ids = append(ids, sessions[i].ID)
+	}
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT id, session_id, exercise_id, sets, reps, weight, duration_seconds, distance_meters, side, notes, created_at, updated_at
+         FROM workout_entries WHERE session_id = ANY($1) ORDER BY session_id, position`,
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+	entries := []domain.WorkoutEntry{}
+	for rows.Next() {
+		var e domain.WorkoutEntry
+		var side string
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.ExerciseID, &e.Sets, &e.Reps, &e.Weight, &e.DurationSeconds, &e.DistanceMeters, &side, &e.Notes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		e.Side = domain.Side(side)
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := r.loadSets(entries); err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		sessions[i].Entries = []domain.WorkoutEntry{}
+	}
+	for _, entry := range entries {
+		session := &sessions[index[entry.SessionID]]
+		session.Entries = append(session.Entries, entry)
+	}
+	return nil
+}
+
+func (r *workoutRepository) UpdateSession(session *domain.WorkoutSession) error {
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE workout_sessions SET started_at=$2, completed_at=$3, updated_at=$4 WHERE id=$1`,
+		session.ID, session.StartedAt, session.CompletedAt, session.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	kept := make([]string, 0, len(session.Entries))
+	for _, entry := range session.Entries {
+		if entry.ID != "" {
+			kept = append(kept, entry.ID)
+		}
+	}
+	if _, err := tx.Exec(context.Background(),
+		`DELETE FROM workout_entries WHERE session_id=$1 AND NOT (id = ANY($2))`, session.ID, kept); err != nil {
+		return err
+	}
+
+	for i := range session.Entries {
+		entry := &session.Entries[i]
+		if entry.ID == "" {
+			if err := insertEntry(tx, session.ID, i, entry); err != nil {
+				return err
+			}
+			continue
+		}
+		_, err := tx.Exec(context.Background(),
+			`UPDATE workout_entries SET position=$3, exercise_id=$4, sets=$5, reps=$6, weight=$7, duration_seconds=$8, distance_meters=$9, side=$10, notes=$11, updated_at=$12
+             WHERE id=$1 AND session_id=$2`,
+			entry.ID, session.ID, i, entry.ExerciseID, entry.Sets, entry.Reps, entry.Weight, entry.DurationSeconds, entry.DistanceMeters, string(entry.Side), entry.Notes, entry.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(context.Background(), `DELETE FROM workout_sets WHERE entry_id=$1`, entry.ID); err != nil {
+			return err
+		}
+		if err := insertSets(tx, entry); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(context.Background())
+}
+
+func (r *workoutRepository) TrashSession(id string, at time.Time) error {
+	return r.setDeleted(id, &at, at)
+}
+
+func (r *workoutRepository) RestoreSession(id string, at time.Time) error {
+	return r.setDeleted(id, nil, at)
+}
+
+func (r *workoutRepository) setDeleted(id string, deletedAt *time.Time, at time.Time) error {
+	tag, err := r.pool.Exec(context.Background(),
+		`UPDATE workout_sessions SET deleted_at=$2, updated_at=$3 WHERE id=$1`, id, deletedAt, at)
+	if isPlannedSessionTaken(err) {
+		return repository.ErrPlannedSessionTaken
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *workoutRepository) PurgeTrash(cutoff time.Time) (int, error) {
+	tag, err := r.pool.Exec(context.Background(),
+		`DELETE FROM workout_sessions WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// loadSets attaches the ordered set details to entries.
+func (r *workoutRepository) loadSets(entries []domain.WorkoutEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(entries))
+	ids := make([]string, 0, len(entries))
+	for i := range entries {
+		entries[i].SetDetails = []domain.WorkoutSet{}
+		index[entries[i].ID] = i
+		ids = append(ids, entries[i].ID)
+	}
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT entry_id, set_type, reps, weight, duration_seconds, distance_meters, rpe, rir, tempo, completed, completed_at, rest_seconds
+         FROM workout_sets WHERE entry_id = ANY($1) ORDER BY entry_id, position`,
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID, setType string
+		var set domain.WorkoutSet
+		if err := rows.Scan(&entryID, &setType, &set.Reps, &set.Weight, &set.DurationSeconds, &set.DistanceMeters, &set.RPE, &set.RIR, &set.Tempo, &set.Completed,
+			&set.CompletedAt, &set.RestSeconds); err != nil {
+			return err
+		}
+		set.Type = domain.SetType(setType)
+		entry := &entries[index[entryID]]
+		entry.SetDetails = append(entry.SetDetails, set)
+	}
+	return rows.Err()
+}
+
+// Template repository
+
+type templateRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *templateRepository) Create(template *domain.WorkoutTemplate) error {
+	if template.ID == "" {
+		template.ID = uuid.NewString()
+	}
+	template.CreatedAt = time.Now().UTC()
+	template.UpdatedAt = template.CreatedAt
+
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO workout_templates (id, user_id, name, notes, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		template.ID, template.UserID, template.Name, template.Notes, template.CreatedAt, template.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if err := insertTemplateExercises(tx, template); err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+func (r *templateRepository) Update(template *domain.WorkoutTemplate) error {
+	template.UpdatedAt = time.Now().UTC()
+
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE workout_templates SET name=$2, notes=$3, updated_at=$4 WHERE id=$1`,
+		template.ID, template.Name, template.Notes, template.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	if _, err := tx.Exec(context.Background(), `DELETE FROM workout_template_exercises WHERE template_id=$1`, template.ID); err != nil {
+		return err
+	}
+	if err := insertTemplateExercises(tx, template); err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+func insertTemplateExercises(tx pgx.Tx, template *domain.WorkoutTemplate) error {
+	for position, ex := range template.Exercises {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO workout_template_exercises (template_id, position, exercise_id, target_sets, target_reps, target_weight,
+                 target_percent, target_duration_seconds, target_distance_meters, rest_seconds, notes)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			template.ID, position, ex.ExerciseID, ex.TargetSets, ex.TargetReps, ex.TargetWeight,
+			ex.TargetPercent, ex.TargetDurationSeconds, ex.TargetDistanceMeters, ex.RestSeconds, ex.Notes,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *templateRepository) Get(id string) (*domain.WorkoutTemplate, error) {
+	templates, err := r.query(`WHERE id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &templates[0], nil
+}
+
+func (r *templateRepository) List(userID string) ([]domain.WorkoutTemplate, error) {
+	return r.query(`WHERE user_id=$1 ORDER BY lower(name)`, userID)
+}
+
+// query loads the templates selected by clause together with their
+// exercises.
+func (r *templateRepository) query(clause string, args ...interface{}) ([]domain.WorkoutTemplate, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT id, user_id, name, notes, created_at, updated_at FROM workout_templates `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	templates := []domain.WorkoutTemplate{}
+	index := map[string]int{}
+	ids := []string{}
+	for rows.Next() {
+		var t domain.WorkoutTemplate
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Notes, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t.Exercises = []domain.TemplateExercise{}
+		index[t.ID] = len(templates)
+		ids = append(ids, t.ID)
+		templates = append(templates, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(templates) == 0 {
+		return templates, err
+	}
+
+	rows, err = r.pool.Query(context.Background(),
+		`SELECT template_id, exercise_id, target_sets, target_reps, target_weight, target_percent, target_duration_seconds,
+             target_distance_meters, rest_seconds, notes
+         FROM workout_template_exercises WHERE template_id = ANY($1) ORDER BY template_id, position`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var templateID string
+		var ex domain.TemplateExercise
+		if err := rows.Scan(&templateID, &ex.ExerciseID, &ex.TargetSets, &ex.TargetReps, &ex.TargetWeight, &ex.TargetPercent,
+			&ex.TargetDurationSeconds, &ex.TargetDistanceMeters, &ex.RestSeconds, &ex.Notes); err != nil {
+			return nil, err
+		}
+		t := &templates[index[templateID]]
+		t.Exercises = append(t.Exercises, ex)
+	}
+	return templates, rows.Err()
+}
+
+func (r *templateRepository) Delete(id string) error {
+	tag, err := r.pool.Exec(context.Background(), `DELETE FROM workout_templates WHERE id=$1`, id)
+	if isForeignKeyViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Training max repository
+
+type trainingMaxRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *trainingMaxRepository) List(userID string) ([]domain.TrainingMax, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT user_id, exercise_id, weight, updated_at FROM training_maxes WHERE user_id=$1 ORDER BY exercise_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	maxes := []domain.TrainingMax{}
+	for rows.Next() {
+		var m domain.TrainingMax
+		if err := rows.Scan(&m.UserID, &m.ExerciseID, &m.Weight, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		maxes = append(maxes, m)
+	}
+	return maxes, rows.Err()
+}
+
+func (r *trainingMaxRepository) Set(tm *domain.TrainingMax) error {
+	tm.UpdatedAt = time.Now().UTC()
+	_, err := r.pool.Exec(context.Background(),
+		`INSERT INTO training_maxes (user_id, exercise_id, weight, updated_at) VALUES ($1, $2, $3, $4)
+         ON CONFLICT (user_id, exercise_id) DO UPDATE SET weight=EXCLUDED.weight, updated_at=EXCLUDED.updated_at`,
+		tm.UserID, tm.ExerciseID, tm.Weight, tm.UpdatedAt,
+	)
+	return err
+}
+
+func (r *trainingMaxRepository) Delete(userID, exerciseID string) error {
+	tag, err := r.pool.Exec(context.Background(),
+		`DELETE FROM training_maxes WHERE user_id=$1 AND exercise_id=$2`, userID, exerciseID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Record repository
+
+type recordRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *recordRepository) List(userID string) ([]domain.PersonalRecord, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT user_id, exercise_id, type, value, weight, reps, session_id, achieved_at
+         FROM personal_records WHERE user_id=$1 ORDER BY exercise_id, type, weight`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := []domain.PersonalRecord{}
+	for rows.Next() {
+		var pr domain.PersonalRecord
+		if err := rows.Scan(&pr.UserID, &pr.ExerciseID, &pr.Type, &pr.Value, &pr.Weight, &pr.Reps, &pr.SessionID, &pr.AchievedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, pr)
+	}
+	return records, rows.Err()
+}
+
+func (r *recordRepository) Update(userID, exerciseID string, compute func([]domain.PersonalRecord) ([]domain.PersonalRecord, error)) error {
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	// Held until commit, so a concurrent save waits and then computes from
+	// a history that includes this one's workout.
+	if _, err := tx.Exec(context.Background(),
+		`SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, userID, exerciseID); err != nil {
+		return err
+	}
+	rows, err := tx.Query(context.Background(),
+		`SELECT user_id, exercise_id, type, value, weight, reps, session_id, achieved_at
+         FROM personal_records WHERE user_id=$1 AND exercise_id=$2 ORDER BY type, weight`, userID, exerciseID)
+	if err != nil {
+		return err
+	}
+	held := []domain.PersonalRecord{}
+	for rows.Next() {
+		var pr domain.PersonalRecord
+		if err := rows.Scan(&pr.UserID, &pr.ExerciseID, &pr.Type, &pr.Value, &pr.Weight, &pr.Reps, &pr.SessionID, &pr.AchievedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		held = append(held, pr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	records, err := compute(held)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(context.Background(),
+		`DELETE FROM personal_records WHERE user_id=$1 AND exercise_id=$2`, userID, exerciseID); err != nil {
+		return err
+	}
+	for _, pr := range records {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO personal_records (user_id, exercise_id, type, value, weight, reps, session_id, achieved_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			userID, exerciseID, pr.Type, pr.Value, pr.Weight, pr.Reps, pr.SessionID, pr.AchievedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(context.Background())
+}
+
+func (r *recordRepository) Holders(exerciseID string) ([]string, error) {
+	return r.userIDs(`SELECT DISTINCT user_id FROM personal_records WHERE exercise_id=$1 ORDER BY user_id`, exerciseID)
+}
+
+func (r *recordRepository) Unscored() ([]string, error) {
+	return r.userIDs(`SELECT DISTINCT s.user_id FROM workout_sessions s
+         WHERE s.deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM personal_records pr WHERE pr.user_id=s.user_id)
+         AND NOT EXISTS (SELECT 1 FROM record_backfills b WHERE b.user_id=s.user_id)
+         ORDER BY s.user_id`)
+}
+
+func (r *recordRepository) MarkScored(userID string) error {
+	_, err := r.pool.Exec(context.Background(),
+		`INSERT INTO record_backfills (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`, userID)
+	return err
+}
+
+func (r *recordRepository) userIDs(query string, args ...any) ([]string, error) {
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Analytics repository
+
+type analyticsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// workingSets joins the working sets of the sessions a series covers. Its
+// parameters are the user ($1), the range ($2, $3) and the bucket ($4).
+const workingSets = `FROM workout_sessions s
+         JOIN workout_entries e ON e.session_id = s.id
+         JOIN exercises x ON x.id = e.exercise_id
+         JOIN workout_sets ws ON ws.entry_id = e.id
+         WHERE s.user_id = $1 AND s.deleted_at IS NULL AND s.started_at >= $2 AND s.started_at < $3
+             AND ws.completed AND ws.set_type <> 'warmup'`
+
+const bucketStart = `date_trunc($4::text, s.started_at AT TIME ZONE 'UTC')`
+
+func seriesArgs(query repository.SeriesQuery) []interface{} {
+	return []interface{}{query.UserID, query.From, query.To, string(query.Bucket)}
+}
+
+func (r *analyticsRepository) ExerciseVolume(query repository.VolumeQuery) ([]domain.ExerciseVolume, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT e.exercise_id, COUNT(*), COALESCE(SUM(ws.reps), 0),
+             COALESCE(SUM(CASE WHEN x.measurement_type = 'weight_reps' THEN ws.reps * ws.weight ELSE 0 END), 0)
+         FROM workout_sessions s
+         JOIN workout_entries e ON e.session_id = s.id
+         JOIN exercises x ON x.id = e.exercise_id
+         JOIN workout_sets ws ON ws.entry_id = e.id
+         WHERE s.user_id = $1 AND s.deleted_at IS NULL
+             AND ($2::timestamptz IS NULL OR s.started_at >= $2) AND ($3::timestamptz IS NULL OR s.started_at < $3)
+             AND ws.completed AND ws.set_type <> 'warmup'
+         GROUP BY e.exercise_id`,
+		query.UserID, query.From, query.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	totals := []domain.ExerciseVolume{}
+	for rows.Next() {
+		var total domain.ExerciseVolume
+		if err := rows.Scan(&total.ExerciseID, &total.Sets, &total.Reps, &total.Volume); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+func (r *analyticsRepository) Workload(query repository.SeriesQuery) ([]domain.WorkloadPoint, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+bucketStart+` AS bucket, COUNT(*), COALESCE(SUM(ws.reps), 0),
+             COALESCE(SUM(CASE WHEN x.measurement_type = 'assisted' THEN 0 ELSE ws.reps * ws.weight END), 0)
+         `+workingSets+`
+         GROUP BY bucket ORDER BY bucket`,
+		seriesArgs(query)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	points := []domain.WorkloadPoint{}
+	for rows.Next() {
+		var p domain.WorkloadPoint
+		if err := rows.Scan(&p.Start, &p.Sets, &p.Reps, &p.Tonnage); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (r *analyticsRepository) MuscleGroupSets(query repository.SeriesQuery) ([]domain.MuscleGroupPoint, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+bucketStart+` AS bucket, lower(COALESCE(x.muscle_group, '')) AS muscle_group, COUNT(*)
+         `+workingSets+`
+         GROUP BY bucket, muscle_group ORDER BY bucket, muscle_group`,
+		seriesArgs(query)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	points := []domain.MuscleGroupPoint{}
+	for rows.Next() {
+		var p domain.MuscleGroupPoint
+		if err := rows.Scan(&p.Start, &p.MuscleGroup, &p.Sets); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (r *analyticsRepository) OneRepMax(query repository.SeriesQuery) ([]domain.OneRepMaxPoint, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT DISTINCT ON (bucket) bucket, estimate, weight, reps FROM (
+             SELECT `+bucketStart+` AS bucket, ws.weight, ws.reps,
+                 CASE WHEN ws.reps = 1 THEN ws.weight
+                      WHEN ws.reps <= 10 THEN ws.weight * 36 / (37 - ws.reps)
+                      ELSE ws.weight * (1 + ws.reps::double precision / 30) END AS estimate
+             `+workingSets+` AND e.exercise_id = $5 AND x.measurement_type = 'weight_reps' AND ws.reps > 0 AND ws.weight > 0
+         ) estimates
+         ORDER BY bucket, estimate DESC, weight DESC`,
+		append(seriesArgs(query), query.ExerciseID)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	points := []domain.OneRepMaxPoint{}
+	for rows.Next() {
+		var p domain.OneRepMaxPoint
+		if err := rows.Scan(&p.Start, &p.EstimatedOneRepMax, &p.Weight, &p.Reps); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (r *analyticsRepository) Frequency(query repository.SeriesQuery) ([]domain.FrequencyPoint, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+bucketStart+` AS bucket, COUNT(*), COUNT(DISTINCT (s.started_at AT TIME ZONE 'UTC')::date)
+         FROM workout_sessions s
+         WHERE s.user_id = $1 AND s.deleted_at IS NULL AND s.started_at >= $2 AND s.started_at < $3
+         GROUP BY bucket ORDER BY bucket`,
+		seriesArgs(query)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	points := []domain.FrequencyPoint{}
+	for rows.Next() {
+		var p domain.FrequencyPoint
+		if err := rows.Scan(&p.Start, &p.Sessions, &p.Days); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// Goal repository
+
+type goalRepository struct {
+	pool *pgxpool.Pool
+}
+
+const goalColumns = `id, user_id, type, COALESCE(exercise_id::text, ''), target, reps, deadline, created_at, updated_at, achieved_at`
+
+func scanGoal(row pgx.Row) (*domain.Goal, error) {
+	var g domain.Goal
+	if err := row.Scan(&g.ID, &g.UserID, &g.Type, &g.ExerciseID, &g.Target, &g.Reps, &g.Deadline,
+		&g.CreatedAt, &g.UpdatedAt, &g.AchievedAt); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *goalRepository) Create(goal *domain.Goal) error {
+	if goal.ID == "" {
+		goal.ID = uuid.NewString()
+	}
+	goal.CreatedAt = time.Now().UTC()
+	goal.UpdatedAt = goal.CreatedAt
+	_, err := r.pool.Exec(context.Background(),
+		`INSERT INTO goals (id, user_id, type, exercise_id, target, reps, deadline, created_at, updated_at, achieved_at)
+         VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10)`,
+		goal.ID, goal.UserID, goal.Type, goal.ExerciseID, goal.Target, goal.Reps, goal.Deadline,
+		goal.CreatedAt, goal.UpdatedAt, goal.AchievedAt,
+	)
+	return err
+}
+
+func (r *goalRepository) Get(id string) (*domain.Goal, error) {
+	goal, err := scanGoal(r.pool.QueryRow(context.Background(), `SELECT `+goalColumns+` FROM goals WHERE id=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return goal, err
+}
+
+func (r *goalRepository) List(userID string) ([]domain.Goal, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+goalColumns+` FROM goals WHERE user_id=$1 ORDER BY deadline, created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	goals := []domain.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *goal)
+	}
+	return goals, rows.Err()
+}
+
+func (r *goalRepository) Update(goal *domain.Goal) error {
+	goal.UpdatedAt = time.Now().UTC()
+	tag, err := r.pool.Exec(context.Background(),
+		`UPDATE goals SET type=$2, exercise_id=NULLIF($3, '')::uuid, target=$4, reps=$5, deadline=$6, updated_at=$7, achieved_at=$8
+         WHERE id=$1`,
+		goal.ID, goal.Type, goal.ExerciseID, goal.Target, goal.Reps, goal.Deadline, goal.UpdatedAt, goal.AchievedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *goalRepository) Delete(id string) error {
+	tag, err := r.pool.Exec(context.Background(), `DELETE FROM goals WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *goalRepository) Achieve(id string, at time.Time) error {
+	_, err := r.pool.Exec(context.Background(),
+		`UPDATE goals SET achieved_at=$2 WHERE id=$1 AND achieved_at IS NULL`, id, at)
+	return err
+}
+
+// Body metric repository
+
+type bodyMetricRepository struct {
+	pool *pgxpool.Pool
+}
+
+const bodyMetricColumns = `id, user_id, measured_at, bodyweight, body_fat, circumferences, note, created_at, updated_at`
+
+func scanBodyMetric(row pgx.Row) (*domain.BodyMetric, error) {
+	var m domain.BodyMetric
+	var circumferences []byte
+	if err := row.Scan(&m.ID, &m.UserID, &m.MeasuredAt, &m.Bodyweight, &m.BodyFat, &circumferences, &m.Note,
+		&m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(circumferences, &m.Circumferences); err != nil {
+		return nil, err
+	}
+	if len(m.Circumferences) == 0 {
+		m.Circumferences = nil
+	}
+	m.Units = domain.MetricUnits
+	return &m, nil
+}
+
+func (r *bodyMetricRepository) Create(metric *domain.BodyMetric) error {
+	return insertBodyMetric(r.pool, metric)
+}
+
+func (r *bodyMetricRepository) CreateOnce(metric *domain.BodyMetric, submission *domain.BodyMetricSubmission) error {
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := insertBodyMetric(tx, metric); err != nil {
+		if isUniqueViolation(err) {
+			return repository.ErrConflict
+		}
+		return err
+	}
+	submission.MetricID = metric.ID
+	submission.CreatedAt = metric.CreatedAt
+	if submission.Response, err = json.Marshal(metric); err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO body_metric_submissions (user_id, key, request_hash, metric_id, response, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6)`,
+		submission.UserID, submission.Key, submission.RequestHash, submission.MetricID, submission.Response, submission.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+// insertBodyMetric writes metric with db, assigning its id and timestamps.
+func insertBodyMetric(db execer, metric *domain.BodyMetric) error {
+	if metric.ID == "" {
+		metric.ID = uuid.NewString()
+	}
+	circumferences, err := circumferencesJSON(metric)
+	if err != nil {
+		return err
+	}
+	metric.CreatedAt = time.Now().UTC()
+	metric.UpdatedAt = metric.CreatedAt
+	_, err = db.Exec(context.Background(),
+		`INSERT INTO body_metrics (id, user_id, measured_at, bodyweight, body_fat, circumferences, note, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		metric.ID, metric.UserID, metric.MeasuredAt, metric.Bodyweight, metric.BodyFat, circumferences, metric.Note,
+		metric.CreatedAt, metric.UpdatedAt,
+	)
+	return err
+}
+
+func circumferencesJSON(metric *domain.BodyMetric) ([]byte, error) {
+	if metric.Circumferences == nil {
+		return []byte(`{}`), nil
+	}
+	return json.Marshal(metric.Circumferences)
+}
+
+func (r *bodyMetricRepository) GetSubmission(userID, key string) (*domain.BodyMetricSubmission, error) {
+	var submission domain.BodyMetricSubmission
+	err := r.pool.QueryRow(context.Background(),
+		`SELECT user_id, key, request_hash, metric_id, response, created_at FROM body_metric_submissions WHERE user_id=$1 AND key=$2`,
+		userID, key,
+	).Scan(&submission.UserID, &submission.Key, &submission.RequestHash, &submission.MetricID, &submission.Response, &submission.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *bodyMetricRepository) Get(id string) (*domain.BodyMetric, error) {
+	metric, err := scanBodyMetric(r.pool.QueryRow(context.Background(),
+		`SELECT `+bodyMetricColumns+` FROM body_metrics WHERE id=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return metric, err
+}
+
+func (r *bodyMetricRepository) List(filter repository.BodyMetricFilter) ([]domain.BodyMetric, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+bodyMetricColumns+` FROM body_metrics
+         WHERE user_id=$1 AND ($2::timestamptz IS NULL OR measured_at >= $2) AND ($3::timestamptz IS NULL OR measured_at < $3)
+         ORDER BY measured_at DESC, id`,
+		filter.UserID, filter.From, filter.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	metrics := []domain.BodyMetric{}
+	for rows.Next() {
+		metric, err := scanBodyMetric(rows)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, *metric)
+	}
+	return metrics, rows.Err()
+}
+
+func (r *bodyMetricRepository) Update(metric *domain.BodyMetric) error {
+	circumferences, err := circumferencesJSON(metric)
+	if err != nil {
+		return err
+	}
+	metric.UpdatedAt = time.Now().UTC()
+	tag, err := r.pool.Exec(context.Background(),
+		`UPDATE body_metrics SET measured_at=$2, bodyweight=$3, body_fat=$4, circumferences=$5, note=$6, updated_at=$7
+         WHERE id=$1`,
+		metric.ID, metric.MeasuredAt, metric.Bodyweight, metric.BodyFat, circumferences, metric.Note, metric.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *bodyMetricRepository) Delete(id string) error {
+	tag, err := r.pool.Exec(context.Background(), `DELETE FROM body_metrics WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Live workout repository
+
+type liveWorkoutRepository struct {
+	pool *pgxpool.Pool
+}
+
+const liveWorkoutColumns = `id, user_id, started_at, last_activity_at, planned_session_id, entries, revision`
+
+func scanLiveWorkout(row pgx.Row) (*domain.LiveWorkout, error) {
+	var live domain.LiveWorkout
+	var plannedSessionID *string
+	var entries []byte
+	if err := row.Scan(&live.ID, &live.UserID, &live.StartedAt, &live.LastActivityAt, &plannedSessionID, &entries, &live.Revision); err != nil {
+		return nil, err
+	}
+	if plannedSessionID != nil {
+		live.PlannedSessionID = *plannedSessionID
+	}
+	if err := json.Unmarshal(entries, &live.Entries); err != nil {
+		return nil, err
+	}
+	return &live, nil
+}
+
+func liveEntriesJSON(live *domain.LiveWorkout) ([]byte, error) {
+	if live.Entries == nil {
+		return []byte(`[]`), nil
+	}
+	return json.Marshal(live.Entries)
+}
+
+func (r *liveWorkoutRepository) Create(live *domain.LiveWorkout) error {
+	if live.ID == "" {
+		live.ID = uuid.NewString()
+	}
+	entries, err := liveEntriesJSON(live)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(context.Background(),
+		`INSERT INTO live_workouts (id, user_id, started_at, last_activity_at, planned_session_id, entries, revision)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		live.ID, live.UserID, live.StartedAt, live.LastActivityAt, nullableString(live.PlannedSessionID), entries, live.Revision,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+func (r *liveWorkoutRepository) GetByUser(userID string) (*domain.LiveWorkout, error) {
+	live, err := scanLiveWorkout(r.pool.QueryRow(context.Background(),
+		`SELECT `+liveWorkoutColumns+` FROM live_workouts WHERE user_id=$1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return live, err
+}
+
+func (r *liveWorkoutRepository) Update(live *domain.LiveWorkout) error {
+	entries, err := liveEntriesJSON(live)
+	if err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(context.Background(),
+		`UPDATE live_workouts SET last_activity_at=$3, planned_session_id=$4, entries=$5, revision=revision+1
+         WHERE id=$1 AND revision=$2`,
+		live.ID, live.Revision, live.LastActivityAt, nullableString(live.PlannedSessionID), entries,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrConflict
+	}
+	live.Revision++
+	return nil
+}
+
+func (r *liveWorkoutRepository) Delete(id string, revision int) error {
+	tag, err := r.pool.Exec(context.Background(), `DELETE FROM live_workouts WHERE id=$1 AND revision=$2`, id, revision)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	if err := r.pool.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM live_workouts WHERE id=$1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return repository.ErrConflict
+	}
+	return repository.ErrNotFound
+}
+
+func (r *liveWorkoutRepository) Idle(before time.Time) ([]domain.LiveWorkout, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+liveWorkoutColumns+` FROM live_workouts WHERE last_activity_at < $1 ORDER BY last_activity_at`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	idle := []domain.LiveWorkout{}
+	for rows.Next() {
+		live, err := scanLiveWorkout(rows)
+		if err != nil {
+			return nil, err
+		}
+		idle = append(idle, *live)
+	}
+	return idle, rows.Err()
+}
+
+// Program repository
+
+type programRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *programRepository) Create(program *domain.Program) error {
+	if program.ID == "" {
+		program.ID = uuid.NewString()
+	}
+	program.CreatedAt = time.Now().UTC()
+	program.UpdatedAt = program.CreatedAt
+
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO programs (id, author_id, name, description, weeks, weight_increment, reps_increment, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		program.ID, program.AuthorID, program.Name, program.Description, program.Weeks,
+		program.Progression.WeightIncrement, program.Progression.RepsIncrement, program.CreatedAt, program.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := insertProgramDays(tx, program); err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+func (r *programRepository) Update(program *domain.Program) error {
+	program.UpdatedAt = time.Now().UTC()
+
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE programs SET name=$2, description=$3, weeks=$4, weight_increment=$5, reps_increment=$6, updated_at=$7 WHERE id=$1`,
+		program.ID, program.Name, program.Description, program.Weeks,
+		program.Progression.WeightIncrement, program.Progression.RepsIncrement, program.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	if _, err := tx.Exec(context.Background(), `DELETE FROM program_days WHERE program_id=$1`, program.ID); err != nil {
+		return err
+	}
+	if err := insertProgramDays(tx, program); err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+func insertProgramDays(tx pgx.Tx, program *domain.Program) error {
+	for _, day := range program.Days {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO program_days (program_id, day, template_id) VALUES ($1, $2, $3)`,
+			program.ID, day.Day, day.TemplateID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *programRepository) Get(id string) (*domain.Program, error) {
+	programs, err := r.query(`WHERE id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(programs) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &programs[0], nil
+}
+
+func (r *programRepository) List() ([]domain.Program, error) {
+	return r.query(`ORDER BY lower(name), id`)
+}
+
+// query loads the programs selected by clause together with their days.
+func (r *programRepository) query(clause string, args ...interface{}) ([]domain.Program, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT id, author_id, name, description, weeks, weight_increment, reps_increment, created_at, updated_at
+         FROM programs `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	programs := []domain.Program{}
+	index := map[string]int{}
+	ids := []string{}
+	for rows.Next() {
+		var p domain.Program
+		if err := rows.Scan(&p.ID, &p.AuthorID, &p.Name, &p.Description, &p.Weeks,
+			&p.Progression.WeightIncrement, &p.Progression.RepsIncrement, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Days = []domain.ProgramDay{}
+		index[p.ID] = len(programs)
+		ids = append(ids, p.ID)
+		programs = append(programs, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(programs) == 0 {
+		return programs, err
+	}
+
+	rows, err = r.pool.Query(context.Background(),
+		`SELECT program_id, day, template_id FROM program_days WHERE program_id = ANY($1) ORDER BY program_id, day`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var programID string
+		var day domain.ProgramDay
+		if err := rows.Scan(&programID, &day.Day, &day.TemplateID); err != nil {
+			return nil, err
+		}
+		p := &programs[index[programID]]
+		p.Days = append(p.Days, day)
+	}
+	return programs, rows.Err()
+}
+
+func (r *programRepository) Delete(id string) error {
+	tag, err := r.pool.Exec(context.Background(), `DELETE FROM programs WHERE id=$1`, id)
+	if isForeignKeyViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *programRepository) Enroll(enrollment *domain.ProgramEnrollment, planned []domain.PlannedSession) error {
+	if enrollment.ID == "" {
+		enrollment.ID = uuid.NewString()
+	}
+	enrollment.CreatedAt = time.Now().UTC()
+
+	tx, err := r.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO program_enrollments (id, user_id, program_id, start_date, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		enrollment.ID, enrollment.UserID, enrollment.ProgramID, enrollment.StartDate, enrollment.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	for i := range planned {
+		p := &planned[i]
+		if p.ID == "" {
+			p.ID = uuid.NewString()
+		}
+		p.EnrollmentID = enrollment.ID
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO planned_sessions (id, enrollment_id, template_id, week, day, scheduled_on) VALUES ($1, $2, $3, $4, $5, $6)`,
+			p.ID, p.EnrollmentID, p.TemplateID, p.Week, p.Day, p.ScheduledOn,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(context.Background())
+}
+
+const enrollmentColumns = `id, user_id, program_id, start_date, created_at, ended_at`
+
+func scanEnrollment(row pgx.Row) (domain.ProgramEnrollment, error) {
+	var e domain.ProgramEnrollment
+	err := row.Scan(&e.ID, &e.UserID, &e.ProgramID, &e.StartDate, &e.CreatedAt, &e.EndedAt)
+	return e, err
+}
+
+func (r *programRepository) GetEnrollment(id string) (*domain.ProgramEnrollment, error) {
+	e, err := scanEnrollment(r.pool.QueryRow(context.Background(),
+		`SELECT `+enrollmentColumns+` FROM program_enrollments WHERE id=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *programRepository) ListEnrollments(userID string) ([]domain.ProgramEnrollment, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+enrollmentColumns+` FROM program_enrollments WHERE user_id=$1 ORDER BY created_at DESC, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	enrollments := []domain.ProgramEnrollment{}
+	for rows.Next() {
+		e, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+func (r *programRepository) EndEnrollment(id string, at time.Time) error {
+	tag, err := r.pool.Exec(context.Background(),
+		`UPDATE program_enrollments SET ended_at=$2 WHERE id=$1 AND ended_at IS NULL`, id, at)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// plannedColumns selects planned sessions aliased as p, resolving the
+// workout that completed each one.
+const plannedColumns = `p.id, p.enrollment_id, p.template_id, p.week, p.day, p.scheduled_on,
+    (SELECT ws.id FROM workout_sessions ws WHERE ws.planned_session_id = p.id AND ws.deleted_at IS NULL
+     ORDER BY ws.started_at, ws.id LIMIT 1)`
+
+func (r *programRepository) GetPlanned(id string) (*domain.PlannedSession, error) {
+	planned, err := r.queryPlanned(`FROM planned_sessions p WHERE p.id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(planned) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &planned[0], nil
+}
+
+func (r *programRepository) ListPlanned(enrollmentID string) ([]domain.PlannedSession, error) {
+	return r.queryPlanned(`FROM planned_sessions p WHERE p.enrollment_id=$1 ORDER BY p.scheduled_on, p.id`, enrollmentID)
+}
+
+func (r *programRepository) Schedule(userID string, from, to time.Time) ([]domain.PlannedSession, error) {
+	return r.queryPlanned(
+		`FROM planned_sessions p JOIN program_enrollments e ON e.id = p.enrollment_id
+         WHERE e.user_id=$1 AND e.ended_at IS NULL AND p.scheduled_on >= $2 AND p.scheduled_on < $3
+         ORDER BY p.scheduled_on, p.id`,
+		userID, from, to,
+	)
+}
+
+func (r *programRepository) queryPlanned(clause string, args ...interface{}) ([]domain.PlannedSession, error) {
+	rows, err := r.pool.Query(context.Background(), `SELECT `+plannedColumns+` `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	planned := []domain.PlannedSession{}
+	for rows.Next() {
+		var p domain.PlannedSession
+		var sessionID *string
+		if err := rows.Scan(&p.ID, &p.EnrollmentID, &p.TemplateID, &p.Week, &p.Day, &p.ScheduledOn, &sessionID); err != nil {
+			return nil, err
+		}
+		if sessionID != nil {
+			p.SessionID = *sessionID
+		}
+		planned = append(planned, p)
+	}
+	return planned, rows.Err()
+}
+
+// Helpers
+
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func nullableInt(value int) *int {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isPlannedSessionTaken reports whether err comes from a second workout
+// outside the trash completing the same planned session.
+func isPlannedSessionTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "workout_sessions_planned_key"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
 }
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/musclementour/app/internal/domain"
+	"github.com/musclementour/app/internal/repository"
+)
+
+// BenchmarkListSessions compares loading a page of 50 sessions with bulk
+// entry queries against the former one-query-per-session approach. It needs
+// a disposable database:
+//
+//	TEST_DATABASE_URL=postgres://... go test -run '^$' -bench ListSessions ./internal/storage/postgres
+func BenchmarkListSessions(b *testing.B) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_URL is not set")
+	}
+	storage, err := New(context.Background(), dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer storage.Close()
+	repo := storage.Repository()
+	workouts := &workoutRepository{pool: storage.pool}
+	userID, exerciseID := seedBenchmarkHistory(b, repo, 50, 5)
+	defer func() {
+		// The user's sessions and entries go first; they reference the
+		// exercise.
+		_, _ = storage.pool.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, userID)
+		_, _ = storage.pool.Exec(context.Background(), `DELETE FROM exercises WHERE id=$1`, exerciseID)
+	}()
+	filter := repository.SessionFilter{UserID: userID}
+
+	b.Run("bulk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sessions, err := workouts.ListSessions(filter, nil, 50)
+			if err != nil || len(sessions) != 50 {
+				b.Fatalf("got %d sessions: %v", len(sessions), err)
+			}
+		}
+	})
+	b.Run("per_session", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sessions, err := listSessionsPerSession(workouts, userID)
+			if err != nil || len(sessions) != 50 {
+				b.Fatalf("got %d sessions: %v", len(sessions), err)
+			}
+		}
+	})
+}
+
+// seedBenchmarkHistory stores sessions for a new user, each with entries of
+// three sets of a new exercise, and returns the user's and exercise's ids.
+func seedBenchmarkHistory(b *testing.B, repo repository.Repository, sessions, entries int) (string, string) {
+	b.Helper()
+	user := &domain.User{Email: fmt.Sprintf("bench-%s@example.com", uuid.NewString()), PasswordHash: "x", Role: domain.RoleUser}
+	if err := repo.Users.Create(user); err != nil {
+		b.Fatal(err)
+	}
+	exercise := &domain.Exercise{Name: "Benchmark Press " + uuid.NewString(), MeasurementType: domain.MeasurementWeightReps}
+	if err := repo.Exercises.Create(exercise, nil); err != nil {
+		b.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)
+	for i := 0; i < sessions; i++ {
+		session := &domain.WorkoutSession{UserID: user.ID, StartedAt: start.AddDate(0, 0, i)}
+		for j := 0; j < entries; j++ {
+			set := domain.WorkoutSet{Type: domain.SetWorking, Reps: 5, Weight: 100, Completed: true}
+			session.Entries = append(session.Entries, domain.WorkoutEntry{
+				ExerciseID: exercise.ID, Sets: 3, Reps: 5, Weight: 100,
+				SetDetails: []domain.WorkoutSet{set, set, set},
+			})
+		}
+		if err := repo.Workouts.CreateSession(session); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return user.ID, exercise.ID
+}
+
+// listSessionsPerSession is the previous listing strategy, kept as the
+// benchmark baseline: entries and sets are fetched separately for every
+// session while the session rows are still open.
+func listSessionsPerSession(r *workoutRepository, userID string) ([]domain.WorkoutSession, error) {
+	rows, err := r.pool.Query(context.Background(),
+		`SELECT `+sessionColumns+` FROM workout_sessions WHERE user_id=$1 AND deleted_at IS NULL ORDER BY started_at DESC LIMIT 50`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []domain.WorkoutSession{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		entryRows, err := r.pool.Query(context.Background(),
+			`SELECT id, session_id, exercise_id, sets, reps, weight, duration_seconds, distance_meters, side, notes, created_at, updated_at
+             FROM workout_entries WHERE session_id=$1`,
+			s.ID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for entryRows.Next() {
+			var e domain.WorkoutEntry
+			var side string
+			if err := entryRows.Scan(&e.ID, &e.SessionID, &e.ExerciseID, &e.Sets, &e.Reps, &e.Weight, &e.DurationSeconds, &e.DistanceMeters, &side, &e.Notes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+				entryRows.Close()
+				return nil, err
+			}
+			e.Side = domain.Side(side)
+			s.Entries = append(s.Entries, e)
+		}
+		entryRows.Close()
+		if err := r.loadSets(s.Entries); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
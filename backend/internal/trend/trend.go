@@ -0,0 +1,122 @@
+// Package trend fits a linear or logarithmic trend to a series of
+// measurements and projects when it will reach a target. It has no
+// dependencies on storage or HTTP.
+package trend
+
+import (
+	"math"
+	"time"
+)
+
+// Point is one measurement.
+type Point struct {
+	At    time.Time
+	Value float64
+}
+
+// Kind names the shape of a trend.
+type Kind string
+
+const (
+	// Linear trends change by the same amount every day.
+	Linear Kind = "linear"
+	// Logarithmic trends slow down over time, as strength gains do.
+	Logarithmic Kind = "log"
+)
+
+// Model is the trend value = Intercept + Slope × x, where x is the number of
+// days since Origin for a linear trend and ln(1 + days) for a logarithmic
+// one. R2 is the share of the variance in the measurements it explains.
+type Model struct {
+	Kind      Kind
+	Origin    time.Time
+	Intercept float64
+	Slope     float64
+	R2        float64
+}
+
+const day = 24 * time.Hour
+
+func (m Model) x(t time.Time) float64 {
+	days := float64(t.Sub(m.Origin)) / float64(day)
+	if m.Kind == Logarithmic {
+		return math.Log1p(math.Max(days, 0))
+	}
+	return days
+}
+
+// At returns the trend's value at t.
+func (m Model) At(t time.Time) float64 {
+	return m.Intercept + m.Slope*m.x(t)
+}
+
+// Reach returns when the trend gets to target, rising to it or falling to
+// it, no earlier than from. It reports false when the trend is flat or heads
+// the other way, or only gets there after horizon.
+func (m Model) Reach(target float64, rising bool, from, horizon time.Time) (time.Time, bool) {
+	if m.Slope == 0 || (m.Slope > 0) != rising {
+		return time.Time{}, false
+	}
+	if current := m.At(from); (rising && current >= target) || (!rising && current <= target) {
+		return from, true
+	}
+	days := (target - m.Intercept) / m.Slope
+	if m.Kind == Logarithmic {
+		days = math.Expm1(days)
+	}
+	if math.IsNaN(days) || days > float64(horizon.Sub(m.Origin))/float64(day) {
+		return time.Time{}, false
+	}
+	return m.Origin.Add(time.Duration(days * float64(day))), true
+}
+
+// Fit fits both kinds of trend to points by least squares and returns the
+// one that explains them better, the linear one on ties. It reports false
+// when the points do not span at least two different times.
+func Fit(points []Point) (Model, bool) {
+	if len(points) < 2 {
+		return Model{}, false
+	}
+	origin := points[0].At
+	for _, p := range points[1:] {
+		if p.At.Before(origin) {
+			origin = p.At
+		}
+	}
+	linear, ok := fit(Linear, origin, points)
+	if !ok {
+		return Model{}, false
+	}
+	if logarithmic, ok := fit(Logarithmic, origin, points); ok && logarithmic.R2 > linear.R2 {
+		return logarithmic, true
+	}
+	return linear, true
+}
+
+func fit(kind Kind, origin time.Time, points []Point) (Model, bool) {
+	m := Model{Kind: kind, Origin: origin}
+	n := float64(len(points))
+	var sumX, sumY float64
+	for _, p := range points {
+		sumX += m.x(p.At)
+		sumY += p.Value
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var sxx, sxy, syy float64
+	for _, p := range points {
+		dx, dy := m.x(p.At)-meanX, p.Value-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return Model{}, false
+	}
+	m.Slope = sxy / sxx
+	m.Intercept = meanY - m.Slope*meanX
+	m.R2 = 1
+	if syy > 0 {
+		m.R2 = sxy * sxy / (sxx * syy)
+	}
+	return m, true
+}
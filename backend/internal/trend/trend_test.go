@@ -0,0 +1,76 @@
+package trend_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/musclementour/app/internal/trend"
+)
+
+var start = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+func daysLater(days float64) time.Time {
+	return start.Add(time.Duration(days * float64(24*time.Hour)))
+}
+
+func TestFit(t *testing.T) {
+	t.Run("straight line", func(t *testing.T) {
+		model, ok := trend.Fit([]trend.Point{{daysLater(0), 80}, {daysLater(10), 79}, {daysLater(20), 78}})
+		require.True(t, ok)
+		require.Equal(t, trend.Linear, model.Kind)
+		require.InDelta(t, -0.1, model.Slope, 1e-9)
+		require.InDelta(t, 77, model.At(daysLater(30)), 1e-9)
+		require.InDelta(t, 1, model.R2, 1e-9)
+	})
+
+	t.Run("diminishing gains", func(t *testing.T) {
+		var points []trend.Point
+		for _, days := range []float64{0, 7, 21, 49, 98, 140} {
+			points = append(points, trend.Point{At: daysLater(days), Value: 100 + 5*math.Log1p(days)})
+		}
+		model, ok := trend.Fit(points)
+		require.True(t, ok)
+		require.Equal(t, trend.Logarithmic, model.Kind)
+		require.InDelta(t, 5, model.Slope, 1e-9)
+	})
+
+	t.Run("too few points", func(t *testing.T) {
+		_, ok := trend.Fit([]trend.Point{{daysLater(0), 100}})
+		require.False(t, ok)
+		_, ok = trend.Fit([]trend.Point{{daysLater(3), 100}, {daysLater(3), 105}})
+		require.False(t, ok)
+	})
+}
+
+func TestModelReach(t *testing.T) {
+	horizon := daysLater(365)
+	losing := trend.Model{Kind: trend.Linear, Origin: start, Intercept: 80, Slope: -0.1}
+	gaining := trend.Model{Kind: trend.Logarithmic, Origin: start, Intercept: 100, Slope: 5}
+
+	cases := []struct {
+		name   string
+		model  trend.Model
+		target float64
+		rising bool
+		from   time.Time
+		want   time.Time
+		ok     bool
+	}{
+		{name: "linear fall", model: losing, target: 75, from: daysLater(10), want: daysLater(50), ok: true},
+		{name: "logarithmic rise", model: gaining, target: 110, rising: true, from: daysLater(1), want: daysLater(math.Expm1(2)), ok: true},
+		{name: "already there", model: losing, target: 79.5, from: daysLater(10), want: daysLater(10), ok: true},
+		{name: "heading away", model: losing, target: 85, rising: true, from: daysLater(10)},
+		{name: "beyond the horizon", model: gaining, target: 200, rising: true, from: daysLater(1)},
+		{name: "flat", model: trend.Model{Kind: trend.Linear, Origin: start, Intercept: 3}, target: 4, rising: true, from: start},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.model.Reach(tc.target, tc.rising, tc.from, horizon)
+			require.Equal(t, tc.ok, ok)
+			require.WithinDuration(t, tc.want, got, time.Second)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS exercise_media (
+    id UUID PRIMARY KEY,
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    blob_key TEXT NOT NULL UNIQUE,
+    thumbnail_key TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS exercise_media_exercise_idx ON exercise_media (exercise_id);
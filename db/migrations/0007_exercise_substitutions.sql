@@ -0,0 +1,25 @@
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS primary_muscles TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS movement_pattern TEXT NOT NULL DEFAULT '';
+
+UPDATE exercises SET primary_muscles = ARRAY['quadriceps', 'glutes'], movement_pattern = 'squat',
+    change_seq = nextval('exercise_change_seq')
+WHERE name = 'Barbell Back Squat' AND movement_pattern = '' AND primary_muscles = '{}';
+UPDATE exercises SET primary_muscles = ARRAY['chest', 'triceps', 'front delts'], movement_pattern = 'horizontal_push',
+    change_seq = nextval('exercise_change_seq')
+WHERE name = 'Bench Press' AND movement_pattern = '' AND primary_muscles = '{}';
+UPDATE exercises SET primary_muscles = ARRAY['hamstrings', 'glutes', 'lower back'], movement_pattern = 'hinge',
+    change_seq = nextval('exercise_change_seq')
+WHERE name = 'Deadlift' AND movement_pattern = '' AND primary_muscles = '{}';
+UPDATE exercises SET primary_muscles = ARRAY['lats', 'biceps'], movement_pattern = 'vertical_pull',
+    change_seq = nextval('exercise_change_seq')
+WHERE name = 'Pull-Up' AND movement_pattern = '' AND primary_muscles = '{}';
+UPDATE exercises SET primary_muscles = ARRAY['abs'], movement_pattern = 'core',
+    change_seq = nextval('exercise_change_seq')
+WHERE name = 'Plank' AND movement_pattern = '' AND primary_muscles = '{}';
+
+CREATE TABLE IF NOT EXISTS exercise_substitutions (
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    substitute_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (exercise_id, substitute_id)
+);
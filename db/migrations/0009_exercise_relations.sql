@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS exercise_relations (
+    id UUID PRIMARY KEY,
+    from_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    to_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (from_id, to_id, type),
+    CHECK (from_id <> to_id)
+);
+
+CREATE INDEX IF NOT EXISTS exercise_relations_to_idx ON exercise_relations (to_id);
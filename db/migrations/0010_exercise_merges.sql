@@ -0,0 +1 @@
+ALTER TABLE exercise_tombstones ADD COLUMN IF NOT EXISTS replaced_by UUID;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS exercise_seeds (
+    key TEXT PRIMARY KEY,
+    exercise_id UUID NOT NULL,
+    seeded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS exercise_catalogue (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    version INTEGER NOT NULL
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS workout_sets (
+    entry_id UUID NOT NULL REFERENCES workout_entries(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    set_type TEXT NOT NULL DEFAULT 'working',
+    reps INTEGER NOT NULL DEFAULT 0,
+    weight DOUBLE PRECISION NOT NULL DEFAULT 0,
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    distance_meters DOUBLE PRECISION NOT NULL DEFAULT 0,
+    rpe DOUBLE PRECISION,
+    rir INTEGER,
+    tempo TEXT NOT NULL DEFAULT '',
+    completed BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (entry_id, position)
+);
+
+INSERT INTO workout_sets (entry_id, position, reps, weight, duration_seconds, distance_meters)
+SELECT e.id, n - 1, COALESCE(e.reps, 0), COALESCE(e.weight, 0),
+    CASE WHEN x.measurement_type IN ('time', 'distance_time') THEN COALESCE(e.duration_seconds, 0) ELSE 0 END,
+    CASE WHEN x.measurement_type IN ('distance', 'distance_time') THEN e.distance_meters ELSE 0 END
+FROM workout_entries e
+JOIN exercises x ON x.id = e.exercise_id
+CROSS JOIN LATERAL generate_series(1, GREATEST(COALESCE(e.sets, 1), 1)) AS n
+WHERE NOT EXISTS (SELECT 1 FROM workout_sets);
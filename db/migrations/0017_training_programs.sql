@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS programs (
+    id UUID PRIMARY KEY,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    weeks INTEGER NOT NULL,
+    weight_increment DOUBLE PRECISION NOT NULL DEFAULT 0,
+    reps_increment INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS program_days (
+    program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    day INTEGER NOT NULL,
+    template_id UUID NOT NULL REFERENCES workout_templates(id),
+    PRIMARY KEY (program_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS program_days_template_idx ON program_days (template_id);
+
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    program_id UUID NOT NULL REFERENCES programs(id),
+    start_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS program_enrollments_active_idx ON program_enrollments (user_id, program_id) WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS planned_sessions (
+    id UUID PRIMARY KEY,
+    enrollment_id UUID NOT NULL REFERENCES program_enrollments(id) ON DELETE CASCADE,
+    template_id UUID NOT NULL REFERENCES workout_templates(id),
+    week INTEGER NOT NULL,
+    day INTEGER NOT NULL,
+    scheduled_on DATE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS planned_sessions_enrollment_idx ON planned_sessions (enrollment_id, scheduled_on);
+
+CREATE INDEX IF NOT EXISTS planned_sessions_template_idx ON planned_sessions (template_id);
+
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS planned_session_id UUID REFERENCES planned_sessions(id) ON DELETE SET NULL;
+
+UPDATE workout_sessions ws SET planned_session_id = NULL
+WHERE ws.planned_session_id IS NOT NULL AND ws.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM workout_sessions earlier
+    WHERE earlier.planned_session_id = ws.planned_session_id AND earlier.deleted_at IS NULL
+      AND (earlier.created_at, earlier.id) < (ws.created_at, ws.id)
+);
+
+DROP INDEX IF EXISTS workout_sessions_planned_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS workout_sessions_planned_key ON workout_sessions (planned_session_id) WHERE planned_session_id IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS training_maxes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    weight DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, exercise_id)
+);
+
+CREATE INDEX IF NOT EXISTS training_maxes_exercise_idx ON training_maxes (exercise_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bar_weight DOUBLE PRECISION;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plates DOUBLE PRECISION[];
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS load_increment DOUBLE PRECISION;
+
+ALTER TABLE workout_template_exercises ADD COLUMN IF NOT EXISTS target_percent DOUBLE PRECISION NOT NULL DEFAULT 0;
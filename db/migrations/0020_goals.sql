@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS goals (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    exercise_id UUID REFERENCES exercises(id) ON DELETE CASCADE,
+    target DOUBLE PRECISION NOT NULL,
+    reps INTEGER NOT NULL DEFAULT 0,
+    deadline DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    achieved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS goals_user_idx ON goals (user_id, deadline);
+
+CREATE INDEX IF NOT EXISTS goals_exercise_idx ON goals (exercise_id);
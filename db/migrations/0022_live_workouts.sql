@@ -0,0 +1,15 @@
+ALTER TABLE workout_sets ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE workout_sets ADD COLUMN IF NOT EXISTS rest_seconds INTEGER;
+
+CREATE TABLE IF NOT EXISTS live_workouts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_activity_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    planned_session_id UUID REFERENCES planned_sessions(id) ON DELETE SET NULL,
+    entries JSONB NOT NULL DEFAULT '[]',
+    revision INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS live_workouts_activity_idx ON live_workouts (last_activity_at);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS record_backfills (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    scored_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -3,6 +3,7 @@ import { Navigate, Route, Routes } from 'react-router-dom';
 import { useAuth } from './context/AuthContext';
 import { useOfflineSync } from './hooks/useOfflineSync';
 import { ProtectedRoute, PublicOnlyRoute } from './components/ProtectedRoute';
+import RejectedWorkouts from './components/RejectedWorkouts';
 import LoginPage from './pages/LoginPage';
 import RegisterPage from './pages/RegisterPage';
 import MainPage from './pages/MainPage';
@@ -13,10 +14,11 @@ import AdminPanelPage from './pages/AdminPanelPage';
 
 export default function App() {
   const { tokens, user } = useAuth();
-  useOfflineSync(tokens?.accessToken);
+  const { rejected, dismissRejected } = useOfflineSync(tokens?.accessToken);
 
   return (
     <div className="min-h-screen bg-background text-onSurface">
+      {user && <RejectedWorkouts workouts={rejected} onDismiss={dismissRejected} />}
       <Routes>
         <Route element={<PublicOnlyRoute />}>
           <Route path="/login" element={<LoginPage />} />
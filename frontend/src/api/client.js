@@ -1,8 +1,8 @@
 const rawApiUrl = (import.meta.env.VITE_API_URL || 'http://localhost').replace(/\/$/, '');
 const API_URL = rawApiUrl.endsWith('/api') ? rawApiUrl.slice(0, -4) : rawApiUrl;
 
-async function request(path, { method = 'GET', body, token, signal } = {}) {
-  const headers = { 'Content-Type': 'application/json' };
+async function request(path, { method = 'GET', body, token, signal, headers: extraHeaders } = {}) {
+  const headers = { 'Content-Type': 'application/json', ...extraHeaders };
   if (token) {
     headers.Authorization = `Bearer ${token}`;
   }
@@ -31,11 +31,26 @@ export const api = {
   logout: (refreshToken) => request('/api/v1/auth/logout', { method: 'POST', body: { refreshToken } }),
   getProfile: (token) => request('/api/v1/profile', { token }),
   listExercises: (token) => request('/api/v1/exercises', { token }),
+  listExerciseChanges: (token, since = '') =>
+    request(`/api/v1/exercises?since=${encodeURIComponent(since)}`, { token }),
   adminCreateExercise: (token, payload) => request('/api/v1/exercises', { method: 'POST', body: payload, token }),
   adminUpdateExercise: (token, id, payload) => request(`/api/v1/exercises/${id}`, { method: 'PUT', body: payload, token }),
   adminDeleteExercise: (token, id) => request(`/api/v1/exercises/${id}`, { method: 'DELETE', token }),
   listWorkouts: (token) => request('/api/v1/workouts', { token }),
-  createWorkout: (token, payload) => request('/api/v1/workouts', { method: 'POST', body: payload, token })
+  // Retries with the same idempotency key return the originally stored workout instead of a duplicate.
+  createWorkout: (token, payload, idempotencyKey) =>
+    request('/api/v1/workouts', {
+      method: 'POST',
+      body: payload,
+      token,
+      headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined
+    }),
+  syncWorkouts: (token, workouts) =>
+    request('/api/v1/workouts/batch', { method: 'POST', body: { workouts }, token }),
+  listTemplates: (token) => request('/api/v1/templates', { token }),
+  saveWorkoutAsTemplate: (token, workoutId, name) =>
+    request(`/api/v1/workouts/${workoutId}/template`, { method: 'POST', body: { name }, token }),
+  startTemplate: (token, id) => request(`/api/v1/templates/${id}/start`, { method: 'POST', token })
 };
 
 export async function fetchPublicExercises() {
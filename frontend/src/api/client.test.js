@@ -56,6 +56,47 @@ describe('api client', () => {
     );
   });
 
+  it('sends the idempotency key with workout submissions', async () => {
+    global.fetch.mockResolvedValue({
+      ok: true,
+      status: 200,
+      json: vi.fn().mockResolvedValue({ id: 'session-1' })
+    });
+
+    await api.createWorkout('token-abc', { entries: [] }, 'session-1');
+
+    expect(global.fetch).toHaveBeenCalledWith(
+      `${API_URL}/api/v1/workouts`,
+      expect.objectContaining({
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          Authorization: 'Bearer token-abc',
+          'Idempotency-Key': 'session-1'
+        }
+      })
+    );
+  });
+
+  it('posts queued workouts as one batch', async () => {
+    global.fetch.mockResolvedValue({
+      ok: true,
+      status: 200,
+      json: vi.fn().mockResolvedValue({ results: [] })
+    });
+    const workouts = [{ idempotencyKey: 'w1', entries: [] }];
+
+    await api.syncWorkouts('token-abc', workouts);
+
+    expect(global.fetch).toHaveBeenCalledWith(
+      `${API_URL}/api/v1/workouts/batch`,
+      expect.objectContaining({
+        method: 'POST',
+        body: JSON.stringify({ workouts })
+      })
+    );
+  });
+
   it('returns null for 204 responses', async () => {
     global.fetch.mockResolvedValue({
       ok: true,
@@ -0,0 +1,48 @@
+import React from 'react';
+
+const describe = (workout) => {
+  const completedAt = workout.payload?.completedAt;
+  return completedAt ? new Date(completedAt).toLocaleString() : workout.id;
+};
+
+export default function RejectedWorkouts({ workouts, onDismiss }) {
+  if (!workouts?.length) {
+    return null;
+  }
+
+  return (
+    <section className="mx-auto max-w-xl space-y-3 px-4 pt-4">
+      {workouts.map((workout) => (
+        <div
+          key={workout.id}
+          className="rounded-2xl border border-outline/40 bg-surface px-4 py-3 text-sm text-onSurface"
+        >
+          <p className="font-semibold text-error">
+            The workout from {describe(workout)} could not be saved.
+          </p>
+          <p className="text-xs text-onSurfaceVariant">
+            {workout.status === 'conflict'
+              ? 'Another workout was already saved with the same id.'
+              : workout.error || 'The server rejected it.'}
+          </p>
+          {workout.fields?.length > 0 && (
+            <ul className="mt-1 list-disc pl-5 text-xs text-onSurfaceVariant">
+              {workout.fields.map((field) => (
+                <li key={field.field}>
+                  {field.field}: {field.message}
+                </li>
+              ))}
+            </ul>
+          )}
+          <button
+            type="button"
+            onClick={() => onDismiss(workout.id)}
+            className="mt-2 rounded-full border border-outline/40 px-4 py-2 text-xs font-semibold text-onSurface transition hover:border-primary hover:text-primary"
+          >
+            Dismiss
+          </button>
+        </div>
+      ))}
+    </section>
+  );
+}
@@ -1,7 +1,7 @@
 import { openDB } from 'idb';
 
 const DB_NAME = 'muscle-mentour';
-const DB_VERSION = 1;
+const DB_VERSION = 4;
 
 async function getDb() {
   return openDB(DB_NAME, DB_VERSION, {
@@ -12,6 +12,15 @@ async function getDb() {
       if (!db.objectStoreNames.contains('pendingWorkouts')) {
         db.createObjectStore('pendingWorkouts', { keyPath: 'id' });
       }
+      if (!db.objectStoreNames.contains('meta')) {
+        db.createObjectStore('meta', { keyPath: 'id' });
+      }
+      if (!db.objectStoreNames.contains('templates')) {
+        db.createObjectStore('templates', { keyPath: 'id' });
+      }
+      if (!db.objectStoreNames.contains('rejectedWorkouts')) {
+        db.createObjectStore('rejectedWorkouts', { keyPath: 'id' });
+      }
     }
   });
 }
@@ -33,6 +42,51 @@ export async function getExercises() {
   return db.getAll('exercises');
 }
 
+export async function getExerciseCursor() {
+  const db = await getDb();
+  const entry = await db.get('meta', 'exerciseCursor');
+  return entry?.value || '';
+}
+
+export async function applyExerciseChanges(feed) {
+  const db = await getDb();
+  const tx = db.transaction(['exercises', 'meta'], 'readwrite');
+  const exercises = tx.objectStore('exercises');
+  await Promise.all([
+    ...feed.exercises.map((ex) => exercises.put(ex)),
+    ...feed.tombstones.map((tombstone) => exercises.delete(tombstone.id)),
+    tx.objectStore('meta').put({ id: 'exerciseCursor', value: feed.cursor })
+  ]);
+  await tx.done;
+}
+
+// saveTemplates replaces the offline copy of the user's templates, so templates deleted elsewhere disappear too.
+export async function saveTemplates(templates) {
+  const db = await getDb();
+  const tx = db.transaction('templates', 'readwrite');
+  await tx.store.clear();
+  await Promise.all(templates.map((template) => tx.store.put(template)));
+  await tx.done;
+}
+
+export async function getTemplates() {
+  const db = await getDb();
+  return db.getAll('templates');
+}
+
+// newPendingWorkoutId returns a UUID used both as the workout's session id and as its idempotency key, so a
+// workout queued after a lost response is not stored twice when it syncs.
+export function newPendingWorkoutId() {
+  if (crypto.randomUUID) {
+    return crypto.randomUUID();
+  }
+  const bytes = crypto.getRandomValues(new Uint8Array(16));
+  bytes[6] = (bytes[6] & 0x0f) | 0x40;
+  bytes[8] = (bytes[8] & 0x3f) | 0x80;
+  const hex = Array.from(bytes, (b) => b.toString(16).padStart(2, '0')).join('');
+  return `${hex.slice(0, 8)}-${hex.slice(8, 12)}-${hex.slice(12, 16)}-${hex.slice(16, 20)}-${hex.slice(20)}`;
+}
+
 export async function addPendingWorkout(workout) {
   const db = await getDb();
   await db.put('pendingWorkouts', workout);
@@ -47,3 +101,27 @@ export async function deletePendingWorkout(id) {
   const db = await getDb();
   await db.delete('pendingWorkouts', id);
 }
+
+// rejectPendingWorkout moves a queued workout the server refused out of the queue, keeping the reason so the athlete
+// can review it. Retrying it unchanged would only fail again.
+export async function rejectPendingWorkout(workout, result) {
+  const db = await getDb();
+  await db.put('rejectedWorkouts', {
+    ...workout,
+    status: result.status,
+    error: result.error || '',
+    fields: result.fields || [],
+    rejectedAt: new Date().toISOString()
+  });
+  await db.delete('pendingWorkouts', workout.id);
+}
+
+export async function getRejectedWorkouts() {
+  const db = await getDb();
+  return db.getAll('rejectedWorkouts');
+}
+
+export async function deleteRejectedWorkout(id) {
+  const db = await getDb();
+  await db.delete('rejectedWorkouts', id);
+}
@@ -1,5 +1,17 @@
 import { afterEach, beforeEach, describe, expect, it, vi } from 'vitest';
-import { saveExercises, getExercises, clearExercises, addPendingWorkout, getPendingWorkouts, deletePendingWorkout } from './useIndexedDB';
+import {
+  saveExercises,
+  getExercises,
+  clearExercises,
+  addPendingWorkout,
+  getPendingWorkouts,
+  deletePendingWorkout,
+  rejectPendingWorkout,
+  getRejectedWorkouts,
+  deleteRejectedWorkout,
+  saveTemplates,
+  getTemplates
+} from './useIndexedDB';
 import { openDB } from 'idb';
 
 vi.mock('idb', () => {
@@ -127,4 +139,36 @@ describe('useIndexedDB storage helpers', () => {
     pending = await getPendingWorkouts();
     expect(pending).toEqual([]);
   });
+
+  it('parks rejected workouts outside the queue', async () => {
+    const workout = { id: 'workout-2', payload: { notes: 'bad' } };
+    await addPendingWorkout(workout);
+
+    await rejectPendingWorkout(workout, {
+      status: 'invalid',
+      error: 'validation failed',
+      fields: [{ field: 'entries[0].reps', message: 'must be empty for time exercises' }]
+    });
+
+    expect(await getPendingWorkouts()).toEqual([]);
+    const [rejected] = await getRejectedWorkouts();
+    expect(rejected).toMatchObject({
+      id: 'workout-2',
+      payload: { notes: 'bad' },
+      status: 'invalid',
+      error: 'validation failed',
+      fields: [{ field: 'entries[0].reps', message: 'must be empty for time exercises' }]
+    });
+
+    await deleteRejectedWorkout('workout-2');
+    expect(await getRejectedWorkouts()).toEqual([]);
+  });
+
+  it('replaces stored templates on each save', async () => {
+    await saveTemplates([{ id: 'tpl-1', name: 'Push Day' }, { id: 'tpl-2', name: 'Pull Day' }]);
+    await saveTemplates([{ id: 'tpl-2', name: 'Pull Day' }]);
+
+    const stored = await getTemplates();
+    expect(stored).toEqual([{ id: 'tpl-2', name: 'Pull Day' }]);
+  });
 });
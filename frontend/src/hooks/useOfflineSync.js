@@ -1,29 +1,83 @@
-import { useCallback, useEffect } from 'react';
+import { useCallback, useEffect, useState } from 'react';
 import { api } from '../api/client';
-import { getPendingWorkouts, deletePendingWorkout } from './useIndexedDB';
+import {
+  getPendingWorkouts,
+  deletePendingWorkout,
+  rejectPendingWorkout,
+  getRejectedWorkouts,
+  deleteRejectedWorkout
+} from './useIndexedDB';
+
+// Stay well below the server's WORKOUT_BATCH_MAX_ITEMS default.
+export const SYNC_BATCH_SIZE = 25;
+
+// Workouts the server stored, now or on an earlier attempt, can leave the
+// queue.
+const SETTLED = new Set(['created', 'duplicate']);
+
+// Workouts the server refused would fail again unchanged, so they are parked
+// for the athlete to review. Only failed workouts stay queued for a retry.
+const REJECTED = new Set(['invalid', 'conflict']);
 
 export function useOfflineSync(token) {
+  const [rejected, setRejected] = useState([]);
+
+  const loadRejected = useCallback(async () => {
+    setRejected(await getRejectedWorkouts());
+  }, []);
+
   const sync = useCallback(async () => {
     if (!token || !navigator.onLine) {
       return;
     }
     const pending = await getPendingWorkouts();
-    for (const workout of pending) {
+    for (let start = 0; start < pending.length; start += SYNC_BATCH_SIZE) {
+      const chunk = pending.slice(start, start + SYNC_BATCH_SIZE);
+      let response;
       try {
-        await api.createWorkout(token, workout.payload);
-        await deletePendingWorkout(workout.id);
+        response = await api.syncWorkouts(
+          token,
+          chunk.map((workout) => ({ ...workout.payload, idempotencyKey: workout.id }))
+        );
       } catch (error) {
         if (error.status === 401) {
           throw error;
         }
         // keep for next attempt
+        continue;
+      }
+      for (const result of response?.results ?? []) {
+        const workout = chunk[result.index];
+        if (!workout) {
+          continue;
+        }
+        if (SETTLED.has(result.status)) {
+          await deletePendingWorkout(workout.id);
+        } else if (REJECTED.has(result.status)) {
+          await rejectPendingWorkout(workout, result);
+        }
       }
     }
-  }, [token]);
+    await loadRejected();
+  }, [token, loadRejected]);
+
+  const dismissRejected = useCallback(
+    async (id) => {
+      await deleteRejectedWorkout(id);
+      await loadRejected();
+    },
+    [loadRejected]
+  );
+
+  useEffect(() => {
+    loadRejected();
+  }, [loadRejected]);
 
   useEffect(() => {
     sync();
     window.addEventListener('online', sync);
     return () => window.removeEventListener('online', sync);
   }, [sync]);
+
+  return { rejected, dismissRejected };
 }
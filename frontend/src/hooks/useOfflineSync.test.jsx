@@ -1,18 +1,27 @@
-import { renderHook, waitFor } from '@testing-library/react';
+import { act, renderHook, waitFor } from '@testing-library/react';
 import { beforeEach, describe, expect, it, vi } from 'vitest';
-import { useOfflineSync } from './useOfflineSync';
+import { SYNC_BATCH_SIZE, useOfflineSync } from './useOfflineSync';
 import { api } from '../api/client';
-import { getPendingWorkouts, deletePendingWorkout } from './useIndexedDB';
+import {
+  getPendingWorkouts,
+  deletePendingWorkout,
+  rejectPendingWorkout,
+  getRejectedWorkouts,
+  deleteRejectedWorkout
+} from './useIndexedDB';
 
 vi.mock('../api/client', () => ({
   api: {
-    createWorkout: vi.fn()
+    syncWorkouts: vi.fn()
   }
 }));
 
 vi.mock('./useIndexedDB', () => ({
   getPendingWorkouts: vi.fn(),
-  deletePendingWorkout: vi.fn()
+  deletePendingWorkout: vi.fn(),
+  rejectPendingWorkout: vi.fn(),
+  getRejectedWorkouts: vi.fn(),
+  deleteRejectedWorkout: vi.fn()
 }));
 
 const setNavigatorOnline = (value) => {
@@ -26,6 +35,7 @@ describe('useOfflineSync', () => {
   beforeEach(() => {
     setNavigatorOnline(true);
     vi.clearAllMocks();
+    getRejectedWorkouts.mockResolvedValue([]);
   });
 
   it('skips syncing when offline', async () => {
@@ -35,45 +45,103 @@ describe('useOfflineSync', () => {
 
     await waitFor(() => {
       expect(getPendingWorkouts).not.toHaveBeenCalled();
-      expect(api.createWorkout).not.toHaveBeenCalled();
+      expect(api.syncWorkouts).not.toHaveBeenCalled();
     });
   });
 
-  it('pushes pending workouts when online', async () => {
+  it('pushes pending workouts in one batch when online', async () => {
     const workouts = [
       { id: 'w1', payload: { notes: 'one' } },
       { id: 'w2', payload: { notes: 'two' } }
     ];
     getPendingWorkouts.mockResolvedValue(workouts);
-    api.createWorkout.mockResolvedValue({});
+    api.syncWorkouts.mockResolvedValue({
+      results: [
+        { index: 0, key: 'w1', status: 'created' },
+        { index: 1, key: 'w2', status: 'duplicate' }
+      ]
+    });
     deletePendingWorkout.mockResolvedValue();
 
     renderHook(() => useOfflineSync('token-sync'));
 
-    await waitFor(() => expect(api.createWorkout).toHaveBeenCalledTimes(2));
-    expect(api.createWorkout).toHaveBeenNthCalledWith(1, 'token-sync', workouts[0].payload);
-    expect(api.createWorkout).toHaveBeenNthCalledWith(2, 'token-sync', workouts[1].payload);
-    expect(deletePendingWorkout).toHaveBeenCalledTimes(2);
+    await waitFor(() => expect(deletePendingWorkout).toHaveBeenCalledTimes(2));
+    expect(api.syncWorkouts).toHaveBeenCalledTimes(1);
+    expect(api.syncWorkouts).toHaveBeenCalledWith('token-sync', [
+      { notes: 'one', idempotencyKey: 'w1' },
+      { notes: 'two', idempotencyKey: 'w2' }
+    ]);
     expect(deletePendingWorkout).toHaveBeenNthCalledWith(1, 'w1');
     expect(deletePendingWorkout).toHaveBeenNthCalledWith(2, 'w2');
   });
 
-  it('retains workouts when API errors are non-auth related', async () => {
+  it('parks rejected workouts and keeps failed ones for retry', async () => {
     const workouts = [
-      { id: 'w1', payload: { notes: 'fail' } },
-      { id: 'w2', payload: { notes: 'ok' } }
+      { id: 'w1', payload: { notes: 'invalid' } },
+      { id: 'w2', payload: { notes: 'ok' } },
+      { id: 'w3', payload: { notes: 'failed' } },
+      { id: 'w4', payload: { notes: 'conflict' } }
     ];
+    const invalid = {
+      index: 0,
+      key: 'w1',
+      status: 'invalid',
+      error: 'validation failed',
+      fields: [{ field: 'entries', message: 'is required' }]
+    };
+    const conflict = { index: 3, key: 'w4', status: 'conflict', error: 'idempotency key reused' };
+    getPendingWorkouts.mockResolvedValue(workouts);
+    api.syncWorkouts.mockResolvedValue({
+      results: [invalid, { index: 1, key: 'w2', status: 'created' }, { index: 2, key: 'w3', status: 'failed' }, conflict]
+    });
+    deletePendingWorkout.mockResolvedValue();
+    rejectPendingWorkout.mockResolvedValue();
+    const parked = [{ ...workouts[0], ...invalid }, { ...workouts[3], ...conflict }];
+    getRejectedWorkouts.mockResolvedValueOnce([]).mockResolvedValue(parked);
+
+    const { result } = renderHook(() => useOfflineSync('token-sync'));
+
+    await waitFor(() => expect(result.current.rejected).toEqual(parked));
+    expect(deletePendingWorkout).toHaveBeenCalledTimes(1);
+    expect(deletePendingWorkout).toHaveBeenCalledWith('w2');
+    expect(rejectPendingWorkout).toHaveBeenCalledTimes(2);
+    expect(rejectPendingWorkout).toHaveBeenCalledWith(workouts[0], invalid);
+    expect(rejectPendingWorkout).toHaveBeenCalledWith(workouts[3], conflict);
+  });
+
+  it('forgets a rejected workout once dismissed', async () => {
+    setNavigatorOnline(false);
+    getRejectedWorkouts
+      .mockResolvedValueOnce([{ id: 'w1', status: 'invalid' }])
+      .mockResolvedValue([]);
+    deleteRejectedWorkout.mockResolvedValue();
+
+    const { result } = renderHook(() => useOfflineSync('token-sync'));
+    await waitFor(() => expect(result.current.rejected).toHaveLength(1));
+
+    await act(() => result.current.dismissRejected('w1'));
+
+    expect(deleteRejectedWorkout).toHaveBeenCalledWith('w1');
+    expect(result.current.rejected).toEqual([]);
+  });
+
+  it('splits large queues into several batches', async () => {
+    const workouts = Array.from({ length: SYNC_BATCH_SIZE + 1 }, (_, i) => ({
+      id: `w${i}`,
+      payload: { notes: `${i}` }
+    }));
     getPendingWorkouts.mockResolvedValue(workouts);
     const failure = Object.assign(new Error('Server error'), { status: 500 });
-    api.createWorkout
+    api.syncWorkouts
       .mockRejectedValueOnce(failure)
-      .mockResolvedValueOnce({});
+      .mockResolvedValueOnce({ results: [{ index: 0, status: 'created' }] });
     deletePendingWorkout.mockResolvedValue();
 
     renderHook(() => useOfflineSync('token-sync'));
 
-    await waitFor(() => expect(api.createWorkout).toHaveBeenCalledTimes(2));
-    expect(deletePendingWorkout).toHaveBeenCalledTimes(1);
-    expect(deletePendingWorkout).toHaveBeenCalledWith('w2');
+    await waitFor(() => expect(api.syncWorkouts).toHaveBeenCalledTimes(2));
+    await waitFor(() => expect(deletePendingWorkout).toHaveBeenCalledTimes(1));
+    expect(api.syncWorkouts.mock.calls[0][1]).toHaveLength(SYNC_BATCH_SIZE);
+    expect(deletePendingWorkout).toHaveBeenCalledWith(`w${SYNC_BATCH_SIZE}`);
   });
 });
@@ -0,0 +1,36 @@
+// Mirrors the backend's measurement types: each one decides which entry
+// fields the server accepts, so the logging form only sends those.
+const MEASUREMENT_WEIGHT_REPS = 'weight_reps';
+
+const COUNTS_REPS = new Set(['weight_reps', 'bodyweight_reps', 'assisted']);
+const REQUIRES_DURATION = new Set(['time', 'distance_time']);
+const REQUIRES_DISTANCE = new Set(['distance', 'distance_time']);
+
+export function measurementOf(exercise) {
+  return exercise?.measurementType || MEASUREMENT_WEIGHT_REPS;
+}
+
+export const countsReps = (measurement) => COUNTS_REPS.has(measurement);
+export const requiresDuration = (measurement) => REQUIRES_DURATION.has(measurement);
+export const requiresDistance = (measurement) => REQUIRES_DISTANCE.has(measurement);
+
+// buildEntry turns the logging form into a workout entry. Time-measured
+// exercises log the time of each set; for the others the duration is the
+// time spent on the whole entry.
+export function buildEntry(exercise, { sets, reps, weight, setSeconds, distanceMeters, sessionMinutes, notes }) {
+  const measurement = measurementOf(exercise);
+  const entry = {
+    exerciseId: exercise.id,
+    sets: Number(sets),
+    weight: weight ? Number(weight) : 0,
+    durationSeconds: requiresDuration(measurement) ? Number(setSeconds) : Number(sessionMinutes) * 60,
+    notes
+  };
+  if (countsReps(measurement)) {
+    entry.reps = Number(reps);
+  }
+  if (requiresDistance(measurement)) {
+    entry.distanceMeters = Number(distanceMeters);
+  }
+  return entry;
+}
@@ -0,0 +1,55 @@
+import { describe, expect, it } from 'vitest';
+import { buildEntry } from './entry';
+
+const form = {
+  sets: '3',
+  reps: '12',
+  weight: '40',
+  setSeconds: '45',
+  distanceMeters: '400',
+  sessionMinutes: '10',
+  notes: 'steady'
+};
+
+describe('buildEntry', () => {
+  it('sends reps and weight for lifts', () => {
+    expect(buildEntry({ id: 'bench', measurementType: 'weight_reps' }, form)).toEqual({
+      exerciseId: 'bench',
+      sets: 3,
+      reps: 12,
+      weight: 40,
+      durationSeconds: 600,
+      notes: 'steady'
+    });
+  });
+
+  it('treats exercises without a measurement type as lifts', () => {
+    expect(buildEntry({ id: 'old' }, form).reps).toBe(12);
+  });
+
+  it('sends the time per set instead of reps for timed exercises', () => {
+    expect(buildEntry({ id: 'plank', measurementType: 'time' }, { ...form, weight: '' })).toEqual({
+      exerciseId: 'plank',
+      sets: 3,
+      weight: 0,
+      durationSeconds: 45,
+      notes: 'steady'
+    });
+  });
+
+  it('sends the distance for distance exercises', () => {
+    const entry = buildEntry({ id: 'carry', measurementType: 'distance' }, form);
+
+    expect(entry).not.toHaveProperty('reps');
+    expect(entry.distanceMeters).toBe(400);
+    expect(entry.durationSeconds).toBe(600);
+  });
+
+  it('sends distance and time per set for runs', () => {
+    const entry = buildEntry({ id: 'run', measurementType: 'distance_time' }, form);
+
+    expect(entry).not.toHaveProperty('reps');
+    expect(entry.distanceMeters).toBe(400);
+    expect(entry.durationSeconds).toBe(45);
+  });
+});
@@ -3,7 +3,13 @@ import { useNavigate } from 'react-router-dom';
 import AppScaffold from '../components/layout/AppScaffold';
 import { useAuth } from '../context/AuthContext';
 import { api } from '../api/client';
-import { getExercises, saveExercises } from '../hooks/useIndexedDB';
+import {
+  applyExerciseChanges,
+  getExerciseCursor,
+  getExercises,
+  saveExercises,
+  saveTemplates
+} from '../hooks/useIndexedDB';
 
 export default function ExerciseSelectionPage() {
   const { callWithAuth, isOnline } = useAuth();
@@ -68,10 +74,21 @@ export default function ExerciseSelectionPage() {
     }
     setMessage('');
     try {
-      const data = await callWithAuth(api.listExercises);
-      setExercises(data);
-      await saveExercises(data);
-      setMessage(`Loaded ${data.length} exercises for offline use.`);
+      const cursor = await getExerciseCursor();
+      const [feed, templates] = await Promise.all([
+        callWithAuth(api.listExerciseChanges, cursor),
+        callWithAuth(api.listTemplates)
+      ]);
+      await applyExerciseChanges(feed);
+      await saveTemplates(templates);
+      const cached = await getExercises();
+      setExercises(cached);
+      const changed = feed.exercises.length + feed.tombstones.length;
+      setMessage(
+        cursor
+          ? `Offline library up to date (${changed} changes, ${cached.length} exercises, ${templates.length} templates).`
+          : `Loaded ${cached.length} exercises and ${templates.length} templates for offline use.`
+      );
     } catch (error) {
       setMessage(error.message || 'Failed to preload exercises.');
     }
@@ -3,7 +3,11 @@ import { useNavigate, useParams } from 'react-router-dom';
 import AppScaffold from '../components/layout/AppScaffold';
 import { useAuth } from '../context/AuthContext';
 import { api } from '../api/client';
-import { addPendingWorkout, getExercises } from '../hooks/useIndexedDB';
+import { addPendingWorkout, getExercises, newPendingWorkoutId } from '../hooks/useIndexedDB';
+import { buildEntry, countsReps, measurementOf, requiresDistance, requiresDuration } from '../modules/workout/entry';
+
+// Network failures carry no status; 5xx responses are the server's fault.
+const isRetryable = (error) => !error.status || error.status >= 500;
 
 export default function WorkoutPerformancePage() {
   const { exerciseId } = useParams();
@@ -14,6 +18,8 @@ export default function WorkoutPerformancePage() {
   const [reps, setReps] = useState(12);
   const [weight, setWeight] = useState('');
   const [duration, setDuration] = useState(10);
+  const [setSeconds, setSetSeconds] = useState(60);
+  const [distance, setDistance] = useState('');
   const [notes, setNotes] = useState('');
   const [message, setMessage] = useState('');
   const [submitting, setSubmitting] = useState(false);
@@ -34,24 +40,26 @@ export default function WorkoutPerformancePage() {
     setSubmitting(true);
     setMessage('');
     const now = new Date();
+    const sessionId = newPendingWorkoutId();
     const payload = {
+      id: sessionId,
       startedAt: new Date(now.getTime() - duration * 60 * 1000),
       completedAt: now,
       entries: [
-        {
-          exerciseId,
-          sets: Number(sets),
-          reps: Number(reps),
-          weight: weight ? Number(weight) : 0,
-          durationSeconds: Number(duration) * 60,
+        buildEntry(exercise, {
+          sets,
+          reps,
+          weight,
+          setSeconds,
+          distanceMeters: distance,
+          sessionMinutes: duration,
           notes
-        }
+        })
       ]
     };
 
     if (!isOnline) {
-      const offlineId = crypto.randomUUID ? crypto.randomUUID() : `${Date.now()}-${Math.random()}`;
-      await addPendingWorkout({ id: offlineId, payload });
+      await addPendingWorkout({ id: sessionId, payload });
       setMessage('Workout saved offline. It will sync automatically.');
       setSubmitting(false);
       setTimeout(() => navigate('/workout/select'), 1000);
@@ -59,13 +67,18 @@ export default function WorkoutPerformancePage() {
     }
 
     try {
-      await callWithAuth(api.createWorkout, payload);
+      await callWithAuth(api.createWorkout, payload, sessionId);
       setMessage('Workout saved successfully.');
       setTimeout(() => navigate('/workout/select'), 800);
     } catch (error) {
-      setMessage(error.message || 'Failed to log workout. Saved for retry.');
-      const offlineId = crypto.randomUUID ? crypto.randomUUID() : `${Date.now()}-${Math.random()}`;
-      await addPendingWorkout({ id: offlineId, payload });
+      // Only failures a retry can fix are queued; the server rejects an
+      // invalid or conflicting workout the same way every time.
+      if (isRetryable(error)) {
+        await addPendingWorkout({ id: sessionId, payload });
+        setMessage('Could not reach the server. Workout saved for retry.');
+      } else {
+        setMessage(error.message || 'Failed to log workout.');
+      }
     } finally {
       setSubmitting(false);
     }
@@ -93,6 +106,8 @@ export default function WorkoutPerformancePage() {
     );
   }
 
+  const measurement = measurementOf(exercise);
+
   return (
     <AppScaffold
       overline="Log session"
@@ -134,24 +149,26 @@ export default function WorkoutPerformancePage() {
                 />
               </div>
             </div>
-            <div className="space-y-2">
-              <label className="text-xs font-semibold uppercase tracking-[0.3em] text-onSurfaceVariant/70" htmlFor="reps-input">
-                Reps
-              </label>
-              <div className="flex items-center gap-3 rounded-2xl bg-surfaceContainerHighest/70 px-4 py-3">
-                <svg aria-hidden="true" viewBox="0 0 24 24" className="h-5 w-5 text-onSurfaceVariant" fill="none" stroke="currentColor" strokeWidth="1.5">
-                  <path d="M6 9h12M6 15h12" strokeLinecap="round" strokeLinejoin="round" />
-                </svg>
-                <input
-                  id="reps-input"
-                  type="number"
-                  min="1"
-                  value={reps}
-                  onChange={(event) => setReps(event.target.value)}
-                  className="w-full bg-transparent text-base font-semibold text-onSurface focus:outline-none"
-                />
+            {countsReps(measurement) ? (
+              <div className="space-y-2">
+                <label className="text-xs font-semibold uppercase tracking-[0.3em] text-onSurfaceVariant/70" htmlFor="reps-input">
+                  Reps
+                </label>
+                <div className="flex items-center gap-3 rounded-2xl bg-surfaceContainerHighest/70 px-4 py-3">
+                  <svg aria-hidden="true" viewBox="0 0 24 24" className="h-5 w-5 text-onSurfaceVariant" fill="none" stroke="currentColor" strokeWidth="1.5">
+                    <path d="M6 9h12M6 15h12" strokeLinecap="round" strokeLinejoin="round" />
+                  </svg>
+                  <input
+                    id="reps-input"
+                    type="number"
+                    min="1"
+                    value={reps}
+                    onChange={(event) => setReps(event.target.value)}
+                    className="w-full bg-transparent text-base font-semibold text-onSurface focus:outline-none"
+                  />
+                </div>
               </div>
-            </div>
+            ) : null}
             <div className="space-y-2">
               <label className="text-xs font-semibold uppercase tracking-[0.3em] text-onSurfaceVariant/70" htmlFor="weight-input">
                 Weight (kg)
@@ -191,6 +208,47 @@ export default function WorkoutPerformancePage() {
                 />
               </div>
             </div>
+            {requiresDuration(measurement) ? (
+              <div className="space-y-2">
+                <label className="text-xs font-semibold uppercase tracking-[0.3em] text-onSurfaceVariant/70" htmlFor="set-seconds-input">
+                  Time per set (s)
+                </label>
+                <div className="flex items-center gap-3 rounded-2xl bg-surfaceContainerHighest/70 px-4 py-3">
+                  <svg aria-hidden="true" viewBox="0 0 24 24" className="h-5 w-5 text-onSurfaceVariant" fill="none" stroke="currentColor" strokeWidth="1.5">
+                    <circle cx="12" cy="13" r="7" />
+                    <path d="M12 10v3M10 3h4" strokeLinecap="round" strokeLinejoin="round" />
+                  </svg>
+                  <input
+                    id="set-seconds-input"
+                    type="number"
+                    min="1"
+                    value={setSeconds}
+                    onChange={(event) => setSetSeconds(event.target.value)}
+                    className="w-full bg-transparent text-base font-semibold text-onSurface focus:outline-none"
+                  />
+                </div>
+              </div>
+            ) : null}
+            {requiresDistance(measurement) ? (
+              <div className="space-y-2">
+                <label className="text-xs font-semibold uppercase tracking-[0.3em] text-onSurfaceVariant/70" htmlFor="distance-input">
+                  Distance (m)
+                </label>
+                <div className="flex items-center gap-3 rounded-2xl bg-surfaceContainerHighest/70 px-4 py-3">
+                  <svg aria-hidden="true" viewBox="0 0 24 24" className="h-5 w-5 text-onSurfaceVariant" fill="none" stroke="currentColor" strokeWidth="1.5">
+                    <path d="M4 17l5-10 4 6 3-4 4 8" strokeLinecap="round" strokeLinejoin="round" />
+                  </svg>
+                  <input
+                    id="distance-input"
+                    type="number"
+                    min="1"
+                    value={distance}
+                    onChange={(event) => setDistance(event.target.value)}
+                    className="w-full bg-transparent text-base font-semibold text-onSurface focus:outline-none"
+                  />
+                </div>
+              </div>
+            ) : null}
           </div>
         </section>
 